@@ -18,20 +18,28 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 
 	"github.com/GoogleContainerTools/skaffold/cmd/skaffold/app/flags"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var (
 	quietFlag       bool
 	buildFormatFlag = flags.NewTemplateFlag("{{range .Builds}}{{.ImageName}} -> {{.Tag}}\n{{end}}", BuildOutput{})
+	buildOutputFile string
 )
 
+// remoteDigest is replaced in tests.
+var remoteDigest = docker.RemoteDigest
+
 // NewCmdBuild describes the CLI command to build artifacts.
 func NewCmdBuild(out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
@@ -44,13 +52,14 @@ func NewCmdBuild(out io.Writer) *cobra.Command {
 	}
 	AddRunDevFlags(cmd)
 	cmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress the build output and print image built on success")
-	cmd.Flags().VarP(buildFormatFlag, "output", "o", buildFormatFlag.Usage())
+	cmd.Flags().VarP(buildFormatFlag, "output", "o", buildFormatFlag.Usage()+" Use '{{json .}}' for json.")
+	cmd.Flags().StringVar(&buildOutputFile, "file-output", "", "Write the build results as json to this file, that can be given to `skaffold deploy --build-artifacts`")
 	return cmd
 }
 
 // BuildOutput is the output of `skaffold build`.
 type BuildOutput struct {
-	Builds []build.Build
+	Builds []build.Build `json:"builds"`
 }
 
 func runBuild(out io.Writer, filename string) error {
@@ -71,9 +80,62 @@ func runBuild(out io.Writer, filename string) error {
 		return errors.Wrap(err, "build step")
 	}
 
+	if pushed(config) {
+		bRes = resolveDigests(bRes)
+	}
+
 	cmdOut := BuildOutput{Builds: bRes}
 	if err := buildFormatFlag.Template().Execute(out, cmdOut); err != nil {
 		return errors.Wrap(err, "executing template")
 	}
+
+	if buildOutputFile != "" {
+		if err := writeBuildOutput(buildOutputFile, cmdOut); err != nil {
+			return errors.Wrap(err, "writing build output")
+		}
+	}
 	return nil
 }
+
+// pushed tells whether the images were pushed to a registry.
+func pushed(cfg *config.SkaffoldConfig) bool {
+	local := cfg.Build.LocalBuild
+	return local == nil || local.SkipPush == nil || !*local.SkipPush
+}
+
+// resolveDigests fills the digests of the pushed images that the builder didn't report.
+func resolveDigests(builds []build.Build) []build.Build {
+	var resolved []build.Build
+	for _, b := range builds {
+		if b.Digest == "" {
+			digest, err := remoteDigest(b.Tag)
+			if err != nil {
+				logrus.Warnf("Unable to get the digest of %s: %s", b.Tag, err)
+			}
+			b.Digest = digest
+		}
+		resolved = append(resolved, b)
+	}
+	return resolved
+}
+
+func writeBuildOutput(file string, output BuildOutput) error {
+	content, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(content, '\n'), 0644)
+}
+
+func readBuildOutput(file string) (BuildOutput, error) {
+	var output BuildOutput
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return output, err
+	}
+	if err := json.Unmarshal(content, &output); err != nil {
+		return output, errors.Wrapf(err, "parsing %s", file)
+	}
+	return output, nil
+}
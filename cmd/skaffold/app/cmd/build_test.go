@@ -0,0 +1,72 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestResolveDigests(t *testing.T) {
+	defer func(f func(string) (string, error)) { remoteDigest = f }(remoteDigest)
+	remoteDigest = func(image string) (string, error) {
+		if image == "missing:v1" {
+			return "", fmt.Errorf("not found")
+		}
+		return "sha256:123", nil
+	}
+
+	builds := resolveDigests([]build.Build{
+		{ImageName: "app", Tag: "app:v1"},
+		{ImageName: "missing", Tag: "missing:v1"},
+		{ImageName: "known", Tag: "known:v1", Digest: "sha256:456"},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []build.Build{
+		{ImageName: "app", Tag: "app:v1", Digest: "sha256:123"},
+		{ImageName: "missing", Tag: "missing:v1"},
+		{ImageName: "known", Tag: "known:v1", Digest: "sha256:456"},
+	}, builds)
+}
+
+func TestBuildOutputFile(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	file := filepath.Join(tmpDir, "builds.json")
+	output := BuildOutput{Builds: []build.Build{
+		{ImageName: "gcr.io/project/app", Tag: "gcr.io/project/app:v1", Digest: "sha256:123"},
+		{ImageName: "local", Tag: "local:v1"},
+	}}
+
+	if err := writeBuildOutput(file, output); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readBuildOutput(file)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, output, read)
+}
+
+func TestReadInvalidBuildOutput(t *testing.T) {
+	_, err := readBuildOutput(filepath.Join("testdata", "missing.json"))
+
+	testutil.CheckError(t, true, err)
+}
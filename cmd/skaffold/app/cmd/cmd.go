@@ -18,11 +18,14 @@ package cmd
 
 import (
 	"io"
+	"path/filepath"
+	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/version"
 	"github.com/pkg/errors"
@@ -48,6 +51,7 @@ func NewSkaffoldCommand(out, err io.Writer) *cobra.Command {
 			return err
 		}
 		rootCmd.SilenceUsage = true
+		opts.Command = cmd.Name()
 		logrus.Infof("Skaffold %+v", version.Get())
 		return nil
 	}
@@ -57,10 +61,14 @@ func NewSkaffoldCommand(out, err io.Writer) *cobra.Command {
 	rootCmd.AddCommand(NewCmdVersion(out))
 	rootCmd.AddCommand(NewCmdRun(out))
 	rootCmd.AddCommand(NewCmdDev(out))
+	rootCmd.AddCommand(NewCmdDebug(out))
 	rootCmd.AddCommand(NewCmdBuild(out))
 	rootCmd.AddCommand(NewCmdDeploy(out))
+	rootCmd.AddCommand(NewCmdRender(out))
 	rootCmd.AddCommand(NewCmdDelete(out))
 	rootCmd.AddCommand(NewCmdFix(out))
+	rootCmd.AddCommand(NewCmdInit(out))
+	rootCmd.AddCommand(NewCmdDiagnose(out))
 	rootCmd.AddCommand(NewCmdDocker(out))
 
 	rootCmd.PersistentFlags().StringVarP(&v, "verbosity", "v", constants.DefaultLogLevel.String(), "Log level (debug, info, warn, error, fatal, panic")
@@ -69,13 +77,22 @@ func NewSkaffoldCommand(out, err io.Writer) *cobra.Command {
 
 func AddDevFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&opts.Cleanup, "cleanup", true, "Delete deployments after dev mode is interrupted")
+	cmd.Flags().BoolVar(&opts.PortForward, "port-forward", true, "Forward the container ports of the deployed pods to local ports")
+	cmd.Flags().StringVar(&opts.Trigger, "trigger", "polling", "How changes are detected: polling, notify or manual")
+	cmd.Flags().IntVar(&opts.WatchPollInterval, "watch-poll-interval", 2000, "Interval (in ms) between two checks for file changes")
+	cmd.Flags().BoolVar(&opts.Tail, "tail", true, "Stream the logs of the deployed containers")
+	cmd.Flags().StringVar(&opts.LogSelector, "log-selector", "", "Only stream the logs of the pods matching this label selector, eg. app=web")
+	cmd.Flags().StringVar(&opts.OnError, "on-error", config.OnErrorContinue, "What to do when a build or a deploy fails: exit, or continue watching and try again on the next change")
+	cmd.Flags().IntVar(&opts.RPCPort, "rpc-port", 0, "Port of the gRPC server exposing the state of the dev loop (0 to disable)")
+	cmd.Flags().IntVar(&opts.RPCHTTPPort, "rpc-http-port", 0, "Port of the HTTP server exposing the state of the dev loop as json (0 to disable)")
 }
 
 func AddRunDevFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&filename, "filename", "f", "skaffold.yaml", "Filename or URL to the pipeline file")
 	cmd.Flags().BoolVar(&opts.Notification, "toot", false, "Emit a terminal beep after the deploy is complete")
 	cmd.Flags().StringArrayVarP(&opts.Profiles, "profile", "p", nil, "Activate profiles by name")
-	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", "", "Run Helm deployments in the specified namespace")
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", "", "Deploy into the specified namespace")
+	cmd.Flags().BoolVar(&opts.CacheArtifacts, "cache-artifacts", false, "Skip the builds of artifacts whose sources didn't change since their last build")
 }
 
 func AddFixFlags(cmd *cobra.Command) {
@@ -94,6 +111,22 @@ func SetUpLogs(out io.Writer, level string) error {
 }
 
 func readConfiguration(filename string) (*config.SkaffoldConfig, error) {
+	cfg, err := loadConfig(filename, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveRequires(cfg, filename); err != nil {
+		return nil, errors.Wrap(err, "resolving required configs")
+	}
+
+	return cfg, nil
+}
+
+// loadConfig reads a config, applies its profiles, expands its templates, resolves its
+// tag files and validates it.
+// Required configs only get the profiles of the command line that they define.
+func loadConfig(filename string, required bool) (*config.SkaffoldConfig, error) {
 	buf, err := util.ReadConfiguration(filename)
 	if err != nil {
 		return nil, errors.Wrap(err, "read skaffold config")
@@ -117,10 +150,74 @@ func readConfiguration(filename string) (*config.SkaffoldConfig, error) {
 	// so this type assertion is safe.
 	latestConfig := cfg.(*config.SkaffoldConfig)
 
-	err = latestConfig.ApplyProfiles(opts.Profiles)
+	explicit := opts.Profiles
+	if required {
+		explicit = definedProfiles(latestConfig, opts.Profiles)
+	}
+
+	profiles, err := activatedProfiles(latestConfig, explicit)
+	if err != nil {
+		return nil, errors.Wrap(err, "activating profiles")
+	}
+
+	err = latestConfig.ApplyProfiles(profiles)
 	if err != nil {
 		return nil, errors.Wrap(err, "applying profiles")
 	}
 
+	if err := latestConfig.ExpandTemplates(); err != nil {
+		return nil, errors.Wrap(err, "expanding templates")
+	}
+
+	// Remote configs, or configs given on stdin, have their tag files
+	// relative to the current directory.
+	if filename != "-" && !strings.HasPrefix(filename, "http://") && !strings.HasPrefix(filename, "https://") {
+		abs, err := filepath.Abs(filename)
+		if err != nil {
+			return nil, errors.Wrap(err, "finding config directory")
+		}
+		latestConfig.ResolveTagFiles(filepath.Dir(abs))
+	}
+
+	if err := latestConfig.Validate(); err != nil {
+		return nil, errors.Wrap(err, "validating skaffold config")
+	}
+
 	return latestConfig, nil
 }
+
+// activatedProfiles returns the profiles activated by the current kube context
+// and command, followed by the explicit ones, that take precedence.
+func activatedProfiles(cfg *config.SkaffoldConfig, explicit []string) ([]string, error) {
+	kubeContext, err := kubernetes.CurrentContext()
+	if err != nil {
+		logrus.Debugf("Unable to get the current kube context: %s", err)
+	}
+
+	activated, err := cfg.ActivatedProfiles(kubeContext, opts.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []string
+	for _, profile := range activated {
+		if !util.StrSliceContains(explicit, profile) {
+			profiles = append(profiles, profile)
+		}
+	}
+	return append(profiles, explicit...), nil
+}
+
+// definedProfiles filters the names of the profiles that a config defines.
+func definedProfiles(cfg *config.SkaffoldConfig, names []string) []string {
+	var defined []string
+	for _, name := range names {
+		for _, profile := range cfg.Profiles {
+			if profile.Name == name {
+				defined = append(defined, name)
+				break
+			}
+		}
+	}
+	return defined
+}
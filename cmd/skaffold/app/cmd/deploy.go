@@ -28,7 +28,8 @@ import (
 )
 
 var (
-	images []string
+	images         []string
+	buildArtifacts string
 )
 
 // NewCmdDeploy describes the CLI command to deploy artifacts.
@@ -42,7 +43,9 @@ func NewCmdDeploy(out io.Writer) *cobra.Command {
 		},
 	}
 	AddRunDevFlags(cmd)
+	cmd.Flags().BoolVar(&opts.StatusCheck, "status-check", true, "Wait for the deployed workloads to be rolled out and fail if they don't stabilize")
 	cmd.Flags().StringSliceVar(&images, "images", nil, "A list of images to deploy")
+	cmd.Flags().StringVarP(&buildArtifacts, "build-artifacts", "a", "", "A json file with the images to deploy, written by `skaffold build --file-output`")
 	cmd.Flags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress the deploy output")
 	return cmd
 }
@@ -61,6 +64,14 @@ func runDeploy(out io.Writer, filename string) error {
 	}
 
 	var builds []build.Build
+	if buildArtifacts != "" {
+		output, err := readBuildOutput(buildArtifacts)
+		if err != nil {
+			return errors.Wrap(err, "reading build artifacts")
+		}
+		builds = output.Builds
+	}
+
 	for _, image := range images {
 		parsed, err := docker.ParseReference(image)
 		if err != nil {
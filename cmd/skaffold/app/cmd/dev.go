@@ -23,6 +23,8 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/server"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -56,6 +58,13 @@ func dev(out io.Writer, filename string) error {
 		return errors.Wrap(err, "creating runner")
 	}
 
+	event.InitializeState(config.Build.Artifacts)
+	shutdown, err := server.Initialize(opts.RPCPort, opts.RPCHTTPPort)
+	if err != nil {
+		return errors.Wrap(err, "starting rpc servers")
+	}
+	defer shutdown()
+
 	built, err := runner.Dev(ctx, out, config.Build.Artifacts)
 
 	if opts.Cleanup && built != nil {
@@ -0,0 +1,141 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdDiagnose describes the CLI command to diagnose skaffold.
+func NewCmdDiagnose(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diagnose",
+		Short: "Run a diagnostic on the pipeline",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDiagnose(out, filename)
+		},
+	}
+	AddRunDevFlags(cmd)
+	return cmd
+}
+
+func runDiagnose(out io.Writer, filename string) error {
+	ctx := context.Background()
+
+	runner, config, err := newRunner(filename)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Configuration %s is valid (%s)\n", filename, config.APIVersion)
+
+	diagnoseDocker(ctx, out)
+	diagnoseKubernetes(out)
+	diagnoseArtifacts(out, runner.Tagger, config.Build.Artifacts)
+
+	return nil
+}
+
+// diagnoseDocker checks the connection to the docker daemon.
+func diagnoseDocker(ctx context.Context, out io.Writer) {
+	client, err := docker.NewAPIClient()
+	if err != nil {
+		fmt.Fprintf(out, "Docker daemon: unable to create a client: %s\n", err)
+		return
+	}
+
+	version, err := client.ServerVersion(ctx)
+	if err != nil {
+		fmt.Fprintf(out, "Docker daemon: unreachable: %s\n", err)
+		return
+	}
+	fmt.Fprintf(out, "Docker daemon: version %s, api %s\n", version.Version, version.APIVersion)
+}
+
+// diagnoseKubernetes checks the connection to the cluster of the current kube context.
+func diagnoseKubernetes(out io.Writer) {
+	kubeContext, err := kubernetes.CurrentContext()
+	if err != nil {
+		fmt.Fprintf(out, "Kube context: unable to read the current context: %s\n", err)
+		return
+	}
+	fmt.Fprintf(out, "Kube context: %s\n", kubeContext)
+
+	client, err := kubernetes.GetClientset()
+	if err != nil {
+		fmt.Fprintf(out, "Cluster: unable to create a client: %s\n", err)
+		return
+	}
+
+	version, err := client.Discovery().ServerVersion()
+	if err != nil {
+		fmt.Fprintf(out, "Cluster: unreachable: %s\n", err)
+		return
+	}
+	fmt.Fprintf(out, "Cluster: version %s\n", version.GitVersion)
+}
+
+// diagnoseArtifacts prints, for each artifact, the tag it would get before being built
+// and the dependencies that are watched, along with the time it took to list them.
+// The sha256 tagger needs the digest of the built image, so its tags are not computed.
+func diagnoseArtifacts(out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) {
+	var total time.Duration
+
+	for _, artifact := range artifacts {
+		fmt.Fprintf(out, "\nArtifact %s\n", artifact.ImageName)
+
+		if _, requiresDigest := tagger.(*tag.ChecksumTagger); requiresDigest {
+			fmt.Fprintf(out, "  Tag: requires build\n")
+		} else {
+			fqn, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.Options{
+				ImageName: artifact.ImageName,
+			})
+			if err != nil {
+				fmt.Fprintf(out, "  Tag: unable to generate tag before build: %s\n", err)
+			} else {
+				fmt.Fprintf(out, "  Tag: %s\n", fqn)
+			}
+		}
+
+		start := time.Now()
+		deps, err := build.DependenciesForArtifact(artifact)
+		elapsed := time.Since(start)
+		total += elapsed
+
+		if err != nil {
+			fmt.Fprintf(out, "  Dependencies: %s\n", errors.Wrap(err, "unable to list dependencies"))
+			continue
+		}
+		fmt.Fprintf(out, "  Dependencies: %d files, listed in %v\n", len(deps), elapsed)
+		for _, dep := range deps {
+			fmt.Fprintf(out, "    %s\n", dep)
+		}
+	}
+
+	fmt.Fprintf(out, "\nDependencies of %d artifacts listed in %v\n", len(artifacts), total)
+}
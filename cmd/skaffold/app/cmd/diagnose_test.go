@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+)
+
+func TestDiagnoseArtifacts(t *testing.T) {
+	defer func(deps func(*v1alpha2.Artifact) ([]string, error)) { build.DependenciesForArtifact = deps }(build.DependenciesForArtifact)
+	build.DependenciesForArtifact = func(a *v1alpha2.Artifact) ([]string, error) {
+		if a.ImageName == "broken" {
+			return nil, fmt.Errorf("missing Dockerfile")
+		}
+		return []string{"Dockerfile", "main.go"}, nil
+	}
+
+	var out bytes.Buffer
+	diagnoseArtifacts(&out, &tag.CustomTag{Tag: "v1"}, []*v1alpha2.Artifact{
+		{ImageName: "app", Workspace: "."},
+		{ImageName: "broken", Workspace: "."},
+	})
+
+	for _, expected := range []string{
+		"Artifact app\n  Tag: app:v1\n  Dependencies: 2 files, listed in ",
+		"    Dockerfile\n    main.go\n",
+		"Artifact broken\n  Tag: broken:v1\n  Dependencies: unable to list dependencies: missing Dockerfile\n",
+		"Dependencies of 2 artifacts listed in ",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got %s", expected, out.String())
+		}
+	}
+}
+
+func TestDiagnoseArtifactsRequiringBuild(t *testing.T) {
+	defer func(deps func(*v1alpha2.Artifact) ([]string, error)) { build.DependenciesForArtifact = deps }(build.DependenciesForArtifact)
+	build.DependenciesForArtifact = func(a *v1alpha2.Artifact) ([]string, error) { return nil, nil }
+
+	var out bytes.Buffer
+	diagnoseArtifacts(&out, &tag.ChecksumTagger{}, []*v1alpha2.Artifact{{ImageName: "app", Workspace: "."}})
+
+	if expected := "Artifact app\n  Tag: requires build\n"; !strings.Contains(out.String(), expected) {
+		t.Errorf("expected output to contain %q, got %s", expected, out.String())
+	}
+}
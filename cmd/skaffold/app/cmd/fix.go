@@ -21,10 +21,7 @@ import (
 	"io"
 	"io/ioutil"
 
-	yaml "gopkg.in/yaml.v2"
-
 	"github.com/pkg/errors"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
@@ -37,23 +34,20 @@ func NewCmdFix(out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "fix",
 		Short: "Converts old skaffold.yaml to newest schema version",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			contents, err := util.ReadConfiguration(filename)
 			if err != nil {
-				logrus.Errorf("fix: %s", err)
+				return errors.Wrap(err, "reading configuration")
 			}
 			cfg, err := config.GetConfig(contents, false)
 			if err != nil {
-				logrus.Error(err)
-				return
+				return errors.Wrap(err, "parsing skaffold config")
 			}
 			if cfg.GetVersion() == config.LatestVersion {
 				fmt.Fprintln(out, "config is already latest version")
-				return
-			}
-			if err := runFix(out, cfg); err != nil {
-				logrus.Errorf("fix: %s", err)
+				return nil
 			}
+			return runFix(out, cfg)
 		},
 		Args: cobra.NoArgs,
 	}
@@ -66,9 +60,13 @@ func runFix(out io.Writer, cfg schemautil.VersionedConfig) error {
 	if err != nil {
 		return err
 	}
-	newCfg, err := yaml.Marshal(cfg)
+	newCfg, err := marshalWithoutEmptyValues(cfg)
 	if err != nil {
-		return errors.Wrap(err, "marshaling new config")
+		return err
+	}
+	// Make sure that the upgraded configuration is valid.
+	if _, err := config.GetConfig(newCfg, true); err != nil {
+		return errors.Wrap(err, "validating the upgraded config")
 	}
 	if overwrite {
 		if err := ioutil.WriteFile(filename, newCfg, 0644); err != nil {
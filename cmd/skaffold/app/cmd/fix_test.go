@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestRunFix(t *testing.T) {
+	var tests = []struct {
+		description string
+		oldConfig   string
+		expected    string
+	}{
+		{
+			description: "kubectl manifests",
+			oldConfig: `apiVersion: skaffold/v1alpha1
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/k8s-skaffold/skaffold-example
+    workspace: .
+deploy:
+  kubectl:
+    manifests:
+    - paths:
+      - k8s-*
+`,
+			expected: `apiVersion: skaffold/v1alpha2
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/k8s-skaffold/skaffold-example
+    workspace: .
+deploy:
+  kubectl:
+    manifests:
+    - k8s-*
+`,
+		},
+		{
+			description: "explicit skipPush false",
+			oldConfig: `apiVersion: skaffold/v1alpha1
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/k8s-skaffold/skaffold-example
+    workspace: .
+  local:
+    skipPush: false
+deploy:
+  kubectl:
+    manifests:
+    - paths:
+      - k8s-*
+`,
+			expected: `apiVersion: skaffold/v1alpha2
+kind: Config
+build:
+  artifacts:
+  - imageName: gcr.io/k8s-skaffold/skaffold-example
+    workspace: .
+  local:
+    skipPush: false
+deploy:
+  kubectl:
+    manifests:
+    - k8s-*
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cfg, err := config.GetConfig([]byte(test.oldConfig), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			err = runFix(&out, cfg)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, out.String())
+		})
+	}
+}
@@ -0,0 +1,437 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/moby/moby/builder/dockerfile/parser"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+)
+
+var (
+	initArtifacts []string
+	initForce     bool
+)
+
+// NewCmdInit describes the CLI command to generate a skaffold configuration.
+func NewCmdInit(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Generates a skaffold.yaml from the Dockerfiles and Kubernetes manifests of the project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInit(os.Stdin, out, ".")
+		},
+	}
+	cmd.Flags().StringVarP(&filename, "filename", "f", "skaffold.yaml", "Filename of the generated pipeline file")
+	cmd.Flags().StringArrayVarP(&initArtifacts, "artifact", "a", nil, "Pair a Dockerfile with the image it builds as `Dockerfile=image`, instead of prompting")
+	cmd.Flags().BoolVar(&initForce, "force", false, "Don't prompt and overwrite an existing pipeline file")
+	return cmd
+}
+
+func runInit(in io.Reader, out io.Writer, root string) error {
+	if _, err := os.Stat(filename); err == nil && !initForce {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", filename)
+	}
+
+	p, err := walkProject(root)
+	if err != nil {
+		return errors.Wrap(err, "walking the project")
+	}
+	if len(p.Manifests) == 0 && len(p.Charts) == 0 {
+		return errors.New("no Kubernetes manifests nor helm charts found")
+	}
+
+	var pairs map[string]string
+	if len(initArtifacts) > 0 {
+		pairs, err = parseArtifactFlags(initArtifacts)
+	} else if initForce {
+		pairs, err = autoPair(p.Images, p.Dockerfiles)
+	} else {
+		pairs, err = promptPairs(in, out, p.Images, p.Dockerfiles)
+	}
+	if err != nil {
+		return err
+	}
+
+	contents, err := generateConfig(p, pairs)
+	if err != nil {
+		return err
+	}
+
+	// Make sure that the generated configuration is valid.
+	if _, err := config.GetConfig(contents, true); err != nil {
+		return errors.Wrap(err, "validating the generated config")
+	}
+
+	if err := ioutil.WriteFile(filename, contents, 0644); err != nil {
+		return errors.Wrap(err, "writing config file")
+	}
+	fmt.Fprintf(out, "Configuration %s was written\n", filename)
+	return nil
+}
+
+// project lists what was found in the project, with paths relative to its root.
+type project struct {
+	Dockerfiles []string
+	Manifests   []string
+	Charts      []string
+	Images      []string
+}
+
+func walkProject(root string) (*project, error) {
+	p := &project{}
+	images := map[string]bool{}
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			name := info.Name()
+			if strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "Chart.yaml")); err == nil {
+				p.Charts = append(p.Charts, rel)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if isDockerfile(path) {
+			p.Dockerfiles = append(p.Dockerfiles, rel)
+			return nil
+		}
+
+		manifestImages, isManifest := parseManifest(path)
+		if isManifest {
+			p.Manifests = append(p.Manifests, rel)
+			for _, image := range manifestImages {
+				images[image] = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for image := range images {
+		p.Images = append(p.Images, image)
+	}
+	sort.Strings(p.Images)
+
+	return p, nil
+}
+
+// isDockerfile tells if a file is named like a Dockerfile and starts with a FROM instruction.
+func isDockerfile(path string) bool {
+	name := filepath.Base(path)
+	if name != "Dockerfile" && !strings.HasPrefix(name, "Dockerfile.") && !strings.HasSuffix(name, ".Dockerfile") {
+		return false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	res, err := parser.Parse(f)
+	if err != nil {
+		return false
+	}
+	for _, node := range res.AST.Children {
+		if node.Value == "from" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseManifest tells if a file is a yaml Kubernetes manifest and lists the images it references.
+func parseManifest(path string) ([]string, bool) {
+	ext := filepath.Ext(path)
+	if ext != ".yaml" && ext != ".yml" {
+		return nil, false
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var images []string
+	isManifest := false
+	for _, doc := range bytes.Split(buf, []byte("\n---")) {
+		var obj map[interface{}]interface{}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, false
+		}
+
+		apiVersion, _ := obj["apiVersion"].(string)
+		kind, _ := obj["kind"].(string)
+		if apiVersion == "" || kind == "" {
+			continue
+		}
+		// Skip the skaffold configurations.
+		if strings.HasPrefix(apiVersion, "skaffold/") {
+			return nil, false
+		}
+
+		isManifest = true
+		images = append(images, findImages(obj)...)
+	}
+
+	return images, isManifest
+}
+
+// findImages lists the images, without their tag, referenced by the `image` keys of a manifest.
+func findImages(obj interface{}) []string {
+	var images []string
+
+	switch t := obj.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range t {
+			if image, ok := v.(string); ok && k == "image" {
+				images = append(images, imageWithoutTag(image))
+				continue
+			}
+			images = append(images, findImages(v)...)
+		}
+	case []interface{}:
+		for _, v := range t {
+			images = append(images, findImages(v)...)
+		}
+	}
+
+	return images
+}
+
+func imageWithoutTag(image string) string {
+	ref, err := reference.Parse(image)
+	if err != nil {
+		return image
+	}
+	if named, ok := ref.(reference.Named); ok {
+		return named.Name()
+	}
+	return image
+}
+
+// parseArtifactFlags parses `Dockerfile=image` pairs into a map of images to Dockerfiles.
+func parseArtifactFlags(flags []string) (map[string]string, error) {
+	pairs := map[string]string{}
+	for _, flag := range flags {
+		parts := strings.SplitN(flag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid artifact %q, expected Dockerfile=image", flag)
+		}
+		pairs[parts[1]] = filepath.Clean(parts[0])
+	}
+	return pairs, nil
+}
+
+// autoPair pairs images with Dockerfiles when it's not ambiguous.
+func autoPair(images, dockerfiles []string) (map[string]string, error) {
+	pairs := map[string]string{}
+	switch {
+	case len(dockerfiles) == 0 || len(images) == 0:
+		return pairs, nil
+	case len(dockerfiles) == 1 && len(images) == 1:
+		pairs[images[0]] = dockerfiles[0]
+		return pairs, nil
+	default:
+		return nil, errors.New("unable to pair the images with the Dockerfiles automatically, use --artifact")
+	}
+}
+
+// promptPairs asks which Dockerfile builds each image. A Dockerfile can only be chosen once.
+func promptPairs(in io.Reader, out io.Writer, images, dockerfiles []string) (map[string]string, error) {
+	pairs := map[string]string{}
+	reader := bufio.NewReader(in)
+
+	remaining := append([]string{}, dockerfiles...)
+	for _, image := range images {
+		if len(remaining) == 0 {
+			break
+		}
+
+		fmt.Fprintf(out, "Choose the Dockerfile that builds %s:\n", image)
+		fmt.Fprintln(out, "  0) None, the image is not built by skaffold")
+		for i, dockerfile := range remaining {
+			fmt.Fprintf(out, "  %d) %s\n", i+1, dockerfile)
+		}
+
+		for {
+			fmt.Fprint(out, "> ")
+			line, err := reader.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				return nil, errors.Wrap(err, "reading choice")
+			}
+
+			choice, err := strconv.Atoi(strings.TrimSpace(line))
+			if err != nil || choice < 0 || choice > len(remaining) {
+				fmt.Fprintf(out, "Please enter a number between 0 and %d\n", len(remaining))
+				continue
+			}
+
+			if choice > 0 {
+				pairs[image] = remaining[choice-1]
+				remaining = append(remaining[:choice-1], remaining[choice:]...)
+			}
+			break
+		}
+	}
+
+	return pairs, nil
+}
+
+// generateConfig generates a skaffold configuration at the latest version.
+func generateConfig(p *project, pairs map[string]string) ([]byte, error) {
+	cfg := &v1alpha2.SkaffoldConfig{
+		APIVersion: config.LatestVersion,
+		Kind:       "Config",
+	}
+
+	var images []string
+	for image := range pairs {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	for _, image := range images {
+		dockerfile := pairs[image]
+		artifact := &v1alpha2.Artifact{
+			ImageName: image,
+			ArtifactType: v1alpha2.ArtifactType{
+				DockerArtifact: &v1alpha2.DockerArtifact{},
+			},
+		}
+		if workspace := filepath.Dir(dockerfile); workspace != "." {
+			artifact.Workspace = filepath.ToSlash(workspace)
+		}
+		if name := filepath.Base(dockerfile); name != "Dockerfile" {
+			artifact.DockerArtifact.DockerfilePath = name
+		}
+		cfg.Build.Artifacts = append(cfg.Build.Artifacts, artifact)
+	}
+
+	if len(p.Manifests) > 0 {
+		var manifests []string
+		for _, manifest := range p.Manifests {
+			manifests = append(manifests, filepath.ToSlash(manifest))
+		}
+		cfg.Deploy.KubectlDeploy = &v1alpha2.KubectlDeploy{
+			Manifests: manifests,
+		}
+	} else {
+		var releases []v1alpha2.HelmRelease
+		for _, chart := range p.Charts {
+			releases = append(releases, v1alpha2.HelmRelease{
+				Name:      filepath.Base(chart),
+				ChartPath: filepath.ToSlash(chart),
+			})
+		}
+		cfg.Deploy.HelmDeploy = &v1alpha2.HelmDeploy{
+			Releases: releases,
+		}
+	}
+
+	return marshalWithoutEmptyValues(cfg)
+}
+
+// marshalWithoutEmptyValues marshals a configuration without the null and
+// empty values of the fields that are not tagged with omitempty. Booleans
+// are kept since an explicit `false`, eg. `skipPush: false`, is meaningful.
+func marshalWithoutEmptyValues(cfg interface{}) ([]byte, error) {
+	buf, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling config")
+	}
+
+	var tree yaml.MapSlice
+	if err := yaml.Unmarshal(buf, &tree); err != nil {
+		return nil, errors.Wrap(err, "unmarshaling config")
+	}
+
+	return yaml.Marshal(pruneEmptyValues(tree))
+}
+
+func pruneEmptyValues(v interface{}) interface{} {
+	switch t := v.(type) {
+	case yaml.MapSlice:
+		pruned := yaml.MapSlice{}
+		for _, item := range t {
+			value := pruneEmptyValues(item.Value)
+			if isEmptyValue(value) {
+				continue
+			}
+			pruned = append(pruned, yaml.MapItem{Key: item.Key, Value: value})
+		}
+		return pruned
+	case []interface{}:
+		var pruned []interface{}
+		for _, item := range t {
+			pruned = append(pruned, pruneEmptyValues(item))
+		}
+		return pruned
+	default:
+		return v
+	}
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case yaml.MapSlice:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const initDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: gcr.io/project/web:v1
+      - name: proxy
+        image: nginx
+`
+
+func writeProjectFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWalkProject(t *testing.T) {
+	root, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	writeProjectFiles(t, root, map[string]string{
+		"web/Dockerfile":             "FROM nginx\n",
+		"proxy/Dockerfile.dev":       "FROM nginx\n",
+		"notes/Dockerfile":           "",
+		"k8s/web.yaml":               initDeployment + "---\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+		"k8s/values.yaml":            "replicas: 1\n",
+		"skaffold.yaml":              "apiVersion: skaffold/v1alpha2\nkind: Config\n",
+		"charts/app/Chart.yaml":      "name: app\n",
+		"charts/app/templates/d.yml": initDeployment,
+		"vendor/k8s/pod.yaml":        initDeployment,
+		".git/Dockerfile":            "FROM scratch\n",
+	})
+
+	p, err := walkProject(root)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, &project{
+		Dockerfiles: []string{filepath.Join("proxy", "Dockerfile.dev"), filepath.Join("web", "Dockerfile")},
+		Manifests:   []string{filepath.Join("k8s", "web.yaml")},
+		Charts:      []string{filepath.Join("charts", "app")},
+		Images:      []string{"gcr.io/project/web", "nginx"},
+	}, p)
+}
+
+func TestParseArtifactFlags(t *testing.T) {
+	var tests = []struct {
+		description string
+		flags       []string
+		expected    map[string]string
+		shouldErr   bool
+	}{
+		{
+			description: "pairs",
+			flags:       []string{"web/Dockerfile=gcr.io/project/web", "./Dockerfile=app"},
+			expected:    map[string]string{"gcr.io/project/web": filepath.Join("web", "Dockerfile"), "app": "Dockerfile"},
+		},
+		{
+			description: "missing image",
+			flags:       []string{"Dockerfile"},
+			shouldErr:   true,
+		},
+		{
+			description: "empty image",
+			flags:       []string{"Dockerfile="},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pairs, err := parseArtifactFlags(test.flags)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, pairs)
+		})
+	}
+}
+
+func TestAutoPair(t *testing.T) {
+	var tests = []struct {
+		description string
+		images      []string
+		dockerfiles []string
+		expected    map[string]string
+		shouldErr   bool
+	}{
+		{
+			description: "single pair",
+			images:      []string{"app"},
+			dockerfiles: []string{"Dockerfile"},
+			expected:    map[string]string{"app": "Dockerfile"},
+		},
+		{
+			description: "no dockerfile",
+			images:      []string{"app"},
+			expected:    map[string]string{},
+		},
+		{
+			description: "ambiguous",
+			images:      []string{"app", "nginx"},
+			dockerfiles: []string{"Dockerfile"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pairs, err := autoPair(test.images, test.dockerfiles)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, pairs)
+		})
+	}
+}
+
+func TestPromptPairs(t *testing.T) {
+	in := strings.NewReader("2\nfoo\n5\n0\n0\n")
+	var out bytes.Buffer
+
+	pairs, err := promptPairs(in, &out, []string{"app", "nginx", "web"}, []string{"Dockerfile", "web/Dockerfile"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, map[string]string{"app": "web/Dockerfile"}, pairs)
+	if !strings.Contains(out.String(), "Please enter a number between 0 and 1") {
+		t.Errorf("invalid choices should be reported, got %s", out.String())
+	}
+}
+
+func TestGenerateConfig(t *testing.T) {
+	var tests = []struct {
+		description string
+		project     *project
+		pairs       map[string]string
+		expected    string
+	}{
+		{
+			description: "kubectl",
+			project: &project{
+				Manifests: []string{filepath.Join("k8s", "web.yaml")},
+			},
+			pairs: map[string]string{
+				"gcr.io/project/web": filepath.Join("web", "Dockerfile"),
+				"app":                "Dockerfile.dev",
+			},
+			expected: `apiVersion: skaffold/v1alpha2
+kind: Config
+build:
+  artifacts:
+  - imageName: app
+    docker:
+      dockerfilePath: Dockerfile.dev
+  - imageName: gcr.io/project/web
+    workspace: web
+deploy:
+  kubectl:
+    manifests:
+    - k8s/web.yaml
+`,
+		},
+		{
+			description: "helm",
+			project: &project{
+				Charts: []string{filepath.Join("charts", "app")},
+			},
+			expected: `apiVersion: skaffold/v1alpha2
+kind: Config
+deploy:
+  helm:
+    releases:
+    - name: app
+      chartPath: charts/app
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			contents, err := generateConfig(test.project, test.pairs)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, string(contents))
+		})
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderOutput string
+)
+
+// NewCmdRender describes the CLI command to build artifacts and render the manifests.
+func NewCmdRender(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Builds the artifacts and prints the manifests that would be deployed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRender(out, filename)
+		},
+	}
+	AddRunDevFlags(cmd)
+	cmd.Flags().StringVarP(&opts.CustomTag, "tag", "t", "", "The optional custom tag to use for images which overrides the current Tagger configuration")
+	cmd.Flags().StringVarP(&renderOutput, "output", "o", "", "File to write the manifests to. Defaults to stdout")
+	return cmd
+}
+
+func runRender(out io.Writer, filename string) error {
+	ctx := context.Background()
+
+	runner, config, err := newRunner(filename)
+	if err != nil {
+		return errors.Wrap(err, "creating runner")
+	}
+
+	// The build output is printed to stderr so that stdout only contains the manifests.
+	buildOut := io.Writer(os.Stderr)
+	manifestsOut := out
+	if renderOutput != "" {
+		f, err := os.Create(renderOutput)
+		if err != nil {
+			return errors.Wrapf(err, "creating %s", renderOutput)
+		}
+		defer f.Close()
+
+		buildOut = out
+		manifestsOut = f
+	}
+
+	return runner.Render(ctx, buildOut, manifestsOut, config.Build.Artifacts)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// requiresResolver merges the required configs into a root config.
+type requiresResolver struct {
+	root *config.SkaffoldConfig
+	// visiting are the configs whose requirements are being resolved, to detect cycles.
+	visiting map[string]bool
+	// merged are the configs already merged, that are merged only once.
+	merged map[string]bool
+	// builtBy maps the image names to the config that builds them.
+	builtBy map[string]string
+}
+
+// resolveRequires merges the artifacts and the deploys of the configs required by cfg,
+// recursively. Every config is merged once, even if it's required several times,
+// and a config is deployed after the configs it requires.
+func resolveRequires(cfg *config.SkaffoldConfig, filename string) error {
+	if len(cfg.Requires) == 0 {
+		return nil
+	}
+
+	r := &requiresResolver{
+		root:     cfg,
+		visiting: map[string]bool{},
+		merged:   map[string]bool{},
+		builtBy:  map[string]string{},
+	}
+
+	// Remote configs, or configs given on stdin, resolve their requirements
+	// relatively to the current directory.
+	dir := "."
+	if filename != "-" && !strings.HasPrefix(filename, "http://") && !strings.HasPrefix(filename, "https://") {
+		abs, err := filepath.Abs(filename)
+		if err != nil {
+			return err
+		}
+		r.visiting[abs] = true
+		dir = filepath.Dir(abs)
+	}
+
+	for _, a := range cfg.Build.Artifacts {
+		r.builtBy[a.ImageName] = filename
+	}
+
+	for _, dep := range cfg.Requires {
+		if err := r.resolve(dir, dep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *requiresResolver) resolve(dir string, dep v1alpha2.ConfigDependency) error {
+	file, err := dependencyFile(dir, dep)
+	if err != nil {
+		return err
+	}
+	if r.visiting[file] {
+		return fmt.Errorf("cycle in the required configs: %s requires itself", file)
+	}
+	if r.merged[file] {
+		return nil
+	}
+
+	logrus.Debugf("Loading required config %s", file)
+	r.visiting[file] = true
+	cfg, err := loadConfig(file, true)
+	if err != nil {
+		return errors.Wrapf(err, "loading required config %s", file)
+	}
+
+	cfgDir := filepath.Dir(file)
+	for _, required := range cfg.Requires {
+		if err := r.resolve(cfgDir, required); err != nil {
+			return err
+		}
+	}
+
+	cfg.Rebase(cfgDir)
+	for _, a := range cfg.Build.Artifacts {
+		if other, present := r.builtBy[a.ImageName]; present {
+			return fmt.Errorf("image %s is built by both %s and %s", a.ImageName, other, file)
+		}
+		r.builtBy[a.ImageName] = file
+		r.root.Build.Artifacts = append(r.root.Build.Artifacts, a)
+	}
+	r.root.Test = append(r.root.Test, cfg.Test...)
+	if cfg.Deploy.DeployType != (v1alpha2.DeployType{}) {
+		r.root.Deploy.Required = append(r.root.Deploy.Required, cfg.Deploy)
+	}
+
+	r.visiting[file] = false
+	r.merged[file] = true
+	return nil
+}
+
+// dependencyFile returns the absolute path of the skaffold config of a dependency.
+func dependencyFile(dir string, dep v1alpha2.ConfigDependency) (string, error) {
+	var path string
+	switch {
+	case dep.Path != "" && dep.Git != nil:
+		return "", fmt.Errorf("required config can't have both a path and a git repository")
+
+	case dep.Path != "":
+		path = dep.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+	case dep.Git != nil:
+		repoDir, err := syncRepo(dep.Git)
+		if err != nil {
+			return "", errors.Wrapf(err, "syncing repository %s", dep.Git.Repo)
+		}
+		path = filepath.Join(repoDir, dep.Git.Path)
+
+	default:
+		return "", fmt.Errorf("required config must have either a path or a git repository")
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "skaffold.yaml")
+	}
+	return filepath.Abs(path)
+}
+
+var invalidRepoDirChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// syncRepo clones a git repository, or updates the clone, and returns its directory.
+func syncRepo(g *v1alpha2.GitDependency) (string, error) {
+	cacheDir, err := homedir.Expand(constants.DefaultRepoCacheDir)
+	if err != nil {
+		return "", errors.Wrap(err, "finding home directory")
+	}
+
+	dir := filepath.Join(cacheDir, repoDirName(g))
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		args := []string{"clone", "--depth", "1"}
+		if g.Ref != "" {
+			args = append(args, "--branch", g.Ref)
+		}
+		args = append(args, g.Repo, dir)
+		return dir, util.RunCmd(exec.Command("git", args...))
+	}
+
+	ref := g.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if err := util.RunCmd(exec.Command("git", "-C", dir, "fetch", "--depth", "1", "origin", ref)); err != nil {
+		return "", err
+	}
+	return dir, util.RunCmd(exec.Command("git", "-C", dir, "reset", "--hard", "FETCH_HEAD"))
+}
+
+// repoDirName is a directory name unique to a repository and a ref.
+func repoDirName(g *v1alpha2.GitDependency) string {
+	name := invalidRepoDirChars.ReplaceAllString(strings.TrimSuffix(g.Repo, ".git"), "-")
+	if g.Ref != "" {
+		name += "@" + invalidRepoDirChars.ReplaceAllString(g.Ref, "-")
+	}
+	return strings.Trim(name, "-")
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func writeConfigs(t *testing.T, dir string, configs map[string]string) {
+	for path, content := range configs {
+		file := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte("apiVersion: skaffold/v1alpha2\nkind: Config\n"+content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadConfigurationWithTagFiles(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	writeConfigs(t, tmpDir, map[string]string{
+		"app/skaffold.yaml": `build:
+  tagPolicy:
+    file:
+      path: build/TAG
+  artifacts:
+  - imageName: web
+    tagPolicy:
+      file:
+        path: /tmp/TAG
+`,
+	})
+
+	cfg, err := readConfiguration(filepath.Join(tmpDir, "app", "skaffold.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, filepath.Join(tmpDir, "app", "build", "TAG"), cfg.Build.TagPolicy.FileTagger.Path)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "/tmp/TAG", cfg.Build.Artifacts[0].TagPolicy.FileTagger.Path)
+}
+
+func TestReadConfigurationWithRequires(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	writeConfigs(t, tmpDir, map[string]string{
+		"skaffold.yaml": `requires:
+- path: backend
+- path: frontend/skaffold.yaml
+`,
+		"backend/skaffold.yaml": `build:
+  artifacts:
+  - imageName: backend
+deploy:
+  kubectl:
+    manifests:
+    - k8s/*.yaml
+`,
+		"frontend/skaffold.yaml": `requires:
+- path: ../backend
+build:
+  artifacts:
+  - imageName: frontend
+    workspace: app
+test:
+- image: frontend
+  structureTests:
+  - test/*.yaml
+deploy:
+  helm:
+    releases:
+    - name: frontend
+      chartPath: chart
+`,
+	})
+
+	cfg, err := readConfiguration(filepath.Join(tmpDir, "skaffold.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var images, workspaces []string
+	for _, a := range cfg.Build.Artifacts {
+		images = append(images, a.ImageName)
+		workspaces = append(workspaces, a.Workspace)
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"backend", "frontend"}, images)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{filepath.Join(tmpDir, "backend"), filepath.Join(tmpDir, "frontend", "app")}, workspaces)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []v1alpha2.TestCase{{
+		ImageName:      "frontend",
+		StructureTests: []string{"test/*.yaml"},
+		Dir:            filepath.Join(tmpDir, "frontend"),
+	}}, cfg.Test)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 2, len(cfg.Deploy.Required))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{filepath.Join(tmpDir, "backend", "k8s", "*.yaml")}, cfg.Deploy.Required[0].KubectlDeploy.Manifests)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, filepath.Join(tmpDir, "frontend", "chart"), cfg.Deploy.Required[1].HelmDeploy.Releases[0].ChartPath)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, v1alpha2.DeployType{}, cfg.Deploy.DeployType)
+}
+
+func TestReadConfigurationWithInvalidRequires(t *testing.T) {
+	var tests = []struct {
+		description string
+		configs     map[string]string
+	}{
+		{
+			description: "cycle",
+			configs: map[string]string{
+				"skaffold.yaml":   "requires:\n- path: a\n",
+				"a/skaffold.yaml": "requires:\n- path: ../b\n",
+				"b/skaffold.yaml": "requires:\n- path: ../a\n",
+			},
+		},
+		{
+			description: "image built twice",
+			configs: map[string]string{
+				"skaffold.yaml":   "requires:\n- path: a\nbuild:\n  artifacts:\n  - imageName: app\n",
+				"a/skaffold.yaml": "build:\n  artifacts:\n  - imageName: app\n",
+			},
+		},
+		{
+			description: "missing config",
+			configs: map[string]string{
+				"skaffold.yaml": "requires:\n- path: missing\n",
+			},
+		},
+		{
+			description: "path and git",
+			configs: map[string]string{
+				"skaffold.yaml": "requires:\n- path: a\n  git:\n    repo: https://github.com/org/repo.git\n",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			writeConfigs(t, tmpDir, test.configs)
+
+			_, err := readConfiguration(filepath.Join(tmpDir, "skaffold.yaml"))
+
+			testutil.CheckError(t, true, err)
+		})
+	}
+}
+
+func TestRepoDirName(t *testing.T) {
+	name := repoDirName(&v1alpha2.GitDependency{Repo: "https://github.com/org/repo.git", Ref: "release/v1"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "https-github.com-org-repo@release-v1", name)
+}
@@ -35,6 +35,7 @@ func NewCmdRun(out io.Writer) *cobra.Command {
 		},
 	}
 	AddRunDevFlags(cmd)
+	cmd.Flags().BoolVar(&opts.StatusCheck, "status-check", true, "Wait for the deployed workloads to be rolled out and fail if they don't stabilize")
 
 	cmd.Flags().StringVarP(&opts.CustomTag, "tag", "t", "", "The optional custom tag to use for images which overrides the current Tagger configuration")
 	return cmd
@@ -31,7 +31,12 @@ import (
 )
 
 func (l *LocalBuilder) buildBazel(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (string, error) {
-	cmd := exec.Command("bazel", "build", a.BazelArtifact.BuildTarget)
+	tarPath, err := bazelTarPath(a.BazelArtifact.BuildTarget)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.CommandContext(ctx, "bazel", "build", a.BazelArtifact.BuildTarget)
 	cmd.Dir = a.Workspace
 	cmd.Stdout = out
 	cmd.Stderr = out
@@ -39,11 +44,6 @@ func (l *LocalBuilder) buildBazel(ctx context.Context, out io.Writer, a *v1alpha
 		return "", errors.Wrap(err, "running command")
 	}
 
-	//TODO(r2d4): strip off leading //:, bad
-	tarPath := strings.TrimPrefix(a.BazelArtifact.BuildTarget, "//:")
-	//TODO(r2d4): strip off trailing .tar, even worse
-	imageTag := strings.TrimSuffix(tarPath, ".tar")
-
 	imageTar, err := os.Open(filepath.Join(a.Workspace, "bazel-bin", tarPath))
 	if err != nil {
 		return "", errors.Wrap(err, "opening image tarball")
@@ -61,5 +61,39 @@ func (l *LocalBuilder) buildBazel(ctx context.Context, out io.Writer, a *v1alpha
 		return "", errors.Wrap(err, "reading from image load response")
 	}
 
-	return fmt.Sprintf("bazel:%s", imageTag), nil
+	return bazelImageTag(a.BazelArtifact.BuildTarget), nil
+}
+
+// bazelTarPath returns the path of the image tarball built by a target, relative to bazel-bin,
+// eg. `cmd/server/image.tar` for `//cmd/server:image.tar`.
+func bazelTarPath(target string) (string, error) {
+	if !strings.HasSuffix(target, ".tar") {
+		return "", fmt.Errorf("bazel target %s should end with .tar, eg. //:image.tar", target)
+	}
+
+	pkg, name := splitBazelTarget(target)
+	return filepath.Join(filepath.FromSlash(pkg), name), nil
+}
+
+// bazelImageTag returns the name that rules_docker gives to the image loaded
+// from a tarball, eg. `bazel/cmd/server:image` for `//cmd/server:image.tar`.
+func bazelImageTag(target string) string {
+	pkg, name := splitBazelTarget(target)
+	name = strings.TrimSuffix(name, ".tar")
+
+	if pkg == "" {
+		return fmt.Sprintf("bazel:%s", name)
+	}
+	return fmt.Sprintf("bazel/%s:%s", pkg, name)
+}
+
+// splitBazelTarget splits a label into its package and its name. A label without
+// a name, eg. `//cmd/server`, is named after the last component of its package.
+func splitBazelTarget(target string) (string, string) {
+	target = strings.TrimPrefix(target, "//")
+
+	if i := strings.Index(target, ":"); i != -1 {
+		return target[:i], target[i+1:]
+	}
+	return target, target[strings.LastIndex(target, "/")+1:]
 }
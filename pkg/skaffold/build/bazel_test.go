@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestBazelTarPath(t *testing.T) {
+	var tests = []struct {
+		description string
+		target      string
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "root package",
+			target:      "//:skaffold_example.tar",
+			expected:    "skaffold_example.tar",
+		},
+		{
+			description: "nested package",
+			target:      "//cmd/server:image.tar",
+			expected:    filepath.Join("cmd", "server", "image.tar"),
+		},
+		{
+			description: "not a tarball",
+			target:      "//cmd/server:image",
+			shouldErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tarPath, err := bazelTarPath(test.target)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tarPath)
+		})
+	}
+}
+
+func TestBazelImageTag(t *testing.T) {
+	var tests = []struct {
+		target   string
+		expected string
+	}{
+		{target: "//:skaffold_example.tar", expected: "bazel:skaffold_example"},
+		{target: "//cmd/server:image.tar", expected: "bazel/cmd/server:image"},
+	}
+	for _, test := range tests {
+		t.Run(test.target, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, bazelImageTag(test.target))
+		})
+	}
+}
@@ -26,8 +26,11 @@ import (
 
 // Build is the result corresponding to each Artifact built.
 type Build struct {
-	ImageName string
-	Tag       string
+	ImageName string `json:"imageName"`
+	Tag       string `json:"tag"`
+
+	// Digest, if known, is the digest of the image in the registry, eg. `sha256:...`.
+	Digest string `json:"digest,omitempty"`
 }
 
 // Builder is an interface to the Build API of Skaffold.
@@ -0,0 +1,42 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io"
+	"os/exec"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/buildpacks"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// buildBuildpacks builds an image into the local docker daemon with Cloud Native Buildpacks.
+func (l *LocalBuilder) buildBuildpacks(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (string, error) {
+	initialTag := util.RandomID()
+
+	cmd := exec.CommandContext(ctx, "pack", buildpacks.Args(a.BuildpackArtifact, initialTag, a.Workspace)...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := util.RunCmd(cmd); err != nil {
+		return "", errors.Wrap(err, "running pack")
+	}
+
+	return initialTag, nil
+}
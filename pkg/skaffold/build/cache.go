@@ -0,0 +1,281 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// maxCachedBuildsPerImage is the number of builds that are remembered for each image.
+// Older builds are pruned when the cache is saved.
+const maxCachedBuildsPerImage = 10
+
+// now is used to record when a cached build was last used.
+var now = time.Now
+
+// ImageChecker tells if an image still exists, either in the local docker daemon or in a registry.
+type ImageChecker func(ctx context.Context, image string) bool
+
+// ArtifactCache remembers the builds of artifacts by the digest of their inputs:
+// their configuration, the content of their dependencies and the tag they would be given.
+type ArtifactCache struct {
+	file        string
+	builds      map[string]cacheEntry
+	imageExists ImageChecker
+}
+
+type cacheEntry struct {
+	Build    Build     `json:"build"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// NewArtifactCache reads the cache from a file, that's created on the first build if it doesn't exist.
+func NewArtifactCache(file string, imageExists ImageChecker) (*ArtifactCache, error) {
+	builds := map[string]cacheEntry{}
+
+	content, err := ioutil.ReadFile(file)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, errors.Wrapf(err, "reading cache %s", file)
+	default:
+		if err := json.Unmarshal(content, &builds); err != nil {
+			logrus.Warnf("Ignoring invalid artifact cache %s: %s", file, err)
+			builds = map[string]cacheEntry{}
+		}
+	}
+
+	for digest, entry := range builds {
+		if entry.Build.ImageName == "" || entry.Build.Tag == "" {
+			delete(builds, digest)
+		}
+	}
+
+	return &ArtifactCache{
+		file:        file,
+		builds:      builds,
+		imageExists: imageExists,
+	}, nil
+}
+
+// LocalImageChecker checks that images exist in the local docker daemon.
+func LocalImageChecker(api docker.APIClient) ImageChecker {
+	return func(ctx context.Context, image string) bool {
+		digest, err := docker.Digest(ctx, api, image)
+		return err == nil && digest != ""
+	}
+}
+
+// RemoteImageChecker checks that images exist in their registry.
+func RemoteImageChecker(ctx context.Context, image string) bool {
+	_, err := docker.RemoteDigest(image)
+	return err == nil
+}
+
+// NewImageChecker checks the local docker daemon for images that are not pushed by the
+// local builder, and the registries otherwise.
+func NewImageChecker(cfg *v1alpha2.BuildConfig) (ImageChecker, error) {
+	if cfg.LocalBuild == nil || cfg.LocalBuild.SkipPush == nil || !*cfg.LocalBuild.SkipPush {
+		return RemoteImageChecker, nil
+	}
+
+	api, err := docker.NewAPIClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting docker client")
+	}
+	return LocalImageChecker(api), nil
+}
+
+// WithCache creates a builder that only builds the artifacts whose inputs changed since
+// they were last built, or whose image does not exist anymore. The previous builds of
+// the other artifacts are reused.
+func WithCache(b Builder, cache *ArtifactCache) Builder {
+	return withCache{
+		Builder: b,
+		cache:   cache,
+	}
+}
+
+type withCache struct {
+	Builder
+	cache *ArtifactCache
+}
+
+func (w withCache) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]Build, error) {
+	digests := map[string]string{}
+	cached := map[string]Build{}
+	var needsBuild []*v1alpha2.Artifact
+
+	for _, artifact := range artifacts {
+		digest, err := artifactDigest(artifact, tagger)
+		if err != nil {
+			logrus.Warnf("Not using the cache for %s: %s", artifact.ImageName, err)
+			needsBuild = append(needsBuild, artifact)
+			continue
+		}
+		digests[artifact.ImageName] = digest
+
+		if entry, present := w.cache.builds[digest]; present && w.cache.imageExists(ctx, entry.Build.Tag) {
+			fmt.Fprintf(out, "Found %s in cache, skipping build\n", entry.Build.Tag)
+			w.cache.builds[digest] = cacheEntry{Build: entry.Build, LastUsed: now()}
+			cached[artifact.ImageName] = entry.Build
+			continue
+		}
+		needsBuild = append(needsBuild, artifact)
+	}
+
+	var builds []Build
+	if len(needsBuild) > 0 {
+		var err error
+		builds, err = w.Builder.Build(ctx, out, tagger, needsBuild)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, build := range builds {
+		if digest, present := digests[build.ImageName]; present {
+			w.cache.builds[digest] = cacheEntry{Build: build, LastUsed: now()}
+		}
+		cached[build.ImageName] = build
+	}
+	if err := w.cache.save(); err != nil {
+		logrus.Warnf("Unable to write artifact cache: %s", err)
+	}
+
+	var all []Build
+	for _, artifact := range artifacts {
+		if build, present := cached[artifact.ImageName]; present {
+			all = append(all, build)
+		}
+	}
+	return all, nil
+}
+
+func (c *ArtifactCache) save() error {
+	c.prune()
+
+	content, err := json.Marshal(c.builds)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.file), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.file, content, 0644)
+}
+
+// prune only keeps the most recently used builds of each image.
+func (c *ArtifactCache) prune() {
+	digestsByImage := map[string][]string{}
+	for digest, entry := range c.builds {
+		digestsByImage[entry.Build.ImageName] = append(digestsByImage[entry.Build.ImageName], digest)
+	}
+
+	for _, digests := range digestsByImage {
+		if len(digests) <= maxCachedBuildsPerImage {
+			continue
+		}
+
+		sort.Slice(digests, func(i, j int) bool {
+			return c.builds[digests[i]].LastUsed.After(c.builds[digests[j]].LastUsed)
+		})
+		for _, digest := range digests[maxCachedBuildsPerImage:] {
+			delete(c.builds, digest)
+		}
+	}
+}
+
+// artifactDigest hashes the configuration of an artifact, the paths and content
+// of its dependencies and the inputs of the tagger.
+func artifactDigest(a *v1alpha2.Artifact, tagger tag.Tagger) (string, error) {
+	h := sha256.New()
+
+	config, err := yaml.Marshal(a)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling artifact")
+	}
+	h.Write(config)
+
+	writeTaggerInputs(h, a, tagger)
+
+	deps, err := DependenciesForArtifact(a)
+	if err != nil {
+		return "", errors.Wrap(err, "getting dependencies")
+	}
+	sort.Strings(deps)
+
+	for _, dep := range deps {
+		path := filepath.Join(a.Workspace, dep)
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d\n", filepath.ToSlash(dep), len(content))
+		h.Write(content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeTaggerInputs hashes the type of the tagger and the tag it gives to the artifact
+// before it's built, so that changing the tag policy, a custom tag or the env values
+// of a template invalidates the cached builds. Taggers that need the digest of the
+// image can't tag it before it's built, but their tag only depends on the content anyway.
+// Taggers that don't depend on the content, eg. dateTime, never hit the cache.
+func writeTaggerInputs(w io.Writer, a *v1alpha2.Artifact, tagger tag.Tagger) {
+	if tagger == nil {
+		return
+	}
+	fmt.Fprintf(w, "tagger=%T\n", tagger)
+
+	expectedTag, err := tagger.GenerateFullyQualifiedImageName(a.Workspace, &tag.Options{
+		ImageName: a.ImageName,
+	})
+	if err != nil {
+		logrus.Debugf("Tagging %s requires the image digest: %s", a.ImageName, err)
+		return
+	}
+	fmt.Fprintf(w, "tag=%s\n", expectedTag)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+// countingBuilder tags each artifact with the number of times it was built.
+type countingBuilder struct {
+	count map[string]int
+}
+
+func (b *countingBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]Build, error) {
+	var builds []Build
+	for _, a := range artifacts {
+		b.count[a.ImageName]++
+		builds = append(builds, Build{
+			ImageName: a.ImageName,
+			Tag:       fmt.Sprintf("%s:%d", a.ImageName, b.count[a.ImageName]),
+		})
+	}
+	return builds, nil
+}
+
+func TestWithCache(t *testing.T) {
+	tmp, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	write := func(file, content string) {
+		if err := ioutil.WriteFile(filepath.Join(tmp, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Dockerfile", "FROM scratch")
+	write("bazelfile", "code")
+
+	DependenciesForArtifact = func(a *v1alpha2.Artifact) ([]string, error) {
+		if a.DockerArtifact != nil {
+			return []string{"Dockerfile"}, nil
+		}
+		return []string{"bazelfile"}, nil
+	}
+	defer func() { DependenciesForArtifact = dependenciesForArtifact }()
+
+	artifacts := []*v1alpha2.Artifact{
+		{ImageName: "docker", Workspace: tmp, ArtifactType: v1alpha2.ArtifactType{DockerArtifact: &v1alpha2.DockerArtifact{}}},
+		{ImageName: "bazel", Workspace: tmp, ArtifactType: v1alpha2.ArtifactType{BazelArtifact: &v1alpha2.BazelArtifact{}}},
+	}
+
+	removed := map[string]bool{}
+	imageExists := func(ctx context.Context, image string) bool { return !removed[image] }
+
+	cacheFile := filepath.Join(tmp, "cache", "builds")
+	inner := &countingBuilder{count: map[string]int{}}
+	build := func() []Build {
+		cache, err := NewArtifactCache(cacheFile, imageExists)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		builds, err := WithCache(inner, cache).Build(context.Background(), ioutil.Discard, nil, artifacts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return builds
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Build{{ImageName: "docker", Tag: "docker:1"}, {ImageName: "bazel", Tag: "bazel:1"}}, build())
+
+	// Nothing changed
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Build{{ImageName: "docker", Tag: "docker:1"}, {ImageName: "bazel", Tag: "bazel:1"}}, build())
+
+	// A dependency changed
+	write("bazelfile", "updated code")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Build{{ImageName: "docker", Tag: "docker:1"}, {ImageName: "bazel", Tag: "bazel:2"}}, build())
+
+	// The image was removed
+	removed["docker:1"] = true
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Build{{ImageName: "docker", Tag: "docker:2"}, {ImageName: "bazel", Tag: "bazel:2"}}, build())
+
+	// Going back to a cached state
+	write("bazelfile", "code")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Build{{ImageName: "docker", Tag: "docker:2"}, {ImageName: "bazel", Tag: "bazel:1"}}, build())
+}
+
+func TestNewArtifactCacheInvalidFile(t *testing.T) {
+	tmp, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	cacheFile := filepath.Join(tmp, "cache")
+	if err := ioutil.WriteFile(cacheFile, []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewArtifactCache(cacheFile, nil)
+	testutil.CheckErrorAndDeepEqual(t, false, err, map[string]cacheEntry{}, cache.builds)
+}
+
+// fixedTagger tags every image with the same tag, eg. like a custom tag.
+type fixedTagger struct {
+	tag string
+}
+
+func (f *fixedTagger) GenerateFullyQualifiedImageName(workingDir string, opts *tag.Options) (string, error) {
+	return opts.ImageName + ":" + f.tag, nil
+}
+
+// namedBuilder tags each artifact with the tagger and counts the builds.
+type namedBuilder struct {
+	count int
+}
+
+func (b *namedBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]Build, error) {
+	var builds []Build
+	for _, a := range artifacts {
+		b.count++
+		name, err := tagger.GenerateFullyQualifiedImageName(a.Workspace, &tag.Options{ImageName: a.ImageName})
+		if err != nil {
+			return nil, err
+		}
+		builds = append(builds, Build{ImageName: a.ImageName, Tag: name})
+	}
+	return builds, nil
+}
+
+func TestWithCacheTaggerInputs(t *testing.T) {
+	tmp, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	if err := ioutil.WriteFile(filepath.Join(tmp, "Dockerfile"), []byte("FROM scratch"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	DependenciesForArtifact = func(a *v1alpha2.Artifact) ([]string, error) { return []string{"Dockerfile"}, nil }
+	defer func() { DependenciesForArtifact = dependenciesForArtifact }()
+
+	var tick int64
+	now = func() time.Time { tick++; return time.Unix(tick, 0) }
+	defer func() { now = time.Now }()
+
+	artifacts := []*v1alpha2.Artifact{
+		{ImageName: "image", Workspace: tmp, ArtifactType: v1alpha2.ArtifactType{DockerArtifact: &v1alpha2.DockerArtifact{}}},
+	}
+	imageExists := func(ctx context.Context, image string) bool { return true }
+
+	cacheFile := filepath.Join(tmp, "cache", "builds")
+	inner := &namedBuilder{}
+	build := func(tagger tag.Tagger) []Build {
+		cache, err := NewArtifactCache(cacheFile, imageExists)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		builds, err := WithCache(inner, cache).Build(context.Background(), ioutil.Discard, tagger, artifacts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return builds
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Build{{ImageName: "image", Tag: "image:v1"}}, build(&fixedTagger{tag: "v1"}))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Build{{ImageName: "image", Tag: "image:v1"}}, build(&fixedTagger{tag: "v1"}))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, inner.count)
+
+	// The tag changed
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []Build{{ImageName: "image", Tag: "image:v2"}}, build(&fixedTagger{tag: "v2"}))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 2, inner.count)
+
+	// Old builds are pruned
+	for i := 3; i <= maxCachedBuildsPerImage+2; i++ {
+		build(&fixedTagger{tag: fmt.Sprintf("v%d", i)})
+	}
+	cache, err := NewArtifactCache(cacheFile, imageExists)
+	testutil.CheckErrorAndDeepEqual(t, false, err, maxCachedBuildsPerImage, len(cache.builds))
+
+	build(&fixedTagger{tag: "v1"})
+	testutil.CheckErrorAndDeepEqual(t, false, nil, maxCachedBuildsPerImage+3, inner.count)
+}
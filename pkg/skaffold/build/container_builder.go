@@ -63,8 +63,11 @@ const (
 	// StatusCancelled  "CANCELLED" - Build was canceled by a user.
 	StatusCancelled = "CANCELLED"
 
-	// RetryDelay is the time to wait in between polling the status of the cloud build
+	// RetryDelay is the initial time to wait in between polling the status of the cloud build
 	RetryDelay = 1 * time.Second
+
+	// MaxRetryDelay is the maximum time to wait in between polling the status of the cloud build
+	MaxRetryDelay = 10 * time.Second
 )
 
 type GoogleCloudBuilder struct {
@@ -108,15 +111,6 @@ func (cb *GoogleCloudBuilder) Build(ctx context.Context, out io.Writer, tagger t
 func (cb *GoogleCloudBuilder) buildArtifact(ctx context.Context, out io.Writer, tagger tag.Tagger, cbclient *cloudbuild.Service, c *cstorage.Client, artifact *v1alpha2.Artifact) (*Build, error) {
 	logrus.Infof("Building artifact: %+v", artifact)
 
-	// need to format build args as strings to pass to container builder docker
-	var buildArgs []string
-	for k, v := range artifact.DockerArtifact.BuildArgs {
-		if v != nil {
-			buildArgs = append(buildArgs, []string{"--build-arg", fmt.Sprintf("%s=%s", k, *v)}...)
-		}
-	}
-	logrus.Debugf("Build args: %s", buildArgs)
-
 	cbBucket := fmt.Sprintf("%s%s", cb.GoogleCloudBuild.ProjectID, constants.GCSBucketSuffix)
 	buildObject := fmt.Sprintf("source/%s-%s.tar.gz", cb.GoogleCloudBuild.ProjectID, util.RandomID())
 
@@ -128,28 +122,16 @@ func (cb *GoogleCloudBuilder) buildArtifact(ctx context.Context, out io.Writer,
 	}
 
 	fmt.Fprintf(out, "Pushing code to gs://%s/%s\n", cbBucket, buildObject)
-	if err := docker.UploadContextToGCS(ctx, artifact.DockerArtifact.DockerfilePath, artifact.Workspace, cbBucket, buildObject); err != nil {
+	if err := docker.UploadContextToGCS(ctx, artifact.DockerArtifact.DockerfilePath, artifact.Workspace, cbBucket, buildObject, artifact.DockerArtifact.BuildArgs); err != nil {
 		return nil, errors.Wrap(err, "uploading source tarball")
 	}
 
-	args := append([]string{"build", "--tag", artifact.ImageName, "-f", artifact.DockerArtifact.DockerfilePath}, buildArgs...)
-	args = append(args, ".")
-	call := cbclient.Projects.Builds.Create(cb.GoogleCloudBuild.ProjectID, &cloudbuild.Build{
-		LogsBucket: cbBucket,
-		Source: &cloudbuild.Source{
-			StorageSource: &cloudbuild.StorageSource{
-				Bucket: cbBucket,
-				Object: buildObject,
-			},
-		},
-		Steps: []*cloudbuild.BuildStep{
-			{
-				Name: "gcr.io/cloud-builders/docker",
-				Args: args,
-			},
-		},
-		Images: []string{artifact.ImageName},
-	})
+	desc, err := cb.buildDescription(artifact, cbBucket, buildObject)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating build description")
+	}
+
+	call := cbclient.Projects.Builds.Create(cb.GoogleCloudBuild.ProjectID, desc)
 	op, err := call.Context(ctx).Do()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create build")
@@ -163,20 +145,22 @@ func (cb *GoogleCloudBuilder) buildArtifact(ctx context.Context, out io.Writer,
 	fmt.Fprintf(out, "Logs at available at \nhttps://console.cloud.google.com/m/cloudstorage/b/%s/o/%s\n", cbBucket, logsObject)
 	var imageID string
 	offset := int64(0)
+	delay := RetryDelay
 watch:
 	for {
 		logrus.Debugf("current offset %d", offset)
-		b, err := cbclient.Projects.Builds.Get(cb.GoogleCloudBuild.ProjectID, remoteID).Do()
+		b, err := cbclient.Projects.Builds.Get(cb.GoogleCloudBuild.ProjectID, remoteID).Context(ctx).Do()
 		if err != nil {
 			return nil, errors.Wrap(err, "getting build status")
 		}
 
-		r, err := cb.getLogs(ctx, offset, cbBucket, logsObject)
+		r, err := cb.getLogs(ctx, c, offset, cbBucket, logsObject)
 		if err != nil {
 			return nil, errors.Wrap(err, "getting logs")
 		}
+		written := int64(0)
 		if r != nil {
-			written, err := io.Copy(out, r)
+			written, err = io.Copy(out, r)
 			if err != nil {
 				return nil, errors.Wrap(err, "copying logs to stdout")
 			}
@@ -197,7 +181,16 @@ watch:
 			return nil, fmt.Errorf("unknown status: %s", b.Status)
 		}
 
-		time.Sleep(RetryDelay)
+		// Poll often while logs are written, less often when the build is queued or silent.
+		if written > 0 {
+			delay = RetryDelay
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = backoff(delay)
 	}
 
 	if err := c.Bucket(cbBucket).Object(buildObject).Delete(ctx); err != nil {
@@ -226,6 +219,71 @@ watch:
 	}, nil
 }
 
+// buildDescription describes the Google Cloud Build that builds an artifact
+// from the sources uploaded to the bucket.
+func (cb *GoogleCloudBuilder) buildDescription(artifact *v1alpha2.Artifact, bucket, object string) (*cloudbuild.Build, error) {
+	// ssh forwarding and secret mounts need BuildKit and files that are not uploaded.
+	if len(artifact.DockerArtifact.SSH) > 0 || len(artifact.DockerArtifact.Secrets) > 0 {
+		return nil, fmt.Errorf("ssh and secrets are not supported by Google Cloud Build")
+	}
+
+	buildFlags := docker.BuildFlags(dockerBuildOptions(artifact, artifact.ImageName))
+	logrus.Debugf("Build flags: %s", buildFlags)
+
+	args := append([]string{"build", "--tag", artifact.ImageName, "-f", artifact.DockerArtifact.DockerfilePath}, buildFlags...)
+	args = append(args, ".")
+
+	dockerImage := cb.GoogleCloudBuild.DockerImage
+	if dockerImage == "" {
+		dockerImage = constants.DefaultCloudBuildDockerImage
+	}
+
+	var timeout string
+	if cb.GoogleCloudBuild.Timeout != "" {
+		d, err := time.ParseDuration(cb.GoogleCloudBuild.Timeout)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing timeout %s", cb.GoogleCloudBuild.Timeout)
+		}
+		timeout = fmt.Sprintf("%ds", int64(d.Seconds()))
+	}
+
+	var options *cloudbuild.BuildOptions
+	if cb.GoogleCloudBuild.DiskSizeGb != 0 || cb.GoogleCloudBuild.MachineType != "" {
+		options = &cloudbuild.BuildOptions{
+			DiskSizeGb:  cb.GoogleCloudBuild.DiskSizeGb,
+			MachineType: cb.GoogleCloudBuild.MachineType,
+		}
+	}
+
+	return &cloudbuild.Build{
+		LogsBucket: bucket,
+		Source: &cloudbuild.Source{
+			StorageSource: &cloudbuild.StorageSource{
+				Bucket: bucket,
+				Object: object,
+			},
+		},
+		Steps: []*cloudbuild.BuildStep{
+			{
+				Name: dockerImage,
+				Args: args,
+			},
+		},
+		Images:  []string{artifact.ImageName},
+		Options: options,
+		Timeout: timeout,
+	}, nil
+}
+
+// backoff doubles the delay between two polls, up to MaxRetryDelay.
+func backoff(delay time.Duration) time.Duration {
+	delay *= 2
+	if delay > MaxRetryDelay {
+		return MaxRetryDelay
+	}
+	return delay
+}
+
 func getBuildID(op *cloudbuild.Operation) (string, error) {
 	if op.Metadata == nil {
 		return "", errors.New("missing Metadata in operation")
@@ -247,13 +305,7 @@ func getImageID(b *cloudbuild.Build) (string, error) {
 	return b.Results.Images[0].Digest, nil
 }
 
-func (cb *GoogleCloudBuilder) getLogs(ctx context.Context, offset int64, bucket, objectName string) (io.ReadCloser, error) {
-	c, err := cstorage.NewClient(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "getting storage client")
-	}
-	defer c.Close()
-
+func (cb *GoogleCloudBuilder) getLogs(ctx context.Context, c *cstorage.Client, offset int64, bucket, objectName string) (io.ReadCloser, error) {
 	r, err := c.Bucket(bucket).Object(objectName).NewRangeReader(ctx, offset, -1)
 	if err != nil {
 		if gerr, ok := err.(*googleapi.Error); ok {
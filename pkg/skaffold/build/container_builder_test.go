@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	cloudbuild "google.golang.org/api/cloudbuild/v1"
+)
+
+func TestBuildDescription(t *testing.T) {
+	value := "value"
+	artifact := &v1alpha2.Artifact{
+		ImageName: "gcr.io/project/image",
+		ArtifactType: v1alpha2.ArtifactType{
+			DockerArtifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: "Dockerfile",
+				BuildArgs: map[string]*string{
+					"key":   &value,
+					"unset": nil,
+				},
+			},
+		},
+	}
+
+	var tests = []struct {
+		description string
+		cfg         v1alpha2.GoogleCloudBuild
+		ssh         []string
+		expected    *cloudbuild.Build
+		shouldErr   bool
+	}{
+		{
+			description: "defaults",
+			cfg:         v1alpha2.GoogleCloudBuild{ProjectID: "project"},
+			expected: &cloudbuild.Build{
+				LogsBucket: "bucket",
+				Source: &cloudbuild.Source{
+					StorageSource: &cloudbuild.StorageSource{
+						Bucket: "bucket",
+						Object: "object",
+					},
+				},
+				Steps: []*cloudbuild.BuildStep{{
+					Name: "gcr.io/cloud-builders/docker",
+					Args: []string{"build", "--tag", "gcr.io/project/image", "-f", "Dockerfile", "--build-arg", "key=value", "."},
+				}},
+				Images: []string{"gcr.io/project/image"},
+			},
+		},
+		{
+			description: "full config",
+			cfg: v1alpha2.GoogleCloudBuild{
+				ProjectID:   "project",
+				DiskSizeGb:  200,
+				MachineType: "N1_HIGHCPU_8",
+				Timeout:     "20m",
+				DockerImage: "gcr.io/cloud-builders/docker:18.06",
+			},
+			expected: &cloudbuild.Build{
+				LogsBucket: "bucket",
+				Source: &cloudbuild.Source{
+					StorageSource: &cloudbuild.StorageSource{
+						Bucket: "bucket",
+						Object: "object",
+					},
+				},
+				Steps: []*cloudbuild.BuildStep{{
+					Name: "gcr.io/cloud-builders/docker:18.06",
+					Args: []string{"build", "--tag", "gcr.io/project/image", "-f", "Dockerfile", "--build-arg", "key=value", "."},
+				}},
+				Images: []string{"gcr.io/project/image"},
+				Options: &cloudbuild.BuildOptions{
+					DiskSizeGb:  200,
+					MachineType: "N1_HIGHCPU_8",
+				},
+				Timeout: "1200s",
+			},
+		},
+		{
+			description: "ssh is not supported",
+			cfg:         v1alpha2.GoogleCloudBuild{ProjectID: "project"},
+			ssh:         []string{"default"},
+			shouldErr:   true,
+		},
+		{
+			description: "invalid timeout",
+			cfg:         v1alpha2.GoogleCloudBuild{Timeout: "20 minutes"},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			builder := &GoogleCloudBuilder{&v1alpha2.BuildConfig{
+				BuildType: v1alpha2.BuildType{
+					GoogleCloudBuild: &test.cfg,
+				},
+			}}
+
+			artifact.DockerArtifact.SSH = test.ssh
+			desc, err := builder.buildDescription(artifact, "bucket", "object")
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, desc)
+		})
+	}
+}
+
+func TestBuildDescriptionBuildArgs(t *testing.T) {
+	one, two, three := "1", "2", "3"
+	artifact := &v1alpha2.Artifact{
+		ImageName: "gcr.io/project/image",
+		ArtifactType: v1alpha2.ArtifactType{
+			DockerArtifact: &v1alpha2.DockerArtifact{
+				DockerfilePath: "Dockerfile",
+				BuildArgs: map[string]*string{
+					"C":     &three,
+					"A":     &one,
+					"unset": nil,
+					"B":     &two,
+				},
+			},
+		},
+	}
+	builder := &GoogleCloudBuilder{&v1alpha2.BuildConfig{
+		BuildType: v1alpha2.BuildType{
+			GoogleCloudBuild: &v1alpha2.GoogleCloudBuild{ProjectID: "project"},
+		},
+	}}
+
+	desc, err := builder.buildDescription(artifact, "bucket", "object")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"build", "--tag", "gcr.io/project/image", "-f", "Dockerfile",
+		"--build-arg", "A=1", "--build-arg", "B=2", "--build-arg", "C=3", "."}, desc.Steps[0].Args)
+}
+
+func TestBackoff(t *testing.T) {
+	var delays []time.Duration
+	for delay := RetryDelay; len(delays) < 6; delay = backoff(delay) {
+		delays = append(delays, delay)
+	}
+
+	expected := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, delays)
+}
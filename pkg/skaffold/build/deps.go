@@ -23,7 +23,9 @@ import (
 	"strings"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/bazel"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/buildpacks"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/jib"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -38,7 +40,7 @@ type DependencyMap struct {
 	pathToArtifacts map[string][]*v1alpha2.Artifact
 }
 
-//TODO(@r2d4): Figure out best UX to support configuring this blacklist
+// TODO(@r2d4): Figure out best UX to support configuring this blacklist
 var ignoredPrefixes = []string{"vendor", ".git"}
 
 func (d *DependencyMap) Paths() []string {
@@ -65,6 +67,17 @@ func (d *DependencyMap) ArtifactsForPaths(paths []string) []*v1alpha2.Artifact {
 	return artifacts
 }
 
+// ChangedPathsByArtifact groups paths by the artifacts that depend on them.
+func (d *DependencyMap) ChangedPathsByArtifact(paths []string) map[*v1alpha2.Artifact][]string {
+	m := map[*v1alpha2.Artifact][]string{}
+	for _, p := range paths {
+		for _, a := range d.pathToArtifacts[p] {
+			m[a] = append(m[a], p)
+		}
+	}
+	return m
+}
+
 func NewDependencyMap(artifacts []*v1alpha2.Artifact) (*DependencyMap, error) {
 	m, err := pathToArtifactMap(artifacts)
 	if err != nil {
@@ -116,11 +129,20 @@ var DependenciesForArtifact = dependenciesForArtifact
 
 func dependenciesForArtifact(a *v1alpha2.Artifact) ([]string, error) {
 	if a.DockerArtifact != nil {
-		return docker.GetDependencies(a.DockerArtifact.DockerfilePath, a.Workspace)
+		return docker.GetDependencies(a.DockerArtifact.DockerfilePath, a.Workspace, a.DockerArtifact.BuildArgs)
 	}
 	if a.BazelArtifact != nil {
 		return bazel.GetDependencies(a)
 	}
+	if a.JibMavenArtifact != nil {
+		return jib.GetDependenciesMaven(a)
+	}
+	if a.JibGradleArtifact != nil {
+		return jib.GetDependenciesGradle(a)
+	}
+	if a.BuildpackArtifact != nil {
+		return buildpacks.GetDependencies(a)
+	}
 
 	return nil, fmt.Errorf("undefined artifact type: %+v", a.ArtifactType)
 }
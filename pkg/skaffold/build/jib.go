@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"context"
+	"io"
+	"os/exec"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/jib"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// buildJibMaven builds an image into the local docker daemon with the jib maven plugin.
+func (l *LocalBuilder) buildJibMaven(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (string, error) {
+	initialTag := util.RandomID()
+
+	args := append(jib.MavenArgs(a.JibMavenArtifact), "--batch-mode", "prepare-package", "jib:dockerBuild", "-Dimage="+initialTag)
+	cmd := exec.CommandContext(ctx, jib.MavenCommand(a.Workspace), args...)
+
+	return initialTag, runJib(cmd, out, a.Workspace)
+}
+
+// buildJibGradle builds an image into the local docker daemon with the jib gradle plugin.
+func (l *LocalBuilder) buildJibGradle(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (string, error) {
+	initialTag := util.RandomID()
+
+	cmd := exec.CommandContext(ctx, jib.GradleCommand(a.Workspace), jib.GradleTask(a.JibGradleArtifact, "jibDockerBuild"), "--image="+initialTag)
+
+	return initialTag, runJib(cmd, out, a.Workspace)
+}
+
+func runJib(cmd *exec.Cmd, out io.Writer, workspace string) error {
+	cmd.Dir = workspace
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := util.RunCmd(cmd); err != nil {
+		return errors.Wrap(err, "running jib")
+	}
+	return nil
+}
@@ -53,7 +53,7 @@ func (k *KanikoBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tag
 		return nil, errors.Wrap(err, "reading secret")
 	}
 
-	_, err = client.CoreV1().Secrets("default").Create(&v1.Secret{
+	_, err = client.CoreV1().Secrets(k.KanikoBuild.Namespace).Create(&v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   "kaniko-secret",
 			Labels: map[string]string{"kaniko": "kaniko"},
@@ -66,16 +66,39 @@ func (k *KanikoBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tag
 		logrus.Warnf("creating secret: %s", err)
 	}
 	defer func() {
-		if err := client.CoreV1().Secrets("default").Delete("kaniko-secret", &metav1.DeleteOptions{}); err != nil {
+		if err := client.CoreV1().Secrets(k.KanikoBuild.Namespace).Delete("kaniko-secret", &metav1.DeleteOptions{}); err != nil {
 			logrus.Warnf("deleting secret")
 		}
 	}()
 
+	if len(k.RegistryCertificates) > 0 {
+		data, err := kaniko.RegistryCertificatesData(k.RegistryCertificates)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = client.CoreV1().Secrets(k.KanikoBuild.Namespace).Create(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   kaniko.RegistryCertificatesSecret,
+				Labels: map[string]string{"kaniko": "kaniko"},
+			},
+			Data: data,
+		})
+		if err != nil {
+			logrus.Warnf("creating registry certificates secret: %s", err)
+		}
+		defer func() {
+			if err := client.CoreV1().Secrets(k.KanikoBuild.Namespace).Delete(kaniko.RegistryCertificatesSecret, &metav1.DeleteOptions{}); err != nil {
+				logrus.Warnf("deleting registry certificates secret")
+			}
+		}()
+	}
+
 	// TODO(r2d4): parallel builds
 	var builds []Build
 
 	for _, artifact := range artifacts {
-		initialTag, err := kaniko.RunKanikoBuild(ctx, out, artifact, k.KanikoBuild)
+		initialTag, err := kaniko.RunKanikoBuild(ctx, out, artifact, k.KanikoBuild, k.RegistryCertificates)
 		if err != nil {
 			return nil, errors.Wrapf(err, "running kaniko build for %s", artifact.ImageName)
 		}
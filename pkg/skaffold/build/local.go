@@ -17,14 +17,15 @@ limitations under the License.
 package build
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
@@ -53,11 +54,11 @@ func NewLocalBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (*LocalBuild
 
 		kubeContext:  kubeContext,
 		api:          api,
-		localCluster: kubeContext == constants.DefaultMinikubeContext || kubeContext == constants.DefaultDockerForDesktopContext,
+		localCluster: docker.IsLocalCluster(kubeContext),
 	}
 
 	if cfg.LocalBuild.SkipPush == nil {
-		logrus.Debugf("skipPush value not present. defaulting to cluster default %t (minikube=true, d4d=true, gke=false)", l.localCluster)
+		logrus.Debugf("skipPush value not present. defaulting to cluster default %t (minikube=true, docker-desktop=true, remote=false)", l.localCluster)
 		cfg.LocalBuild.SkipPush = &l.localCluster
 	}
 
@@ -71,12 +72,23 @@ func (l *LocalBuilder) runBuildForArtifact(ctx context.Context, out io.Writer, a
 	if artifact.BazelArtifact != nil {
 		return l.buildBazel(ctx, out, artifact)
 	}
+	if artifact.JibMavenArtifact != nil {
+		return l.buildJibMaven(ctx, out, artifact)
+	}
+	if artifact.JibGradleArtifact != nil {
+		return l.buildJibGradle(ctx, out, artifact)
+	}
+	if artifact.BuildpackArtifact != nil {
+		return l.buildBuildpacks(ctx, out, artifact)
+	}
 
 	return "", fmt.Errorf("undefined artifact type: %+v", artifact.ArtifactType)
 }
 
 // Build runs a docker build on the host and tags the resulting image with
 // its checksum. It streams build progress to the writer argument.
+// With a concurrency greater than 1, artifacts are built in parallel and the
+// output of each build is buffered and written as a whole, in order.
 func (l *LocalBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]Build, error) {
 	if l.localCluster {
 		if _, err := fmt.Fprintf(out, "Found [%s] context, using local docker daemon.\n", l.kubeContext); err != nil {
@@ -85,49 +97,115 @@ func (l *LocalBuilder) Build(ctx context.Context, out io.Writer, tagger tag.Tagg
 	}
 	defer l.api.Close()
 
+	if l.LocalBuild == nil || l.LocalBuild.Concurrency <= 1 || len(artifacts) <= 1 {
+		return l.buildSequentially(ctx, out, tagger, artifacts)
+	}
+	return l.buildInParallel(ctx, out, tagger, artifacts, l.LocalBuild.Concurrency)
+}
+
+func (l *LocalBuilder) buildSequentially(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]Build, error) {
 	var builds []Build
 
 	for _, artifact := range artifacts {
-		initialTag, err := l.runBuildForArtifact(ctx, out, artifact)
+		build, err := l.buildArtifact(ctx, out, tagger, artifact)
 		if err != nil {
-			return nil, errors.Wrap(err, "running build for artifact")
+			return nil, err
 		}
 
-		digest, err := docker.Digest(ctx, l.api, initialTag)
-		if err != nil {
-			return nil, errors.Wrapf(err, "build and tag: %s", initialTag)
-		}
-		if digest == "" {
-			return nil, fmt.Errorf("digest not found")
-		}
-		tag, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.Options{
-			ImageName: artifact.ImageName,
-			Digest:    digest,
-		})
-		if err != nil {
-			return nil, errors.Wrap(err, "generating tag")
-		}
-		if err := l.api.ImageTag(ctx, initialTag, tag); err != nil {
-			return nil, errors.Wrap(err, "tagging image")
-		}
-		if _, err := io.WriteString(out, fmt.Sprintf("Successfully tagged %s\n", tag)); err != nil {
-			return nil, errors.Wrap(err, "writing tag status")
-		}
-		if !*l.LocalBuild.SkipPush {
-			if err := docker.RunPush(ctx, l.api, tag, out); err != nil {
-				return nil, errors.Wrap(err, "running push")
+		builds = append(builds, build)
+	}
+
+	return builds, nil
+}
+
+func (l *LocalBuilder) buildInParallel(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact, concurrency int) ([]Build, error) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	n := len(artifacts)
+	outputs := make([]bytes.Buffer, n)
+	builds := make([]Build, n)
+	errs := make([]error, n)
+	done := make([]chan struct{}, n)
+	sem := make(chan struct{}, concurrency)
+
+	for i := range artifacts {
+		done[i] = make(chan struct{})
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(done[i])
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
 			}
-		}
+			defer func() { <-sem }()
 
-		builds = append(builds, Build{
-			ImageName: artifact.ImageName,
-			Tag:       tag,
-		})
+			builds[i], errs[i] = l.buildArtifact(ctx, &outputs[i], tagger, artifacts[i])
+		}(i)
+	}
+
+	// Write the outputs in order, as soon as each build is done.
+	for i := range artifacts {
+		<-done[i]
+
+		if _, err := io.Copy(out, &outputs[i]); err != nil {
+			return nil, errors.Wrap(err, "writing build output")
+		}
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
 	}
 
 	return builds, nil
 }
 
+// buildArtifact builds, tags and, unless skipped, pushes an artifact.
+func (l *LocalBuilder) buildArtifact(ctx context.Context, out io.Writer, tagger tag.Tagger, artifact *v1alpha2.Artifact) (Build, error) {
+	initialTag, err := l.runBuildForArtifact(ctx, out, artifact)
+	if err != nil {
+		return Build{}, errors.Wrap(err, "running build for artifact")
+	}
+
+	digest, err := docker.Digest(ctx, l.api, initialTag)
+	if err != nil {
+		return Build{}, errors.Wrapf(err, "build and tag: %s", initialTag)
+	}
+	if digest == "" {
+		return Build{}, fmt.Errorf("digest not found")
+	}
+	tag, err := tagger.GenerateFullyQualifiedImageName(artifact.Workspace, &tag.Options{
+		ImageName: artifact.ImageName,
+		Digest:    digest,
+	})
+	if err != nil {
+		return Build{}, errors.Wrap(err, "generating tag")
+	}
+	if err := l.api.ImageTag(ctx, initialTag, tag); err != nil {
+		return Build{}, errors.Wrap(err, "tagging image")
+	}
+	if _, err := io.WriteString(out, fmt.Sprintf("Successfully tagged %s\n", tag)); err != nil {
+		return Build{}, errors.Wrap(err, "writing tag status")
+	}
+	if !*l.LocalBuild.SkipPush {
+		if err := docker.RunPush(ctx, l.api, tag, out); err != nil {
+			return Build{}, errors.Wrap(err, "running push")
+		}
+	}
+
+	return Build{
+		ImageName: artifact.ImageName,
+		Tag:       tag,
+	}, nil
+}
+
 func (l *LocalBuilder) buildDocker(ctx context.Context, out io.Writer, a *v1alpha2.Artifact) (string, error) {
 	initialTag := util.RandomID()
 	// Add a sanity check to check if the dockerfile exists before running the build
@@ -137,16 +215,26 @@ func (l *LocalBuilder) buildDocker(ctx context.Context, out io.Writer, a *v1alph
 		}
 		return "", errors.Wrap(err, "stat dockerfile")
 	}
-	err := docker.RunBuild(ctx, l.api, &docker.BuildOptions{
-		ImageName:   initialTag,
+	opts := dockerBuildOptions(a, initialTag)
+	opts.ProgressBuf = out
+	opts.BuildBuf = out
+	if err := docker.RunBuild(ctx, l.api, opts); err != nil {
+		return "", errors.Wrap(err, "running build")
+	}
+	return fmt.Sprintf("%s:latest", initialTag), nil
+}
+
+// dockerBuildOptions are the options to build a docker artifact as the given image.
+func dockerBuildOptions(a *v1alpha2.Artifact, image string) *docker.BuildOptions {
+	return &docker.BuildOptions{
+		ImageName:   image,
 		Dockerfile:  a.DockerArtifact.DockerfilePath,
 		ContextDir:  a.Workspace,
-		ProgressBuf: out,
-		BuildBuf:    out,
 		BuildArgs:   a.DockerArtifact.BuildArgs,
-	})
-	if err != nil {
-		return "", errors.Wrap(err, "running build")
+		Target:      a.DockerArtifact.Target,
+		NetworkMode: a.DockerArtifact.NetworkMode,
+		CacheFrom:   a.DockerArtifact.CacheFrom,
+		SSH:         a.DockerArtifact.SSH,
+		Secrets:     a.DockerArtifact.Secrets,
 	}
-	return fmt.Sprintf("%s:latest", initialTag), nil
 }
@@ -146,6 +146,83 @@ func TestLocalRun(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "parallel build",
+			out:         &bytes.Buffer{},
+			config: &v1alpha2.BuildConfig{
+				Artifacts: []*v1alpha2.Artifact{
+					{
+						ImageName: "gcr.io/test/image",
+						Workspace: tmp,
+						ArtifactType: v1alpha2.ArtifactType{
+							DockerArtifact: &v1alpha2.DockerArtifact{},
+						},
+					},
+					{
+						ImageName: "gcr.io/test/image2",
+						Workspace: tmp,
+						ArtifactType: v1alpha2.ArtifactType{
+							DockerArtifact: &v1alpha2.DockerArtifact{},
+						},
+					},
+					{
+						ImageName: "gcr.io/test/image3",
+						Workspace: tmp,
+						ArtifactType: v1alpha2.ArtifactType{
+							DockerArtifact: &v1alpha2.DockerArtifact{},
+						},
+					},
+				},
+				BuildType: v1alpha2.BuildType{
+					LocalBuild: &v1alpha2.LocalBuild{
+						SkipPush:    util.BoolPtr(true),
+						Concurrency: 2,
+					},
+				},
+			},
+			tagger: &tag.ChecksumTagger{},
+			api:    testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{}),
+			expected: []Build{
+				{
+					ImageName: "gcr.io/test/image",
+					Tag:       "gcr.io/test/image:imageid",
+				},
+				{
+					ImageName: "gcr.io/test/image2",
+					Tag:       "gcr.io/test/image2:imageid",
+				},
+				{
+					ImageName: "gcr.io/test/image3",
+					Tag:       "gcr.io/test/image3:imageid",
+				},
+			},
+		},
+		{
+			description: "error parallel build",
+			out:         &bytes.Buffer{},
+			config: &v1alpha2.BuildConfig{
+				Artifacts: []*v1alpha2.Artifact{
+					{
+						ImageName: "test",
+						Workspace: ".",
+					},
+					{
+						ImageName: "test2",
+						Workspace: ".",
+					},
+				},
+				BuildType: v1alpha2.BuildType{
+					LocalBuild: &v1alpha2.LocalBuild{
+						Concurrency: 2,
+					},
+				},
+			},
+			tagger: &tag.ChecksumTagger{},
+			api: testutil.NewFakeImageAPIClient(map[string]string{}, &testutil.FakeImageAPIOptions{
+				ErrImageBuild: true,
+			}),
+			shouldErr: true,
+		},
 		{
 			description:  "local cluster bad writer",
 			out:          &testutil.BadWriter{},
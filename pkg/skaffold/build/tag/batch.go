@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BatchItem is an image to be tagged by GenerateBatch.
+type BatchItem struct {
+	Tagger     Tagger
+	WorkingDir string
+	Opts       *Options
+}
+
+// BatchResult is the outcome of tagging a BatchItem.
+type BatchResult struct {
+	FQN string
+	Err error
+}
+
+// GenerateBatch tags many images, possibly from different repositories, with at most
+// concurrency taggers running at the same time. The results are in the same order as
+// the items. A failing item doesn't abort the batch: its error is reported in its result.
+func GenerateBatch(items []BatchItem, concurrency int) ([]BatchResult, error) {
+	results := make([]BatchResult, len(items))
+
+	err := runBatch(len(items), concurrency, func(index int) {
+		results[index] = generateItem(items[index])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// TimedBatchResult is a BatchResult with the time it took to tag the item.
+type TimedBatchResult struct {
+	BatchResult
+	Duration time.Duration
+}
+
+// GenerateBatchTimed is like GenerateBatch but also reports how long each item took,
+// eg. to find which artifact or tagger is slow.
+func GenerateBatchTimed(items []BatchItem, concurrency int) ([]TimedBatchResult, error) {
+	return generateBatchTimed(items, concurrency, time.Now)
+}
+
+func generateBatchTimed(items []BatchItem, concurrency int, timeFn func() time.Time) ([]TimedBatchResult, error) {
+	results := make([]TimedBatchResult, len(items))
+
+	err := runBatch(len(items), concurrency, func(index int) {
+		start := timeFn()
+		result := generateItem(items[index])
+		results[index] = TimedBatchResult{
+			BatchResult: result,
+			Duration:    timeFn().Sub(start),
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// runBatch calls fn for every index from 0 to count-1, with at most concurrency calls at the same time.
+func runBatch(count, concurrency int, fn func(index int)) error {
+	if concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d", concurrency)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				fn(index)
+			}
+		}()
+	}
+
+	for index := 0; index < count; index++ {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	return nil
+}
+
+func generateItem(item BatchItem) BatchResult {
+	if item.Tagger == nil {
+		return BatchResult{Err: fmt.Errorf("no tagger provided for %s", item.WorkingDir)}
+	}
+
+	fqn, err := item.Tagger.GenerateFullyQualifiedImageName(item.WorkingDir, item.Opts)
+	return BatchResult{
+		FQN: fqn,
+		Err: err,
+	}
+}
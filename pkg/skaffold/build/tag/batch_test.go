@@ -0,0 +1,144 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+// concurrencyTagger records the maximum number of concurrent calls.
+type concurrencyTagger struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (c *concurrencyTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	c.mu.Lock()
+	c.current++
+	if c.current > c.max {
+		c.max = c.current
+	}
+	c.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	c.mu.Lock()
+	c.current--
+	c.mu.Unlock()
+
+	return opts.ImageName + ":" + workingDir, nil
+}
+
+func TestGenerateBatch(t *testing.T) {
+	repoDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	notARepo, cleanupNotARepo := testutil.TempDir(t)
+	defer cleanupNotARepo()
+
+	gitInit(t, repoDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	items := []BatchItem{
+		{Tagger: &GitCommit{}, WorkingDir: repoDir, Opts: &Options{ImageName: "first"}},
+		{Tagger: &GitCommit{}, WorkingDir: notARepo, Opts: &Options{ImageName: "second"}},
+		{Tagger: &CustomTag{Tag: "v1"}, WorkingDir: notARepo, Opts: &Options{ImageName: "third"}},
+		{Tagger: &ChecksumTagger{}, WorkingDir: repoDir, Opts: &Options{ImageName: "fourth", Digest: "invalid"}},
+		{Tagger: &GitCommit{}, WorkingDir: repoDir, Opts: &Options{ImageName: "fifth"}},
+	}
+
+	results, err := GenerateBatch(items, 2)
+	failNowIfError(t, err)
+
+	var names []string
+	var failed []bool
+	for _, result := range results {
+		names = append(names, result.FQN)
+		failed = append(failed, result.Err != nil)
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"first:eefe1b9", "", "third:v1", "", "fifth:eefe1b9"}, names)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []bool{false, true, false, true, false}, failed)
+}
+
+func TestGenerateBatchConcurrency(t *testing.T) {
+	tagger := &concurrencyTagger{}
+
+	var items []BatchItem
+	var expected []BatchResult
+	for i := 0; i < 10; i++ {
+		dir := fmt.Sprintf("dir%d", i)
+		items = append(items, BatchItem{Tagger: tagger, WorkingDir: dir, Opts: &Options{ImageName: "test"}})
+		expected = append(expected, BatchResult{FQN: "test:" + dir})
+	}
+
+	results, err := GenerateBatch(items, 2)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, results)
+	if tagger.max > 2 {
+		t.Errorf("Expected at most 2 concurrent taggers, got %d", tagger.max)
+	}
+}
+
+func TestGenerateBatchInvalidConcurrency(t *testing.T) {
+	_, err := GenerateBatch([]BatchItem{}, 0)
+
+	testutil.CheckError(t, true, err)
+}
+
+func TestGenerateBatchTimed(t *testing.T) {
+	items := []BatchItem{
+		{Tagger: &CustomTag{Tag: "v1"}, Opts: &Options{ImageName: "first"}},
+		{Tagger: &CustomTag{Tag: "v2"}, Opts: &Options{ImageName: "second"}},
+		{Opts: &Options{ImageName: "third"}},
+	}
+
+	// Each call of the fake clock is one second later.
+	var mu sync.Mutex
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeFn := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(time.Second)
+		return now
+	}
+
+	results, err := generateBatchTimed(items, 1, timeFn)
+	failNowIfError(t, err)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []TimedBatchResult{
+		{BatchResult: BatchResult{FQN: "first:v1"}, Duration: time.Second},
+		{BatchResult: BatchResult{FQN: "second:v2"}, Duration: time.Second},
+	}, results[:2])
+	testutil.CheckErrorAndDeepEqual(t, true, results[2].Err, time.Second, results[2].Duration)
+
+	// With the real clock
+	results, err = GenerateBatchTimed(items, 2)
+	failNowIfError(t, err)
+	for _, result := range results {
+		if result.Duration < 0 {
+			t.Errorf("Expected a non negative duration, got %s", result.Duration)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBazelStatusPath = "bazel-out/stable-status.txt"
+	defaultBazelStatusKey  = "STABLE_GIT_COMMIT"
+)
+
+// BazelStatus tags an image with a value of Bazel's stable status file,
+// by default the git commit. It falls back to the short commit hash of
+// the git repository if the file or the key is missing.
+type BazelStatus struct {
+	// Path of the stable status file, relative to the working directory.
+	// Defaults to `bazel-out/stable-status.txt`.
+	Path string
+
+	// Key of the value used as tag. Defaults to `STABLE_GIT_COMMIT`.
+	Key string
+}
+
+// GenerateFullyQualifiedImageName tags an image with the value found in the stable status file.
+// Full commit hashes are abbreviated.
+func (b *BazelStatus) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("tag options not provided")
+	}
+
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+
+	path := b.Path
+	if path == "" {
+		path = defaultBazelStatusPath
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	key := b.Key
+	if key == "" {
+		key = defaultBazelStatusKey
+	}
+
+	value, found, err := readBazelStatus(path, key)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading %s", path)
+	}
+	if !found || value == "" {
+		logrus.Debugf("%s not found in %s, using the git commit", key, path)
+		tag, err := shortCommit(workingDir)
+		if err != nil {
+			return "", err
+		}
+		return fullyQualifiedName(name, tag, opts)
+	}
+
+	tag := value
+	if isCommitHash(tag) {
+		tag = strings.ToLower(tag[0:7])
+	}
+	if err := validateTag(tag); err != nil {
+		return "", errors.Wrapf(err, "reading %s from %s", key, path)
+	}
+	if err := checkReserved(tag, opts); err != nil {
+		return "", errors.Wrapf(err, "reading %s from %s", key, path)
+	}
+
+	return fullyQualifiedName(name, tag, opts)
+}
+
+// readBazelStatus reads the value of a key in a Bazel status file, made
+// of `KEY value` lines. A missing file is reported as a missing key.
+func readBazelStatus(path, key string) (string, bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if fields[0] != key {
+			continue
+		}
+		if len(fields) == 1 {
+			return "", true, nil
+		}
+		return strings.TrimSpace(fields[1]), true, nil
+	}
+
+	return "", false, scanner.Err()
+}
+
+// shortCommit returns the short hash of the current commit of the git repository containing workingDir.
+func shortCommit(workingDir string) (string, error) {
+	if gitDisabled() {
+		return "", ErrGitDisabled
+	}
+
+	repo, err := openGitRepo(workingDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "opening git repo in %s", workingDir)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "determining current git commit")
+	}
+
+	return head.Hash().String()[0:7], nil
+}
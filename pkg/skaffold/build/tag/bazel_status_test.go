@@ -0,0 +1,131 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const sampleStableStatus = `BUILD_EMBED_LABEL
+BUILD_HOST buildkite-agent-1
+BUILD_USER builder
+STABLE_GIT_COMMIT aea33bcc86b5af8c8570ff45d8a643202d63c808
+STABLE_RELEASE v1.2.0
+STABLE_EMPTY
+`
+
+func TestBazelStatus(t *testing.T) {
+	var tests = []struct {
+		description string
+		status      string
+		tagger      *BazelStatus
+		expected    string
+		fallback    bool
+		shouldErr   bool
+	}{
+		{
+			description: "default key",
+			status:      sampleStableStatus,
+			tagger:      &BazelStatus{},
+			expected:    "test:aea33bc",
+		},
+		{
+			description: "custom key",
+			status:      sampleStableStatus,
+			tagger:      &BazelStatus{Key: "STABLE_RELEASE"},
+			expected:    "test:v1.2.0",
+		},
+		{
+			description: "missing custom path",
+			status:      sampleStableStatus,
+			tagger:      &BazelStatus{Path: "stable-status.txt"},
+			fallback:    true,
+		},
+		{
+			description: "missing key",
+			status:      sampleStableStatus,
+			tagger:      &BazelStatus{Key: "STABLE_MISSING"},
+			fallback:    true,
+		},
+		{
+			description: "empty value",
+			status:      sampleStableStatus,
+			tagger:      &BazelStatus{Key: "STABLE_EMPTY"},
+			fallback:    true,
+		},
+		{
+			description: "missing file",
+			tagger:      &BazelStatus{},
+			fallback:    true,
+		},
+		{
+			description: "volatile key",
+			status:      sampleStableStatus,
+			tagger:      &BazelStatus{Key: "BUILD_HOST"},
+			expected:    "test:buildkite-agent-1",
+		},
+		{
+			description: "invalid tag",
+			status:      "STABLE_GIT_COMMIT not/a tag\n",
+			tagger:      &BazelStatus{},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+
+			if test.status != "" {
+				failNowIfError(t, os.MkdirAll(filepath.Join(tmpDir, "bazel-out"), os.ModePerm))
+				failNowIfError(t, ioutil.WriteFile(filepath.Join(tmpDir, "bazel-out", "stable-status.txt"), []byte(test.status), os.ModePerm))
+			}
+
+			expected := test.expected
+			if test.fallback {
+				head, err := repo.repo.Head()
+				failNowIfError(t, err)
+				expected = "test:" + head.Hash().String()[:7]
+			}
+
+			name, err := test.tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, expected, name)
+		})
+	}
+}
+
+func TestBazelStatusAbsolutePath(t *testing.T) {
+	file, cleanup := testutil.TempFile(t, "stable-status", []byte(sampleStableStatus))
+	defer cleanup()
+
+	tagger := &BazelStatus{Path: file, Key: "STABLE_RELEASE"}
+	name, err := tagger.GenerateFullyQualifiedImageName("/does/not/exist", &Options{ImageName: "test"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:v1.2.0", name)
+}
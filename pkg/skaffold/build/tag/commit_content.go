@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// contentDigestLength is the number of hex characters of the content digest of CommitPlusContent tags.
+const contentDigestLength = 8
+
+// CommitPlusContent tags an image with the short commit hash and a short digest of the files
+// of its build context, eg. `app:eefe1b9-1a2b3c4d`, so that the tag tells both which commit
+// and which exact content was built, including local changes.
+type CommitPlusContent struct{}
+
+// GenerateFullyQualifiedImageName tags an image with the supplied image name, the short commit hash and the content digest.
+func (t *CommitPlusContent) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("tag options not provided")
+	}
+
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+
+	commit, err := shortCommit(workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	sum, err := (&ContentTagger{}).contentHash(workingDir, prefixedEnv(opts.EnvHashPrefix))
+	if err != nil {
+		return "", errors.Wrapf(err, "hashing content of %s", workingDir)
+	}
+	digest := hex.EncodeToString(sum)[:contentDigestLength]
+
+	return fullyQualifiedName(name, commit+"-"+digest, opts)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestCommitPlusContent(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	tagger := &CommitPlusContent{}
+	generate := func() string {
+		tag, err := tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+		return tag
+	}
+
+	clean := generate()
+	if !regexp.MustCompile(`^test:eefe1b9-[0-9a-f]{8}$`).MatchString(clean) {
+		t.Fatalf("Expected the commit and the content digest, got %s", clean)
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, clean, generate())
+
+	repo.write("source.go", []byte("updated code"))
+	dirty := generate()
+	if !strings.HasPrefix(dirty, "test:eefe1b9-") {
+		t.Errorf("Expected the commit to stay the same, got %s", dirty)
+	}
+	if dirty == clean {
+		t.Errorf("Expected local changes to change the content digest, got %s twice", clean)
+	}
+
+	// Not a git repository
+	notARepo, cleanupNotARepo := testutil.TempDir(t)
+	defer cleanupNotARepo()
+	_, err := tagger.GenerateFullyQualifiedImageName(notARepo, &Options{ImageName: "test"})
+	testutil.CheckError(t, true, err)
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// ContentTagger tags an image with a hash of the files of its build context,
+// ie. the working directory, without relying on git. `.git` directories are ignored.
+type ContentTagger struct {
+	// ContentSetHash hashes the sorted contents of the files, ignoring their paths,
+	// so that trees with the same contents under different names get the same tag.
+	// It's meant for special deduplication cases: renaming or moving a file doesn't
+	// change the tag, even if it changes the image.
+	ContentSetHash bool
+
+	// IncludeEmptyDirs also hashes the paths of the directories, including the empty ones,
+	// for build contexts that depend on them. Their paths are hashed even with ContentSetHash.
+	IncludeEmptyDirs bool
+}
+
+// GenerateFullyQualifiedImageName tags an image with the supplied image name and the hash of the build context.
+func (c *ContentTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("tag options not provided")
+	}
+
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+
+	sum, err := c.contentHash(workingDir, prefixedEnv(opts.EnvHashPrefix))
+	if err != nil {
+		return "", errors.Wrapf(err, "hashing content of %s", workingDir)
+	}
+
+	sha, err := shortSha(sum, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return fullyQualifiedName(name, sha, opts)
+}
+
+// contentHash hashes the path and content of every file under workingDir or,
+// with ContentSetHash, only the sorted hashes of their contents, and the env variables.
+func (c *ContentTagger) contentHash(workingDir string, env []string) ([]byte, error) {
+	files, dirs, err := contentFiles(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, file := range files {
+		fileSum, err := fileHash(filepath.Join(workingDir, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, err
+		}
+
+		if c.ContentSetHash {
+			lines = append(lines, fmt.Sprintf("%x\n", fileSum))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s\x00%x\n", file, fileSum))
+		}
+	}
+
+	// Paths are already sorted. Without them, the content hashes need to be.
+	if c.ContentSetHash {
+		sort.Strings(lines)
+	}
+
+	h := sha256.New()
+	for _, line := range lines {
+		if _, err := io.WriteString(h, line); err != nil {
+			return nil, err
+		}
+	}
+	if c.IncludeEmptyDirs {
+		for _, dir := range dirs {
+			if _, err := fmt.Fprintf(h, "dir=%s\n", dir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := writeEnv(h, env); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
+
+// contentFiles lists the sorted, slash separated, paths of the regular files and of the
+// directories under workingDir, relative to it.
+func contentFiles(workingDir string) ([]string, []string, error) {
+	var files, dirs []string
+
+	err := filepath.Walk(workingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == git.GitDirName {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(workingDir, path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !info.IsDir():
+			files = append(files, filepath.ToSlash(rel))
+		case rel != ".":
+			dirs = append(dirs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Strings(files)
+	sort.Strings(dirs)
+	return files, dirs, nil
+}
+
+// fileHash returns the sha256 of a file's content.
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestContentTagger(t *testing.T) {
+	tests := []struct {
+		description    string
+		contentSetHash bool
+		expectEqual    bool
+	}{
+		{
+			description: "paths are hashed by default",
+			expectEqual: false,
+		},
+		{
+			description:    "content set hash ignores paths",
+			contentSetHash: true,
+			expectEqual:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir1, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			writeFiles(t, tmpDir1, map[string]string{"main.go": "code", "assets/logo.svg": "logo"})
+
+			tmpDir2, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			writeFiles(t, tmpDir2, map[string]string{"other.go": "code", "static/images/icon.svg": "logo"})
+
+			c := &ContentTagger{ContentSetHash: test.contentSetHash}
+			tag1, err := c.GenerateFullyQualifiedImageName(tmpDir1, &Options{ImageName: "test"})
+			failNowIfError(t, err)
+			tag2, err := c.GenerateFullyQualifiedImageName(tmpDir2, &Options{ImageName: "test"})
+			failNowIfError(t, err)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectEqual, tag1 == tag2)
+		})
+	}
+}
+
+func TestContentTaggerChanges(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	writeFiles(t, tmpDir, map[string]string{"main.go": "code", ".git/HEAD": "ref: refs/heads/master"})
+
+	c := &ContentTagger{}
+	generate := func() string {
+		tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+		return tag
+	}
+
+	initial := generate()
+	writeFiles(t, tmpDir, map[string]string{".git/HEAD": "ref: refs/heads/other"})
+	testutil.CheckErrorAndDeepEqual(t, false, nil, initial, generate())
+
+	writeFiles(t, tmpDir, map[string]string{"main.go": "updated code"})
+	if generate() == initial {
+		t.Errorf("Expected editing a file to change the tag, got %s twice", initial)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		failNowIfError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		failNowIfError(t, ioutil.WriteFile(path, []byte(content), os.ModePerm))
+	}
+}
+
+func TestContentTaggerIncludeEmptyDirs(t *testing.T) {
+	tests := []struct {
+		description      string
+		includeEmptyDirs bool
+		expectChange     bool
+	}{
+		{
+			description:      "empty dirs are hashed",
+			includeEmptyDirs: true,
+			expectChange:     true,
+		},
+		{
+			description:  "empty dirs are ignored by default",
+			expectChange: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			writeFiles(t, tmpDir, map[string]string{"main.go": "code"})
+
+			c := &ContentTagger{IncludeEmptyDirs: test.includeEmptyDirs}
+			before, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+			failNowIfError(t, err)
+
+			failNowIfError(t, os.MkdirAll(filepath.Join(tmpDir, "data", "cache"), os.ModePerm))
+			after, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+			failNowIfError(t, err)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectChange, before != after)
+		})
+	}
+}
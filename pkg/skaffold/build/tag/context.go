@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+// TagContext holds the tag results of the artifacts, by image name, eg. so that
+// deploy steps can substitute them in manifests.
+type TagContext map[string]TagResult
+
+// Lookup returns the tag result of an image.
+func (c TagContext) Lookup(imageName string) (TagResult, bool) {
+	result, found := c[imageName]
+	return result, found
+}
+
+// AsTemplateData returns the tag results as data for go templates, by image name.
+// Each image has the `FQN`, `Tag`, `Digest`, `Source`, `Commit`, `Dirty` and `DirtyFiles`
+// keys, eg. `{{(index . "gcr.io/project/app").Tag}}`.
+func (c TagContext) AsTemplateData() map[string]interface{} {
+	data := make(map[string]interface{}, len(c))
+
+	for imageName, result := range c {
+		ref := splitRef(result.FQN)
+		data[imageName] = map[string]interface{}{
+			"FQN":        result.FQN,
+			"Tag":        ref.tag,
+			"Digest":     ref.digest,
+			"Source":     result.Source,
+			"Commit":     result.Commit,
+			"Dirty":      result.Dirty,
+			"DirtyFiles": result.DirtyFiles,
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestTagContextLookup(t *testing.T) {
+	ctx := TagContext{
+		"gcr.io/project/app": {FQN: "gcr.io/project/app:v1", Source: SourceGitCommit},
+	}
+
+	result, found := ctx.Lookup("gcr.io/project/app")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, TagResult{FQN: "gcr.io/project/app:v1", Source: SourceGitCommit}, result)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, found)
+
+	result, found = ctx.Lookup("gcr.io/project/other")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, TagResult{}, result)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, found)
+}
+
+func TestTagContextAsTemplateData(t *testing.T) {
+	ctx := TagContext{
+		"gcr.io/project/app": {
+			FQN:        "gcr.io/project/app:eefe1b9-dirty-a66e73246939372e",
+			Source:     SourceGitCommit,
+			Commit:     "eefe1b9f2e4ad557e9ab0ae6d53a5b26e7dd41a3",
+			Dirty:      true,
+			DirtyFiles: []string{"source.go"},
+		},
+		"worker": {
+			FQN:    "worker@sha256:12345abcde",
+			Source: "sha256",
+		},
+	}
+
+	data := ctx.AsTemplateData()
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]interface{}{
+		"gcr.io/project/app": map[string]interface{}{
+			"FQN":        "gcr.io/project/app:eefe1b9-dirty-a66e73246939372e",
+			"Tag":        "eefe1b9-dirty-a66e73246939372e",
+			"Digest":     "",
+			"Source":     SourceGitCommit,
+			"Commit":     "eefe1b9f2e4ad557e9ab0ae6d53a5b26e7dd41a3",
+			"Dirty":      true,
+			"DirtyFiles": []string{"source.go"},
+		},
+		"worker": map[string]interface{}{
+			"FQN":        "worker@sha256:12345abcde",
+			"Tag":        "",
+			"Digest":     "sha256:12345abcde",
+			"Source":     "sha256",
+			"Commit":     "",
+			"Dirty":      false,
+			"DirtyFiles": []string(nil),
+		},
+	}, data)
+
+	tmpl := template.Must(template.New("manifest").Parse(`image: {{(index . "gcr.io/project/app").FQN}}`))
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, data)
+	testutil.CheckErrorAndDeepEqual(t, false, err, "image: gcr.io/project/app:eefe1b9-dirty-a66e73246939372e", buf.String())
+}
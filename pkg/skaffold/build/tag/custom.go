@@ -33,5 +33,12 @@ func (c *CustomTag) GenerateFullyQualifiedImageName(workingDir string, opts *Opt
 	if tag == "" {
 		return "", fmt.Errorf("Custom tag not provided")
 	}
-	return fmt.Sprintf("%s:%s", opts.ImageName, tag), nil
+	if err := checkReserved(tag, opts); err != nil {
+		return "", err
+	}
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+	return fullyQualifiedName(name, tag, opts)
 }
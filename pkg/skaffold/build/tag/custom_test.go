@@ -36,3 +36,13 @@ func TestCustomTag_GenerateFullyQualifiedImageName(t *testing.T) {
 	tag, err := c.GenerateFullyQualifiedImageName(".", opts)
 	testutil.CheckErrorAndDeepEqual(t, false, err, "test:"+expectedTag, tag)
 }
+
+func TestCustomTagReserveLatest(t *testing.T) {
+	c := &CustomTag{Tag: "latest"}
+
+	tag, err := c.GenerateFullyQualifiedImageName(".", &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:latest", tag)
+
+	_, err = c.GenerateFullyQualifiedImageName(".", &Options{ImageName: "test", ReserveLatest: true})
+	testutil.CheckError(t, true, err)
+}
@@ -19,6 +19,8 @@ package tag
 import (
 	"fmt"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 const tagTime = "2006-01-02_15-04-05.999_MST"
@@ -61,5 +63,16 @@ func (tagger *dateTimeTagger) GenerateFullyQualifiedImageName(workingDir string,
 		return "", fmt.Errorf("bad timezone provided: \"%s\", error: %s", timezone, err)
 	}
 
-	return fmt.Sprintf("%s:%s", opts.ImageName, tagger.timeFn().In(loc).Format(format)), nil
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+
+	// Formats such as RFC3339 contain characters that are not allowed in tags.
+	tag := tagger.timeFn().In(loc).Format(format)
+	if err := validateTag(tag); err != nil {
+		return "", errors.Wrapf(err, "formatting time with %q", format)
+	}
+
+	return fullyQualifiedName(name, tag, opts)
 }
@@ -64,6 +64,24 @@ func TestDateTime_GenerateFullyQualifiedImageName(t *testing.T) {
 			},
 			want: "test_image:2015-03-07",
 		},
+		{
+			description: "format not allowed in tags",
+			buildTime:   aLocalTimeStamp,
+			format:      time.RFC3339,
+			opts: &Options{
+				ImageName: "test_image",
+			},
+			shouldErr: true,
+		},
+		{
+			description: "bad timezone",
+			buildTime:   aLocalTimeStamp,
+			timezone:    "Mars/Olympus",
+			opts: &Options{
+				ImageName: "test_image",
+			},
+			shouldErr: true,
+		},
 		{
 			description: "error no tag opts",
 			shouldErr:   true,
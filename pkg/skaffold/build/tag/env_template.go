@@ -17,13 +17,19 @@ limitations under the License.
 package tag
 
 import (
+	"fmt"
 	"strings"
 	"text/template"
+	"text/template/parse"
+	"unicode"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
 )
 
+// readGitTag is replaced in tests.
+var readGitTag = currentGitTag
+
 // envTemplateTagger implements Tagger
 type envTemplateTagger struct {
 	Template *template.Template
@@ -40,11 +46,22 @@ func NewEnvTemplateTagger(t string) (Tagger, error) {
 	}, nil
 }
 
-// GenerateFullyQualifiedImageName tags an image with the custom tag
+// GenerateFullyQualifiedImageName tags an image with the custom tag.
+// Byte order marks, control characters and surrounding whitespaces are
+// removed from the result, that must then be a valid image reference.
 func (c *envTemplateTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+
 	customMap := map[string]string{}
 
-	customMap["IMAGE_NAME"] = opts.ImageName
+	customMap["IMAGE_NAME"] = name
+	// Opening the repository is only worth it if the template uses the tag.
+	if c.Template.Tree == nil || referencesField(c.Template.Tree.Root, "GIT_TAG") {
+		customMap["GIT_TAG"] = readGitTag(workingDir)
+	}
 	digest := opts.Digest
 	customMap["DIGEST"] = digest
 	if digest != "" {
@@ -55,5 +72,95 @@ func (c *envTemplateTagger) GenerateFullyQualifiedImageName(workingDir string, o
 		}
 	}
 
-	return util.ExecuteEnvTemplate(c.Template, customMap)
+	fqn, err := util.ExecuteEnvTemplate(c.Template, customMap)
+	if err != nil {
+		return "", err
+	}
+
+	fqn = cleanTemplateOutput(fqn)
+	if err := ValidateRef(fqn); err != nil {
+		return "", errors.Wrap(err, "executing tag template")
+	}
+
+	// The label is only added to references that have a tag.
+	if ref := splitRef(fqn); opts.Label != "" && ref.hasTag && !ref.hasDigest {
+		fqn = fmt.Sprintf("%s:%s", strings.TrimSuffix(fqn, ":"+ref.tag), labeledTag(ref.tag, opts.Label))
+	}
+
+	return fqn, nil
+}
+
+// referencesField tells if a template node can read a field, either by its name, eg.
+// `{{.GIT_TAG}}` or `{{index . "GIT_TAG"}}`, or by passing the whole data along.
+func referencesField(node parse.Node, field string) bool {
+	switch n := node.(type) {
+	case nil:
+		return false
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if referencesField(child, field) {
+				return true
+			}
+		}
+		return false
+	case *parse.ActionNode:
+		return referencesField(n.Pipe, field)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if referencesField(cmd, field) {
+				return true
+			}
+		}
+		return false
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if referencesField(arg, field) {
+				return true
+			}
+		}
+		return false
+	case *parse.IfNode:
+		return referencesField(n.Pipe, field) || referencesField(n.List, field) || referencesField(n.ElseList, field)
+	case *parse.RangeNode:
+		return referencesField(n.Pipe, field) || referencesField(n.List, field) || referencesField(n.ElseList, field)
+	case *parse.WithNode:
+		return referencesField(n.Pipe, field) || referencesField(n.List, field) || referencesField(n.ElseList, field)
+	case *parse.TemplateNode:
+		return referencesField(n.Pipe, field)
+	case *parse.ChainNode:
+		return referencesField(n.Node, field)
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == field
+	case *parse.StringNode:
+		return n.Text == field
+	case *parse.DotNode:
+		return true
+	case *parse.VariableNode:
+		// `$` is the whole data.
+		return n.Ident[0] == "$" && (len(n.Ident) == 1 || n.Ident[1] == field)
+	case *parse.TextNode, *parse.BoolNode, *parse.NumberNode, *parse.NilNode, *parse.IdentifierNode:
+		return false
+	default:
+		// Unknown nodes might read the field.
+		return true
+	}
+}
+
+// cleanTemplateOutput removes the byte order marks, control characters and surrounding
+// whitespaces that env values can contain, eg. when they are read from files.
+func cleanTemplateOutput(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '\uFEFF' || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	return strings.TrimSpace(s)
 }
@@ -45,22 +45,22 @@ func TestEnvTemplateTagger_GenerateFullyQualifiedImageName(t *testing.T) {
 		{
 			name:     "env",
 			template: "{{.FOO}}-{{.BAZ}}:latest",
-			env:      []string{"FOO=BAR", "BAZ=BAT"},
+			env:      []string{"FOO=bar", "BAZ=bat"},
 			opts: &Options{
 				ImageName: "foo",
 				Digest:    "bar",
 			},
-			want: "BAR-BAT:latest",
+			want: "bar-bat:latest",
 		},
 		{
 			name:     "opts precedence",
 			template: "{{.IMAGE_NAME}}-{{.FROM_ENV}}:latest",
-			env:      []string{"FROM_ENV=FOO", "IMAGE_NAME=BAT"},
+			env:      []string{"FROM_ENV=foo", "IMAGE_NAME=bat"},
 			opts: &Options{
 				ImageName: "image_name",
 				Digest:    "bar",
 			},
-			want: "image_name-FOO:latest",
+			want: "image_name-foo:latest",
 		},
 		{
 			name:     "digest algo hex",
@@ -71,6 +71,41 @@ func TestEnvTemplateTagger_GenerateFullyQualifiedImageName(t *testing.T) {
 			},
 			want: "foo:sha256-abcd",
 		},
+		{
+			name:     "bom and trailing newline",
+			template: "{{.IMAGE_NAME}}:{{.VERSION}}",
+			env:      []string{"VERSION=\uFEFFv1.0\r\n"},
+			opts: &Options{
+				ImageName: "foo",
+			},
+			want: "foo:v1.0",
+		},
+		{
+			name:     "control characters and whitespaces",
+			template: " {{.IMAGE_NAME}}:{{.VERSION}}\t",
+			env:      []string{"VERSION=v1\x00.0\x1b"},
+			opts: &Options{
+				ImageName: "foo",
+			},
+			want: "foo:v1.0",
+		},
+		{
+			name:     "invalid once cleaned",
+			template: "{{.IMAGE_NAME}}:{{.VERSION}}",
+			env:      []string{"VERSION=\uFEFFv 1.0\n"},
+			opts: &Options{
+				ImageName: "foo",
+			},
+			shouldErr: true,
+		},
+		{
+			name:     "invalid repository",
+			template: "{{.IMAGE_NAME}}:latest",
+			opts: &Options{
+				ImageName: "Foo\x00",
+			},
+			shouldErr: true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -87,6 +122,124 @@ func TestEnvTemplateTagger_GenerateFullyQualifiedImageName(t *testing.T) {
 	}
 }
 
+func TestEnvTemplateTagger_GitTag(t *testing.T) {
+	tests := []struct {
+		name          string
+		createGitRepo func(string)
+		want          string
+	}{
+		{
+			name: "tag",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					tag("v1")
+			},
+			want: "foo:v1",
+		},
+		{
+			name: "annotated tag",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					annotatedTag("v2", "release v2")
+			},
+			want: "foo:v2",
+		},
+		{
+			name: "no tag",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			want: "foo:latest",
+		},
+		{
+			name:          "not a git repo",
+			createGitRepo: func(dir string) {},
+			want:          "foo:latest",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			test.createGitRepo(tmpDir)
+
+			c := &envTemplateTagger{
+				Template: template.Must(template.New("").Parse(`{{.IMAGE_NAME}}:{{or .GIT_TAG "latest"}}`)),
+			}
+			util.OSEnviron = func() []string {
+				return nil
+			}
+
+			got, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "foo"})
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.want, got)
+		})
+	}
+}
+
+func TestEnvTemplateTagger_GitTagOnlyIfReferenced(t *testing.T) {
+	defer func(f func(string) string) { readGitTag = f }(readGitTag)
+	util.OSEnviron = func() []string { return nil }
+
+	tests := []struct {
+		name       string
+		template   string
+		expectRead bool
+	}{
+		{name: "field", template: "{{.IMAGE_NAME}}:{{.GIT_TAG}}", expectRead: true},
+		{name: "index", template: `{{.IMAGE_NAME}}:{{index . "GIT_TAG"}}`, expectRead: true},
+		{name: "condition", template: "{{.IMAGE_NAME}}:{{if .GIT_TAG}}{{.GIT_TAG}}{{else}}dev{{end}}", expectRead: true},
+		{name: "root variable", template: "{{.IMAGE_NAME}}:{{$.GIT_TAG}}", expectRead: true},
+		{name: "not referenced", template: "{{.IMAGE_NAME}}:latest"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			read := false
+			readGitTag = func(string) string {
+				read = true
+				return "v1"
+			}
+
+			c := &envTemplateTagger{
+				Template: template.Must(template.New("").Parse(test.template)),
+			}
+			_, err := c.GenerateFullyQualifiedImageName(".", &Options{ImageName: "foo"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectRead, read)
+		})
+	}
+}
+
+func TestEnvTemplateTagger_GitDisabled(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		tag("v1")
+
+	defer testutil.SetEnvs(t, map[string]string{"SKAFFOLD_NO_GIT": "true"})(t)
+	util.OSEnviron = func() []string { return nil }
+
+	c := &envTemplateTagger{
+		Template: template.Must(template.New("").Parse("{{.IMAGE_NAME}}:dev{{.GIT_TAG}}")),
+	}
+	got, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "foo"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "foo:dev", got)
+}
+
 func TestNewEnvTemplateTagger(t *testing.T) {
 	tests := []struct {
 		name      string
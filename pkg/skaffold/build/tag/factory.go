@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultTaggerEnvVar is the environment variable that selects the kind
+// of tagger when none is provided, eg. `SKAFFOLD_DEFAULT_TAGGER=dateTime`.
+const DefaultTaggerEnvVar = "SKAFFOLD_DEFAULT_TAGGER"
+
+// TaggerKinds are the kinds of taggers that can be created by name.
+var TaggerKinds = []string{"gitCommit", "sha256", "dateTime"}
+
+// NewTagger creates a tagger, with its default configuration, from the name of its kind,
+// case insensitively. If kind is empty, the kind is read from SKAFFOLD_DEFAULT_TAGGER and
+// defaults to `gitCommit`.
+func NewTagger(kind string) (Tagger, error) {
+	if kind != "" {
+		return newTagger(kind)
+	}
+
+	kind = os.Getenv(DefaultTaggerEnvVar)
+	if kind == "" {
+		return &GitCommit{}, nil
+	}
+
+	tagger, err := newTagger(kind)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: unknown tagger %q, expected one of %s", DefaultTaggerEnvVar, kind, strings.Join(TaggerKinds, ", "))
+	}
+	return tagger, nil
+}
+
+func newTagger(kind string) (Tagger, error) {
+	switch strings.ToLower(kind) {
+	case "gitcommit":
+		return &GitCommit{}, nil
+	case "sha256":
+		return &ChecksumTagger{}, nil
+	case "datetime":
+		return NewDateTimeTagger("", ""), nil
+	default:
+		return nil, fmt.Errorf("unknown tagger %q, expected one of %s", kind, strings.Join(TaggerKinds, ", "))
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestNewTagger(t *testing.T) {
+	var tests = []struct {
+		description string
+		kind        string
+		env         string
+		expected    Tagger
+		shouldErr   bool
+	}{
+		{description: "default", expected: &GitCommit{}},
+		{description: "env gitCommit", env: "gitCommit", expected: &GitCommit{}},
+		{description: "env sha256", env: "sha256", expected: &ChecksumTagger{}},
+		{description: "env dateTime", env: "dateTime", expected: &dateTimeTagger{}},
+		{description: "env is case insensitive", env: "datetime", expected: &dateTimeTagger{}},
+		{description: "invalid env", env: "unknown", shouldErr: true},
+		{description: "explicit kind", kind: "sha256", env: "dateTime", expected: &ChecksumTagger{}},
+		{description: "invalid kind", kind: "unknown", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer testutil.SetEnvs(t, map[string]string{"SKAFFOLD_DEFAULT_TAGGER": test.env})(t)
+
+			tagger, err := NewTagger(test.kind)
+
+			testutil.CheckErrorAndTypeEquality(t, test.shouldErr, err, test.expected, tagger)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileTag tags an image with a tag read from a file,
+// usually written by an external tool.
+type FileTag struct {
+	Path string
+}
+
+// GenerateFullyQualifiedImageName tags an image with the tag found in the file
+func (f *FileTag) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("tag options not provided")
+	}
+
+	content, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return "", errors.Wrap(err, "reading tag file")
+	}
+
+	tag := strings.TrimSpace(string(content))
+	if err := validateTag(tag); err != nil {
+		return "", errors.Wrapf(err, "reading tag from %s", f.Path)
+	}
+	if err := checkReserved(tag, opts); err != nil {
+		return "", errors.Wrapf(err, "reading tag from %s", f.Path)
+	}
+
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+	return fullyQualifiedName(name, tag, opts)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestFileTag_GenerateFullyQualifiedImageName(t *testing.T) {
+	var tests = []struct {
+		description string
+		content     *string
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "valid file",
+			content:     stringPtr("v1.2.3"),
+			expected:    "test:v1.2.3",
+		},
+		{
+			description: "trailing whitespace",
+			content:     stringPtr("  v1.2.3 \n\n"),
+			expected:    "test:v1.2.3",
+		},
+		{
+			description: "invalid tag",
+			content:     stringPtr("v1 2"),
+			shouldErr:   true,
+		},
+		{
+			description: "empty file",
+			content:     stringPtr("\n"),
+			shouldErr:   true,
+		},
+		{
+			description: "missing file",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			path := filepath.Join(tmpDir, "TAG")
+			if test.content != nil {
+				if err := ioutil.WriteFile(path, []byte(*test.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f := &FileTag{
+				Path: path,
+			}
+			tag, err := f.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
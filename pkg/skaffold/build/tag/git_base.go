@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	billy "gopkg.in/src-d/go-billy.v4"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// resolveCommit returns the commit a branch, a tag or a commit hash points to.
+// Annotated tags are peeled to the commit they point to.
+func resolveCommit(repo gitRepo, rev string) (plumbing.Hash, error) {
+	for _, rule := range append([]string{"%s"}, plumbing.RefRevParseRules...) {
+		ref, err := repo.Reference(plumbing.ReferenceName(fmt.Sprintf(rule, rev)), true)
+		if err != nil {
+			continue
+		}
+
+		hash := ref.Hash()
+		if tagObject, err := repo.TagObject(hash); err == nil {
+			hash = tagObject.Target
+		}
+		return hash, nil
+	}
+
+	if hash := plumbing.NewHash(rev); hash.String() == rev {
+		return hash, nil
+	}
+
+	return plumbing.ZeroHash, fmt.Errorf("unknown git ref %s", rev)
+}
+
+// matchesCommit tells if the files of the worktree under the given scope have the
+// same content as in the given base commit. Only the files that are either tracked
+// at HEAD or at the base commit, or reported by the status, are compared.
+func matchesCommit(repo gitRepo, w *git.Worktree, status git.Status, head, base plumbing.Hash, scope string) (bool, error) {
+	baseFiles, err := commitFiles(repo, base)
+	if err != nil {
+		return false, errors.Wrapf(err, "listing files of commit %s", base)
+	}
+	headFiles, err := commitFiles(repo, head)
+	if err != nil {
+		return false, errors.Wrapf(err, "listing files of commit %s", head)
+	}
+
+	paths := map[string]bool{}
+	for path := range baseFiles {
+		paths[path] = true
+	}
+	for path := range headFiles {
+		paths[path] = true
+	}
+	for path := range status {
+		paths[path] = true
+	}
+
+	for path := range paths {
+		if !inScope(path, scope) {
+			continue
+		}
+
+		actual, exists, err := worktreeBlobHash(w.Filesystem, path)
+		if err != nil {
+			return false, errors.Wrapf(err, "hashing %s", path)
+		}
+
+		expected, tracked := baseFiles[path]
+		if exists != tracked || actual != expected {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// commitFiles returns the git blob hash of each file of a commit.
+func commitFiles(repo gitRepo, hash plumbing.Hash) (map[string]plumbing.Hash, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]plumbing.Hash{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files[f.Name] = f.Hash
+		return nil
+	})
+
+	return files, err
+}
+
+// worktreeBlobHash returns the git blob hash of a file of the worktree.
+func worktreeBlobHash(fs billy.Filesystem, path string) (plumbing.Hash, bool, error) {
+	info, err := fs.Lstat(path)
+	if os.IsNotExist(err) {
+		return plumbing.ZeroHash, false, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, false, err
+	}
+
+	var content []byte
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := fs.Readlink(path)
+		if err != nil {
+			return plumbing.ZeroHash, false, err
+		}
+		content = []byte(target)
+	case info.IsDir():
+		return plumbing.ZeroHash, false, nil
+	default:
+		f, err := fs.Open(path)
+		if err != nil {
+			return plumbing.ZeroHash, false, err
+		}
+		defer f.Close()
+
+		if content, err = ioutil.ReadAll(f); err != nil {
+			return plumbing.ZeroHash, false, err
+		}
+	}
+
+	return plumbing.ComputeHash(plumbing.BlobObject, content), true, nil
+}
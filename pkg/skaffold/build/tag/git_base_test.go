@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestGitCommitDirtyBaseRef(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		annotatedTag("v1", "release v1").
+		write("source.go", []byte("updated code")).
+		write("other.go", []byte("other")).
+		add("source.go", "other.go").
+		commit("update")
+
+	generate := func(baseRef string) (string, error) {
+		c := &GitCommit{DirtyBaseRef: baseRef}
+		return c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	}
+
+	// The worktree matches HEAD but not v1
+	name, err := generate("v1")
+	failNowIfError(t, err)
+	if !strings.Contains(name, "-dirty-") {
+		t.Errorf("Expected a dirty tag, got %s", name)
+	}
+
+	// Go back to v1's tree
+	repo.write("source.go", []byte("code")).
+		delete("other.go")
+
+	name, err = generate("v1")
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:v1", name)
+
+	name, err = generate("")
+	failNowIfError(t, err)
+	if !strings.Contains(name, "-dirty-") {
+		t.Errorf("Expected a dirty tag relative to HEAD, got %s", name)
+	}
+
+	// Untracked files make the worktree differ from v1's tree
+	repo.write("untracked.go", []byte("untracked"))
+
+	name, err = generate("v1")
+	failNowIfError(t, err)
+	if !strings.Contains(name, "-dirty-") {
+		t.Errorf("Expected a dirty tag, got %s", name)
+	}
+
+	_, err = generate("unknown")
+	testutil.CheckError(t, true, err)
+}
+
+func TestResolveCommit(t *testing.T) {
+	tagObject := plumbing.NewHash("1111111111111111111111111111111111111111")
+	repo := (&fakeRepo{}).
+		withTag("lightweight", commit1).
+		withAnnotatedTag("annotated", tagObject, commit2, "")
+
+	var tests = []struct {
+		description string
+		rev         string
+		expected    string
+		shouldErr   bool
+	}{
+		{description: "lightweight tag", rev: "lightweight", expected: commit1.String()},
+		{description: "full ref", rev: "refs/tags/lightweight", expected: commit1.String()},
+		{description: "annotated tag", rev: "annotated", expected: commit2.String()},
+		{description: "commit hash", rev: commit2.String(), expected: commit2.String()},
+		{description: "unknown", rev: "unknown", expected: "0000000000000000000000000000000000000000", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			hash, err := resolveCommit(repo, test.rev)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, hash.String())
+		})
+	}
+}
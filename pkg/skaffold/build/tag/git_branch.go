@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// GitNearestBranch tags an image with the name of the branch that most likely
+// contains the current commit, eg. when CI checks out a detached HEAD.
+// It falls back to the short commit hash if no branch contains it.
+type GitNearestBranch struct {
+	// openRepo can be replaced in tests. It defaults to openGitRepo.
+	openRepo repoOpener
+}
+
+// GenerateFullyQualifiedImageName tags an image with the supplied image name and the nearest branch.
+func (t *GitNearestBranch) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("tag options not provided")
+	}
+
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+
+	if gitDisabled() {
+		return "", ErrGitDisabled
+	}
+
+	open := t.openRepo
+	if open == nil {
+		open = openGitRepo
+	}
+
+	repo, err := open(workingDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "opening git repo in %s", workingDir)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", errors.Wrap(err, "determining current git commit")
+	}
+
+	branch, err := nearestBranch(repo, head.Hash())
+	if err != nil {
+		return "", errors.Wrap(err, "finding nearest branch")
+	}
+
+	tag := head.Hash().String()[0:7]
+	if branch != "" {
+		tag = branchTag(branch)
+	}
+
+	return fullyQualifiedName(name, tag, opts)
+}
+
+// nearestBranch returns the name of the branch whose tip is the closest to the given
+// commit, among those that contain it, or an empty string if no branch contains it.
+// Ties are broken by alphabetical order.
+func nearestBranch(repo gitRepo, commit plumbing.Hash) (string, error) {
+	branches, err := repo.Branches()
+	if err != nil {
+		return "", errors.Wrap(err, "listing branches")
+	}
+
+	var tips []*plumbing.Reference
+	if err := branches.ForEach(func(ref *plumbing.Reference) error {
+		tips = append(tips, ref)
+		return nil
+	}); err != nil {
+		return "", errors.Wrap(err, "listing branches")
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Name() < tips[j].Name() })
+
+	nearest := ""
+	nearestDistance := -1
+	for _, tip := range tips {
+		distance, err := commitDistance(repo, tip.Hash(), commit)
+		if err != nil {
+			return "", errors.Wrapf(err, "walking branch %s", tip.Name().Short())
+		}
+		if distance >= 0 && (nearestDistance < 0 || distance < nearestDistance) {
+			nearest = tip.Name().Short()
+			nearestDistance = distance
+		}
+	}
+
+	return nearest, nil
+}
+
+// commitDistance returns the minimum number of commits to walk from a tip, through all the
+// parents, to reach the given commit, or -1 if the commit is not reachable.
+func commitDistance(repo gitRepo, tip, commit plumbing.Hash) (int, error) {
+	seen := map[plumbing.Hash]bool{tip: true}
+	current := []plumbing.Hash{tip}
+
+	for distance := 0; len(current) > 0; distance++ {
+		var next []plumbing.Hash
+
+		for _, hash := range current {
+			if hash == commit {
+				return distance, nil
+			}
+
+			c, err := repo.CommitObject(hash)
+			if err != nil {
+				if err == plumbing.ErrObjectNotFound {
+					// Shallow clones don't have all the parents.
+					continue
+				}
+				return -1, err
+			}
+
+			for _, parent := range c.ParentHashes {
+				if !seen[parent] {
+					seen[parent] = true
+					next = append(next, parent)
+				}
+			}
+		}
+
+		current = next
+	}
+
+	return -1, nil
+}
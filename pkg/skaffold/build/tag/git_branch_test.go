@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestGitNearestBranch(t *testing.T) {
+	var tests = []struct {
+		description string
+		setup       func(*testRepo)
+		expected    string
+	}{
+		{
+			description: "contained in one branch",
+			setup: func(repo *testRepo) {
+				repo.branch("feature/login").
+					commit("work").
+					detach()
+			},
+			expected: "test:feature-login",
+		},
+		{
+			description: "closest branch",
+			setup: func(repo *testRepo) {
+				repo.branch("feature").
+					commit("work").
+					branch("later").
+					commit("more").
+					checkout("refs/heads/feature")
+			},
+			expected: "test:feature",
+		},
+		{
+			description: "other branch is closer",
+			setup: func(repo *testRepo) {
+				repo.branch("feature").
+					commit("work").
+					branch("later").
+					commit("more").
+					checkout("refs/heads/later")
+			},
+			expected: "test:later",
+		},
+		{
+			description: "fallback to short hash",
+			setup: func(repo *testRepo) {
+				repo.detach().
+					commit("detached work")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+			test.setup(repo)
+
+			expected := test.expected
+			if expected == "" {
+				head, err := repo.repo.Head()
+				failNowIfError(t, err)
+				expected = "test:" + head.Hash().String()[:7]
+			}
+
+			tagger := &GitNearestBranch{}
+			name, err := tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, expected, name)
+		})
+	}
+}
+
+func TestGitNearestBranchNoRepo(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	tagger := &GitNearestBranch{}
+	_, err := tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+	testutil.CheckError(t, true, err)
+}
@@ -0,0 +1,438 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	billy "gopkg.in/src-d/go-billy.v4"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+)
+
+// maxCachedTags is the number of tags that are kept in a cache directory.
+// The least recently used ones are pruned when a tag is cached.
+const maxCachedTags = 100
+
+// cachedTag is what's stored in the tag cache.
+type cachedTag struct {
+	Result    TagResult `json:"result"`
+	Immutable string    `json:"immutable"`
+}
+
+// cachedGenerate opens the git repository containing workingDir and tags the image
+// with cachedGenerateWithRepo.
+func (c *GitCommit) cachedGenerate(workingDir string, opts *Options) (TagResult, string, error) {
+	if opts == nil {
+		return TagResult{}, "", fmt.Errorf("tag options not provided")
+	}
+	if _, err := imageName(opts); err != nil {
+		return TagResult{}, "", err
+	}
+
+	repo, release, err := c.open(workingDir)
+	if err == ErrGitDisabled {
+		return TagResult{}, "", err
+	}
+	if err != nil {
+		return TagResult{}, "", errors.Wrapf(err, "opening git repo in %s", workingDir)
+	}
+	defer release()
+
+	return c.cachedGenerateWithRepo(repo, workingDir, opts)
+}
+
+// cachedGenerateWithRepo is like generate but reuses the last result if neither the git index,
+// the refs, the metadata of the files nor the tagger's configuration changed.
+// The cache is only used if CacheDir is set, and neither with a SequenceProvider since
+// each generation gets a new sequence number, nor with a UniquenessChecker since the
+// existing images can change, nor with a DirtyReducer that can't be hashed, nor with
+// HashSubmodules since the cache key doesn't cover the submodules.
+func (c *GitCommit) cachedGenerateWithRepo(repo gitRepo, workingDir string, opts *Options) (TagResult, string, error) {
+	if c.CacheDir == "" || opts == nil || opts.SequenceProvider != nil || opts.UniquenessChecker != nil || opts.DirtyReducer != nil || c.HashSubmodules {
+		return c.generate(repo, workingDir, opts)
+	}
+
+	key, err := c.cacheKey(repo, workingDir, opts)
+	if err != nil {
+		logrus.Debugf("Not using the tag cache: %s", err)
+		return c.generate(repo, workingDir, opts)
+	}
+
+	cacheFile := filepath.Join(c.CacheDir, key+".json")
+	if cached, err := readCachedTag(cacheFile); err == nil {
+		// The thresholds warn, or fail, on every generation.
+		if err := checkDirtyFileCount(cached.Result.DirtyFiles, opts); err != nil {
+			return TagResult{}, "", err
+		}
+
+		logrus.Debugf("Using cached tag %s", cached.Result.FQN)
+		touchCachedTag(cacheFile)
+		cached.Result.GeneratedAt = time.Now()
+		return cached.Result, cached.Immutable, nil
+	}
+
+	result, immutable, err := c.generate(repo, workingDir, opts)
+	if err != nil {
+		return result, immutable, err
+	}
+
+	if err := writeCachedTag(cacheFile, cachedTag{Result: result, Immutable: immutable}); err != nil {
+		logrus.Warnf("Unable to write tag cache: %s", err)
+	} else if err := pruneCachedTags(c.CacheDir, maxCachedTags); err != nil {
+		logrus.Warnf("Unable to prune tag cache: %s", err)
+	}
+
+	return result, immutable, nil
+}
+
+// cacheKey hashes everything that can change the tag without reading the content of the files:
+// the tagger's configuration and options, the refs, the git index and the metadata of the tracked
+// files, of their directories and of the untracked files, so that it detects both unstaged changes
+// and changes to untracked files. The configuration and the options are hashed as a whole, so that
+// new fields are covered. Their function fields aren't serialized and are either irrelevant to the
+// tag or disable the cache.
+func (c *GitCommit) cacheKey(repo gitRepo, workingDir string, opts *Options) (string, error) {
+	w, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	absWorkingDir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	// The commit length can be read from the git config, that's not part of the refs nor the index.
+	commitLength, err := c.commitLength(repo)
+	if err != nil {
+		return "", err
+	}
+
+	tagger, err := json.Marshal(c)
+	if err != nil {
+		return "", errors.Wrap(err, "serializing tagger")
+	}
+	options, err := json.Marshal(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "serializing options")
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "workingDir=%s\n", absWorkingDir)
+	fmt.Fprintf(h, "commitLength=%d\n", commitLength)
+	fmt.Fprintf(h, "tagger=%s|%t\n", tagger, c.TagValidator != nil)
+	fmt.Fprintf(h, "options=%s\n", options)
+	if err := writeEnv(h, prefixedEnv(opts.EnvHashPrefix)); err != nil {
+		return "", err
+	}
+
+	if opts.DockerfilePath != "" {
+		dockerfile := opts.DockerfilePath
+		if !filepath.IsAbs(dockerfile) {
+			dockerfile = filepath.Join(workingDir, dockerfile)
+		}
+		if err := writeStat(h, osStat(dockerfile), "dockerfile"); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writeRefs(h, repo); err != nil {
+		return "", errors.Wrap(err, "listing references")
+	}
+
+	if err := writeIndex(h, w.Filesystem); err != nil {
+		return "", errors.Wrap(err, "reading git index")
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeRefs writes HEAD and all the references to the hash.
+func writeRefs(h hash.Hash, repo gitRepo) error {
+	head, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(h, "HEAD=%s %s\n", head.Name(), head.Hash())
+
+	refs, err := repo.References()
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		lines = append(lines, ref.String())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(lines)
+	for _, line := range lines {
+		fmt.Fprintf(h, "ref=%s\n", line)
+	}
+	return nil
+}
+
+// writeIndex writes the content of the git index and the metadata of the
+// tracked files, of their directories and of the untracked files to the hash.
+// The index is read from the git directory, that's elsewhere for submodules.
+// Files modified since the index was written are also hashed by content since
+// changes in the same tick of the clock don't change their metadata.
+func writeIndex(h hash.Hash, fs billy.Filesystem) error {
+	gitDir, err := resolveGitDir(fs.Root())
+	if err != nil {
+		return err
+	}
+	indexPath := filepath.Join(gitDir, "index")
+
+	f, err := os.Open(indexPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	indexInfo, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := writeStat(h, osStat(indexPath), "index"); err != nil {
+		return err
+	}
+	racy := racyFiles{fs: fs, since: indexInfo.ModTime()}
+
+	idx := &index.Index{}
+	if err := index.NewDecoder(io.TeeReader(f, h)).Decode(idx); err != nil {
+		return err
+	}
+
+	tracked := map[string]bool{}
+	dirs := map[string]bool{".": true}
+	for _, entry := range idx.Entries {
+		tracked[entry.Name] = true
+		if err := racy.write(h, entry.Name, entry.Name); err != nil {
+			return err
+		}
+
+		for dir := path.Dir(entry.Name); !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	var sortedDirs []string
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Strings(sortedDirs)
+
+	for _, dir := range sortedDirs {
+		if err := writeStat(h, billyStat(fs, dir), dir+"/"); err != nil {
+			return err
+		}
+	}
+
+	patterns, err := gitignore.ReadPatterns(fs, nil)
+	if err != nil {
+		return err
+	}
+	u := untrackedFiles{
+		fs:      fs,
+		ignored: gitignore.NewMatcher(patterns),
+		tracked: tracked,
+		dirs:    dirs,
+		racy:    racy,
+	}
+	for _, dir := range sortedDirs {
+		if err := u.write(h, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// untrackedFiles writes the metadata of the files that are neither tracked nor ignored,
+// since editing them doesn't change the metadata of their directory.
+type untrackedFiles struct {
+	fs      billy.Filesystem
+	ignored gitignore.Matcher
+	tracked map[string]bool
+	dirs    map[string]bool
+	racy    racyFiles
+}
+
+// write writes the untracked files of a directory, and of its untracked subdirectories.
+func (u untrackedFiles) write(h hash.Hash, dir string) error {
+	infos, err := u.fs.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		p := path.Join(dir, info.Name())
+		if p == git.GitDirName || u.tracked[p] || u.dirs[p] || u.ignored.Match(strings.Split(p, "/"), info.IsDir()) {
+			continue
+		}
+
+		if info.IsDir() {
+			if err := u.write(h, p); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := u.racy.write(h, p, "untracked="+p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// racyFiles writes the metadata of files and, for those modified since a given time,
+// their content.
+type racyFiles struct {
+	fs    billy.Filesystem
+	since time.Time
+}
+
+func (r racyFiles) write(h hash.Hash, path, name string) error {
+	if err := writeStat(h, billyStat(r.fs, path), name); err != nil {
+		return err
+	}
+
+	info, err := r.fs.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() || info.ModTime().Before(r.since) {
+		return nil
+	}
+
+	f, err := r.fs.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(h, "content=%s %d\n", name, info.Size())
+	_, err = io.Copy(h, f)
+	return err
+}
+
+type statFn func() (os.FileInfo, error)
+
+func osStat(path string) statFn {
+	return func() (os.FileInfo, error) { return os.Lstat(path) }
+}
+
+func billyStat(fs billy.Filesystem, path string) statFn {
+	return func() (os.FileInfo, error) { return fs.Lstat(path) }
+}
+
+// writeStat writes the size, mode and modification time of a file to the hash.
+func writeStat(h hash.Hash, stat statFn, name string) error {
+	info, err := stat()
+	if os.IsNotExist(err) {
+		fmt.Fprintf(h, "missing=%s\n", name)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(h, "stat=%s %d %s %d\n", name, info.Size(), info.Mode(), info.ModTime().UnixNano())
+	return nil
+}
+
+func readCachedTag(path string) (*cachedTag, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cached cachedTag
+	if err := json.Unmarshal(content, &cached); err != nil {
+		return nil, err
+	}
+	return &cached, nil
+}
+
+// touchCachedTag marks a cached tag as recently used.
+func touchCachedTag(path string) {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		logrus.Debugf("Unable to touch cached tag %s: %s", path, err)
+	}
+}
+
+// pruneCachedTags removes the least recently used tags of a cache directory
+// beyond the max most recent ones.
+func pruneCachedTags(dir string, max int) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) <= max {
+		return err
+	}
+
+	modTimes := map[string]time.Time{}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return modTimes[files[i]].After(modTimes[files[j]])
+	})
+
+	for _, file := range files[max:] {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCachedTag(path string, cached cachedTag) error {
+	content, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0644)
+}
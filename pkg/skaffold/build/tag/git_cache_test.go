@@ -0,0 +1,263 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestGitCommitCache(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	cacheDir, cleanupCache := testutil.TempDir(t)
+	defer cleanupCache()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	c := &GitCommit{CacheDir: cacheDir}
+	generate := func() string {
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+		return name
+	}
+
+	// tamper replaces the cached tags with a marker to detect cache hits.
+	tamper := func() {
+		files, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+		failNowIfError(t, err)
+		if len(files) == 0 {
+			t.Fatal("Expected tags to be cached")
+		}
+		for _, file := range files {
+			err := ioutil.WriteFile(file, []byte(`{"result":{"FQN":"test:cached"}}`), 0644)
+			failNowIfError(t, err)
+		}
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "test:eefe1b9", generate())
+
+	// Cache hit: nothing changed
+	tamper()
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "test:cached", generate())
+
+	// Cache miss: the index was touched
+	future := time.Now().Add(time.Hour)
+	err := os.Chtimes(filepath.Join(tmpDir, ".git", "index"), future, future)
+	failNowIfError(t, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "test:eefe1b9", generate())
+
+	// Cache miss: a tracked file was modified without being staged
+	tamper()
+	repo.write("source.go", []byte("updated code"))
+	if name := generate(); !strings.HasPrefix(name, "test:eefe1b9-dirty-") {
+		t.Errorf("Expected a dirty tag, got %s", name)
+	}
+
+	// Cache miss: a file was added
+	repo.write("source.go", []byte("code"))
+	tamper()
+	repo.write("new.go", []byte("new"))
+	if name := generate(); !strings.HasPrefix(name, "test:eefe1b9-dirty-") {
+		t.Errorf("Expected a dirty tag, got %s", name)
+	}
+
+	// Cache miss: the options changed
+	tamper()
+	name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "other"})
+	failNowIfError(t, err)
+	if !strings.HasPrefix(name, "other:eefe1b9-dirty-") {
+		t.Errorf("Expected a dirty tag, got %s", name)
+	}
+
+	// Cache miss: an untracked file was edited in place
+	dirty := generate()
+	tamper()
+	repo.write("new.go", []byte("edited"))
+	if name := generate(); name == dirty || !strings.HasPrefix(name, "test:eefe1b9-dirty-") {
+		t.Errorf("Expected a new dirty tag, got %s", name)
+	}
+
+	// Cache miss: any option is part of the key
+	tamper()
+	name, err = c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test", DirtyFileWarnThreshold: 100})
+	failNowIfError(t, err)
+	if !strings.HasPrefix(name, "test:eefe1b9-dirty-") {
+		t.Errorf("Expected a dirty tag, got %s", name)
+	}
+}
+
+func TestGitCommitCacheChecksThresholds(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	cacheDir, cleanupCache := testutil.TempDir(t)
+	defer cleanupCache()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		write("source.go", []byte("updated code"))
+
+	c := &GitCommit{CacheDir: cacheDir}
+	opts := &Options{ImageName: "test", DirtyFileErrorThreshold: 2}
+	_, err := c.GenerateFullyQualifiedImageName(tmpDir, opts)
+	failNowIfError(t, err)
+
+	// The cached tag has more dirty files than the threshold
+	files, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	failNowIfError(t, err)
+	for _, file := range files {
+		err := ioutil.WriteFile(file, []byte(`{"result":{"FQN":"test:cached","DirtyFiles":["a","b","c"]}}`), 0644)
+		failNowIfError(t, err)
+	}
+
+	_, err = c.GenerateFullyQualifiedImageName(tmpDir, opts)
+	testutil.CheckError(t, true, err)
+}
+
+func TestGitCommitCacheRacyFiles(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	cacheDir, cleanupCache := testutil.TempDir(t)
+	defer cleanupCache()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	// The file is modified in the same tick as the index was written,
+	// without changing its size.
+	tick := time.Now().Truncate(time.Second)
+	failNowIfError(t, os.Chtimes(filepath.Join(tmpDir, ".git", "index"), tick, tick))
+	failNowIfError(t, os.Chtimes(filepath.Join(tmpDir, "source.go"), tick, tick))
+
+	c := &GitCommit{CacheDir: cacheDir}
+	name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", name)
+
+	repo.write("source.go", []byte("edit"))
+	failNowIfError(t, os.Chtimes(filepath.Join(tmpDir, "source.go"), tick, tick))
+
+	name, err = c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+	if !strings.HasPrefix(name, "test:eefe1b9-dirty-") {
+		t.Errorf("Expected a dirty tag, got %s", name)
+	}
+}
+
+func TestGitCommitCacheRefreshesGeneratedAt(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	cacheDir, cleanupCache := testutil.TempDir(t)
+	defer cleanupCache()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	c := &GitCommit{CacheDir: cacheDir}
+	_, err := c.GenerateTagResult(tmpDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	failNowIfError(t, err)
+	for _, file := range files {
+		err := ioutil.WriteFile(file, []byte(`{"result":{"FQN":"test:cached","GeneratedAt":"2018-01-01T00:00:00Z"}}`), 0644)
+		failNowIfError(t, err)
+	}
+
+	before := time.Now()
+	result, err := c.GenerateTagResult(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:cached", result.FQN)
+	if result.GeneratedAt.Before(before) {
+		t.Errorf("Expected a refreshed generation time, got %s", result.GeneratedAt)
+	}
+}
+
+func TestGitCommitCacheIsPruned(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	cacheDir, cleanupCache := testutil.TempDir(t)
+	defer cleanupCache()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	old := time.Now().Add(-time.Hour)
+	for i := 0; i < maxCachedTags+5; i++ {
+		file := filepath.Join(cacheDir, fmt.Sprintf("old-%d.json", i))
+		failNowIfError(t, ioutil.WriteFile(file, []byte(`{"result":{"FQN":"test:old"}}`), 0644))
+		failNowIfError(t, os.Chtimes(file, old, old))
+	}
+
+	c := &GitCommit{CacheDir: cacheDir}
+	name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", name)
+
+	files, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	failNowIfError(t, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, maxCachedTags, len(files))
+
+	// The tag that was just cached is kept.
+	name, err = c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", name)
+	files, err = filepath.Glob(filepath.Join(cacheDir, "old-*.json"))
+	failNowIfError(t, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, maxCachedTags-1, len(files))
+}
+
+func TestGitCommitCacheSubmodule(t *testing.T) {
+	superDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	cacheDir, cleanupCache := testutil.TempDir(t)
+	defer cleanupCache()
+
+	gitInit(t, superDir)
+	subDir := filepath.Join(superDir, "sub")
+	gitInit(t, subDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	modulesDir := filepath.Join(superDir, ".git", "modules")
+	failNowIfError(t, os.MkdirAll(modulesDir, os.ModePerm))
+	failNowIfError(t, os.Rename(filepath.Join(subDir, ".git"), filepath.Join(modulesDir, "sub")))
+	failNowIfError(t, ioutil.WriteFile(filepath.Join(subDir, ".git"), []byte("gitdir: ../.git/modules/sub\n"), os.ModePerm))
+
+	c := &GitCommit{CacheDir: cacheDir}
+	_, err := c.GenerateFullyQualifiedImageName(subDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	failNowIfError(t, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, len(files))
+}
@@ -20,104 +20,812 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"io"
-	"sort"
+	"hash"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/text/unicode/norm"
 	git "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
+// invalidTagCharRegexp matches the characters that are not allowed in tags.
+var invalidTagCharRegexp = regexp.MustCompile(`[^\w.-]`)
+
+// maxTagLength is the maximum length of image tags.
+const maxTagLength = 128
+
+// maxBranchTagLength leaves room for the `-wip` suffix.
+const maxBranchTagLength = maxTagLength - len("-wip")
+
+// pullRequestRefRegexp matches pull request merge refs, either local or fetched from a remote.
+var pullRequestRefRegexp = regexp.MustCompile(`^refs/(?:remotes/(?:[^/]+/)?)?pull/(\d+)/merge$`)
+
 // GitCommit tags an image by the git commit it was built at.
 type GitCommit struct {
+	// RemoteName is the remote used for Origin metadata. Defaults to `origin`.
+	RemoteName string
+
+	// PathScope, relative to the root of the repository, restricts the tagging
+	// to the files under that path. The tag is derived from the most recent commit
+	// that modified those files and only their local changes make the tag dirty.
+	PathScope string
+
+	// RequireSignedTag makes the tagger use only signed annotated git tags.
+	// Otherwise, the tagger falls back to the short commit hash.
+	RequireSignedTag bool
+
+	// PRTagging tags images built from a detached HEAD at a pull request
+	// merge ref, as checked out by CI systems, with `pr-<number>`.
+	PRTagging bool
+
+	// DirtyScope selects which local changes make the worktree dirty.
+	// By default, any change makes it dirty but only unstaged changes are hashed.
+	DirtyScope DirtyScope
+
+	// ScopeToWorkingDir restricts the changes that make the worktree dirty,
+	// and that are hashed, to the ones under the working directory.
+	// It narrows PathScope if both are used.
+	ScopeToWorkingDir bool
+
+	// DirtyBaseRef, eg. the last release tag, makes the worktree clean if it
+	// matches the tree of that ref, instead of the tree of HEAD. The tag is
+	// then computed from the commit of that ref.
+	DirtyBaseRef string
+
+	// CleanStatuses are git status codes, eg. `git.Untracked`, that
+	// don't make a file dirty.
+	CleanStatuses []git.StatusCode
+
+	// LocalIterationMode tags dirty worktrees on a branch other than master or main
+	// with `<branch>-wip`, without any content hash, so that local iterations
+	// reuse the same tag.
+	LocalIterationMode bool
+
+	// UseTreeHash derives the tag from the hash of the commit's tree instead of
+	// the commit hash, so that commits with the same files get the same tag.
+	UseTreeHash bool
+
+	// WorkspaceTreeHash derives the tag from the hash of the working directory's tree in the
+	// commit, instead of the commit hash, so that artifacts in different directories of a
+	// monorepo are only retagged when their own files change. It takes precedence over UseTreeHash.
+	WorkspaceTreeHash bool
+
+	// Prefix, eg. `release-`, is prepended to the tag.
+	Prefix string
+
+	// BranchPrefix prepends the name of the current branch to the tag, eg. `master-eefe1b9`.
+	// It's ignored on a detached HEAD.
+	BranchPrefix bool
+
+	// ReadOnly opens the git repository in a mode that never writes to it, nor locks
+	// its refs, so that concurrent runs on a shared checkout don't contend.
+	ReadOnly bool
+
+	// CacheDir, if set, is where the last tags are cached. A cached tag is reused
+	// as long as the git index, the refs and the tracked files' metadata don't change.
+	CacheDir string
+
+	// TagValidator checks the git tag names. Those that are rejected are sanitized if
+	// SanitizeTagNames is true, or ignored in favor of the short commit hash.
+	// Defaults to checking that it's a valid docker tag.
+	TagValidator TagValidator `json:"-"`
+
+	// SanitizeTagNames turns git tag names rejected by TagValidator into valid ones,
+	// eg. `Release/1.0` becomes `release-1.0`.
+	SanitizeTagNames bool
+
+	// GitDirOverride, if set, is the git repository used for tagging instead of the one
+	// containing the working directory, eg. when the sources are copied to a temp dir.
+	// It's either the root of the worktree or its .git directory.
+	GitDirOverride string
+
+	// PreferSubmodule tells whether artifacts built inside a git submodule, or any nested
+	// repository, are tagged from the innermost repository or the outermost one, when
+	// set to false. Defaults to true.
+	PreferSubmodule *bool
+
+	// CommitLength is the number of characters of the abbreviated commit hash.
+	// Defaults to `core.abbrev` from the git config or, if it's not set, to 7.
+	CommitLength int
+
+	// ExpectedRepoURL, if set, makes the tagger fail with ErrUnexpectedRepo unless the URL of
+	// the remote matches it, eg. to prevent tagging from the wrong repository in scripts that
+	// change directories. URLs are compared independently of their protocol and `.git` suffix.
+	ExpectedRepoURL string
+
+	// CleanDefault, eg. `latest`, is the tag used instead of the short commit hash
+	// when the worktree is clean and no git tag points at the commit.
+	CleanDefault string
+
+	// FallbackToCommitOnStatusError tags the commit as if the worktree was clean, instead of
+	// failing, when the git index can't be parsed, eg. with index formats that are too recent.
+	// Local changes are then ignored.
+	FallbackToCommitOnStatusError bool
+
+	// Transliterate maps the accented letters of branch names, and of the git tag names that
+	// are sanitized, to ASCII letters instead of replacing them, eg. `feature/café` becomes
+	// `feature-cafe` instead of `feature-caf-`. Other characters are still replaced.
+	Transliterate bool
+
+	// HashSubmodules folds the commit and the local changes of the submodules,
+	// recursively, into the hash of dirty worktrees.
+	HashSubmodules bool
+
+	// ForceDirty, if set, overrides whether the worktree is considered dirty, eg. to test
+	// deploy pipelines. A forced dirty tag hashes the actual changes, if any.
+	ForceDirty *bool
+
+	// openRepo can be replaced in tests. It defaults to openGitRepo.
+	openRepo repoOpener
+	// worktreeStatus can be replaced in tests. It defaults to (*git.Worktree).Status.
+	worktreeStatus func(*git.Worktree) (git.Status, error)
+}
+
+// Variants of the git commit tagger.
+const (
+	// AbbrevCommitSha tags with the abbreviated commit hash. It's the default.
+	AbbrevCommitSha = "AbbrevCommitSha"
+	// CommitSha tags with the full commit hash.
+	CommitSha = "CommitSha"
+	// TreeSha tags with the hash of the working directory's tree, and only
+	// considers the changes under the working directory.
+	TreeSha = "TreeSha"
+)
+
+// NewGitCommit creates a git commit tagger for a variant, case insensitively.
+// An empty variant is AbbrevCommitSha.
+func NewGitCommit(variant string) (*GitCommit, error) {
+	switch strings.ToLower(variant) {
+	case "", strings.ToLower(AbbrevCommitSha):
+		return &GitCommit{}, nil
+	case strings.ToLower(CommitSha):
+		return &GitCommit{CommitLength: commitHashLength}, nil
+	case strings.ToLower(TreeSha):
+		return &GitCommit{WorkspaceTreeHash: true, ScopeToWorkingDir: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown git commit variant %q, expected one of %s, %s, %s", variant, AbbrevCommitSha, CommitSha, TreeSha)
+	}
 }
 
 // GenerateFullyQualifiedImageName tags an image with the supplied image name and the git commit.
 func (c *GitCommit) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
-	repo, err := git.PlainOpenWithOptions(workingDir, &git.PlainOpenOptions{DetectDotGit: true})
+	mutable, _, err := c.GenerateBoth(workingDir, opts)
+	return mutable, err
+}
+
+// GenerateBoth returns both the usual mutable tag and an immutable `@sha256:` reference
+// based on the content of the worktree. Both are computed in one pass over the worktree.
+func (c *GitCommit) GenerateBoth(workingDir string, opts *Options) (string, string, error) {
+	result, immutable, err := c.cachedGenerate(workingDir, opts)
+	return result.FQN, immutable, err
+}
+
+// GenerateTagResult tags an image and describes how the tag was computed.
+func (c *GitCommit) GenerateTagResult(workingDir string, opts *Options) (TagResult, error) {
+	result, _, err := c.cachedGenerate(workingDir, opts)
+	return result, err
+}
+
+// GenerateWithRepo tags an image like GenerateFullyQualifiedImageName, with a git repository
+// that's already opened instead of opening the one containing workingDir.
+func (c *GitCommit) GenerateWithRepo(repo *git.Repository, workingDir string, opts *Options) (string, error) {
+	if repo == nil {
+		return "", fmt.Errorf("git repository not provided")
+	}
+	if err := c.checkRepo(repo); err != nil {
+		return "", err
+	}
+
+	result, _, err := c.cachedGenerateWithRepo(repo, workingDir, opts)
+	return result.FQN, err
+}
+
+// Deferred returns a function that opens the repository and tags the image on its first call,
+// and returns the same result on the next calls, so that the cost of git is only paid if
+// the tag is needed. The options are copied, so later changes to them are ignored.
+func (c *GitCommit) Deferred(workingDir string, opts *Options) func() (string, error) {
+	if opts != nil {
+		copied := *opts
+		opts = &copied
+	}
+
+	var (
+		once sync.Once
+		fqn  string
+		err  error
+	)
+	return func() (string, error) {
+		once.Do(func() {
+			fqn, err = c.GenerateFullyQualifiedImageName(workingDir, opts)
+		})
+		return fqn, err
+	}
+}
+
+func (c *GitCommit) generate(repo gitRepo, workingDir string, opts *Options) (TagResult, string, error) {
+	if opts == nil {
+		return TagResult{}, "", fmt.Errorf("tag options not provided")
+	}
+
+	name, err := imageName(opts)
 	if err != nil {
-		return "", errors.Wrap(err, "opening git repo")
+		return TagResult{}, "", err
+	}
+
+	if c.CleanDefault != "" {
+		if err := validateTag(c.CleanDefault); err != nil {
+			return TagResult{}, "", errors.Wrap(err, "invalid clean default")
+		}
+	}
+	if c.Prefix != "" {
+		if err := validateTag(c.Prefix); err != nil {
+			return TagResult{}, "", errors.Wrap(err, "invalid prefix")
+		}
 	}
 
 	w, err := repo.Worktree()
 	if err != nil {
-		return "", errors.Wrap(err, "reading worktree")
+		return TagResult{}, "", errors.Wrapf(err, "reading worktree of git repo found from %s", workingDir)
+	}
+
+	// The paths that are hashed are relative to the worktree. With GitDirOverride,
+	// the working directory is expected to be somewhere else.
+	if c.GitDirOverride == "" {
+		if err := checkInsideRepo(w.Filesystem.Root(), workingDir); err != nil {
+			return TagResult{}, "", err
+		}
+	}
+
+	worktreeStatus := c.worktreeStatus
+	if worktreeStatus == nil {
+		worktreeStatus = (*git.Worktree).Status
 	}
 
-	status, err := w.Status()
+	status, err := worktreeStatus(w)
 	if err != nil {
-		return "", errors.Wrap(err, "reading status")
+		if !c.FallbackToCommitOnStatusError || !isIndexParseError(err) {
+			return TagResult{}, "", errors.Wrapf(err, "reading status of git repo %s", w.Filesystem.Root())
+		}
+		logrus.Warnf("Unable to read the status of git repo %s, tagging as if it was clean: %s", w.Filesystem.Root(), err)
+		status = git.Status{}
 	}
 
 	head, err := repo.Head()
 	if err != nil {
-		return "", errors.Wrap(err, "determining current git commit")
+		return TagResult{}, "", errors.Wrap(err, "determining current git commit")
+	}
+
+	commit := head.Hash()
+	scope := cleanScope(c.PathScope)
+	if scope != "" {
+		commit, err = scopedCommit(repo, commit, scope)
+		if err != nil {
+			return TagResult{}, "", errors.Wrapf(err, "finding last commit for %s", scope)
+		}
 	}
 
-	commitHash := head.Hash().String()
-	currentTag := commitHash[0:7]
+	dirtyPathScope := scope
+	if c.ScopeToWorkingDir {
+		wdScope, err := workingDirScope(w.Filesystem.Root(), workingDir)
+		if err != nil {
+			return TagResult{}, "", errors.Wrapf(err, "finding %s in git repo", workingDir)
+		}
+		if inScope(wdScope, scope) {
+			dirtyPathScope = wdScope
+		}
+	}
 
-	if status.IsClean() {
-		tagrefs, _ := repo.Tags()
-		err = tagrefs.ForEach(func(t *plumbing.Reference) error {
-			if t.Hash() == head.Hash() {
-				currentTag = t.Name().Short()
+	filter := statusFilter{
+		pathScope:     dirtyPathScope,
+		dirtyScope:    c.DirtyScope,
+		foldCase:      opts.CaseInsensitiveOrder,
+		cleanStatuses: c.CleanStatuses,
+	}
+
+	clean := filter.isClean(status)
+	if c.DirtyBaseRef != "" {
+		base, err := resolveCommit(repo, c.DirtyBaseRef)
+		if err != nil {
+			return TagResult{}, "", errors.Wrapf(err, "resolving %s", c.DirtyBaseRef)
+		}
+
+		clean, err = matchesCommit(repo, w, status, head.Hash(), base, filter.pathScope)
+		if err != nil {
+			return TagResult{}, "", errors.Wrapf(err, "comparing worktree with %s", c.DirtyBaseRef)
+		}
+		if clean {
+			commit = base
+		}
+	}
+
+	if c.ForceDirty != nil {
+		clean = !*c.ForceDirty
+	}
+
+	commitHash := commit.String()
+	taggedHash := commitHash
+	switch {
+	case c.WorkspaceTreeHash:
+		wdScope, err := workingDirScope(w.Filesystem.Root(), workingDir)
+		if err != nil {
+			return TagResult{}, "", errors.Wrapf(err, "finding %s in git repo", workingDir)
+		}
+		treeHash, err := scopedTreeHash(repo, commit, wdScope)
+		if err != nil {
+			return TagResult{}, "", errors.Wrapf(err, "reading tree of %s", workingDir)
+		}
+		taggedHash = treeHash.String()
+	case c.UseTreeHash:
+		commitObject, err := repo.CommitObject(commit)
+		if err != nil {
+			return TagResult{}, "", errors.Wrap(err, "reading current git commit")
+		}
+		taggedHash = commitObject.TreeHash.String()
+	}
+
+	length, err := c.commitLength(repo)
+	if err != nil {
+		return TagResult{}, "", err
+	}
+	shortHash := taggedHash[0:length]
+	currentTag := shortHash
+
+	if c.PRTagging && head.Name() == plumbing.HEAD {
+		pr, err := pullRequest(repo, head.Hash())
+		if err != nil {
+			return TagResult{}, "", errors.Wrap(err, "determining pull request")
+		}
+		if pr != "" {
+			currentTag = "pr-" + pr
+		}
+	}
+
+	// The content digest covers the commit and, if any, the local changes.
+	content := sha256.New()
+	content.Write([]byte(taggedHash))
+
+	dockerfile, err := dockerfileHash(workingDir, opts.DockerfilePath)
+	if err != nil {
+		return TagResult{}, "", err
+	}
+
+	// A git tag pointing at the commit is used instead of the short commit hash,
+	// whether the worktree is clean or not.
+	tagName, err := gitTag(repo, commit, c.RequireSignedTag)
+	if err != nil {
+		return TagResult{}, "", errors.Wrap(err, "determining git tag")
+	}
+	if tagName == latestTag && opts.ReserveLatest {
+		logrus.Warnf("Ignoring git tag %s that is reserved", tagName)
+		tagName = ""
+	}
+	if tagName != "" {
+		tagName = c.checkTagName(tagName)
+	}
+
+	if clean {
+		switch {
+		case tagName != "":
+			currentTag = tagName
+		case c.CleanDefault == latestTag && opts.ReserveLatest:
+			logrus.Warnf("Ignoring clean default %s that is reserved", c.CleanDefault)
+		case c.CleanDefault != "" && currentTag == shortHash:
+			currentTag = c.CleanDefault
+		}
+
+		// Build args, the Dockerfile, the base image and the env can change the resulting image even if the sources are unchanged.
+		suffix := ""
+		env := prefixedEnv(opts.EnvHashPrefix)
+		if len(opts.BuildArgs) > 0 || dockerfile != nil || opts.BaseImageDigest != "" || len(env) > 0 {
+			h := sha256.New()
+			if err := writeBuildArgs(h, opts.BuildArgs); err != nil {
+				return TagResult{}, "", errors.Wrap(err, "hashing build args")
 			}
-			return nil
-		})
+			if err := writeDockerfile(h, dockerfile); err != nil {
+				return TagResult{}, "", errors.Wrap(err, "hashing Dockerfile")
+			}
+			if err := writeBaseImageDigest(h, opts.BaseImageDigest); err != nil {
+				return TagResult{}, "", errors.Wrap(err, "hashing base image digest")
+			}
+			if err := writeEnv(h, env); err != nil {
+				return TagResult{}, "", errors.Wrap(err, "hashing env variables")
+			}
+			sum := h.Sum(nil)
+			content.Write(sum)
+
+			sha, err := shortSha(sum, opts)
+			if err != nil {
+				return TagResult{}, "", err
+			}
+			suffix = "-" + sha
+		}
+
+		fqn, err := composeFQN(FQNFields{Name: name, Tag: c.prefixed(head, currentTag), ShortHash: shortHash, Suffix: suffix}, opts)
 		if err != nil {
-			return "", errors.Wrap(err, "determining git tag")
+			return TagResult{}, "", err
 		}
 
-		fqn := fmt.Sprintf("%s:%s", opts.ImageName, currentTag)
-		return fqn, nil
+		return TagResult{
+			FQN:         fqn,
+			Source:      SourceGitCommit,
+			Commit:      commitHash,
+			GeneratedAt: time.Now(),
+		}, digestReference(name, content), nil
 	}
 
 	// The file state is dirty. To generate a unique suffix, let's hash all the modified files.
 	// We add a -dirty-unique-id suffix to work well with local iterations.
-	h := sha256.New()
-	for _, changedPath := range changedPaths(status) {
-		status := status[changedPath].Worktree
+	sum, err := dirtyHash(w, status, filter, opts, dockerfile)
+	if err != nil {
+		return TagResult{}, "", err
+	}
+	if c.HashSubmodules {
+		if sum, err = withSubmodules(sum, w.Filesystem.Root()); err != nil {
+			return TagResult{}, "", err
+		}
+	}
+	content.Write(sum)
+
+	if tagName != "" {
+		currentTag = tagName
+	}
 
-		statusLine := fmt.Sprintf("%c %s", status, changedPath)
-		if _, err := h.Write([]byte(statusLine)); err != nil {
-			return "", errors.Wrap(err, "adding deleted file to diff")
+	var fqn string
+	if c.LocalIterationMode && head.Name().IsBranch() && !isMainBranch(head.Name().Short()) {
+		fqn, err = composeFQN(FQNFields{Name: name, Tag: branchTag(c.readable(head.Name().Short())), Dirty: true, ShortHash: shortHash, Suffix: "-wip"}, opts)
+	} else {
+		sequence := ""
+		if opts.SequenceProvider != nil {
+			sequence = fmt.Sprintf("%d-", opts.SequenceProvider())
 		}
 
-		if status == git.Deleted {
+		fqn, err = uniqueFQN(sum, opts, func(sha string) (string, error) {
+			suffix := fmt.Sprintf("-dirty-%s%s", sequence, sha)
+			return composeFQN(FQNFields{Name: name, Tag: c.prefixed(head, currentTag), Dirty: true, ShortHash: shortHash, Suffix: suffix}, opts)
+		})
+	}
+	if err != nil {
+		return TagResult{}, "", err
+	}
+
+	return TagResult{
+		FQN:         fqn,
+		Source:      SourceGitCommit,
+		Commit:      commitHash,
+		Dirty:       true,
+		DirtyFiles:  filter.changedPaths(status),
+		GeneratedAt: time.Now(),
+	}, digestReference(name, content), nil
+}
+
+// scopedTreeHash returns the hash of the tree of a directory, relative to the root
+// of the repository, in a commit. An empty scope is the root tree.
+func scopedTreeHash(repo gitRepo, commit plumbing.Hash, scope string) (plumbing.Hash, error) {
+	commitObject, err := repo.CommitObject(commit)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if scope == "" {
+		return commitObject.TreeHash, nil
+	}
+
+	tree, err := commitObject.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	subtree, err := tree.Tree(scope)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return subtree.Hash, nil
+}
+
+// prefixed prepends the Prefix and, with BranchPrefix, the current branch to a tag.
+func (c *GitCommit) prefixed(head *plumbing.Reference, tag string) string {
+	if c.BranchPrefix && head.Name().IsBranch() {
+		tag = branchTag(c.readable(head.Name().Short())) + "-" + tag
+	}
+	tag = c.Prefix + tag
+	if len(tag) > maxTagLength {
+		tag = tag[:maxTagLength]
+	}
+	return tag
+}
+
+// isIndexParseError tells if an error is caused by a git index that can't be parsed.
+// The decoder reads an unknown extension as the checksum of the index, so that it
+// fails with ErrInvalidChecksum.
+func isIndexParseError(err error) bool {
+	switch errors.Cause(err) {
+	case index.ErrUnsupportedVersion, index.ErrMalformedSignature, index.ErrInvalidChecksum:
+		return true
+	default:
+		return false
+	}
+}
+
+// commitLength returns the length of the abbreviated commit hash: CommitLength if it's set,
+// or else `core.abbrev` from the git config, or else 7.
+func (c *GitCommit) commitLength(repo gitRepo) (int, error) {
+	if c.CommitLength != 0 {
+		if c.CommitLength < minCommitLength || c.CommitLength > commitHashLength {
+			return 0, fmt.Errorf("invalid commit length %d, should be between %d and %d", c.CommitLength, minCommitLength, commitHashLength)
+		}
+		return c.CommitLength, nil
+	}
+
+	abbrev, err := gitConfigAbbrev(repo)
+	if err != nil {
+		return 0, err
+	}
+	if abbrev != 0 {
+		return abbrev, nil
+	}
+	return defaultCommitLength, nil
+}
+
+// isMainBranch tells if a branch is the main development branch.
+func isMainBranch(branch string) bool {
+	return branch == "master" || branch == "main"
+}
+
+// branchTag turns a branch name into a valid tag, eg. `feature/login` becomes `feature-login`.
+func branchTag(branch string) string {
+	tag := invalidTagCharRegexp.ReplaceAllString(branch, "-")
+	tag = strings.TrimLeft(tag, ".-")
+	if len(tag) > maxBranchTagLength {
+		tag = tag[:maxBranchTagLength]
+	}
+	if tag == "" {
+		return "branch"
+	}
+	return tag
+}
+
+// checkTagName validates a git tag name and, if it's rejected, either sanitizes it
+// or returns an empty string so that the short commit hash is used instead.
+func (c *GitCommit) checkTagName(tagName string) string {
+	validate := c.TagValidator
+	if validate == nil {
+		validate = validateTag
+	}
+
+	err := validate(tagName)
+	if err == nil {
+		return tagName
+	}
+
+	if c.SanitizeTagNames {
+		sanitized := sanitizeTagName(c.readable(tagName))
+		if validate(sanitized) == nil {
+			logrus.Debugf("Using git tag %s as %s", tagName, sanitized)
+			return sanitized
+		}
+	}
+
+	logrus.Warnf("Ignoring git tag %s: %s", tagName, err)
+	return ""
+}
+
+// sanitizeTagName lowercases a git tag name and replaces the characters that are not
+// allowed in image tags, eg. `Release/1.0` becomes `release-1.0`.
+func sanitizeTagName(tagName string) string {
+	tag := invalidTagCharRegexp.ReplaceAllString(strings.ToLower(tagName), "-")
+	tag = strings.TrimLeft(tag, ".-")
+	if len(tag) > maxTagLength {
+		tag = tag[:maxTagLength]
+	}
+	return tag
+}
+
+// transliterations are the letters that don't decompose into an ASCII letter and marks.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D", 'ł': "l", 'Ł': "L", 'þ': "th", 'Þ': "TH", 'ı': "i",
+}
+
+// transliterate maps accented letters to their ASCII equivalent, eg. `café` becomes `cafe`.
+// Other characters, eg. emojis or CJK characters, are left untouched.
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
 			continue
 		}
+		if t, found := transliterations[r]; found {
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// readable transliterates a name if Transliterate is set.
+func (c *GitCommit) readable(name string) string {
+	if c.Transliterate {
+		return transliterate(name)
+	}
+	return name
+}
+
+// open opens the git repository containing workingDir, or GitDirOverride if set.
+// For nested repositories, the innermost one is used unless PreferSubmodule is false.
+// It fails with ErrGitDisabled if git is disabled. The repository must be released
+// once the tagger is done with it, since it can be shared with other taggers.
+func (c *GitCommit) open(workingDir string) (gitRepo, func(), error) {
+	if gitDisabled() {
+		return nil, nil, ErrGitDisabled
+	}
+
+	var err error
+	repoDir := workingDir
+	switch {
+	case c.GitDirOverride != "":
+		repoDir, err = gitDirOverride(c.GitDirOverride)
+	case c.PreferSubmodule != nil && !*c.PreferSubmodule:
+		repoDir, err = findOutermostRepoRoot(workingDir)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo, release, err := c.openDir(repoDir)
+	if err != nil {
+		if os.IsPermission(errors.Cause(err)) {
+			return nil, nil, &ErrRepoPermission{WorkingDir: workingDir, Err: err}
+		}
+		return nil, nil, err
+	}
 
-		f, err := w.Filesystem.Open(changedPath)
-		if err != nil {
-			return "", errors.Wrap(err, "reading diff")
+	if err := c.checkRepo(repo); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return repo, release, nil
+}
+
+// openDir opens a repository with openRepo, read only or from the shared repository cache.
+func (c *GitCommit) openDir(repoDir string) (gitRepo, func(), error) {
+	open := c.openRepo
+	if open == nil {
+		open = openGitRepo
+		if c.ReadOnly {
+			open = openReadOnlyGitRepo
+		} else if cache := currentRepoCache(); cache != nil {
+			return cache.checkout(repoDir)
 		}
+	}
+
+	repo, err := open(repoDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, func() {}, nil
+}
+
+// checkRepo verifies that the refs of the repository can be read and that it's
+// the expected one, if ExpectedRepoURL is set.
+func (c *GitCommit) checkRepo(repo gitRepo) error {
+	if err := checkRefBackend(repo); err != nil {
+		return err
+	}
+	if c.ExpectedRepoURL == "" {
+		return nil
+	}
+	return checkRepoURL(repo, c.RemoteName, c.ExpectedRepoURL)
+}
+
+// digestReference composes an `image@sha256:` reference.
+func digestReference(name string, h hash.Hash) string {
+	return fmt.Sprintf("%s@sha256:%s", name, hex.EncodeToString(h.Sum(nil)))
+}
+
+// gitTag returns the name of a tag that points to the given commit, or an empty string
+// if there's none. Annotated tags are peeled to the commit they point to. If there are
+// many, the highest version is used, honoring the `versionsort.suffix` git config.
+// If requireSigned is true, only annotated tags with a PGP signature are considered.
+func gitTag(repo gitRepo, commit plumbing.Hash, requireSigned bool) (string, error) {
+	tagrefs, err := repo.Tags()
+	if err != nil {
+		return "", errors.Wrap(err, "listing tags")
+	}
 
-		if _, err := io.Copy(h, f); err != nil {
-			f.Close()
-			return "", errors.Wrap(err, "reading diff")
+	var names []string
+	err = tagrefs.ForEach(func(t *plumbing.Reference) error {
+		// Corrupt refs can have an empty name that would result in an invalid tag.
+		tagName := strings.TrimPrefix(t.Name().String(), "refs/tags/")
+		if strings.TrimSpace(tagName) == "" {
+			return nil
+		}
+
+		target := t.Hash()
+		tagObject, err := repo.TagObject(target)
+		if err == nil {
+			target = tagObject.Target
+		}
+
+		if target != commit {
+			return nil
 		}
 
-		f.Close()
+		if requireSigned && (tagObject == nil || !isSigned(tagObject)) {
+			logrus.Warnf("Ignoring git tag %s that is not signed", tagName)
+			return nil
+		}
+
+		names = append(names, tagName)
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
-	sha := h.Sum(nil)
-	shaStr := hex.EncodeToString(sha[:])[:16]
-	fqn := fmt.Sprintf("%s:%s-dirty-%s", opts.ImageName, currentTag, shaStr)
-	return fqn, nil
+	return highestVersion(names, versionSortSuffixes(repo)), nil
 }
 
-// changedPaths returns the changed paths in a consistent order.
-// The order is important because we generate a sha256 out of it.
-func changedPaths(status git.Status) []string {
-	var changes []string
+// pullRequest returns the number of the pull request whose merge ref points
+// to the given commit, or an empty string if there's none.
+func pullRequest(repo gitRepo, commit plumbing.Hash) (string, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return "", errors.Wrap(err, "listing references")
+	}
+
+	var number string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || ref.Hash() != commit {
+			return nil
+		}
 
-	for path, change := range status {
-		if change.Worktree != git.Unmodified {
-			changes = append(changes, path)
+		if matches := pullRequestRefRegexp.FindStringSubmatch(ref.Name().String()); matches != nil {
+			number = matches[1]
 		}
+		return nil
+	})
+
+	return number, err
+}
+
+// isSigned checks the presence of a PGP signature on a tag.
+// The signature itself is not verified.
+func isSigned(tag *object.Tag) bool {
+	return strings.Contains(tag.PGPSignature, "-----BEGIN PGP SIGNATURE-----")
+}
+
+// currentGitTag returns the name of a tag that points to HEAD in the repo
+// found at workingDir. Any failure, or git being disabled, results in an empty string.
+func currentGitTag(workingDir string) string {
+	if gitDisabled() {
+		return ""
+	}
+
+	repo, err := openGitRepo(workingDir)
+	if err != nil {
+		logrus.Debugf("Unable to open git repo at %s: %s", workingDir, err)
+		return ""
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		logrus.Debugf("Unable to determine current git commit: %s", err)
+		return ""
+	}
+
+	tagName, err := gitTag(repo, head.Hash(), false)
+	if err != nil {
+		logrus.Debugf("Unable to determine git tag: %s", err)
+		return ""
 	}
 
-	sort.Strings(changes)
-	return changes
+	return tagName
 }
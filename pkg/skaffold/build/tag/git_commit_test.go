@@ -17,15 +17,22 @@ limitations under the License.
 package tag
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
 	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/index"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
 )
 
@@ -70,12 +77,26 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 					tag("v2")
 			},
 		},
+		{
+			description: "use annotated tag",
+			opts: &Options{
+				ImageName: "test",
+			},
+			expectedName: "test:v1",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					annotatedTag("v1", "release v1")
+			},
+		},
 		{
 			description: "dirty",
 			opts: &Options{
 				ImageName: "test",
 			},
-			expectedName: "test:eefe1b9-dirty-af8de1fde8be4367",
+			expectedName: "test:eefe1b9-dirty-a66e73246939372e",
 			createGitRepo: func(dir string) {
 				gitInit(t, dir).
 					write("source.go", []byte("code")).
@@ -85,12 +106,12 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 			},
 		},
 		{
-			description: "ignore tag when dirty",
+			description: "keep tag when dirty",
 			opts: &Options{
 				ImageName: "test",
 				Digest:    "sha256:12345abcde",
 			},
-			expectedName: "test:eefe1b9-dirty-af8de1fde8be4367",
+			expectedName: "test:v1-dirty-a66e73246939372e",
 			createGitRepo: func(dir string) {
 				gitInit(t, dir).
 					write("source.go", []byte("code")).
@@ -105,7 +126,7 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 			opts: &Options{
 				ImageName: "test",
 			},
-			expectedName: "test:eefe1b9-dirty-bfe9b4566c9d3fec",
+			expectedName: "test:eefe1b9-dirty-6dda2ce71d9f7638",
 			createGitRepo: func(dir string) {
 				gitInit(t, dir).
 					write("source.go", []byte("code")).
@@ -119,7 +140,7 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 			opts: &Options{
 				ImageName: "test",
 			},
-			expectedName: "test:279d53f-dirty-6a3ce511c689eda7",
+			expectedName: "test:279d53f-dirty-25cd57ff58e897aa",
 			createGitRepo: func(dir string) {
 				gitInit(t, dir).
 					write("source1.go", []byte("code1")).
@@ -134,7 +155,7 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 			opts: &Options{
 				ImageName: "test",
 			},
-			expectedName: "test:279d53f-dirty-d48c11ed65c37a09", // Must be <> than when only one file is deleted
+			expectedName: "test:279d53f-dirty-6d656dd584156e59", // Must be <> than when only one file is deleted
 			createGitRepo: func(dir string) {
 				gitInit(t, dir).
 					write("source1.go", []byte("code1")).
@@ -149,7 +170,7 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 			opts: &Options{
 				ImageName: "test",
 			},
-			expectedName: "test:eefe1b9-dirty-9c858d88cc0bf792",
+			expectedName: "test:eefe1b9-dirty-dbdffce18ef2b624",
 			createGitRepo: func(dir string) {
 				gitInit(t, dir).
 					write("source.go", []byte("code")).
@@ -163,7 +184,7 @@ func TestGitCommit_GenerateFullyQualifiedImageName(t *testing.T) {
 			opts: &Options{
 				ImageName: "test",
 			},
-			expectedName: "test:eefe1b9-dirty-6534adc17ccd1cf4", // Must be <> each time a new name is used
+			expectedName: "test:eefe1b9-dirty-4a849bb7c761755a", // Must be <> each time a new name is used
 			createGitRepo: func(dir string) {
 				gitInit(t, dir).
 					write("source.go", []byte("code")).
@@ -217,94 +238,1486 @@ func TestGenerateFullyQualifiedImageNameFromSubDirectory(t *testing.T) {
 	}
 }
 
-// gitRepo deals with test git repositories
-type gitRepo struct {
-	dir      string
-	repo     *git.Repository
-	workTree *git.Worktree
-	t        *testing.T
+func TestGitCommitBuildArgs(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	c := &GitCommit{}
+
+	name1, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+		ImageName: "test",
+		BuildArgs: map[string]string{"VERSION": "1"},
+	})
+	failNowIfError(t, err)
+
+	name2, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+		ImageName: "test",
+		BuildArgs: map[string]string{"VERSION": "2"},
+	})
+	failNowIfError(t, err)
+
+	if name1 == name2 {
+		t.Errorf("Expected different tags for different build args, got %s twice", name1)
+	}
+	if !strings.HasPrefix(name1, "test:eefe1b9-") {
+		t.Errorf("Expected tag to start with the commit, got %s", name1)
+	}
 }
 
-func gitInit(t *testing.T, dir string) *gitRepo {
-	repo, err := git.PlainInit(dir, false)
+func TestGitCommitDockerfile(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		mkdir("app").
+		mkdir("docker").
+		write("app/source.go", []byte("code")).
+		write("docker/Dockerfile", []byte("FROM scratch")).
+		add("app/source.go", "docker/Dockerfile").
+		commit("initial")
+
+	// The Dockerfile is out of the scope of the tagger.
+	c := &GitCommit{PathScope: "app"}
+	generate := func(dockerfile string) (string, error) {
+		return c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+			ImageName:      "test",
+			DockerfilePath: dockerfile,
+		})
+	}
+
+	withoutDockerfile, err := generate("")
+	failNowIfError(t, err)
+	withDockerfile, err := generate("docker/Dockerfile")
 	failNowIfError(t, err)
 
-	w, err := repo.Worktree()
+	if !strings.HasPrefix(withDockerfile, withoutDockerfile+"-") {
+		t.Errorf("Expected the Dockerfile to be folded into the tag, got %s", withDockerfile)
+	}
+
+	repo.write("docker/Dockerfile", []byte("FROM alpine"))
+
+	editedWithoutDockerfile, err := generate("")
+	failNowIfError(t, err)
+	editedWithDockerfile, err := generate("docker/Dockerfile")
 	failNowIfError(t, err)
 
-	return &gitRepo{
-		dir:      dir,
-		repo:     repo,
-		workTree: w,
-		t:        t,
+	testutil.CheckErrorAndDeepEqual(t, false, nil, withoutDockerfile, editedWithoutDockerfile)
+	if editedWithDockerfile == withDockerfile {
+		t.Errorf("Expected editing the Dockerfile to change the tag, got %s twice", withDockerfile)
+	}
+
+	// Also when the worktree is dirty
+	repo.write("app/source.go", []byte("updated code"))
+	dirty, err := generate("docker/Dockerfile")
+	failNowIfError(t, err)
+	repo.write("docker/Dockerfile", []byte("FROM busybox"))
+	dirtyEdited, err := generate("docker/Dockerfile")
+	failNowIfError(t, err)
+
+	if dirty == dirtyEdited {
+		t.Errorf("Expected editing the Dockerfile to change the dirty tag, got %s twice", dirty)
 	}
+
+	_, err = generate("docker/Missing")
+	testutil.CheckError(t, true, err)
 }
 
-func (g *gitRepo) mkdir(folder string) *gitRepo {
-	err := os.MkdirAll(filepath.Join(g.dir, folder), os.ModePerm)
-	failNowIfError(g.t, err)
-	return g
+func TestGitCommitBaseImageDigest(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	c := &GitCommit{}
+	generate := func(baseImageDigest string) (string, error) {
+		return c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+			ImageName:       "test",
+			BaseImageDigest: baseImageDigest,
+		})
+	}
+
+	withoutDigest, err := generate("")
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", withoutDigest)
+
+	digest1, err := generate("sha256:1111")
+	failNowIfError(t, err)
+	digest2, err := generate("sha256:2222")
+	failNowIfError(t, err)
+
+	if !strings.HasPrefix(digest1, withoutDigest+"-") {
+		t.Errorf("Expected the base image digest to be folded into the tag, got %s", digest1)
+	}
+	if digest1 == digest2 {
+		t.Errorf("Expected different base image digests to give different tags, got %s twice", digest1)
+	}
+
+	// Also when the worktree is dirty
+	repo.write("source.go", []byte("updated code"))
+	dirty1, err := generate("sha256:1111")
+	failNowIfError(t, err)
+	dirty2, err := generate("sha256:2222")
+	failNowIfError(t, err)
+
+	if dirty1 == dirty2 {
+		t.Errorf("Expected different base image digests to give different dirty tags, got %s twice", dirty1)
+	}
+
+	dirtyWithoutDigest, err := generate("")
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9-dirty-a66e73246939372e", dirtyWithoutDigest)
 }
 
-func (g *gitRepo) write(file string, content []byte) *gitRepo {
-	err := ioutil.WriteFile(filepath.Join(g.dir, file), content, os.ModePerm)
-	failNowIfError(g.t, err)
-	return g
+func TestGitCommitEnvHashPrefix(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	for _, key := range []string{"TEST_BUILD_MODE", "TEST_BUILD_REGION", "TEST_OTHER"} {
+		defer os.Unsetenv(key)
+	}
+	os.Setenv("TEST_BUILD_MODE", "release")
+	os.Setenv("TEST_BUILD_REGION", "eu")
+	os.Setenv("TEST_OTHER", "a")
+
+	c := &GitCommit{}
+	generate := func() string {
+		tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+			ImageName:     "test",
+			EnvHashPrefix: "TEST_BUILD_",
+		})
+		failNowIfError(t, err)
+		return tag
+	}
+
+	clean := generate()
+	if !strings.HasPrefix(clean, "test:eefe1b9-") {
+		t.Errorf("Expected the env to be folded into the tag, got %s", clean)
+	}
+
+	os.Setenv("TEST_OTHER", "b")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, clean, generate())
+
+	os.Setenv("TEST_BUILD_REGION", "us")
+	if generate() == clean {
+		t.Errorf("Expected changing a matching env variable to change the tag, got %s twice", clean)
+	}
+
+	// Also when the worktree is dirty
+	repo.write("source.go", []byte("updated code"))
+	dirty := generate()
+	os.Setenv("TEST_BUILD_MODE", "debug")
+	if generate() == dirty {
+		t.Errorf("Expected changing a matching env variable to change the dirty tag, got %s twice", dirty)
+	}
 }
 
-func (g *gitRepo) rename(file, to string) *gitRepo {
-	err := os.Rename(filepath.Join(g.dir, file), filepath.Join(g.dir, to))
-	failNowIfError(g.t, err)
-	return g
+func TestGitCommitFallbackToCommitOnStatusError(t *testing.T) {
+	tests := []struct {
+		description string
+		fallback    bool
+		statusErr   error
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "fallback",
+			fallback:    true,
+			statusErr:   index.ErrUnsupportedVersion,
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: "no fallback by default",
+			statusErr:   index.ErrUnsupportedVersion,
+			shouldErr:   true,
+		},
+		{
+			description: "other errors",
+			fallback:    true,
+			statusErr:   fmt.Errorf("BUG"),
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial").
+				write("source.go", []byte("updated code"))
+
+			c := &GitCommit{
+				FallbackToCommitOnStatusError: test.fallback,
+				worktreeStatus: func(*git.Worktree) (git.Status, error) {
+					return nil, test.statusErr
+				},
+			}
+			tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
 }
 
-func (g *gitRepo) delete(files ...string) *gitRepo {
-	for _, file := range files {
-		err := os.Remove(filepath.Join(g.dir, file))
-		failNowIfError(g.t, err)
+func TestIsIndexParseError(t *testing.T) {
+	header := func(signature string, version uint32) []byte {
+		b := []byte(signature)
+		b = append(b, 0, 0, 0, byte(version))
+		return append(b, 0, 0, 0, 0) // no entries
 	}
-	return g
+
+	tests := []struct {
+		description string
+		index       []byte
+		expected    bool
+	}{
+		{
+			description: "malformed signature",
+			index:       header("XXXX", 2),
+			expected:    true,
+		},
+		{
+			description: "unsupported version",
+			index:       header("DIRC", 99),
+			expected:    true,
+		},
+		{
+			description: "unknown extension",
+			index:       append(header("DIRC", 2), append([]byte("ZZZZ\x00\x00\x00\x01x"), make([]byte, 20)...)...),
+			expected:    true,
+		},
+		{
+			description: "truncated",
+			index:       []byte("DI"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := index.NewDecoder(bytes.NewReader(test.index)).Decode(&index.Index{})
+			if err == nil {
+				t.Fatal("Expected the index not to be parsed")
+			}
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, isIndexParseError(errors.Wrap(err, "reading status")))
+		})
+	}
+
+	// Only the errors of the decoder match, not their message.
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, isIndexParseError(fmt.Errorf("unknown extension")))
 }
 
-func (g *gitRepo) add(files ...string) *gitRepo {
-	for _, file := range files {
-		_, err := g.workTree.Add(file)
-		failNowIfError(g.t, err)
+func TestGitCommitForceDirty(t *testing.T) {
+	forceDirty, forceClean := true, false
+
+	tests := []struct {
+		description string
+		forceDirty  *bool
+		dirty       bool
+		expected    string
+	}{
+		{
+			description: "force clean on a dirty tree",
+			forceDirty:  &forceClean,
+			dirty:       true,
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: "force dirty on a dirty tree",
+			forceDirty:  &forceDirty,
+			dirty:       true,
+			expected:    "test:eefe1b9-dirty-a66e73246939372e",
+		},
+		{
+			description: "force dirty on a clean tree",
+			forceDirty:  &forceDirty,
+			expected:    "test:eefe1b9-dirty-e3b0c44298fc1c14",
+		},
+		{
+			description: "not forced",
+			dirty:       true,
+			expected:    "test:eefe1b9-dirty-a66e73246939372e",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+			if test.dirty {
+				repo.write("source.go", []byte("updated code"))
+			}
+
+			c := &GitCommit{ForceDirty: test.forceDirty}
+			tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, tag)
+		})
 	}
-	return g
 }
 
-func (g *gitRepo) commit(msg string) *gitRepo {
-	now, err := time.Parse("Jan 2, 2006 at 15:04:05 -0700 MST", "Feb 3, 2013 at 19:54:00 -0700 MST")
-	failNowIfError(g.t, err)
+func TestGitCommitUniquenessChecker(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
 
-	_, err = g.workTree.Commit(msg, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "John Doe",
-			Email: "john@doe.org",
-			When:  now,
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		write("source.go", []byte("updated code"))
+
+	var checked []string
+	c := &GitCommit{}
+	tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+		ImageName: "test",
+		UniquenessChecker: func(tag string) (bool, error) {
+			checked = append(checked, tag)
+			// Only the first tag collides
+			return len(checked) == 1, nil
 		},
 	})
-	failNowIfError(g.t, err)
+	failNowIfError(t, err)
 
-	return g
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"test:eefe1b9-dirty-a66e73246939372e", tag}, checked)
+	if !strings.HasPrefix(tag, "test:eefe1b9-dirty-a66e73246939372e") || len(tag) != len("test:eefe1b9-dirty-a66e73246939372e")+uniquenessLengthStep {
+		t.Errorf("Expected the hash to be extended, got %s", tag)
+	}
+
+	// Always colliding
+	_, err = c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+		ImageName:         "test",
+		UniquenessChecker: func(string) (bool, error) { return true, nil },
+	})
+	testutil.CheckError(t, true, err)
+
+	// Failing checker
+	_, err = c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+		ImageName:         "test",
+		UniquenessChecker: func(string) (bool, error) { return false, fmt.Errorf("registry unavailable") },
+	})
+	testutil.CheckError(t, true, err)
 }
 
-func (g *gitRepo) tag(tag string) *gitRepo {
-	head, err := g.repo.Head()
-	failNowIfError(g.t, err)
+func TestGitCommitCommitLength(t *testing.T) {
+	tests := []struct {
+		description  string
+		abbrev       string
+		commitLength int
+		expected     string
+		shouldErr    bool
+	}{
+		{
+			description: "default",
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: "core.abbrev",
+			abbrev:      "10",
+			expected:    "test:eefe1b9c44",
+		},
+		{
+			description:  "tagger config overrides git config",
+			abbrev:       "10",
+			commitLength: 8,
+			expected:     "test:eefe1b9c",
+		},
+		{
+			description: "core.abbrev auto",
+			abbrev:      "auto",
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: "core.abbrev below minimum",
+			abbrev:      "2",
+			expected:    "test:eefe",
+		},
+		{
+			description: "invalid core.abbrev",
+			abbrev:      "ten",
+			shouldErr:   true,
+		},
+		{
+			description:  "invalid commit length",
+			commitLength: 41,
+			shouldErr:    true,
+		},
+	}
 
-	n := plumbing.ReferenceName("refs/tags/" + tag)
-	t := plumbing.NewHashReference(n, head.Hash())
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
 
-	err = g.repo.Storer.SetReference(t)
-	failNowIfError(g.t, err)
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+			if test.abbrev != "" {
+				repo.config("core", "abbrev", test.abbrev)
+			}
 
-	return g
+			c := &GitCommit{CommitLength: test.commitLength}
+			tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
 }
 
-func failNowIfError(t *testing.T, err error) {
-	if err != nil {
-		t.Fatal(err)
+func TestGitCommitDeferred(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	opened := 0
+	c := &GitCommit{
+		openRepo: func(workingDir string) (gitRepo, error) {
+			opened++
+			return openGitRepo(workingDir)
+		},
+	}
+
+	opts := &Options{ImageName: "test"}
+	deferred := c.Deferred(tmpDir, opts)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, opened)
+
+	// The options are captured right away but the worktree is only read on the first call
+	opts.ImageName = "other"
+	repo.write("source.go", []byte("updated code"))
+
+	first, err := deferred()
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9-dirty-a66e73246939372e", first)
+
+	repo.write("source.go", []byte("code"))
+	second, err := deferred()
+	testutil.CheckErrorAndDeepEqual(t, false, err, first, second)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, opened)
+}
+
+func TestGitCommitCleanDefault(t *testing.T) {
+	tests := []struct {
+		description   string
+		cleanDefault  string
+		createGitRepo func(string)
+		opts          *Options
+		expected      string
+		shouldErr     bool
+	}{
+		{
+			description:  "clean untagged commit",
+			cleanDefault: "latest",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			expected: "test:latest",
+		},
+		{
+			description: "short hash by default",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			expected: "test:eefe1b9",
+		},
+		{
+			description:  "git tag wins",
+			cleanDefault: "latest",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					tag("v1")
+			},
+			expected: "test:v1",
+		},
+		{
+			description:  "dirty worktree",
+			cleanDefault: "latest",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					write("source.go", []byte("updated code"))
+			},
+			expected: "test:eefe1b9-dirty-a66e73246939372e",
+		},
+		{
+			description:  "reserved",
+			cleanDefault: "latest",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			opts:     &Options{ReserveLatest: true},
+			expected: "test:eefe1b9",
+		},
+		{
+			description:  "invalid default",
+			cleanDefault: "not/a/tag",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			test.createGitRepo(tmpDir)
+
+			opts := test.opts
+			if opts == nil {
+				opts = &Options{}
+			}
+			opts.ImageName = "test"
+
+			c := &GitCommit{CleanDefault: test.cleanDefault}
+			tag, err := c.GenerateFullyQualifiedImageName(tmpDir, opts)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
+}
+
+func TestGitCommitLocalIterationMode(t *testing.T) {
+	tests := []struct {
+		description   string
+		createGitRepo func(string)
+		expectedName  string
+		expectedDirty bool
+	}{
+		{
+			description: "dirty feature branch",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					branch("feature/login").
+					write("source.go", []byte("updated code"))
+			},
+			expectedName: "test:feature-login-wip",
+		},
+		{
+			description: "clean feature branch",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					branch("feature")
+			},
+			expectedName: "test:eefe1b9",
+		},
+		{
+			description: "dirty master",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					write("source.go", []byte("updated code"))
+			},
+			expectedDirty: true,
+		},
+		{
+			description: "dirty main",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					branch("main").
+					write("source.go", []byte("updated code"))
+			},
+			expectedDirty: true,
+		},
+		{
+			description: "dirty detached head",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					detach().
+					write("source.go", []byte("updated code"))
+			},
+			expectedDirty: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			test.createGitRepo(tmpDir)
+
+			c := &GitCommit{LocalIterationMode: true}
+			name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+			failNowIfError(t, err)
+
+			if test.expectedDirty {
+				if !strings.HasPrefix(name, "test:eefe1b9-dirty-") {
+					t.Errorf("Expected a dirty tag, got %s", name)
+				}
+			} else {
+				testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedName, name)
+			}
+		})
+	}
+}
+
+func TestGitCommitReserveLatest(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		tag("latest")
+
+	c := &GitCommit{}
+
+	name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:latest", name)
+
+	name, err = c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test", ReserveLatest: true})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", name)
+}
+
+func TestGitCommitUseTreeHash(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	generate := func(c *GitCommit) string {
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+		return name
+	}
+
+	byCommit := generate(&GitCommit{})
+	byTree := generate(&GitCommit{UseTreeHash: true})
+
+	// Same tree, different commit
+	repo.commit("reworded")
+
+	if name := generate(&GitCommit{}); name == byCommit {
+		t.Errorf("Expected a different tag for a different commit, got %s twice", name)
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, byTree, generate(&GitCommit{UseTreeHash: true}))
+	if byTree == byCommit {
+		t.Errorf("Expected the tree hash to differ from the commit hash, got %s", byTree)
+	}
+
+	repo.write("source.go", []byte("updated code"))
+	if name := generate(&GitCommit{UseTreeHash: true}); !strings.HasPrefix(name, byTree+"-dirty-") {
+		t.Errorf("Expected a dirty tag based on the tree hash, got %s", name)
+	}
+}
+
+func TestGitCommitWorkspaceTreeHash(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		mkdir("a").
+		mkdir("b").
+		write("a/source.go", []byte("code a")).
+		write("b/source.go", []byte("code b")).
+		add("a/source.go", "b/source.go").
+		commit("initial")
+
+	generate := func(dir string) string {
+		c := &GitCommit{WorkspaceTreeHash: true}
+		name, err := c.GenerateFullyQualifiedImageName(filepath.Join(tmpDir, dir), &Options{ImageName: "test"})
+		failNowIfError(t, err)
+		return name
+	}
+
+	tagA, tagB := generate("a"), generate("b")
+	if tagA == tagB {
+		t.Errorf("Expected different tags for different directories, got %s twice", tagA)
+	}
+
+	repo.write("b/source.go", []byte("updated code b")).
+		add("b/source.go").
+		commit("update b")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, tagA, generate("a"))
+	if name := generate("b"); name == tagB {
+		t.Errorf("Expected a different tag for an updated directory, got %s twice", name)
+	}
+}
+
+func TestGitCommitPrefix(t *testing.T) {
+	tests := []struct {
+		description string
+		tagger      *GitCommit
+		detach      bool
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "prefix",
+			tagger:      &GitCommit{Prefix: "release-"},
+			expected:    "test:release-eefe1b9",
+		},
+		{
+			description: "branch prefix",
+			tagger:      &GitCommit{BranchPrefix: true},
+			expected:    "test:master-eefe1b9",
+		},
+		{
+			description: "both prefixes",
+			tagger:      &GitCommit{Prefix: "ci-", BranchPrefix: true, CommitLength: 10},
+			expected:    "test:ci-master-eefe1b9c44",
+		},
+		{
+			description: "branch prefix on detached head",
+			tagger:      &GitCommit{BranchPrefix: true},
+			detach:      true,
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: "invalid prefix",
+			tagger:      &GitCommit{Prefix: "release/"},
+			shouldErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+			if test.detach {
+				repo.detach()
+			}
+
+			name, err := test.tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, name)
+		})
+	}
+}
+
+func TestBranchTag(t *testing.T) {
+	var tests = []struct {
+		branch   string
+		expected string
+	}{
+		{branch: "feature", expected: "feature"},
+		{branch: "feature/login", expected: "feature-login"},
+		{branch: "user@fix#1", expected: "user-fix-1"},
+		{branch: ".hidden", expected: "hidden"},
+		{branch: "/", expected: "branch"},
+		{branch: strings.Repeat("a", 200), expected: strings.Repeat("a", 124)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.branch, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, branchTag(test.branch))
+		})
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		description string
+		branch      string
+		expected    string
+	}{
+		{description: "ascii", branch: "feature/login", expected: "feature-login"},
+		{description: "accented", branch: "feature/café", expected: "feature-cafe"},
+		{description: "accented uppercase", branch: "Élan/Ñandú", expected: "Elan-Nandu"},
+		{description: "not decomposable", branch: "straße/smørrebrød", expected: "strasse-smorrebrod"},
+		{description: "emoji", branch: "fix/🐛", expected: "fix--"},
+		{description: "cjk", branch: "feature/日本", expected: "feature---"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, branchTag(transliterate(test.branch)))
+		})
+	}
+}
+
+func TestGitCommitTransliterate(t *testing.T) {
+	tests := []struct {
+		description   string
+		transliterate bool
+		expected      string
+	}{
+		{
+			description:   "transliterate",
+			transliterate: true,
+			expected:      "test:feature-cafe-wip",
+		},
+		{
+			description: "replace by default",
+			expected:    "test:feature-caf--wip",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial").
+				branch("feature/café").
+				write("source.go", []byte("updated code"))
+
+			c := &GitCommit{LocalIterationMode: true, Transliterate: test.transliterate}
+			tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, tag)
+		})
+	}
+}
+
+func TestGitCommitPRTagging(t *testing.T) {
+	tests := []struct {
+		description   string
+		prTagging     bool
+		expectedName  string
+		createGitRepo func(string)
+	}{
+		{
+			description:  "detached at pull request merge ref",
+			prTagging:    true,
+			expectedName: "test:pr-42",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					ref("refs/remotes/pull/42/merge").
+					detach()
+			},
+		},
+		{
+			description:  "dirty",
+			prTagging:    true,
+			expectedName: "test:pr-42-dirty-a66e73246939372e",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					ref("refs/pull/42/merge").
+					detach().
+					write("source.go", []byte("updated code"))
+			},
+		},
+		{
+			description:  "disabled",
+			expectedName: "test:eefe1b9",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					ref("refs/pull/42/merge").
+					detach()
+			},
+		},
+		{
+			description:  "not detached",
+			prTagging:    true,
+			expectedName: "test:eefe1b9",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					ref("refs/pull/42/merge")
+			},
+		},
+		{
+			description:  "git tag wins",
+			prTagging:    true,
+			expectedName: "test:v1",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					tag("v1").
+					ref("refs/pull/42/merge").
+					detach()
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			test.createGitRepo(tmpDir)
+
+			c := &GitCommit{PRTagging: test.prTagging}
+			name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedName, name)
+		})
+	}
+}
+
+func TestGitCommitErrorContainsWorkingDir(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	c := &GitCommit{}
+	_, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+	if err == nil || !strings.Contains(err.Error(), tmpDir) {
+		t.Errorf("Expected error to mention %s, got %v", tmpDir, err)
+	}
+}
+
+func TestGitCommit_GenerateBoth(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	opts := &Options{ImageName: "test"}
+	c := &GitCommit{}
+
+	mutable, cleanImmutable, err := c.GenerateBoth(tmpDir, opts)
+	failNowIfError(t, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "test:eefe1b9", mutable)
+	checkDigestReference(t, "test", cleanImmutable)
+
+	repo.write("source.go", []byte("updated code"))
+
+	mutable, dirtyImmutable, err := c.GenerateBoth(tmpDir, opts)
+	failNowIfError(t, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "test:eefe1b9-dirty-a66e73246939372e", mutable)
+	checkDigestReference(t, "test", dirtyImmutable)
+
+	if cleanImmutable == dirtyImmutable {
+		t.Errorf("Expected different immutable references, got %s twice", cleanImmutable)
+	}
+}
+
+func checkDigestReference(t *testing.T, name, ref string) {
+	parsed, err := reference.Parse(ref)
+	failNowIfError(t, err)
+
+	digested, ok := parsed.(reference.Canonical)
+	if !ok {
+		t.Fatalf("Expected a digest reference, got %s", ref)
+	}
+	if digested.Name() != name {
+		t.Errorf("Expected image name %s, got %s", name, digested.Name())
+	}
+	if err := digested.Digest().Validate(); err != nil {
+		t.Errorf("Invalid digest in %s: %s", ref, err)
+	}
+}
+
+// testRepo deals with test git repositories
+type testRepo struct {
+	dir      string
+	repo     *git.Repository
+	workTree *git.Worktree
+	t        *testing.T
+}
+
+func gitInit(t *testing.T, dir string) *testRepo {
+	repo, err := git.PlainInit(dir, false)
+	failNowIfError(t, err)
+
+	w, err := repo.Worktree()
+	failNowIfError(t, err)
+
+	return &testRepo{
+		dir:      dir,
+		repo:     repo,
+		workTree: w,
+		t:        t,
+	}
+}
+
+func (g *testRepo) mkdir(folder string) *testRepo {
+	err := os.MkdirAll(filepath.Join(g.dir, folder), os.ModePerm)
+	failNowIfError(g.t, err)
+	return g
+}
+
+func (g *testRepo) write(file string, content []byte) *testRepo {
+	err := ioutil.WriteFile(filepath.Join(g.dir, file), content, os.ModePerm)
+	failNowIfError(g.t, err)
+	return g
+}
+
+func (g *testRepo) rename(file, to string) *testRepo {
+	err := os.Rename(filepath.Join(g.dir, file), filepath.Join(g.dir, to))
+	failNowIfError(g.t, err)
+	return g
+}
+
+func (g *testRepo) delete(files ...string) *testRepo {
+	for _, file := range files {
+		err := os.Remove(filepath.Join(g.dir, file))
+		failNowIfError(g.t, err)
+	}
+	return g
+}
+
+// remove is `git rm`, that stages the deletion of the files.
+func (g *testRepo) remove(files ...string) *testRepo {
+	for _, file := range files {
+		_, err := g.workTree.Remove(file)
+		failNowIfError(g.t, err)
+	}
+	return g
+}
+
+func (g *testRepo) add(files ...string) *testRepo {
+	for _, file := range files {
+		_, err := g.workTree.Add(file)
+		failNowIfError(g.t, err)
+	}
+	return g
+}
+
+func (g *testRepo) commit(msg string) *testRepo {
+	now, err := time.Parse("Jan 2, 2006 at 15:04:05 -0700 MST", "Feb 3, 2013 at 19:54:00 -0700 MST")
+	failNowIfError(g.t, err)
+
+	_, err = g.workTree.Commit(msg, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  now,
+		},
+	})
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func (g *testRepo) tag(tag string) *testRepo {
+	head, err := g.repo.Head()
+	failNowIfError(g.t, err)
+
+	n := plumbing.ReferenceName("refs/tags/" + tag)
+	t := plumbing.NewHashReference(n, head.Hash())
+
+	err = g.repo.Storer.SetReference(t)
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func (g *testRepo) annotatedTag(tag, msg string) *testRepo {
+	return g.writeTag(tag, msg, "")
+}
+
+func (g *testRepo) writeTag(tag, msg, signature string) *testRepo {
+	head, err := g.repo.Head()
+	failNowIfError(g.t, err)
+
+	tagObject := &object.Tag{
+		Name:         tag,
+		Message:      msg,
+		PGPSignature: signature,
+		TargetType:   plumbing.CommitObject,
+		Target:       head.Hash(),
+		Tagger: object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+		},
+	}
+
+	obj := g.repo.Storer.NewEncodedObject()
+	err = tagObject.Encode(obj)
+	failNowIfError(g.t, err)
+
+	h, err := g.repo.Storer.SetEncodedObject(obj)
+	failNowIfError(g.t, err)
+
+	n := plumbing.ReferenceName("refs/tags/" + tag)
+	err = g.repo.Storer.SetReference(plumbing.NewHashReference(n, h))
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func (g *testRepo) ref(name string) *testRepo {
+	head, err := g.repo.Head()
+	failNowIfError(g.t, err)
+
+	err = g.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.ReferenceName(name), head.Hash()))
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func (g *testRepo) branch(name string) *testRepo {
+	head, err := g.repo.Head()
+	failNowIfError(g.t, err)
+
+	branch := plumbing.ReferenceName("refs/heads/" + name)
+	err = g.repo.Storer.SetReference(plumbing.NewHashReference(branch, head.Hash()))
+	failNowIfError(g.t, err)
+	err = g.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, branch))
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func (g *testRepo) detach() *testRepo {
+	head, err := g.repo.Head()
+	failNowIfError(g.t, err)
+
+	err = g.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head.Hash()))
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func (g *testRepo) checkout(name string) *testRepo {
+	ref, err := g.repo.Reference(plumbing.ReferenceName(name), true)
+	failNowIfError(g.t, err)
+
+	err = g.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, ref.Hash()))
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+// stash commits the staged changes as a stash, like `git stash`, records it
+// in the stash reflog and resets the worktree to HEAD.
+func (g *testRepo) stash(msg string) *testRepo {
+	head, err := g.repo.Head()
+	failNowIfError(g.t, err)
+
+	previous := plumbing.ZeroHash
+	if ref, err := g.repo.Reference("refs/stash", true); err == nil {
+		previous = ref.Hash()
+	}
+
+	g.commit(msg)
+	stash, err := g.repo.Head()
+	failNowIfError(g.t, err)
+
+	err = g.repo.Storer.SetReference(plumbing.NewHashReference("refs/stash", stash.Hash()))
+	failNowIfError(g.t, err)
+
+	logs := filepath.Join(g.dir, ".git", "logs", "refs", "stash")
+	failNowIfError(g.t, os.MkdirAll(filepath.Dir(logs), os.ModePerm))
+	f, err := os.OpenFile(logs, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	failNowIfError(g.t, err)
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s %s John Doe <john@doe.org> 1359946440 -0700\t%s\n", previous, stash.Hash(), msg)
+	failNowIfError(g.t, err)
+
+	err = g.workTree.Reset(&git.ResetOptions{Commit: head.Hash(), Mode: git.HardReset})
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func (g *testRepo) remote(name, url string) *testRepo {
+	_, err := g.repo.CreateRemote(&config.RemoteConfig{
+		Name: name,
+		URLs: []string{url},
+	})
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func (g *testRepo) config(section, key string, values ...string) *testRepo {
+	cfg, err := g.repo.Config()
+	failNowIfError(g.t, err)
+
+	cfg.Raw.Section(section).RemoveOption(key)
+	for _, value := range values {
+		cfg.Raw.Section(section).AddOption(key, value)
+	}
+	err = g.repo.Storer.SetConfig(cfg)
+	failNowIfError(g.t, err)
+
+	return g
+}
+
+func failNowIfError(t *testing.T, err error) {
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGitCommitSequenceProvider(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	cacheDir, cleanupCache := testutil.TempDir(t)
+	defer cleanupCache()
+
+	sequence := 0
+	opts := &Options{
+		ImageName: "test",
+		SequenceProvider: func() int {
+			sequence++
+			return sequence
+		},
+	}
+	c := &GitCommit{CacheDir: cacheDir}
+
+	// Clean tags don't have a sequence number
+	name, err := c.GenerateFullyQualifiedImageName(tmpDir, opts)
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", name)
+
+	repo.write("source.go", []byte("updated code"))
+
+	dirty, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+	sha := strings.TrimPrefix(dirty, "test:eefe1b9-dirty-")
+
+	for i := 1; i <= 3; i++ {
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, opts)
+		testutil.CheckErrorAndDeepEqual(t, false, err, fmt.Sprintf("test:eefe1b9-dirty-%d-%s", i, sha), name)
+	}
+}
+
+func TestGitCommitTagValidator(t *testing.T) {
+	var tests = []struct {
+		description string
+		tagName     string
+		tagger      *GitCommit
+		expected    string
+	}{
+		{
+			description: "uppercase allowed by default",
+			tagName:     "Release-1.0",
+			tagger:      &GitCommit{},
+			expected:    "test:Release-1.0",
+		},
+		{
+			description: "fallback to short hash",
+			tagName:     "Release-1.0",
+			tagger:      &GitCommit{TagValidator: LowercaseTagValidator},
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: "sanitize",
+			tagName:     "Release-1.0",
+			tagger:      &GitCommit{TagValidator: LowercaseTagValidator, SanitizeTagNames: true},
+			expected:    "test:release-1.0",
+		},
+		{
+			description: "sanitize invalid characters",
+			tagName:     "Release/1.0",
+			tagger:      &GitCommit{SanitizeTagNames: true},
+			expected:    "test:release-1.0",
+		},
+		{
+			description: "valid tag is left untouched",
+			tagName:     "v1.0",
+			tagger:      &GitCommit{TagValidator: LowercaseTagValidator, SanitizeTagNames: true},
+			expected:    "test:v1.0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial").
+				tag(test.tagName)
+
+			name, err := test.tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, name)
+		})
+	}
+}
+
+func TestGitCommitEmptyImageName(t *testing.T) {
+	var tests = []struct {
+		description string
+		setup       func(*testRepo)
+	}{
+		{
+			description: "clean",
+			setup:       func(*testRepo) {},
+		},
+		{
+			description: "dirty",
+			setup: func(repo *testRepo) {
+				repo.write("source.go", []byte("updated code"))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+			test.setup(repo)
+
+			c := &GitCommit{}
+			_, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{})
+
+			if err != ErrEmptyImageName {
+				t.Errorf("Expected %v, got %v", ErrEmptyImageName, err)
+			}
+		})
+	}
+}
+
+func TestGitCommitLabel(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		write("source.go", []byte("updated code"))
+
+	c := &GitCommit{}
+	dirty, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+
+	name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test", Label: "alice@laptop"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, dirty+"-alice-laptop", name)
+}
+
+func TestGitCommitFQNTemplate(t *testing.T) {
+	var tests = []struct {
+		description string
+		template    string
+		dirty       bool
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "clean",
+			template:    "{{.Name}}:{{.Tag}}{{if .Dirty}}.dev{{end}}",
+			expected:    "test:v1",
+		},
+		{
+			description: "dirty",
+			template:    "{{.Name}}:{{.Tag}}{{if .Dirty}}.dev{{end}}",
+			dirty:       true,
+			expected:    "test:v1.dev",
+		},
+		{
+			description: "short hash",
+			template:    "{{.Name}}:{{.ShortHash}}",
+			dirty:       true,
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: "same as default",
+			template:    "{{.Name}}:{{.Tag}}{{.Suffix}}",
+			dirty:       true,
+			expected:    "test:v1-dirty-a66e73246939372e",
+		},
+		{
+			description: "invalid reference",
+			template:    "{{.Name}}:{{.Tag}}!",
+			shouldErr:   true,
+		},
+		{
+			description: "unknown field",
+			template:    "{{.Name}}:{{.Unknown}}",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial").
+				tag("v1")
+			if test.dirty {
+				repo.write("source.go", []byte("updated code"))
+			}
+
+			c := &GitCommit{}
+			name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test", FQNTemplate: test.template})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, name)
+		})
 	}
 }
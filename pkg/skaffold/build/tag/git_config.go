@@ -0,0 +1,89 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+const (
+	gitConfigSection     = "skaffold"
+	gitConfigTagTemplate = "tagTemplate"
+
+	defaultCommitLength = 7
+	minCommitLength     = 4
+)
+
+// GitConfigTagTemplate returns the tag template configured under
+// `skaffold.tagTemplate` in the git config of the repo found at workingDir.
+// It returns an empty string if workingDir is not inside a git repo or if
+// the key is not set.
+func GitConfigTagTemplate(workingDir string) (string, error) {
+	repo, err := openGitRepo(workingDir)
+	if err != nil {
+		if err == git.ErrRepositoryNotExists {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "opening git repo in %s", workingDir)
+	}
+
+	return gitConfigTemplate(repo)
+}
+
+func gitConfigTemplate(repo gitRepo) (string, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", errors.Wrap(err, "reading git config")
+	}
+
+	return cfg.Raw.Section(gitConfigSection).Option(gitConfigTagTemplate), nil
+}
+
+// gitConfigAbbrev returns the abbreviation length of commit hashes configured under
+// `core.abbrev` in the git config, or 0 if it's not set or set to `auto`.
+// Like git, `no` means the full hash and lengths are at least 4.
+func gitConfigAbbrev(repo gitRepo) (int, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return 0, errors.Wrap(err, "reading git config")
+	}
+
+	abbrev := strings.ToLower(cfg.Raw.Section("core").Option("abbrev"))
+	switch abbrev {
+	case "", "auto":
+		return 0, nil
+	case "no", "false", "off":
+		return commitHashLength, nil
+	}
+
+	length, err := strconv.Atoi(abbrev)
+	if err != nil {
+		return 0, fmt.Errorf("invalid core.abbrev %q in git config", abbrev)
+	}
+	if length < minCommitLength {
+		return minCommitLength, nil
+	}
+	if length > commitHashLength {
+		return commitHashLength, nil
+	}
+	return length, nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestGitConfigTagTemplate(t *testing.T) {
+	tests := []struct {
+		description      string
+		createGitRepo    func(string)
+		expectedTemplate string
+	}{
+		{
+			description: "template",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					config("skaffold", "tagTemplate", "{{.IMAGE_NAME}}:{{.GIT_TAG}}")
+			},
+			expectedTemplate: "{{.IMAGE_NAME}}:{{.GIT_TAG}}",
+		},
+		{
+			description: "key is case insensitive",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					config("Skaffold", "tagtemplate", "{{.IMAGE_NAME}}:latest")
+			},
+			expectedTemplate: "{{.IMAGE_NAME}}:latest",
+		},
+		{
+			description: "other section",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					config("other", "tagTemplate", "{{.IMAGE_NAME}}:latest")
+			},
+		},
+		{
+			description: "no template",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir)
+			},
+		},
+		{
+			description:   "not a git repo",
+			createGitRepo: func(dir string) {},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			test.createGitRepo(tmpDir)
+
+			template, err := GitConfigTagTemplate(tmpDir)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedTemplate, template)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// ChangedBetween tells if any file under pathScope, relative to the root of the repository,
+// changed between two commits of the git repository containing workingDir.
+// An empty pathScope contains every file.
+func ChangedBetween(workingDir, fromRef, toRef string, pathScope string) (bool, error) {
+	repo, err := openGitRepo(workingDir)
+	if err != nil {
+		return false, errors.Wrapf(err, "opening git repo in %s", workingDir)
+	}
+
+	return changedBetween(repo, fromRef, toRef, cleanScope(pathScope))
+}
+
+func changedBetween(repo gitRepo, fromRef, toRef string, scope string) (bool, error) {
+	from, err := commitTree(repo, fromRef)
+	if err != nil {
+		return false, err
+	}
+	to, err := commitTree(repo, toRef)
+	if err != nil {
+		return false, err
+	}
+
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return false, errors.Wrapf(err, "diffing %s and %s", fromRef, toRef)
+	}
+
+	for _, change := range changes {
+		// Renamed files have both names.
+		if inScope(change.From.Name, scope) || inScope(change.To.Name, scope) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// commitTree returns the tree of the commit a revision points to.
+func commitTree(repo gitRepo, rev string) (*object.Tree, error) {
+	hash, err := resolveCommit(repo, rev)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving %s", rev)
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading commit %s", hash)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading tree of commit %s", hash)
+	}
+
+	return tree, nil
+}
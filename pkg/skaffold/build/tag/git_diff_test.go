@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestChangedBetween(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		mkdir("app").
+		mkdir("docs").
+		write("app/source.go", []byte("code")).
+		write("docs/README.md", []byte("docs")).
+		add("app/source.go", "docs/README.md").
+		commit("initial").
+		tag("v1").
+		write("docs/README.md", []byte("updated docs")).
+		add("docs/README.md").
+		commit("update docs").
+		tag("v2").
+		write("app/source.go", []byte("updated code")).
+		add("app/source.go").
+		commit("update code").
+		tag("v3")
+
+	var tests = []struct {
+		description string
+		from        string
+		to          string
+		scope       string
+		expected    bool
+		shouldErr   bool
+	}{
+		{description: "change outside the scope", from: "v1", to: "v2", scope: "app"},
+		{description: "change inside the scope", from: "v2", to: "v3", scope: "app", expected: true},
+		{description: "range with changes inside the scope", from: "v1", to: "v3", scope: "app/", expected: true},
+		{description: "no scope", from: "v1", to: "v2", expected: true},
+		{description: "same commit", from: "v2", to: "v2"},
+		{description: "prefix is not a scope", from: "v2", to: "v3", scope: "ap"},
+		{description: "unknown ref", from: "v1", to: "unknown", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			changed, err := ChangedBetween(tmpDir, test.from, test.to, test.scope)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, changed)
+		})
+	}
+}
@@ -0,0 +1,555 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	billy "gopkg.in/src-d/go-billy.v4"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// HashEncoding is the encoding of the hashes used in tags.
+type HashEncoding string
+
+const (
+	// HashEncodingHex encodes hashes in hexadecimal. This is the default.
+	HashEncodingHex HashEncoding = "hex"
+	// HashEncodingBase32 encodes hashes in lowercase base32, without padding.
+	HashEncodingBase32 HashEncoding = "base32"
+	// HashEncodingBase36 encodes hashes with lowercase letters and digits.
+	HashEncodingBase36 HashEncoding = "base36"
+
+	defaultHashLength = 16
+
+	// uniquenessLengthStep is the number of characters added to a hash that collides.
+	uniquenessLengthStep = 8
+	// maxUniquenessRetries is the maximum number of times a hash that collides is extended.
+	maxUniquenessRetries = 3
+
+	// maxDirtyFileSample is the number of paths listed when too many files are dirty.
+	maxDirtyFileSample = 10
+)
+
+// dirtyHash hashes all the modified files of a worktree.
+func dirtyHash(w *git.Worktree, status git.Status, filter statusFilter, opts *Options, dockerfile []byte) ([]byte, error) {
+	paths := filter.changedPaths(status)
+	if err := checkDirtyFileCount(paths, opts); err != nil {
+		return nil, err
+	}
+
+	lfs, err := readLFSPatterns(w.Filesystem)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading .gitattributes")
+	}
+
+	var progress *progress
+	if opts.Progress != nil {
+		total, err := changedSize(w, status, filter, paths)
+		if err != nil {
+			return nil, err
+		}
+
+		progress = newProgress(total, opts.Progress)
+		defer progress.done()
+	}
+
+	h := sha256.New()
+	for _, changedPath := range paths {
+		change := status[changedPath]
+		deleted := filter.isDeleted(change)
+
+		if opts.MetadataOnlyHash {
+			if _, err := h.Write([]byte(filter.statusLine(changedPath, change))); err != nil {
+				return nil, errors.Wrap(err, "adding file to diff")
+			}
+			if !deleted {
+				if err := writeMetadata(h, w.Filesystem, changedPath); err != nil {
+					return nil, errors.Wrap(err, "reading diff")
+				}
+				// The content isn't read but the file is accounted for.
+				if err := progress.skip(w.Filesystem, changedPath); err != nil {
+					return nil, errors.Wrap(err, "reading diff")
+				}
+			}
+			continue
+		}
+
+		var reduced []byte
+		if opts.DirtyReducer != nil {
+			var keep bool
+			reduced, keep, err = reduceFile(w.Filesystem, changedPath, deleted, progress, opts.DirtyReducer)
+			if err != nil {
+				return nil, errors.Wrap(err, "reading diff")
+			}
+			if !keep {
+				continue
+			}
+		}
+
+		statusLine := filter.statusLine(changedPath, change)
+		if _, err := h.Write([]byte(statusLine)); err != nil {
+			return nil, errors.Wrap(err, "adding deleted file to diff")
+		}
+
+		if deleted {
+			continue
+		}
+
+		if opts.DirtyReducer != nil {
+			if err := hashFile(h, bytes.NewReader(reduced), lfs.matches(changedPath), opts.NormalizeEOLInHash); err != nil {
+				return nil, errors.Wrap(err, "reading diff")
+			}
+			continue
+		}
+
+		f, err := w.Filesystem.Open(changedPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading diff")
+		}
+
+		if err := hashFile(h, progress.reader(f), lfs.matches(changedPath), opts.NormalizeEOLInHash); err != nil {
+			f.Close()
+			return nil, errors.Wrap(err, "reading diff")
+		}
+
+		f.Close()
+	}
+
+	if err := writeBuildArgs(h, opts.BuildArgs); err != nil {
+		return nil, errors.Wrap(err, "hashing build args")
+	}
+
+	if err := writeDockerfile(h, dockerfile); err != nil {
+		return nil, errors.Wrap(err, "hashing Dockerfile")
+	}
+
+	if err := writeBaseImageDigest(h, opts.BaseImageDigest); err != nil {
+		return nil, errors.Wrap(err, "hashing base image digest")
+	}
+
+	if err := writeEnv(h, prefixedEnv(opts.EnvHashPrefix)); err != nil {
+		return nil, errors.Wrap(err, "hashing env variables")
+	}
+
+	if opts.Salt != "" {
+		if _, err := fmt.Fprintf(h, "salt=%s\n", opts.Salt); err != nil {
+			return nil, errors.Wrap(err, "hashing salt")
+		}
+	}
+
+	return h.Sum(nil), nil
+}
+
+// checkDirtyFileCount warns, or fails, if more files are dirty than the thresholds
+// of the options, eg. after a bad checkout.
+func checkDirtyFileCount(paths []string, opts *Options) error {
+	count := len(paths)
+
+	if opts.DirtyFileErrorThreshold > 0 && count > opts.DirtyFileErrorThreshold {
+		return fmt.Errorf("%d files are dirty, more than %d: %s", count, opts.DirtyFileErrorThreshold, dirtyFileSample(paths))
+	}
+	if opts.DirtyFileWarnThreshold > 0 && count > opts.DirtyFileWarnThreshold {
+		logrus.Warnf("%d files are dirty, more than %d, which makes tagging slow. Is the checkout broken? %s", count, opts.DirtyFileWarnThreshold, dirtyFileSample(paths))
+	}
+
+	return nil
+}
+
+// dirtyFileSample lists the first dirty files.
+func dirtyFileSample(paths []string) string {
+	if len(paths) <= maxDirtyFileSample {
+		return strings.Join(paths, ", ")
+	}
+	return strings.Join(paths[:maxDirtyFileSample], ", ") + fmt.Sprintf(" and %d more", len(paths)-maxDirtyFileSample)
+}
+
+// writeMetadata writes the size and the mode of a file to the hash.
+func writeMetadata(w io.Writer, fs billy.Filesystem, path string) error {
+	info, err := fs.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "size=%d mode=%s\n", info.Size(), info.Mode())
+	return err
+}
+
+// reduceFile reads a changed file and passes it to the reducer. Deleted files
+// are passed with a nil content.
+func reduceFile(fs billy.Filesystem, path string, deleted bool, progress *progress, reducer DirtyReducer) ([]byte, bool, error) {
+	if deleted {
+		_, keep := reducer(path, nil)
+		return nil, keep, nil
+	}
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	content, err := ioutil.ReadAll(progress.reader(f))
+	if err != nil {
+		return nil, false, err
+	}
+
+	reduced, keep := reducer(path, content)
+	return reduced, keep, nil
+}
+
+// hashFile writes a file's content to the hash. LFS pointers, and
+// files tracked by LFS, are hashed as their canonical pointer.
+func hashFile(w io.Writer, r io.Reader, trackedByLFS, normalizeEOL bool) error {
+	head := make([]byte, lfsPointerMaxSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	head = head[:n]
+
+	isSmall := err != nil
+	if isSmall {
+		if pointer, ok := parseLFSPointer(head); ok {
+			_, err := w.Write(pointer)
+			return err
+		}
+	}
+
+	if !trackedByLFS {
+		// Files with a null byte in their first bytes are considered binary.
+		if normalizeEOL && bytes.IndexByte(head, 0) == -1 {
+			eol := &eolWriter{w: w}
+			if err := copyFile(eol, head, r); err != nil {
+				return err
+			}
+			return eol.flush()
+		}
+
+		return copyFile(w, head, r)
+	}
+
+	// The file is smudged. Compute the pointer from its content.
+	pointer, err := lfsPointerFor(io.MultiReader(bytes.NewReader(head), r))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(pointer)
+	return err
+}
+
+// copyFile writes the already read head of a file, then the rest of it.
+func copyFile(w io.Writer, head []byte, r io.Reader) error {
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	_, err := io.Copy(w, r)
+	return err
+}
+
+// eolWriter converts CRLF line endings to LF.
+type eolWriter struct {
+	w io.Writer
+	// cr is true if the last byte written was a CR.
+	cr bool
+}
+
+func (e *eolWriter) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p)+1)
+	for _, b := range p {
+		if e.cr && b != '\n' {
+			out = append(out, '\r')
+		}
+		e.cr = b == '\r'
+		if !e.cr {
+			out = append(out, b)
+		}
+	}
+
+	if _, err := e.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flush writes a trailing CR, if any.
+func (e *eolWriter) flush() error {
+	if !e.cr {
+		return nil
+	}
+	e.cr = false
+	_, err := e.w.Write([]byte{'\r'})
+	return err
+}
+
+// changedSize computes the total size of the changed files that will be hashed.
+func changedSize(w *git.Worktree, status git.Status, filter statusFilter, paths []string) (int64, error) {
+	var total int64
+
+	for _, changedPath := range paths {
+		if filter.isDeleted(status[changedPath]) {
+			continue
+		}
+
+		info, err := w.Filesystem.Stat(changedPath)
+		if err != nil {
+			return 0, errors.Wrap(err, "reading diff")
+		}
+		total += info.Size()
+	}
+
+	return total, nil
+}
+
+// progress reports the number of bytes read from the changed files.
+type progress struct {
+	read  int64
+	total int64
+	fn    func(bytesHashed, totalBytes int64)
+}
+
+func newProgress(total int64, fn func(bytesHashed, totalBytes int64)) *progress {
+	return &progress{
+		total: total,
+		fn:    fn,
+	}
+}
+
+// reader wraps a reader to report its progress. It's a no-op on a nil progress.
+func (p *progress) reader(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return &progressReader{r: r, p: p}
+}
+
+// skip reports a file as hashed without reading it. It's a no-op on a nil progress.
+func (p *progress) skip(fs billy.Filesystem, path string) error {
+	if p == nil {
+		return nil
+	}
+
+	info, err := fs.Stat(path)
+	if err != nil {
+		return err
+	}
+	p.read += info.Size()
+	p.fn(p.read, p.total)
+	return nil
+}
+
+func (p *progress) done() {
+	p.fn(p.read, p.total)
+}
+
+type progressReader struct {
+	r io.Reader
+	p *progress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if n > 0 {
+		r.p.read += int64(n)
+		r.p.fn(r.p.read, r.p.total)
+	}
+	return n, err
+}
+
+// dockerfileHash returns the sha256 of the Dockerfile at path, relative to workingDir,
+// or nil if no path is given.
+func dockerfileHash(workingDir, path string) ([]byte, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workingDir, path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading Dockerfile %s", path)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, errors.Wrapf(err, "reading Dockerfile %s", path)
+	}
+
+	return h.Sum(nil), nil
+}
+
+// writeDockerfile writes the hash of the Dockerfile, if any, to the hash.
+func writeDockerfile(w io.Writer, dockerfile []byte) error {
+	if dockerfile == nil {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(w, "dockerfile=%x\n", dockerfile)
+	return err
+}
+
+// writeBaseImageDigest writes the digest of the base image, if any, to the hash.
+func writeBaseImageDigest(w io.Writer, digest string) error {
+	if digest == "" {
+		return nil
+	}
+
+	_, err := fmt.Fprintf(w, "base=%s\n", digest)
+	return err
+}
+
+// prefixedEnv returns the sorted `key=value` pairs of the env variables whose name
+// starts with the prefix. It returns nothing for an empty prefix.
+func prefixedEnv(prefix string) []string {
+	if prefix == "" {
+		return nil
+	}
+
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			env = append(env, kv)
+		}
+	}
+	sort.Strings(env)
+
+	return env
+}
+
+// writeEnv writes `key=value` pairs of env variables to the hash.
+func writeEnv(w io.Writer, env []string) error {
+	for _, kv := range env {
+		if _, err := fmt.Fprintf(w, "env=%s\n", kv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBuildArgs writes the build args to the hash in a consistent order, as
+// `len(key)\x00key\x00len(value)\x00value\x00` records like the status lines,
+// so that keys and values containing delimiters are unambiguous.
+func writeBuildArgs(w io.Writer, buildArgs map[string]string) error {
+	var keys []string
+	for key := range buildArgs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := buildArgs[key]
+		if _, err := fmt.Fprintf(w, "%d\x00%s\x00%d\x00%s\x00", len(key), key, len(value), value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shortSha encodes a hash sum and truncates it, according to the options.
+func shortSha(sum []byte, opts *Options) (string, error) {
+	encoded, err := encodeSum(sum, opts.HashEncoding)
+	if err != nil {
+		return "", err
+	}
+
+	length := opts.DirtyHashLength
+	if length == 0 {
+		length = defaultHashLength
+	}
+	if length < 0 || length > len(encoded) {
+		return "", fmt.Errorf("invalid hash length %d, should be between 1 and %d", length, len(encoded))
+	}
+
+	return encoded[:length], nil
+}
+
+// encodeSum encodes a whole hash sum.
+func encodeSum(sum []byte, encoding HashEncoding) (string, error) {
+	switch encoding {
+	case "", HashEncodingHex:
+		return hex.EncodeToString(sum), nil
+	case HashEncodingBase32:
+		return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum)), nil
+	case HashEncodingBase36:
+		encoded := new(big.Int).SetBytes(sum).Text(36)
+		// Keep a fixed width, whatever the value
+		width := int(math.Ceil(float64(8*len(sum)) / math.Log2(36)))
+		return strings.Repeat("0", width-len(encoded)) + encoded, nil
+	default:
+		return "", fmt.Errorf("unknown hash encoding %q", encoding)
+	}
+}
+
+// uniqueFQN composes a fully qualified name from a hash sum. If the options have a UniquenessChecker
+// that reports a collision, the hash is extended by uniquenessLengthStep characters and the name is
+// checked again, at most maxUniquenessRetries times.
+func uniqueFQN(sum []byte, opts *Options, compose func(sha string) (string, error)) (string, error) {
+	sha, err := shortSha(sum, opts)
+	if err != nil {
+		return "", err
+	}
+
+	fqn, err := compose(sha)
+	if err != nil || opts.UniquenessChecker == nil {
+		return fqn, err
+	}
+
+	encoded, err := encodeSum(sum, opts.HashEncoding)
+	if err != nil {
+		return "", err
+	}
+
+	for retry := 0; ; retry++ {
+		collides, err := opts.UniquenessChecker(fqn)
+		if err != nil {
+			return "", errors.Wrapf(err, "checking uniqueness of %s", fqn)
+		}
+		if !collides {
+			return fqn, nil
+		}
+		if retry == maxUniquenessRetries || len(sha) == len(encoded) {
+			return "", fmt.Errorf("unable to find a unique tag, %s already exists", fqn)
+		}
+
+		length := len(sha) + uniquenessLengthStep
+		if length > len(encoded) {
+			length = len(encoded)
+		}
+		sha = encoded[:length]
+
+		if fqn, err = compose(sha); err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,488 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/sirupsen/logrus"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+func TestDirtyHashProgress(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source1.go", []byte("code1")).
+		write("source2.go", []byte("code2")).
+		add("source1.go", "source2.go").
+		commit("initial").
+		write("source1.go", bytes.Repeat([]byte("a"), 100*1024)).
+		write("new.go", []byte("new code")).
+		delete("source2.go")
+
+	var calls int
+	var lastHashed, lastTotal int64
+	opts := &Options{
+		ImageName: "test",
+		Progress: func(bytesHashed, totalBytes int64) {
+			calls++
+			lastHashed = bytesHashed
+			lastTotal = totalBytes
+		},
+	}
+
+	c := &GitCommit{}
+	name, err := c.GenerateFullyQualifiedImageName(tmpDir, opts)
+	failNowIfError(t, err)
+
+	if calls < 2 {
+		t.Errorf("Expected progress to be reported several times, got %d", calls)
+	}
+	expectedTotal := int64(100*1024 + len("new code"))
+	if lastTotal != expectedTotal || lastHashed != expectedTotal {
+		t.Errorf("Expected final progress to be %d/%d, got %d/%d", expectedTotal, expectedTotal, lastHashed, lastTotal)
+	}
+
+	// Progress reporting doesn't change the tag
+	opts.Progress = nil
+	nameWithoutProgress, err := c.GenerateFullyQualifiedImageName(tmpDir, opts)
+	testutil.CheckErrorAndDeepEqual(t, false, err, nameWithoutProgress, name)
+}
+
+func TestDirtyHashSalt(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	generate := func(salt string) string {
+		c := &GitCommit{}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+			ImageName: "test",
+			Salt:      salt,
+		})
+		failNowIfError(t, err)
+		return name
+	}
+
+	// Clean builds are not affected
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "test:eefe1b9", generate("build-1"))
+
+	repo.write("source.go", []byte("updated code"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "test:eefe1b9-dirty-a66e73246939372e", generate(""))
+	salted1 := generate("build-1")
+	salted2 := generate("build-2")
+	if salted1 == salted2 || salted1 == "test:eefe1b9-dirty-a66e73246939372e" {
+		t.Errorf("Expected salts to produce different tags, got %s and %s", salted1, salted2)
+	}
+}
+
+func TestShortSha(t *testing.T) {
+	sum := sha256.Sum256([]byte("content"))
+
+	var tests = []struct {
+		description string
+		encoding    HashEncoding
+		length      int
+		alphabet    string
+		expectedLen int
+		shouldErr   bool
+	}{
+		{
+			description: "default",
+			alphabet:    "0123456789abcdef",
+			expectedLen: 16,
+		},
+		{
+			description: "hex",
+			encoding:    HashEncodingHex,
+			length:      64,
+			alphabet:    "0123456789abcdef",
+			expectedLen: 64,
+		},
+		{
+			description: "base32",
+			encoding:    HashEncodingBase32,
+			length:      12,
+			alphabet:    "abcdefghijklmnopqrstuvwxyz234567",
+			expectedLen: 12,
+		},
+		{
+			description: "full base32",
+			encoding:    HashEncodingBase32,
+			length:      52,
+			alphabet:    "abcdefghijklmnopqrstuvwxyz234567",
+			expectedLen: 52,
+		},
+		{
+			description: "base36",
+			encoding:    HashEncodingBase36,
+			length:      10,
+			alphabet:    "0123456789abcdefghijklmnopqrstuvwxyz",
+			expectedLen: 10,
+		},
+		{
+			description: "too long",
+			encoding:    HashEncodingBase32,
+			length:      53,
+			shouldErr:   true,
+		},
+		{
+			description: "unknown encoding",
+			encoding:    "base64",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			sha, err := shortSha(sum[:], &Options{
+				HashEncoding:    test.encoding,
+				DirtyHashLength: test.length,
+			})
+
+			testutil.CheckError(t, test.shouldErr, err)
+			if test.shouldErr {
+				return
+			}
+			if len(sha) != test.expectedLen {
+				t.Errorf("Expected %d characters, got %s", test.expectedLen, sha)
+			}
+			if strings.Trim(sha, test.alphabet) != "" {
+				t.Errorf("Unexpected characters in %s", sha)
+			}
+			if err := validateTag(sha); err != nil {
+				t.Errorf("Expected a valid tag, got %s", err)
+			}
+		})
+	}
+}
+
+func TestShortShaBase36FixedWidth(t *testing.T) {
+	var zero [32]byte
+
+	sha, err := shortSha(zero[:], &Options{HashEncoding: HashEncodingBase36, DirtyHashLength: 50})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, strings.Repeat("0", 50), sha)
+}
+
+func TestDirtyHashNormalizeEOL(t *testing.T) {
+	generate := func(content []byte, normalize bool) string {
+		tmpDir, cleanup := testutil.TempDir(t)
+		defer cleanup()
+
+		gitInit(t, tmpDir).
+			write("source.go", []byte("code")).
+			add("source.go").
+			commit("initial").
+			write("untracked.txt", content)
+
+		c := &GitCommit{}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test", NormalizeEOLInHash: normalize})
+		failNowIfError(t, err)
+
+		return name
+	}
+
+	lf := []byte("line1\nline2\n")
+	crlf := []byte("line1\r\nline2\r\n")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, generate(lf, true), generate(crlf, true))
+	if generate(lf, false) == generate(crlf, false) {
+		t.Error("Expected line endings to change the tag by default")
+	}
+
+	// Binary files are not normalized
+	binaryLF := []byte("\x00line1\nline2\n")
+	binaryCRLF := []byte("\x00line1\r\nline2\r\n")
+	if generate(binaryLF, true) == generate(binaryCRLF, true) {
+		t.Error("Expected line endings of binary files to change the tag")
+	}
+}
+
+func TestEOLWriter(t *testing.T) {
+	var tests = []struct {
+		description string
+		chunks      []string
+		expected    string
+	}{
+		{description: "lf", chunks: []string{"a\nb\n"}, expected: "a\nb\n"},
+		{description: "crlf", chunks: []string{"a\r\nb\r\n"}, expected: "a\nb\n"},
+		{description: "lone cr", chunks: []string{"a\rb\r\r\n"}, expected: "a\rb\r\n"},
+		{description: "crlf across chunks", chunks: []string{"a\r", "\nb"}, expected: "a\nb"},
+		{description: "trailing cr", chunks: []string{"a\r"}, expected: "a\r"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := &eolWriter{w: &buf}
+			for _, chunk := range test.chunks {
+				w.Write([]byte(chunk))
+			}
+			err := w.flush()
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, buf.String())
+		})
+	}
+}
+
+func TestDirtyHashDirtyReducer(t *testing.T) {
+	timestampRegexp := regexp.MustCompile(`generated at \d+`)
+	normalize := func(path string, content []byte) ([]byte, bool) {
+		if path == "mocks.go" {
+			return nil, false
+		}
+		if strings.HasSuffix(path, ".pb.go") {
+			return timestampRegexp.ReplaceAll(content, []byte("generated at <timestamp>")), true
+		}
+		return content, true
+	}
+
+	generate := func(generated, mocks string, reducer DirtyReducer) string {
+		tmpDir, cleanup := testutil.TempDir(t)
+		defer cleanup()
+
+		gitInit(t, tmpDir).
+			write("source.go", []byte("code")).
+			write("api.pb.go", []byte("// generated at 1\ncode")).
+			write("mocks.go", []byte("mocks")).
+			add("source.go", "api.pb.go", "mocks.go").
+			commit("initial").
+			write("source.go", []byte("updated code")).
+			write("api.pb.go", []byte(generated)).
+			write("mocks.go", []byte(mocks))
+
+		c := &GitCommit{}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test", DirtyReducer: reducer})
+		failNowIfError(t, err)
+
+		return name
+	}
+
+	first := generate("// generated at 2\ncode", "mocks 2", normalize)
+	second := generate("// generated at 3\ncode", "mocks 3", normalize)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, first, second)
+
+	if generate("// generated at 2\nupdated code", "mocks 2", normalize) == first {
+		t.Error("Expected non generated changes to change the tag")
+	}
+	if generate("// generated at 2\ncode", "mocks 2", nil) == generate("// generated at 3\ncode", "mocks 3", nil) {
+		t.Error("Expected generated changes to change the tag by default")
+	}
+}
+
+func TestDirtyHashMetadataOnly(t *testing.T) {
+	var hashed, total int64
+	generate := func(content string, metadataOnly bool) string {
+		tmpDir, cleanup := testutil.TempDir(t)
+		defer cleanup()
+
+		gitInit(t, tmpDir).
+			write("source.go", []byte("code")).
+			add("source.go").
+			commit("initial").
+			write("source.go", []byte(content))
+
+		c := &GitCommit{}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{
+			ImageName:        "test",
+			MetadataOnlyHash: metadataOnly,
+			Progress: func(bytesHashed, totalBytes int64) {
+				hashed, total = bytesHashed, totalBytes
+			},
+		})
+		failNowIfError(t, err)
+
+		return name
+	}
+
+	// The progress reaches the total without reading the files
+	generate("code 10", true)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, int64(7), total)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, total, hashed)
+
+	// Same size, different content
+	testutil.CheckErrorAndDeepEqual(t, false, nil, generate("code 1", true), generate("code 2", true))
+	if generate("code 1", false) == generate("code 2", false) {
+		t.Error("Expected content changes to change the tag by default")
+	}
+
+	// Different size
+	if generate("code 1", true) == generate("code 10", true) {
+		t.Error("Expected size changes to change the tag")
+	}
+}
+
+func BenchmarkDirtyHash(b *testing.B) {
+	tmpDir, err := ioutil.TempDir("", "skaffold")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	repo, err := git.PlainInit(tmpDir, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	for i := 0; i < 16; i++ {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("file%d", i)), content, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, metadataOnly := range []bool{false, true} {
+		b.Run(fmt.Sprintf("metadataOnly=%t", metadataOnly), func(b *testing.B) {
+			opts := &Options{MetadataOnlyHash: metadataOnly}
+			for i := 0; i < b.N; i++ {
+				if _, err := dirtyHash(w, status, statusFilter{}, opts, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDirtyHashUnambiguousRecords(t *testing.T) {
+	// Under the former `<status> <path>` format, with the content right after the path,
+	// both changes below were hashed as `M abc`.
+	oldFormat := func(path, content string) []byte {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%c %s", 'M', path) + content))
+		return sum[:]
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, oldFormat("a", "bc"), oldFormat("ab", "c"))
+
+	generate := func(path, content string) string {
+		tmpDir, cleanup := testutil.TempDir(t)
+		defer cleanup()
+
+		gitInit(t, tmpDir).
+			write(path, []byte("initial")).
+			add(path).
+			commit("initial").
+			write(path, []byte(content))
+
+		c := &GitCommit{}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+
+		return strings.SplitN(name, "-dirty-", 2)[1]
+	}
+
+	if generate("a", "bc") == generate("ab", "c") {
+		t.Error("Expected distinct changes to have distinct hashes")
+	}
+}
+
+func TestDirtyFileThresholds(t *testing.T) {
+	tests := []struct {
+		description  string
+		opts         *Options
+		expectedWarn bool
+		shouldErr    bool
+	}{
+		{
+			description: "no thresholds",
+			opts:        &Options{ImageName: "test"},
+		},
+		{
+			description:  "warning",
+			opts:         &Options{ImageName: "test", DirtyFileWarnThreshold: 10},
+			expectedWarn: true,
+		},
+		{
+			description: "below warning threshold",
+			opts:        &Options{ImageName: "test", DirtyFileWarnThreshold: 12},
+		},
+		{
+			description: "error",
+			opts:        &Options{ImageName: "test", DirtyFileWarnThreshold: 5, DirtyFileErrorThreshold: 10},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+			for i := 0; i < 12; i++ {
+				repo.write(fmt.Sprintf("file%02d", i), []byte("new"))
+			}
+
+			var logs bytes.Buffer
+			logrus.SetOutput(&logs)
+			defer logrus.SetOutput(os.Stderr)
+
+			_, err := (&GitCommit{}).GenerateFullyQualifiedImageName(tmpDir, test.opts)
+
+			testutil.CheckError(t, test.shouldErr, err)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedWarn, strings.Contains(logs.String(), "12 files are dirty"))
+			if test.expectedWarn && !strings.Contains(logs.String(), "file00, file01") {
+				t.Errorf("Expected a sample of the dirty files, got %s", logs.String())
+			}
+			if test.expectedWarn && !strings.Contains(logs.String(), "and 2 more") {
+				t.Errorf("Expected the sample to be capped, got %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestWriteBuildArgsIsUnambiguous(t *testing.T) {
+	hashOf := func(buildArgs map[string]string) string {
+		var buf bytes.Buffer
+		failNowIfError(t, writeBuildArgs(&buf, buildArgs))
+		return buf.String()
+	}
+
+	if hashOf(map[string]string{"a": "b\nc=d"}) == hashOf(map[string]string{"a": "b", "c": "d"}) {
+		t.Error("Expected build args with delimiters in their values to be hashed differently")
+	}
+	if hashOf(map[string]string{"a=b": "c"}) == hashOf(map[string]string{"a": "b=c"}) {
+		t.Error("Expected build args with delimiters in their keys to be hashed differently")
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GenerateForImages tags several images built from the same working directory.
+// The git repository is opened, and the tag computed, only once, unless the options
+// have an FQNTemplate or a UniquenessChecker.
+// The result maps each image name to its fully qualified name.
+func (c *GitCommit) GenerateForImages(workingDir string, imageNames []string, opts *Options) (map[string]string, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("tag options not provided")
+	}
+
+	fqns := map[string]string{}
+	if len(imageNames) == 0 {
+		return fqns, nil
+	}
+
+	// A template can compose the name in any way, and a tag unique for one image
+	// can collide for another, so each image is tagged separately.
+	if opts.FQNTemplate != "" || opts.UniquenessChecker != nil {
+		for _, image := range imageNames {
+			imageOpts := *opts
+			imageOpts.ImageName = image
+
+			fqn, err := c.GenerateFullyQualifiedImageName(workingDir, &imageOpts)
+			if err != nil {
+				return nil, err
+			}
+			fqns[image] = fqn
+		}
+		return fqns, nil
+	}
+
+	first := *opts
+	first.ImageName = imageNames[0]
+	result, err := c.GenerateTagResult(workingDir, &first)
+	if err != nil {
+		return nil, err
+	}
+
+	firstName, err := imageName(&first)
+	if err != nil {
+		return nil, err
+	}
+	tag := strings.TrimPrefix(result.FQN, firstName+":")
+
+	for _, image := range imageNames {
+		imageOpts := *opts
+		imageOpts.ImageName = image
+
+		name, err := imageName(&imageOpts)
+		if err != nil {
+			return nil, err
+		}
+		fqns[image] = fmt.Sprintf("%s:%s", name, tag)
+	}
+
+	return fqns, nil
+}
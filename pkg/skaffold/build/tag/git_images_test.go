@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestGitCommitGenerateForImages(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	head, err := repo.repo.Head()
+	failNowIfError(t, err)
+	short := head.Hash().String()[:7]
+
+	opened := 0
+	c := &GitCommit{
+		openRepo: func(workingDir string) (gitRepo, error) {
+			opened++
+			return openGitRepo(workingDir)
+		},
+	}
+
+	fqns, err := c.GenerateForImages(tmpDir, []string{"frontend", "backend", "gcr.io/project/worker"}, &Options{})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, map[string]string{
+		"frontend":              "frontend:" + short,
+		"backend":               "backend:" + short,
+		"gcr.io/project/worker": "gcr.io/project/worker:" + short,
+	}, fqns)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, opened)
+}
+
+func TestGitCommitGenerateForImagesDirty(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		write("source.go", []byte("updated code"))
+
+	c := &GitCommit{}
+	fqns, err := c.GenerateForImages(tmpDir, []string{"frontend", "backend"}, &Options{})
+	failNowIfError(t, err)
+
+	expected, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "backend"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, fqns["backend"])
+}
+
+func TestGitCommitGenerateForImagesUniquenessChecker(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		write("source.go", []byte("updated code"))
+
+	var checked []string
+	c := &GitCommit{}
+	fqns, err := c.GenerateForImages(tmpDir, []string{"frontend", "backend"}, &Options{
+		UniquenessChecker: func(tag string) (bool, error) {
+			checked = append(checked, tag)
+			// Only the first tag of the backend collides
+			return tag == "backend:eefe1b9-dirty-a66e73246939372e", nil
+		},
+	})
+	failNowIfError(t, err)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "frontend:eefe1b9-dirty-a66e73246939372e", fqns["frontend"])
+	if !strings.HasPrefix(fqns["backend"], "backend:eefe1b9-dirty-a66e73246939372e") || len(fqns["backend"]) != len("backend:eefe1b9-dirty-a66e73246939372e")+uniquenessLengthStep {
+		t.Errorf("Expected the hash to be extended, got %s", fqns["backend"])
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		"frontend:eefe1b9-dirty-a66e73246939372e",
+		"backend:eefe1b9-dirty-a66e73246939372e",
+		fqns["backend"],
+	}, checked)
+}
+
+func TestGitCommitGenerateForImagesNoImages(t *testing.T) {
+	c := &GitCommit{
+		openRepo: func(string) (gitRepo, error) {
+			t.Fatal("Unexpected repo open")
+			return nil, nil
+		},
+	}
+
+	fqns, err := c.GenerateForImages("dir", nil, &Options{})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, map[string]string{}, fqns)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const commitHashLength = 40
+
+// TagFromCommit composes `imageName:<abbreviated commit>` from a commit hash
+// that is already known, without opening the repository.
+// An abbrev of 0 uses the full hash.
+func TagFromCommit(image, commitHash string, abbrev int) (string, error) {
+	name, err := imageName(&Options{ImageName: image})
+	if err != nil {
+		return "", err
+	}
+	if !isCommitHash(commitHash) {
+		return "", fmt.Errorf("invalid commit hash %q: expected %d hexadecimal characters", commitHash, commitHashLength)
+	}
+	if abbrev < 0 || abbrev > commitHashLength {
+		return "", fmt.Errorf("invalid abbreviation length %d: must be between 0 and %d", abbrev, commitHashLength)
+	}
+
+	if abbrev == 0 {
+		abbrev = commitHashLength
+	}
+
+	return fmt.Sprintf("%s:%s", name, strings.ToLower(commitHash[:abbrev])), nil
+}
+
+// isCommitHash tells if s is a full, hexadecimal, commit hash.
+func isCommitHash(s string) bool {
+	if len(s) != commitHashLength {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestTagFromCommit(t *testing.T) {
+	var tests = []struct {
+		description string
+		commitHash  string
+		abbrev      int
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "abbreviated",
+			commitHash:  "aea33bcc86b5af8c8570ff45d8a643202d63c808",
+			abbrev:      7,
+			expected:    "test:aea33bc",
+		},
+		{
+			description: "full hash",
+			commitHash:  "aea33bcc86b5af8c8570ff45d8a643202d63c808",
+			expected:    "test:aea33bcc86b5af8c8570ff45d8a643202d63c808",
+		},
+		{
+			description: "uppercase",
+			commitHash:  "AEA33BCC86B5AF8C8570FF45D8A643202D63C808",
+			abbrev:      7,
+			expected:    "test:aea33bc",
+		},
+		{
+			description: "too short",
+			commitHash:  "aea33bc",
+			abbrev:      7,
+			shouldErr:   true,
+		},
+		{
+			description: "not hexadecimal",
+			commitHash:  "zea33bcc86b5af8c8570ff45d8a643202d63c808",
+			abbrev:      7,
+			shouldErr:   true,
+		},
+		{
+			description: "empty",
+			shouldErr:   true,
+		},
+		{
+			description: "negative abbreviation",
+			commitHash:  "aea33bcc86b5af8c8570ff45d8a643202d63c808",
+			abbrev:      -1,
+			shouldErr:   true,
+		},
+		{
+			description: "abbreviation too long",
+			commitHash:  "aea33bcc86b5af8c8570ff45d8a643202d63c808",
+			abbrev:      41,
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tag, err := TagFromCommit("test", test.commitHash, test.abbrev)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
+}
+
+func TestTagFromCommitEmptyImageName(t *testing.T) {
+	_, err := TagFromCommit("", "aea33bcc86b5af8c8570ff45d8a643202d63c808", 7)
+
+	if err != ErrEmptyImageName {
+		t.Errorf("Expected ErrEmptyImageName, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+)
+
+const (
+	lfsPointerVersion = "https://git-lfs.github.com/spec/v1"
+
+	// lfsPointerMaxSize is the maximum size of an LFS pointer file.
+	lfsPointerMaxSize = 1024
+)
+
+var lfsOidRegexp = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// parseLFSPointer parses the content of an LFS pointer file and returns its canonical form.
+func parseLFSPointer(content []byte) ([]byte, bool) {
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) < 3 || lines[0] != "version "+lfsPointerVersion {
+		return nil, false
+	}
+
+	var oid string
+	size := int64(-1)
+	for _, line := range lines[1:] {
+		kv := strings.SplitN(line, " ", 2)
+		if len(kv) != 2 {
+			return nil, false
+		}
+
+		switch kv[0] {
+		case "oid":
+			oid = kv[1]
+		case "size":
+			s, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return nil, false
+			}
+			size = s
+		}
+	}
+
+	if !lfsOidRegexp.MatchString(oid) || size < 0 {
+		return nil, false
+	}
+
+	return lfsPointer(oid, size), true
+}
+
+// lfsPointerFor computes the LFS pointer of a smudged file's content.
+func lfsPointerFor(r io.Reader) ([]byte, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return lfsPointer("sha256:"+hex.EncodeToString(h.Sum(nil)), size), nil
+}
+
+func lfsPointer(oid string, size int64) []byte {
+	return []byte(fmt.Sprintf("version %s\noid %s\nsize %d\n", lfsPointerVersion, oid, size))
+}
+
+// lfsPatterns are the patterns of files tracked by LFS.
+type lfsPatterns []gitignore.Pattern
+
+// readLFSPatterns reads the patterns with the lfs filter from the top level .gitattributes.
+func readLFSPatterns(fs billy.Filesystem) (lfsPatterns, error) {
+	f, err := fs.Open(".gitattributes")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns lfsPatterns
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		for _, attr := range fields[1:] {
+			if attr == "filter=lfs" {
+				patterns = append(patterns, gitignore.ParsePattern(fields[0], nil))
+				break
+			}
+		}
+	}
+
+	return patterns, scanner.Err()
+}
+
+// matches tells if a file is tracked by LFS.
+func (l lfsPatterns) matches(path string) bool {
+	parts := strings.Split(path, "/")
+
+	for _, pattern := range l {
+		if pattern.Match(parts, false) == gitignore.Exclude {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+const largeFileContent = "large file content"
+
+// largeFilePointer is the LFS pointer of largeFileContent.
+const largeFilePointer = `version https://git-lfs.github.com/spec/v1
+oid sha256:bc452649b0d57a72fc5c3a9a8b4b69b730779bedd92d112daba41a6c54b623b5
+size 18
+`
+
+func TestLFSPointerFor(t *testing.T) {
+	pointer, err := lfsPointerFor(bytes.NewReader([]byte(largeFileContent)))
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, largeFilePointer, string(pointer))
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	var tests = []struct {
+		description string
+		content     string
+		expected    string
+		isPointer   bool
+	}{
+		{
+			description: "canonical pointer",
+			content:     largeFilePointer,
+			expected:    largeFilePointer,
+			isPointer:   true,
+		},
+		{
+			description: "pointer without trailing newline and with extension",
+			content:     "version https://git-lfs.github.com/spec/v1\next-0-foo sha256:abcd\noid sha256:bc452649b0d57a72fc5c3a9a8b4b69b730779bedd92d112daba41a6c54b623b5\nsize 18",
+			expected:    largeFilePointer,
+			isPointer:   true,
+		},
+		{
+			description: "regular file",
+			content:     "package main",
+		},
+		{
+			description: "invalid oid",
+			content:     "version https://git-lfs.github.com/spec/v1\noid sha256:abcd\nsize 18\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			pointer, isPointer := parseLFSPointer([]byte(test.content))
+
+			if isPointer != test.isPointer {
+				t.Errorf("Expected isPointer to be %t, got %t", test.isPointer, isPointer)
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, string(pointer))
+		})
+	}
+}
+
+func TestGitCommitLFS(t *testing.T) {
+	tagFor := func(largeFile string) string {
+		tmpDir, cleanup := testutil.TempDir(t)
+		defer cleanup()
+
+		gitInit(t, tmpDir).
+			write(".gitattributes", []byte("*.bin filter=lfs diff=lfs merge=lfs -text\n")).
+			write("source.go", []byte("code")).
+			add(".gitattributes", "source.go").
+			commit("initial").
+			write("large.bin", []byte(largeFile))
+
+		c := &GitCommit{}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+
+		return name
+	}
+
+	smudged := tagFor(largeFileContent)
+	pointer := tagFor(largeFilePointer)
+	other := tagFor("other content")
+
+	if smudged != pointer {
+		t.Errorf("Expected same tag for smudged and pointer files, got %s and %s", smudged, pointer)
+	}
+	if smudged == other {
+		t.Errorf("Expected different tags for different content, got %s", smudged)
+	}
+}
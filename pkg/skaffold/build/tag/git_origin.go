@@ -0,0 +1,141 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const defaultRemoteName = "origin"
+
+// ErrUnexpectedRepo is returned when the git repository doesn't have the expected origin,
+// eg. when a working directory is inside the wrong repository.
+var ErrUnexpectedRepo = errors.New("unexpected git repository")
+
+// Origin describes the git remote a repository comes from.
+type Origin struct {
+	// Remote is the name of the remote that was used.
+	Remote string
+	URL    string
+}
+
+// Origin returns the Origin metadata of the git repo found at workingDir.
+// If the default `origin` remote doesn't exist, the first remote in alphabetical
+// order is used instead. It returns nil if the repo has no remote.
+func (c *GitCommit) Origin(workingDir string) (*Origin, error) {
+	repo, release, err := c.open(workingDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening git repo in %s", workingDir)
+	}
+	defer release()
+
+	return gitOrigin(repo, c.RemoteName)
+}
+
+func gitOrigin(repo gitRepo, remoteName string) (*Origin, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading git config")
+	}
+
+	name := remoteName
+	if name == "" {
+		name = defaultRemoteName
+	}
+
+	remote, found := cfg.Remotes[name]
+	if !found {
+		if remoteName != "" {
+			return nil, fmt.Errorf("git remote %s not found", remoteName)
+		}
+		if len(cfg.Remotes) == 0 {
+			return nil, nil
+		}
+
+		var names []string
+		for name := range cfg.Remotes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		remote = cfg.Remotes[names[0]]
+	}
+
+	var url string
+	if len(remote.URLs) > 0 {
+		url = remote.URLs[0]
+	}
+
+	return &Origin{
+		Remote: remote.Name,
+		URL:    url,
+	}, nil
+}
+
+// checkRepoURL fails with ErrUnexpectedRepo if the URL of the remote doesn't match
+// the expected one, once both are normalized. Unlike Origin, there's no fallback
+// to another remote.
+func checkRepoURL(repo gitRepo, remoteName, expected string) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return errors.Wrap(err, "reading git config")
+	}
+
+	if remoteName == "" {
+		remoteName = defaultRemoteName
+	}
+
+	remote, found := cfg.Remotes[remoteName]
+	if !found || len(remote.URLs) == 0 {
+		return errors.Wrapf(ErrUnexpectedRepo, "git remote %s not found, expected %s", remoteName, expected)
+	}
+
+	if normalizeRepoURL(remote.URLs[0]) != normalizeRepoURL(expected) {
+		return errors.Wrapf(ErrUnexpectedRepo, "git remote %s is %s, expected %s", remoteName, remote.URLs[0], expected)
+	}
+	return nil
+}
+
+// normalizeRepoURL turns the different forms of a repository URL into `host/path`,
+// eg. `git@github.com:org/repo.git` and `https://github.com/org/repo` both
+// become `github.com/org/repo`. The host is lowercased.
+func normalizeRepoURL(repoURL string) string {
+	var host, path string
+
+	if u, err := url.Parse(repoURL); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if i := strings.Index(repoURL, ":"); i != -1 && !strings.Contains(repoURL[:i], "/") {
+		// scp-like syntax: [user@]host:path
+		host, path = repoURL[:i], repoURL[i+1:]
+		if at := strings.LastIndex(host, "@"); at != -1 {
+			host = host[at+1:]
+		}
+	} else {
+		path = repoURL
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" {
+		return path
+	}
+	return strings.ToLower(host) + "/" + path
+}
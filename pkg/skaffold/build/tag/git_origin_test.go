@@ -0,0 +1,172 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestGitCommit_Origin(t *testing.T) {
+	tests := []struct {
+		description    string
+		remoteName     string
+		createGitRepo  func(string)
+		expectedOrigin *Origin
+		shouldErr      bool
+	}{
+		{
+			description: "origin",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					remote("origin", "https://github.com/org/origin.git")
+			},
+			expectedOrigin: &Origin{Remote: "origin", URL: "https://github.com/org/origin.git"},
+		},
+		{
+			description: "single non-origin remote",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					remote("upstream", "https://github.com/org/upstream.git")
+			},
+			expectedOrigin: &Origin{Remote: "upstream", URL: "https://github.com/org/upstream.git"},
+		},
+		{
+			description: "multiple remotes without origin",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					remote("upstream", "https://github.com/org/upstream.git").
+					remote("fork", "https://github.com/me/fork.git")
+			},
+			expectedOrigin: &Origin{Remote: "fork", URL: "https://github.com/me/fork.git"},
+		},
+		{
+			description: "multiple remotes with origin",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					remote("another", "https://github.com/org/another.git").
+					remote("origin", "https://github.com/org/origin.git")
+			},
+			expectedOrigin: &Origin{Remote: "origin", URL: "https://github.com/org/origin.git"},
+		},
+		{
+			description: "configured remote",
+			remoteName:  "upstream",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					remote("origin", "https://github.com/org/origin.git").
+					remote("upstream", "https://github.com/org/upstream.git")
+			},
+			expectedOrigin: &Origin{Remote: "upstream", URL: "https://github.com/org/upstream.git"},
+		},
+		{
+			description: "configured remote not found",
+			remoteName:  "upstream",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					remote("origin", "https://github.com/org/origin.git")
+			},
+			shouldErr: true,
+		},
+		{
+			description: "no remote",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			test.createGitRepo(tmpDir)
+
+			c := &GitCommit{
+				RemoteName: test.remoteName,
+			}
+			origin, err := c.Origin(tmpDir)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expectedOrigin, origin)
+		})
+	}
+}
+
+func TestGitCommitExpectedRepoURL(t *testing.T) {
+	tests := []struct {
+		description     string
+		expectedRepoURL string
+		remoteURL       string
+		shouldErr       bool
+	}{
+		{
+			description:     "same url",
+			expectedRepoURL: "https://github.com/org/app.git",
+			remoteURL:       "https://github.com/org/app.git",
+		},
+		{
+			description:     "same repo with ssh",
+			expectedRepoURL: "https://github.com/org/app",
+			remoteURL:       "git@GitHub.com:org/app.git",
+		},
+		{
+			description:     "same repo with ssh url",
+			expectedRepoURL: "github.com/org/app",
+			remoteURL:       "ssh://git@github.com/org/app.git/",
+		},
+		{
+			description:     "other repo",
+			expectedRepoURL: "https://github.com/org/app.git",
+			remoteURL:       "https://github.com/org/other.git",
+			shouldErr:       true,
+		},
+		{
+			description:     "no remote",
+			expectedRepoURL: "https://github.com/org/app.git",
+			shouldErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			repo := gitInit(t, tmpDir).
+				write("source.go", []byte("code")).
+				add("source.go").
+				commit("initial")
+			if test.remoteURL != "" {
+				repo.remote("origin", test.remoteURL)
+			}
+
+			c := &GitCommit{ExpectedRepoURL: test.expectedRepoURL}
+			tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			if test.shouldErr {
+				if errors.Cause(err) != ErrUnexpectedRepo {
+					t.Errorf("Expected ErrUnexpectedRepo, got %v", err)
+				}
+				return
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", tag)
+		})
+	}
+}
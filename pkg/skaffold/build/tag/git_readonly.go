@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	billy "gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// openReadOnlyGitRepo opens the git repository containing workingDir, looking
+// into parent directories if needed, so that nothing is ever written to it.
+// In particular, no lock is acquired on the refs. The .git of submodules can be a
+// file pointing to the git directory. Linked worktrees, whose git directory only has
+// part of the repository, are not supported.
+func openReadOnlyGitRepo(workingDir string) (gitRepo, error) {
+	root, err := findRepoRoot(workingDir)
+	if err != nil {
+		return nil, err
+	}
+
+	gitDir, err := resolveGitDir(root)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "commondir")); err == nil {
+		return nil, fmt.Errorf("%s is a linked worktree, that can't be opened read only", root)
+	}
+
+	storage, err := filesystem.NewStorage(&readOnlyFilesystem{osfs.New(gitDir)})
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := git.Open(storage, &readOnlyFilesystem{osfs.New(root)})
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// findRepoRoot finds the closest parent directory of workingDir with a .git entry.
+func findRepoRoot(workingDir string) (string, error) {
+	dir, err := filepath.Abs(workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, git.GitDirName)); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", git.ErrRepositoryNotExists
+		}
+		dir = parent
+	}
+}
+
+// findOutermostRepoRoot finds the farthest parent directory of workingDir with a .git entry,
+// eg. the superproject of a submodule.
+func findOutermostRepoRoot(workingDir string) (string, error) {
+	root, err := findRepoRoot(workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		parent := filepath.Dir(root)
+		if parent == root {
+			return root, nil
+		}
+
+		outer, err := findRepoRoot(parent)
+		if err != nil {
+			return root, nil
+		}
+		root = outer
+	}
+}
+
+// readOnlyFilesystem is a billy.Filesystem that fails on any write.
+type readOnlyFilesystem struct {
+	billy.Filesystem
+}
+
+const writeFlags = os.O_WRONLY | os.O_RDWR | os.O_APPEND | os.O_CREATE | os.O_TRUNC
+
+func (fs *readOnlyFilesystem) Create(filename string) (billy.File, error) {
+	return nil, billy.ErrReadOnly
+}
+
+func (fs *readOnlyFilesystem) OpenFile(filename string, flag int, perm os.FileMode) (billy.File, error) {
+	if flag&writeFlags != 0 {
+		return nil, billy.ErrReadOnly
+	}
+	return fs.Filesystem.OpenFile(filename, flag, perm)
+}
+
+func (fs *readOnlyFilesystem) Rename(oldpath, newpath string) error {
+	return billy.ErrReadOnly
+}
+
+func (fs *readOnlyFilesystem) Remove(filename string) error {
+	return billy.ErrReadOnly
+}
+
+func (fs *readOnlyFilesystem) TempFile(dir, prefix string) (billy.File, error) {
+	return nil, billy.ErrReadOnly
+}
+
+func (fs *readOnlyFilesystem) MkdirAll(filename string, perm os.FileMode) error {
+	return billy.ErrReadOnly
+}
+
+func (fs *readOnlyFilesystem) Symlink(target, link string) error {
+	return billy.ErrReadOnly
+}
+
+func (fs *readOnlyFilesystem) Chroot(path string) (billy.Filesystem, error) {
+	chroot, err := fs.Filesystem.Chroot(path)
+	if err != nil {
+		return nil, err
+	}
+	return &readOnlyFilesystem{chroot}, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	billy "gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+)
+
+// snapshot lists the files of a directory with their size and modification time.
+func snapshot(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		files[path] = fmt.Sprintf("%d %s", info.Size(), info.ModTime())
+		return nil
+	})
+	failNowIfError(t, err)
+	return files
+}
+
+func TestGitCommitReadOnly(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		mkdir("sub").
+		write("sub/source.go", []byte("code")).
+		add("sub/source.go").
+		commit("initial")
+
+	head, err := repo.repo.Head()
+	failNowIfError(t, err)
+	packedRefs := fmt.Sprintf("# pack-refs with: peeled fully-peeled sorted \n%s refs/tags/v1\n", head.Hash())
+	err = ioutil.WriteFile(filepath.Join(tmpDir, ".git", "packed-refs"), []byte(packedRefs), 0644)
+	failNowIfError(t, err)
+
+	before := snapshot(t, filepath.Join(tmpDir, ".git"))
+
+	c := &GitCommit{ReadOnly: true}
+	name, err := c.GenerateFullyQualifiedImageName(filepath.Join(tmpDir, "sub"), &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:v1", name)
+
+	repo.write("sub/source.go", []byte("updated code"))
+	dirty, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+	expected, err := (&GitCommit{}).GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, dirty)
+
+	// Nothing was written to .git, not even a lock file.
+	testutil.CheckErrorAndDeepEqual(t, false, nil, before, snapshot(t, filepath.Join(tmpDir, ".git")))
+}
+
+func TestGitCommitReadOnlyNotARepo(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	c := &GitCommit{ReadOnly: true}
+	_, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+	testutil.CheckError(t, true, err)
+}
+
+func TestGitCommitReadOnlySubmoduleIsNotWritten(t *testing.T) {
+	superDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, superDir)
+	subDir := filepath.Join(superDir, "sub")
+	gitInit(t, subDir).
+		write("source.go", []byte("sub")).
+		add("source.go").
+		commit("initial")
+
+	modulesDir := filepath.Join(superDir, ".git", "modules")
+	failNowIfError(t, os.MkdirAll(modulesDir, os.ModePerm))
+	failNowIfError(t, os.Rename(filepath.Join(subDir, ".git"), filepath.Join(modulesDir, "sub")))
+	failNowIfError(t, ioutil.WriteFile(filepath.Join(subDir, ".git"), []byte("gitdir: ../.git/modules/sub\n"), os.ModePerm))
+
+	repo, err := openReadOnlyGitRepo(subDir)
+	failNowIfError(t, err)
+	w, err := repo.Worktree()
+	failNowIfError(t, err)
+
+	if _, err := w.Filesystem.Create("new.go"); err != billy.ErrReadOnly {
+		t.Errorf("Expected the worktree to be read only. Got %v", err)
+	}
+
+	c := &GitCommit{ReadOnly: true}
+	before := snapshot(t, filepath.Join(modulesDir, "sub"))
+	_, err = c.GenerateFullyQualifiedImageName(subDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, before, snapshot(t, filepath.Join(modulesDir, "sub")))
+}
+
+func TestGitCommitReadOnlyLinkedWorktree(t *testing.T) {
+	repoDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, repoDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	// A linked worktree, as created by `git worktree add`.
+	worktreeDir, cleanupWorktree := testutil.TempDir(t)
+	defer cleanupWorktree()
+	gitDir := filepath.Join(repoDir, ".git", "worktrees", "feature")
+	failNowIfError(t, os.MkdirAll(gitDir, os.ModePerm))
+	failNowIfError(t, ioutil.WriteFile(filepath.Join(gitDir, "commondir"), []byte("../..\n"), os.ModePerm))
+	failNowIfError(t, ioutil.WriteFile(filepath.Join(worktreeDir, ".git"), []byte("gitdir: "+gitDir+"\n"), os.ModePerm))
+
+	c := &GitCommit{ReadOnly: true}
+	_, err := c.GenerateFullyQualifiedImageName(worktreeDir, &Options{ImageName: "test"})
+
+	testutil.CheckError(t, true, err)
+}
+
+func TestReadOnlyFilesystem(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	err := ioutil.WriteFile(filepath.Join(tmpDir, "file"), []byte("content"), 0644)
+	failNowIfError(t, err)
+
+	fs := &readOnlyFilesystem{osfs.New(tmpDir)}
+
+	f, err := fs.Open("file")
+	testutil.CheckError(t, false, err)
+	f.Close()
+
+	chroot, err := fs.Chroot(".")
+	failNowIfError(t, err)
+
+	writes := map[string]func() error{
+		"create":         func() error { _, err := fs.Create("new"); return err },
+		"open for write": func() error { _, err := fs.OpenFile("file", os.O_RDWR, 0644); return err },
+		"temp file":      func() error { _, err := fs.TempFile("", "tmp"); return err },
+		"rename":         func() error { return fs.Rename("file", "renamed") },
+		"remove":         func() error { return fs.Remove("file") },
+		"mkdir":          func() error { return fs.MkdirAll("dir", 0755) },
+		"symlink":        func() error { return fs.Symlink("file", "link") },
+		"chroot create":  func() error { _, err := chroot.Create("new"); return err },
+	}
+
+	for description, write := range writes {
+		if err := write(); err != billy.ErrReadOnly {
+			t.Errorf("%s: expected a read-only error, got %v", description, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// gitRepo is the subset of a go-git repository used by the taggers.
+// *git.Repository implements it.
+type gitRepo interface {
+	Worktree() (*git.Worktree, error)
+	Head() (*plumbing.Reference, error)
+	Tags() (storer.ReferenceIter, error)
+	References() (storer.ReferenceIter, error)
+	Branches() (storer.ReferenceIter, error)
+	Reference(name plumbing.ReferenceName, resolved bool) (*plumbing.Reference, error)
+	TagObject(h plumbing.Hash) (*object.Tag, error)
+	CommitObject(h plumbing.Hash) (*object.Commit, error)
+	Remote(name string) (*git.Remote, error)
+	Config() (*config.Config, error)
+}
+
+// noGitEnvVar is the environment variable that disables git, eg. in hermetic builds.
+const noGitEnvVar = "SKAFFOLD_NO_GIT"
+
+// ErrGitDisabled is returned by the git taggers, without accessing the filesystem,
+// when the SKAFFOLD_NO_GIT environment variable is true.
+var ErrGitDisabled = errors.New("git is disabled by " + noGitEnvVar)
+
+// gitDisabled tells if the SKAFFOLD_NO_GIT environment variable is true.
+func gitDisabled() bool {
+	disabled, err := strconv.ParseBool(os.Getenv(noGitEnvVar))
+	return err == nil && disabled
+}
+
+// ErrRepoPermission is returned when the git repository can't be read
+// because of missing permissions.
+type ErrRepoPermission struct {
+	WorkingDir string
+	Err        error
+}
+
+func (e *ErrRepoPermission) Error() string {
+	return fmt.Sprintf("permission denied reading git repo found from %s: %s. "+
+		"Make sure the .git directory is readable by the current user, eg. when running "+
+		"in a container, that the uid matches the owner of the mounted volume", e.WorkingDir, e.Err)
+}
+
+// ErrUnsupportedRefBackend is returned when the refs of a git repository can't be read
+// because it uses a ref backend other than files, eg. reftable.
+type ErrUnsupportedRefBackend struct {
+	Backend string
+	Err     error
+}
+
+func (e *ErrUnsupportedRefBackend) Error() string {
+	return fmt.Sprintf("unable to read git refs: the %s ref backend is not supported: %s. "+
+		"Use a tagger that doesn't read the repository instead, eg. envTemplate with the commit "+
+		"given by the CI, or convert the repository with `git refs migrate --ref-format=files`", e.Backend, e.Err)
+}
+
+// checkRefBackend reads HEAD and, if it fails because the repository uses
+// an unsupported ref backend, returns an ErrUnsupportedRefBackend.
+func checkRefBackend(repo gitRepo) error {
+	_, err := repo.Head()
+	if err == nil {
+		return nil
+	}
+
+	cfg, cfgErr := repo.Config()
+	if cfgErr != nil {
+		return nil
+	}
+
+	backend := strings.ToLower(cfg.Raw.Section("extensions").Option("refStorage"))
+	if backend == "" || backend == "files" {
+		return nil
+	}
+	return &ErrUnsupportedRefBackend{Backend: backend, Err: err}
+}
+
+// repoOpener opens the git repository containing a working directory.
+type repoOpener func(workingDir string) (gitRepo, error)
+
+// openGitRepo opens the git repository containing workingDir, looking
+// into parent directories if needed.
+func openGitRepo(workingDir string) (gitRepo, error) {
+	repo, err := git.PlainOpenWithOptions(workingDir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// gitDirOverride checks that path is a git repository, either the root of its
+// worktree or its .git directory, and returns the directory to open it from.
+func gitDirOverride(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading git dir override %s", path)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("git dir override %s is not a directory", path)
+	}
+
+	path = filepath.Clean(path)
+	if filepath.Base(path) == git.GitDirName {
+		return filepath.Dir(path), nil
+	}
+
+	if _, err := os.Stat(filepath.Join(path, git.GitDirName)); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("git dir override %s is not a git repository", path)
+		}
+		return "", errors.Wrapf(err, "reading git dir override %s", path)
+	}
+
+	return path, nil
+}
+
+// IsTaggable tells if workingDir is inside a git repository with at least one commit.
+// Directories outside of a git repository and empty repositories are reported
+// as not taggable, without an error.
+func IsTaggable(workingDir string) (bool, error) {
+	repo, err := openGitRepo(workingDir)
+	if err != nil {
+		if err == git.ErrRepositoryNotExists {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "opening git repo in %s", workingDir)
+	}
+
+	if _, err := repo.Head(); err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "determining current git commit")
+	}
+
+	return true, nil
+}
+
+// HeadCommit returns the commit checked out in the git repository containing
+// workingDir. Any failure results in an empty string.
+func HeadCommit(workingDir string) string {
+	repo, err := openGitRepo(workingDir)
+	if err != nil {
+		logrus.Debugf("Unable to open git repo at %s: %s", workingDir, err)
+		return ""
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		logrus.Debugf("Unable to determine current git commit: %s", err)
+		return ""
+	}
+
+	return head.Hash().String()
+}
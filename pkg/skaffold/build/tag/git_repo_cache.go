@@ -0,0 +1,142 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"container/list"
+	"sync"
+)
+
+// RepoCache is an LRU cache of opened git repositories, keyed by their git directory,
+// for tools that tag many repositories repeatedly. A cached repository is used by one
+// tagger at a time since go-git repositories aren't safe for concurrent use.
+// Evicted repositories are only dropped: go-git doesn't keep files open between calls.
+type RepoCache struct {
+	size int
+	// open can be replaced in tests. It defaults to openGitRepo.
+	open repoOpener
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type repoCacheEntry struct {
+	gitDir string
+	repo   gitRepo
+	// inUse is held while a tagger uses the repository.
+	inUse sync.Mutex
+}
+
+// NewRepoCache creates a cache of at most size repositories.
+func NewRepoCache(size int) *RepoCache {
+	return &RepoCache{
+		size:    size,
+		open:    openGitRepo,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// checkout returns the cached repository containing workingDir, or opens it, and a
+// function that releases it. Until then, the other checkouts of the same repository wait.
+func (c *RepoCache) checkout(workingDir string) (gitRepo, func(), error) {
+	entry, err := c.entry(workingDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry.inUse.Lock()
+	return entry.repo, entry.inUse.Unlock, nil
+}
+
+func (c *RepoCache) entry(workingDir string) (*repoCacheEntry, error) {
+	root, err := findRepoRoot(workingDir)
+	if err != nil {
+		return nil, err
+	}
+	gitDir, err := resolveGitDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, found := c.entries[gitDir]; found {
+		c.lru.MoveToFront(element)
+		return element.Value.(*repoCacheEntry), nil
+	}
+
+	repo, err := c.open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &repoCacheEntry{gitDir: gitDir, repo: repo}
+	c.entries[gitDir] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+
+	return entry, nil
+}
+
+// purge removes all the cached repositories.
+func (c *RepoCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+}
+
+// evict drops the least recently used repository. A tagger that's still using it
+// can finish with it.
+func (c *RepoCache) evict(element *list.Element) {
+	entry := c.lru.Remove(element).(*repoCacheEntry)
+	delete(c.entries, entry.gitDir)
+}
+
+var (
+	sharedRepoCacheMu sync.Mutex
+	sharedRepoCache   *RepoCache
+)
+
+// SetRepoCacheSize makes GitCommit taggers reuse up to size opened repositories.
+// A size of 0, the default, disables the cache and drops the cached repositories.
+func SetRepoCacheSize(size int) {
+	sharedRepoCacheMu.Lock()
+	defer sharedRepoCacheMu.Unlock()
+
+	if sharedRepoCache != nil {
+		sharedRepoCache.purge()
+		sharedRepoCache = nil
+	}
+	if size > 0 {
+		sharedRepoCache = NewRepoCache(size)
+	}
+}
+
+// currentRepoCache returns the shared repository cache, or nil if it's disabled.
+func currentRepoCache() *RepoCache {
+	sharedRepoCacheMu.Lock()
+	defer sharedRepoCacheMu.Unlock()
+
+	return sharedRepoCache
+}
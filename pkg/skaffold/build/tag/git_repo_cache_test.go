@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestRepoCache(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	for _, name := range []string{"a", "b", "c"} {
+		gitInit(t, filepath.Join(tmpDir, name))
+	}
+
+	var opened []string
+	cache := NewRepoCache(2)
+	cache.open = func(root string) (gitRepo, error) {
+		opened = append(opened, filepath.Base(root))
+		return &fakeRepo{}, nil
+	}
+
+	open := func(dir string) {
+		_, release, err := cache.checkout(filepath.Join(tmpDir, dir))
+		failNowIfError(t, err)
+		release()
+	}
+
+	open("a")
+	open("b")
+	// Cache hits, even from a sub directory
+	open("a")
+	failNowIfError(t, os.MkdirAll(filepath.Join(tmpDir, "b", "sub"), os.ModePerm))
+	open("b/sub")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"a", "b"}, opened)
+
+	// a is the least recently used
+	open("c")
+	open("a")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"a", "b", "c", "a"}, opened)
+
+	cache.purge()
+	open("c")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"a", "b", "c", "a", "c"}, opened)
+}
+
+func TestRepoCacheCheckoutIsExclusive(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir)
+
+	cache := NewRepoCache(1)
+	cache.open = func(root string) (gitRepo, error) {
+		return &fakeRepo{}, nil
+	}
+
+	_, release, err := cache.checkout(tmpDir)
+	failNowIfError(t, err)
+
+	checkedOut := make(chan struct{})
+	go func() {
+		_, release, err := cache.checkout(tmpDir)
+		if err == nil {
+			release()
+		}
+		close(checkedOut)
+	}()
+
+	select {
+	case <-checkedOut:
+		t.Fatal("Expected the second checkout to wait for the first one to be released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	select {
+	case <-checkedOut:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the second checkout once the first one is released")
+	}
+}
+
+func TestGitCommitSharedRepoCache(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	SetRepoCacheSize(1)
+	defer SetRepoCacheSize(0)
+
+	cache := currentRepoCache()
+	opened := 0
+	cache.open = func(root string) (gitRepo, error) {
+		opened++
+		return openGitRepo(root)
+	}
+
+	c := &GitCommit{}
+	for i := 0; i < 2; i++ {
+		tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", tag)
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, opened)
+}
@@ -0,0 +1,606 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+var (
+	commit1 = plumbing.NewHash("eefe1b9f2e4ad557e9ab0ae6d53a5b26e7dd41a3")
+	commit2 = plumbing.NewHash("279d53fcfee0c0c01d61a4d9d297eed5b8c0ae8e")
+)
+
+// fakeRepo is an in-memory gitRepo.
+type fakeRepo struct {
+	head       *plumbing.Reference
+	tags       []*plumbing.Reference
+	tagObjects map[plumbing.Hash]*object.Tag
+	config     *config.Config
+	err        error
+}
+
+func (f *fakeRepo) Worktree() (*git.Worktree, error) {
+	return nil, fmt.Errorf("no worktree")
+}
+
+func (f *fakeRepo) Head() (*plumbing.Reference, error) {
+	if f.head == nil {
+		return nil, plumbing.ErrReferenceNotFound
+	}
+	return f.head, nil
+}
+
+func (f *fakeRepo) Tags() (storer.ReferenceIter, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return storer.NewReferenceSliceIter(f.tags), nil
+}
+
+func (f *fakeRepo) References() (storer.ReferenceIter, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return storer.NewReferenceSliceIter(f.tags), nil
+}
+
+func (f *fakeRepo) Branches() (storer.ReferenceIter, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return storer.NewReferenceFilteredIter(func(ref *plumbing.Reference) bool {
+		return ref.Name().IsBranch()
+	}, storer.NewReferenceSliceIter(f.tags)), nil
+}
+
+func (f *fakeRepo) Reference(name plumbing.ReferenceName, resolved bool) (*plumbing.Reference, error) {
+	for _, ref := range f.tags {
+		if ref.Name() == name {
+			return ref, nil
+		}
+	}
+	return nil, plumbing.ErrReferenceNotFound
+}
+
+func (f *fakeRepo) TagObject(h plumbing.Hash) (*object.Tag, error) {
+	if tag, found := f.tagObjects[h]; found {
+		return tag, nil
+	}
+	return nil, plumbing.ErrObjectNotFound
+}
+
+func (f *fakeRepo) CommitObject(h plumbing.Hash) (*object.Commit, error) {
+	return nil, plumbing.ErrObjectNotFound
+}
+
+func (f *fakeRepo) Remote(name string) (*git.Remote, error) {
+	return nil, git.ErrRemoteNotFound
+}
+
+func (f *fakeRepo) Config() (*config.Config, error) {
+	if f.config == nil {
+		return config.NewConfig(), nil
+	}
+	return f.config, nil
+}
+
+func (f *fakeRepo) withTag(name string, target plumbing.Hash) *fakeRepo {
+	f.tags = append(f.tags, plumbing.NewHashReference(plumbing.ReferenceName("refs/tags/"+name), target))
+	return f
+}
+
+func (f *fakeRepo) withAnnotatedTag(name string, hash, target plumbing.Hash, signature string) *fakeRepo {
+	if f.tagObjects == nil {
+		f.tagObjects = map[plumbing.Hash]*object.Tag{}
+	}
+	f.tagObjects[hash] = &object.Tag{
+		Hash:         hash,
+		Name:         name,
+		PGPSignature: signature,
+		TargetType:   plumbing.CommitObject,
+		Target:       target,
+	}
+	return f.withTag(name, hash)
+}
+
+func TestGitTag(t *testing.T) {
+	tagObject := plumbing.NewHash("1111111111111111111111111111111111111111")
+	signature := "-----BEGIN PGP SIGNATURE-----\n\nabcd\n-----END PGP SIGNATURE-----\n"
+
+	tests := []struct {
+		description   string
+		repo          *fakeRepo
+		requireSigned bool
+		expected      string
+		shouldErr     bool
+	}{
+		{
+			description: "no tag",
+			repo:        &fakeRepo{},
+		},
+		{
+			description: "lightweight tag",
+			repo:        (&fakeRepo{}).withTag("v1", commit1),
+			expected:    "v1",
+		},
+		{
+			description: "tag on another commit",
+			repo:        (&fakeRepo{}).withTag("v1", commit2),
+		},
+		{
+			description: "annotated tag",
+			repo:        (&fakeRepo{}).withAnnotatedTag("v1", tagObject, commit1, ""),
+			expected:    "v1",
+		},
+		{
+			description: "annotated tag on another commit",
+			repo:        (&fakeRepo{}).withAnnotatedTag("v1", tagObject, commit2, ""),
+		},
+		{
+			description:   "signed tag",
+			repo:          (&fakeRepo{}).withAnnotatedTag("v1", tagObject, commit1, signature),
+			requireSigned: true,
+			expected:      "v1",
+		},
+		{
+			description:   "unsigned tag",
+			repo:          (&fakeRepo{}).withAnnotatedTag("v1", tagObject, commit1, ""),
+			requireSigned: true,
+		},
+		{
+			description:   "lightweight tag when signature is required",
+			repo:          (&fakeRepo{}).withTag("v1", commit1),
+			requireSigned: true,
+		},
+		{
+			description: "empty tag name",
+			repo:        (&fakeRepo{}).withTag("", commit1),
+		},
+		{
+			description: "whitespace tag name",
+			repo:        (&fakeRepo{}).withTag(" ", commit1),
+		},
+		{
+			description: "empty tag name after valid tag",
+			repo:        (&fakeRepo{}).withTag("v1", commit1).withTag("", commit1),
+			expected:    "v1",
+		},
+		{
+			description: "error listing tags",
+			repo:        &fakeRepo{err: fmt.Errorf("BUG")},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tag, err := gitTag(test.repo, commit1, test.requireSigned)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
+}
+
+func TestGitCommitInjectedRepo(t *testing.T) {
+	var opened []string
+	c := &GitCommit{
+		openRepo: func(workingDir string) (gitRepo, error) {
+			opened = append(opened, workingDir)
+			return &fakeRepo{}, nil
+		},
+	}
+
+	_, err := c.GenerateFullyQualifiedImageName("dir", &Options{ImageName: "test"})
+
+	testutil.CheckErrorAndDeepEqual(t, true, err, []string{"dir"}, opened)
+}
+
+// extraTagsRepo is a real repo that lists arbitrary tag refs.
+type extraTagsRepo struct {
+	*git.Repository
+	tags []*plumbing.Reference
+}
+
+func (r *extraTagsRepo) Tags() (storer.ReferenceIter, error) {
+	return storer.NewReferenceSliceIter(r.tags), nil
+}
+
+func TestGitCommitEmptyTagName(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	head, err := repo.repo.Head()
+	failNowIfError(t, err)
+
+	c := &GitCommit{
+		openRepo: func(string) (gitRepo, error) {
+			return &extraTagsRepo{
+				Repository: repo.repo,
+				tags:       []*plumbing.Reference{plumbing.NewHashReference("refs/tags/", head.Hash())},
+			}, nil
+		},
+	}
+
+	name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:"+head.Hash().String()[:7], name)
+}
+
+func TestGitCommitPermissionDenied(t *testing.T) {
+	c := &GitCommit{
+		openRepo: func(workingDir string) (gitRepo, error) {
+			return nil, &os.PathError{Op: "open", Path: workingDir + "/.git/HEAD", Err: os.ErrPermission}
+		},
+	}
+
+	_, err := c.GenerateFullyQualifiedImageName("dir", &Options{ImageName: "test"})
+
+	permissionErr, ok := errors.Cause(err).(*ErrRepoPermission)
+	if !ok {
+		t.Fatalf("Expected a permission error, got %v", err)
+	}
+	testutil.CheckErrorAndDeepEqual(t, true, err, "dir", permissionErr.WorkingDir)
+	if !strings.Contains(err.Error(), "uid") {
+		t.Errorf("Expected actionable guidance, got %s", err)
+	}
+
+	// Other errors are left untouched
+	c.openRepo = func(string) (gitRepo, error) { return nil, fmt.Errorf("BUG") }
+	_, err = c.GenerateFullyQualifiedImageName("dir", &Options{ImageName: "test"})
+	if _, ok := errors.Cause(err).(*ErrRepoPermission); ok {
+		t.Errorf("Unexpected permission error: %v", err)
+	}
+}
+
+func TestGitCommitUnsupportedRefBackend(t *testing.T) {
+	reftable := config.NewConfig()
+	reftable.Raw.Section("extensions").SetOption("refStorage", "reftable")
+
+	c := &GitCommit{
+		openRepo: func(string) (gitRepo, error) {
+			return &fakeRepo{config: reftable}, nil
+		},
+	}
+
+	_, err := c.GenerateFullyQualifiedImageName("dir", &Options{ImageName: "test"})
+
+	backendErr, ok := errors.Cause(err).(*ErrUnsupportedRefBackend)
+	if !ok {
+		t.Fatalf("Expected an unsupported ref backend error, got %v", err)
+	}
+	testutil.CheckErrorAndDeepEqual(t, true, err, "reftable", backendErr.Backend)
+	if !strings.Contains(err.Error(), "envTemplate") {
+		t.Errorf("Expected actionable guidance, got %s", err)
+	}
+
+	// Other failures to read refs are left untouched
+	c.openRepo = func(string) (gitRepo, error) { return &fakeRepo{}, nil }
+	_, err = c.GenerateFullyQualifiedImageName("dir", &Options{ImageName: "test"})
+	if _, ok := errors.Cause(err).(*ErrUnsupportedRefBackend); ok {
+		t.Errorf("Unexpected unsupported ref backend error: %v", err)
+	}
+}
+
+func TestIsTaggable(t *testing.T) {
+	tests := []struct {
+		description   string
+		createGitRepo func(string)
+		expected      bool
+	}{
+		{
+			description: "git repo",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			expected: true,
+		},
+		{
+			description:   "not a git repo",
+			createGitRepo: func(dir string) {},
+		},
+		{
+			description: "empty repo",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			test.createGitRepo(tmpDir)
+
+			taggable, err := IsTaggable(tmpDir)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, taggable)
+		})
+	}
+}
+
+func TestHeadCommit(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "", HeadCommit(tmpDir))
+
+	repo := gitInit(t, tmpDir)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "", HeadCommit(tmpDir))
+
+	repo.mkdir("sub").
+		write("sub/source.go", []byte("code")).
+		add("sub/source.go").
+		commit("initial")
+	head, err := repo.repo.Head()
+	failNowIfError(t, err)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, head.Hash().String(), HeadCommit(filepath.Join(tmpDir, "sub")))
+}
+
+func TestGitCommitGitDirOverride(t *testing.T) {
+	repoDir, cleanupRepo := testutil.TempDir(t)
+	defer cleanupRepo()
+
+	gitInit(t, repoDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	workingDir, cleanupWorkingDir := testutil.TempDir(t)
+	defer cleanupWorkingDir()
+
+	notARepo, cleanupNotARepo := testutil.TempDir(t)
+	defer cleanupNotARepo()
+
+	file, cleanupFile := testutil.TempFile(t, "skaffold", []byte("content"))
+	defer cleanupFile()
+
+	var tests = []struct {
+		description string
+		override    string
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "worktree",
+			override:    repoDir,
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: ".git directory",
+			override:    filepath.Join(repoDir, ".git"),
+			expected:    "test:eefe1b9",
+		},
+		{
+			description: "missing",
+			override:    filepath.Join(repoDir, "missing"),
+			shouldErr:   true,
+		},
+		{
+			description: "not a directory",
+			override:    file,
+			shouldErr:   true,
+		},
+		{
+			description: "not a git repository",
+			override:    notARepo,
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			c := &GitCommit{GitDirOverride: test.override}
+
+			name, err := c.GenerateFullyQualifiedImageName(workingDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, name)
+		})
+	}
+}
+
+func TestGitCommitDisabled(t *testing.T) {
+	var tests = []struct {
+		value    string
+		disabled bool
+	}{
+		{value: "true", disabled: true},
+		{value: "1", disabled: true},
+		{value: "false"},
+		{value: "0"},
+		{value: ""},
+		{value: "yes please"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			defer testutil.SetEnvs(t, map[string]string{"SKAFFOLD_NO_GIT": test.value})(t)
+
+			opened := false
+			c := &GitCommit{
+				CacheDir: "/does/not/exist",
+				openRepo: func(string) (gitRepo, error) {
+					opened = true
+					return nil, fmt.Errorf("no repo")
+				},
+			}
+
+			_, err := c.GenerateFullyQualifiedImageName("/does/not/exist", &Options{ImageName: "test"})
+			_, originErr := c.Origin("/does/not/exist")
+
+			testutil.CheckError(t, true, err)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, !test.disabled, opened)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.disabled, err == ErrGitDisabled)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.disabled, errors.Cause(originErr) == ErrGitDisabled)
+		})
+	}
+}
+
+func TestGitDisabledFallback(t *testing.T) {
+	defer testutil.SetEnvs(t, map[string]string{"SKAFFOLD_NO_GIT": "true", "SKAFFOLD_TAG": "v1"})(t)
+
+	tagger, err := NewPrecedenceTagger([]string{"gitCommit", "$SKAFFOLD_TAG"})
+	failNowIfError(t, err)
+
+	name, err := tagger.GenerateFullyQualifiedImageName("/does/not/exist", &Options{ImageName: "test"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:v1", name)
+}
+
+func TestGitCommitSubmodule(t *testing.T) {
+	superDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	super := gitInit(t, superDir).
+		write(".gitignore", []byte("sub\n")).
+		write("source.go", []byte("super")).
+		add(".gitignore", "source.go").
+		commit("initial")
+	superHead, err := super.repo.Head()
+	failNowIfError(t, err)
+
+	// Absorbed submodule, whose .git is a file pointing into the superproject's .git.
+	subDir := filepath.Join(superDir, "sub")
+	sub := gitInit(t, subDir).
+		write("source.go", []byte("sub")).
+		add("source.go").
+		commit("initial")
+	subHead, err := sub.repo.Head()
+	failNowIfError(t, err)
+
+	modulesDir := filepath.Join(superDir, ".git", "modules")
+	failNowIfError(t, os.MkdirAll(modulesDir, os.ModePerm))
+	failNowIfError(t, os.Rename(filepath.Join(subDir, ".git"), filepath.Join(modulesDir, "sub")))
+	failNowIfError(t, ioutil.WriteFile(filepath.Join(subDir, ".git"), []byte("gitdir: ../.git/modules/sub\n"), os.ModePerm))
+
+	var tests = []struct {
+		description string
+		tagger      *GitCommit
+		expected    string
+	}{
+		{
+			description: "innermost by default",
+			tagger:      &GitCommit{},
+			expected:    "test:" + subHead.Hash().String()[:7],
+		},
+		{
+			description: "submodule",
+			tagger:      &GitCommit{PreferSubmodule: util.BoolPtr(true)},
+			expected:    "test:" + subHead.Hash().String()[:7],
+		},
+		{
+			description: "superproject",
+			tagger:      &GitCommit{PreferSubmodule: util.BoolPtr(false)},
+			expected:    "test:" + superHead.Hash().String()[:7],
+		},
+		{
+			description: "submodule read only",
+			tagger:      &GitCommit{ReadOnly: true},
+			expected:    "test:" + subHead.Hash().String()[:7],
+		},
+		{
+			description: "superproject read only",
+			tagger:      &GitCommit{PreferSubmodule: util.BoolPtr(false), ReadOnly: true},
+			expected:    "test:" + superHead.Hash().String()[:7],
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			name, err := test.tagger.GenerateFullyQualifiedImageName(subDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, name)
+		})
+	}
+}
+
+func TestGitCommitGenerateWithRepo(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	cacheDir, cleanupCache := testutil.TempDir(t)
+	defer cleanupCache()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	var tests = []struct {
+		description string
+		cacheDir    string
+	}{
+		{description: "no cache"},
+		{description: "cache", cacheDir: cacheDir},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			opened := 0
+			c := &GitCommit{
+				CacheDir: test.cacheDir,
+				openRepo: func(workingDir string) (gitRepo, error) {
+					opened++
+					return openGitRepo(workingDir)
+				},
+			}
+
+			name, err := c.GenerateWithRepo(repo.repo, tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", name)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, 0, opened)
+
+			// The usual method opens the repo once
+			name, err = c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", name)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, 1, opened)
+		})
+	}
+}
+
+func TestGitCommitGenerateWithNilRepo(t *testing.T) {
+	c := &GitCommit{}
+	_, err := c.GenerateWithRepo(nil, ".", &Options{ImageName: "test"})
+
+	testutil.CheckError(t, true, err)
+}
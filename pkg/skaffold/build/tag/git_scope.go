@@ -0,0 +1,144 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// cleanScope normalizes a path scope, relative to the root of the repository.
+func cleanScope(scope string) string {
+	if scope == "" {
+		return ""
+	}
+
+	scope = strings.Trim(path.Clean(strings.Replace(scope, "\\", "/", -1)), "/")
+	if scope == "." {
+		return ""
+	}
+	return scope
+}
+
+// inScope tells if a path of the repository is under the given scope.
+// An empty scope contains every path.
+func inScope(path, scope string) bool {
+	return scope == "" || path == scope || strings.HasPrefix(path, scope+"/")
+}
+
+// workingDirScope returns the path of workingDir relative to the root of the repository.
+func workingDirScope(root, workingDir string) (string, error) {
+	root, err := realPath(root)
+	if err != nil {
+		return "", err
+	}
+	workingDir, err = realPath(workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(root, workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	return cleanScope(filepath.ToSlash(rel)), nil
+}
+
+// ErrWorkingDirOutsideRepo is returned when the working directory is not under the worktree
+// of the git repository that was found, eg. with symlinked trees.
+var ErrWorkingDirOutsideRepo = errors.New("working directory is outside of the git worktree")
+
+// checkInsideRepo fails with ErrWorkingDirOutsideRepo if workingDir is not under root.
+func checkInsideRepo(root, workingDir string) error {
+	realRoot, err := realPath(root)
+	if err != nil {
+		return err
+	}
+	realWorkingDir, err := realPath(workingDir)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(realRoot, realWorkingDir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errors.Wrapf(ErrWorkingDirOutsideRepo, "%s is not under %s", workingDir, root)
+	}
+	return nil
+}
+
+func realPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// scopedCommit returns the most recent commit, following the first parents from
+// the given commit, that modified files under the given scope.
+func scopedCommit(repo gitRepo, from plumbing.Hash, scope string) (plumbing.Hash, error) {
+	commit, err := repo.CommitObject(from)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "reading commit")
+	}
+
+	current, err := scopeHash(commit, scope)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	for commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return plumbing.ZeroHash, errors.Wrap(err, "reading parent commit")
+		}
+
+		parentHash, err := scopeHash(parent, scope)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		if parentHash != current {
+			break
+		}
+		commit = parent
+	}
+
+	return commit.Hash, nil
+}
+
+// scopeHash returns the hash of the tree entry for the scope in the given commit.
+// It returns a zero hash when the commit doesn't contain the scope.
+func scopeHash(commit *object.Commit, scope string) (plumbing.Hash, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "reading tree")
+	}
+
+	entry, err := tree.FindEntry(scope)
+	if err != nil {
+		return plumbing.ZeroHash, nil
+	}
+
+	return entry.Hash, nil
+}
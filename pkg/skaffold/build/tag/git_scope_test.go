@@ -0,0 +1,177 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/pkg/errors"
+)
+
+func TestGitCommitPathScope(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	opts := &Options{ImageName: "test"}
+	generate := func(scope string) string {
+		c := &GitCommit{PathScope: scope}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, opts)
+		failNowIfError(t, err)
+		return name
+	}
+
+	repo := gitInit(t, tmpDir).
+		mkdir("a").
+		mkdir("b").
+		write("a/source.go", []byte("a")).
+		write("b/source.go", []byte("b")).
+		add("a/source.go", "b/source.go").
+		commit("initial").
+		write("a/source.go", []byte("a updated")).
+		add("a/source.go").
+		commit("update a")
+	updateA := generate("")
+
+	repo.write("b/source.go", []byte("b updated")).
+		add("b/source.go").
+		commit("update b")
+	updateB := generate("")
+
+	tagA := generate("a")
+	tagB := generate("b/")
+	testutil.CheckErrorAndDeepEqual(t, false, nil, updateA, tagA)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, updateB, tagB)
+
+	// Editing a doesn't change b's tag
+	repo.write("a/source.go", []byte("a edited"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, updateB, generate("b"))
+	if dirtyA := generate("a"); !strings.HasPrefix(dirtyA, tagA+"-dirty-") {
+		t.Errorf("Expected a's tag to be dirty, got %s", dirtyA)
+	}
+}
+
+func TestGitCommitScopeToWorkingDir(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		mkdir("services/api/cmd").
+		mkdir("services/web").
+		write("services/api/cmd/main.go", []byte("api")).
+		write("services/web/main.go", []byte("web")).
+		write("README.md", []byte("readme")).
+		add("services/api/cmd/main.go", "services/web/main.go", "README.md").
+		commit("initial")
+
+	opts := &Options{ImageName: "test"}
+	subDir := filepath.Join(tmpDir, "services", "api")
+	generate := func(c *GitCommit) string {
+		name, err := c.GenerateFullyQualifiedImageName(subDir, opts)
+		failNowIfError(t, err)
+		return name
+	}
+
+	clean := generate(&GitCommit{ScopeToWorkingDir: true})
+
+	// Edits outside of the working directory are ignored
+	repo.write("services/web/main.go", []byte("web updated")).
+		write("README.md", []byte("readme updated")).
+		write("untracked.txt", []byte("untracked"))
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, clean, generate(&GitCommit{ScopeToWorkingDir: true}))
+	if name := generate(&GitCommit{}); !strings.HasPrefix(name, clean+"-dirty-") {
+		t.Errorf("Expected the whole worktree to be dirty without the option, got %s", name)
+	}
+
+	// Edits under the working directory make the tag dirty
+	repo.write("services/api/cmd/main.go", []byte("api updated"))
+	dirty := generate(&GitCommit{ScopeToWorkingDir: true})
+	if !strings.HasPrefix(dirty, clean+"-dirty-") {
+		t.Errorf("Expected a dirty tag, got %s", dirty)
+	}
+
+	// Only the changes under the working directory are hashed
+	testutil.CheckErrorAndDeepEqual(t, false, nil, dirty, generate(&GitCommit{PathScope: "services/api"}))
+}
+
+func TestWorkingDirScope(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).mkdir("a/b")
+
+	scope, err := workingDirScope(tmpDir, filepath.Join(tmpDir, "a", "b"))
+	testutil.CheckErrorAndDeepEqual(t, false, err, "a/b", scope)
+
+	scope, err = workingDirScope(tmpDir, tmpDir)
+	testutil.CheckErrorAndDeepEqual(t, false, err, "", scope)
+}
+
+func TestInScope(t *testing.T) {
+	var tests = []struct {
+		path     string
+		scope    string
+		expected bool
+	}{
+		{path: "a/file", scope: "", expected: true},
+		{path: "a/file", scope: "a", expected: true},
+		{path: "a", scope: "a", expected: true},
+		{path: "ab/file", scope: "a", expected: false},
+		{path: "b/file", scope: "a", expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := inScope(test.path, cleanScope(test.scope)); actual != test.expected {
+			t.Errorf("Expected inScope(%s, %s) to be %t", test.path, test.scope, test.expected)
+		}
+	}
+}
+
+func TestGitCommitWorkingDirOutsideRepo(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repoDir := filepath.Join(tmpDir, "repo")
+	workingDir := filepath.Join(tmpDir, "app")
+	failNowIfError(t, os.MkdirAll(repoDir, os.ModePerm))
+	failNowIfError(t, os.MkdirAll(workingDir, os.ModePerm))
+
+	gitInit(t, repoDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	// The detected repo is a sibling of the working directory
+	c := &GitCommit{
+		openRepo: func(string) (gitRepo, error) {
+			return openGitRepo(repoDir)
+		},
+	}
+	_, err := c.GenerateFullyQualifiedImageName(workingDir, &Options{ImageName: "test"})
+	if errors.Cause(err) != ErrWorkingDirOutsideRepo {
+		t.Errorf("Expected ErrWorkingDirOutsideRepo, got %v", err)
+	}
+
+	// Working directory under the repo
+	tag, err := c.GenerateFullyQualifiedImageName(repoDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:eefe1b9", tag)
+}
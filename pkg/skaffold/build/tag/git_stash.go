@@ -0,0 +1,151 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+const (
+	defaultStashRef = "stash@{0}"
+	stashRefName    = plumbing.ReferenceName("refs/stash")
+)
+
+var stashRefRegexp = regexp.MustCompile(`^stash@\{(\d+)\}$`)
+
+// GitStash tags an image with the hash of a git stash and a `-stash` marker,
+// eg. to build what's in a stash during local iterations.
+type GitStash struct {
+	// StashRef is the stash to tag, eg. `stash@{1}`. Defaults to `stash@{0}`, the latest stash.
+	StashRef string
+
+	// openRepo can be replaced in tests. It defaults to openGitRepo.
+	openRepo repoOpener
+}
+
+// GenerateFullyQualifiedImageName tags an image with the supplied image name and the hash of the stash.
+func (t *GitStash) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("tag options not provided")
+	}
+
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+
+	if gitDisabled() {
+		return "", ErrGitDisabled
+	}
+
+	open := t.openRepo
+	if open == nil {
+		open = openGitRepo
+	}
+
+	repo, err := open(workingDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "opening git repo in %s", workingDir)
+	}
+
+	stashRef := t.StashRef
+	if stashRef == "" {
+		stashRef = defaultStashRef
+	}
+
+	hash, err := resolveStash(repo, stashRef)
+	if err != nil {
+		return "", err
+	}
+
+	return fullyQualifiedName(name, hash.String()[0:7]+"-stash", opts)
+}
+
+// resolveStash returns the hash of the commit of a stash, given as `stash@{<n>}`.
+// The latest stash is read from `refs/stash` and the older ones from its reflog.
+func resolveStash(repo gitRepo, stashRef string) (plumbing.Hash, error) {
+	match := stashRefRegexp.FindStringSubmatch(stashRef)
+	if match == nil {
+		return plumbing.ZeroHash, fmt.Errorf("invalid stash reference %q, should be stash@{<n>}", stashRef)
+	}
+	index, err := strconv.Atoi(match[1])
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrapf(err, "invalid stash reference %q", stashRef)
+	}
+
+	ref, err := repo.Reference(stashRefName, true)
+	if err == plumbing.ErrReferenceNotFound {
+		return plumbing.ZeroHash, fmt.Errorf("stash %s doesn't exist: there are no stashes", stashRef)
+	}
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "reading stash reference")
+	}
+	if index == 0 {
+		return ref.Hash(), nil
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "reading stash reflog")
+	}
+
+	stashes, err := readStashReflog(filepath.Join(w.Filesystem.Root(), git.GitDirName, "logs", stashRefName.String()))
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "reading stash reflog")
+	}
+	if index >= len(stashes) {
+		return plumbing.ZeroHash, fmt.Errorf("stash %s doesn't exist: there are only %d stashes", stashRef, len(stashes))
+	}
+
+	return stashes[index], nil
+}
+
+// readStashReflog returns the hashes of the stashes, from the latest to the oldest.
+// Each line of a reflog is `<old hash> <new hash> <committer> <timestamp>\t<message>`,
+// from the oldest to the latest.
+func readStashReflog(path string) ([]plumbing.Hash, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var stashes []plumbing.Hash
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !isCommitHash(fields[1]) {
+			continue
+		}
+		stashes = append([]plumbing.Hash{plumbing.NewHash(fields[1])}, stashes...)
+	}
+
+	return stashes, scanner.Err()
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestGitStash(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	// No stash yet
+	_, err := (&GitStash{}).GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckError(t, true, err)
+
+	repo.write("source.go", []byte("first wip")).
+		add("source.go").
+		stash("first wip")
+	oldest, err := repo.repo.Reference(plumbing.ReferenceName("refs/stash"), true)
+	failNowIfError(t, err)
+
+	repo.write("source.go", []byte("second wip")).
+		add("source.go").
+		stash("second wip")
+	latest, err := repo.repo.Reference(plumbing.ReferenceName("refs/stash"), true)
+	failNowIfError(t, err)
+
+	tests := []struct {
+		description string
+		stashRef    string
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "default to latest stash",
+			expected:    "test:" + latest.Hash().String()[0:7] + "-stash",
+		},
+		{
+			description: "latest stash",
+			stashRef:    "stash@{0}",
+			expected:    "test:" + latest.Hash().String()[0:7] + "-stash",
+		},
+		{
+			description: "older stash",
+			stashRef:    "stash@{1}",
+			expected:    "test:" + oldest.Hash().String()[0:7] + "-stash",
+		},
+		{
+			description: "missing stash",
+			stashRef:    "stash@{2}",
+			shouldErr:   true,
+		},
+		{
+			description: "invalid stash reference",
+			stashRef:    "HEAD",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tag, err := (&GitStash{StashRef: test.stashRef}).GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// DirtyScope selects the local changes that make a worktree dirty.
+type DirtyScope string
+
+const (
+	// DirtyScopeWorktree considers only unstaged changes.
+	DirtyScopeWorktree DirtyScope = "worktree"
+	// DirtyScopeStaging considers only staged changes.
+	DirtyScopeStaging DirtyScope = "staging"
+	// DirtyScopeUnion considers both staged and unstaged changes.
+	DirtyScopeUnion DirtyScope = "union"
+)
+
+// statusFilter selects the changes of a worktree that are taken into account.
+type statusFilter struct {
+	pathScope  string
+	dirtyScope DirtyScope
+	// foldCase orders paths regardless of their case.
+	foldCase bool
+	// cleanStatuses are status codes that don't make a file modified.
+	cleanStatuses []git.StatusCode
+}
+
+// modified tells if a status code counts as a modification.
+func (f statusFilter) modified(code git.StatusCode) bool {
+	if code == git.Unmodified {
+		return false
+	}
+	for _, clean := range f.cleanStatuses {
+		if code == clean {
+			return false
+		}
+	}
+	return true
+}
+
+// isClean tells if none of the selected files are modified.
+func (f statusFilter) isClean(status git.Status) bool {
+	for path, change := range status {
+		if !inScope(path, f.pathScope) {
+			continue
+		}
+
+		var modified bool
+		switch f.dirtyScope {
+		case DirtyScopeWorktree, DirtyScopeStaging:
+			modified = f.isChanged(change)
+		default:
+			modified = f.modified(change.Worktree) || f.modified(change.Staging)
+		}
+
+		if modified {
+			return false
+		}
+	}
+
+	return true
+}
+
+// changedPaths returns the selected changed paths in a consistent order.
+// The order is important because we generate a sha256 out of it.
+func (f statusFilter) changedPaths(status git.Status) []string {
+	var changes []string
+
+	for path, change := range status {
+		if f.isChanged(change) && inScope(path, f.pathScope) {
+			changes = append(changes, path)
+		}
+	}
+
+	if f.foldCase {
+		sort.Slice(changes, func(i, j int) bool {
+			left, right := strings.ToLower(changes[i]), strings.ToLower(changes[j])
+			if left != right {
+				return left < right
+			}
+			return changes[i] < changes[j]
+		})
+	} else {
+		sort.Strings(changes)
+	}
+	return changes
+}
+
+// isChanged tells if a file's change is one that's hashed.
+func (f statusFilter) isChanged(change *git.FileStatus) bool {
+	switch f.dirtyScope {
+	case DirtyScopeStaging:
+		return f.modified(change.Staging)
+	case DirtyScopeUnion:
+		return f.modified(change.Staging) || f.modified(change.Worktree)
+	default:
+		return f.modified(change.Worktree)
+	}
+}
+
+// isDeleted tells if a file's change, within the scope, is a deletion. A staged
+// `git rm` is only a deletion in the staging area.
+func (f statusFilter) isDeleted(change *git.FileStatus) bool {
+	switch f.dirtyScope {
+	case DirtyScopeStaging:
+		return change.Staging == git.Deleted
+	case DirtyScopeUnion:
+		return change.Staging == git.Deleted || change.Worktree == git.Deleted
+	default:
+		return change.Worktree == git.Deleted
+	}
+}
+
+// statusLine describes a file's change. It's the header of the file's record in
+// the hash, `status\x00len(path)\x00path\x00`, followed by the file's content.
+// The delimiters and the length prefix make records of different paths unambiguous.
+func (f statusFilter) statusLine(path string, change *git.FileStatus) string {
+	var status string
+	switch f.dirtyScope {
+	case DirtyScopeStaging:
+		status = string(change.Staging)
+	case DirtyScopeUnion:
+		status = string(change.Staging) + string(change.Worktree)
+	default:
+		status = string(change.Worktree)
+	}
+
+	return fmt.Sprintf("%s\x00%d\x00%s\x00", status, len(path), path)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+func TestGitCommitDirtyScope(t *testing.T) {
+	generate := func(scope DirtyScope, changes func(*testRepo)) string {
+		tmpDir, cleanup := testutil.TempDir(t)
+		defer cleanup()
+
+		repo := gitInit(t, tmpDir).
+			write("staged.go", []byte("staged")).
+			write("unstaged.go", []byte("unstaged")).
+			add("staged.go", "unstaged.go").
+			commit("initial")
+		changes(repo)
+
+		c := &GitCommit{DirtyScope: scope}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+
+		return name
+	}
+
+	mixed := func(repo *testRepo) {
+		repo.write("staged.go", []byte("staged update")).
+			add("staged.go").
+			write("unstaged.go", []byte("unstaged update"))
+	}
+	stagedOnly := func(repo *testRepo) {
+		repo.write("staged.go", []byte("staged update")).
+			add("staged.go")
+	}
+	unstagedOnly := func(repo *testRepo) {
+		repo.write("unstaged.go", []byte("unstaged update"))
+	}
+
+	worktree := generate(DirtyScopeWorktree, mixed)
+	staging := generate(DirtyScopeStaging, mixed)
+	union := generate(DirtyScopeUnion, mixed)
+
+	for _, name := range []string{worktree, staging, union} {
+		if !strings.Contains(name, "-dirty-") {
+			t.Errorf("Expected a dirty tag, got %s", name)
+		}
+	}
+	if worktree == staging || worktree == union || staging == union {
+		t.Errorf("Expected different tags for each scope, got %s, %s and %s", worktree, staging, union)
+	}
+
+	// Each scope only hashes the changes it considers
+	testutil.CheckErrorAndDeepEqual(t, false, nil, worktree, generate(DirtyScopeWorktree, unstagedOnly))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, staging, generate(DirtyScopeStaging, stagedOnly))
+
+	// Changes outside of the scope leave the tree clean
+	clean := generate(DirtyScopeWorktree, func(*testRepo) {})
+	testutil.CheckErrorAndDeepEqual(t, false, nil, clean, generate(DirtyScopeWorktree, stagedOnly))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, clean, generate(DirtyScopeStaging, unstagedOnly))
+	if name := generate(DirtyScopeUnion, stagedOnly); name == clean {
+		t.Errorf("Expected staged changes to make the tree dirty, got %s", name)
+	}
+}
+
+func TestGitCommitStagedDeletion(t *testing.T) {
+	var tests = []struct {
+		description string
+		scope       DirtyScope
+		opts        *Options
+	}{
+		{
+			description: "staging",
+			scope:       DirtyScopeStaging,
+			opts:        &Options{ImageName: "test"},
+		},
+		{
+			description: "union",
+			scope:       DirtyScopeUnion,
+			opts:        &Options{ImageName: "test"},
+		},
+		{
+			description: "union with progress",
+			scope:       DirtyScopeUnion,
+			opts:        &Options{ImageName: "test", Progress: func(int64, int64) {}},
+		},
+		{
+			description: "staging with metadata only",
+			scope:       DirtyScopeStaging,
+			opts:        &Options{ImageName: "test", MetadataOnlyHash: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			gitInit(t, tmpDir).
+				write("removed.go", []byte("removed")).
+				write("kept.go", []byte("kept")).
+				add("removed.go", "kept.go").
+				commit("initial").
+				remove("removed.go")
+
+			c := &GitCommit{DirtyScope: test.scope}
+			name, err := c.GenerateFullyQualifiedImageName(tmpDir, test.opts)
+
+			testutil.CheckError(t, false, err)
+			if !strings.Contains(name, "-dirty-") {
+				t.Errorf("Expected a dirty tag, got %s", name)
+			}
+		})
+	}
+}
+
+func TestChangedPathsCaseInsensitiveOrder(t *testing.T) {
+	status := git.Status{
+		"b.go":       {Worktree: git.Modified},
+		"Foo/c.go":   {Worktree: git.Modified},
+		"foo/a.go":   {Worktree: git.Modified},
+		"A.go":       {Worktree: git.Untracked},
+		"foo":        {Worktree: git.Modified},
+		"Foo":        {Worktree: git.Modified},
+		"unmodified": {Worktree: git.Unmodified},
+	}
+
+	sensitive := statusFilter{}.changedPaths(status)
+	insensitive := statusFilter{foldCase: true}.changedPaths(status)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"A.go", "Foo", "Foo/c.go", "b.go", "foo", "foo/a.go"}, sensitive)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"A.go", "b.go", "Foo", "foo", "foo/a.go", "Foo/c.go"}, insensitive)
+
+	// The order doesn't depend on how the filesystem reports the case of the paths.
+	folded := git.Status{
+		"foo/a.go": {Worktree: git.Modified},
+		"FOO/c.go": {Worktree: git.Modified},
+		"B.go":     {Worktree: git.Modified},
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"B.go", "foo/a.go", "FOO/c.go"}, statusFilter{foldCase: true}.changedPaths(folded))
+}
+
+func TestGitCommitCleanStatuses(t *testing.T) {
+	generate := func(cleanStatuses []git.StatusCode, changes func(*testRepo)) string {
+		tmpDir, cleanup := testutil.TempDir(t)
+		defer cleanup()
+
+		repo := gitInit(t, tmpDir).
+			write("source.go", []byte("code")).
+			add("source.go").
+			commit("initial")
+		changes(repo)
+
+		c := &GitCommit{CleanStatuses: cleanStatuses}
+		name, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+
+		return name
+	}
+
+	untracked := func(repo *testRepo) {
+		repo.write(".env.local", []byte("SECRET=1")).
+			write("source.go.swp", []byte("swap"))
+	}
+	modified := func(repo *testRepo) {
+		repo.write(".env.local", []byte("SECRET=1")).
+			write("source.go", []byte("updated code"))
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "test:eefe1b9", generate([]git.StatusCode{git.Untracked}, untracked))
+
+	if name := generate(nil, untracked); !strings.Contains(name, "-dirty-") {
+		t.Errorf("Expected untracked files to make the tree dirty by default, got %s", name)
+	}
+
+	// Untracked files are not hashed, only tracked modifications are.
+	onlyModified := generate(nil, func(repo *testRepo) { repo.write("source.go", []byte("updated code")) })
+	testutil.CheckErrorAndDeepEqual(t, false, nil, onlyModified, generate([]git.StatusCode{git.Untracked}, modified))
+}
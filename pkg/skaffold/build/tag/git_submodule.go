@@ -0,0 +1,161 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+)
+
+// ErrSubmoduleCycle is returned when hashing submodules that contain themselves,
+// directly or not, eg. with a misconfigured `.git` file.
+var ErrSubmoduleCycle = errors.New("git submodules form a cycle")
+
+// withSubmodules folds the state of the submodules of the worktree at root into a hash sum.
+func withSubmodules(sum []byte, root string) ([]byte, error) {
+	h := sha256.New()
+	h.Write(sum)
+
+	if err := writeSubmodules(h, root, map[string]bool{}); err != nil {
+		return nil, errors.Wrap(err, "hashing submodules")
+	}
+
+	return h.Sum(nil), nil
+}
+
+// writeSubmodules writes the HEAD and the hash of the local changes of each submodule
+// of the worktree at root to the hash, recursively. visited are the git directories
+// that were already hashed, to detect cycles.
+func writeSubmodules(w io.Writer, root string, visited map[string]bool) error {
+	gitDir, err := resolveGitDir(root)
+	if err != nil {
+		return err
+	}
+	if visited[gitDir] {
+		return errors.Wrapf(ErrSubmoduleCycle, "%s uses %s that was already hashed", root, gitDir)
+	}
+	visited[gitDir] = true
+
+	repo, err := git.PlainOpen(root)
+	if err != nil {
+		return errors.Wrapf(err, "opening git repo %s", root)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return errors.Wrapf(err, "reading worktree of git repo %s", root)
+	}
+	submodules, err := worktree.Submodules()
+	if err != nil {
+		return errors.Wrapf(err, "reading submodules of git repo %s", root)
+	}
+
+	var paths []string
+	for _, submodule := range submodules {
+		paths = append(paths, submodule.Config().Path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		subRoot := filepath.Join(root, filepath.FromSlash(path))
+		if _, err := os.Stat(filepath.Join(subRoot, git.GitDirName)); err != nil {
+			fmt.Fprintf(w, "submodule=%s uninitialized\n", path)
+			continue
+		}
+
+		state, err := submoduleState(subRoot)
+		if err != nil {
+			return errors.Wrapf(err, "reading submodule %s", path)
+		}
+		fmt.Fprintf(w, "submodule=%s %s\n", path, state)
+
+		if err := writeSubmodules(w, subRoot, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// submoduleState describes the HEAD of a submodule and, if it's dirty, its local changes.
+func submoduleState(root string) (string, error) {
+	repo, err := git.PlainOpen(root)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return "", err
+	}
+	if status.IsClean() {
+		return head.Hash().String(), nil
+	}
+
+	sum, err := dirtyHash(w, status, statusFilter{}, &Options{}, nil)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s dirty=%x", head.Hash(), sum), nil
+}
+
+// resolveGitDir returns the real path of the git directory of the worktree at root,
+// following `.git` files that point to another directory, as used by submodules.
+func resolveGitDir(root string) (string, error) {
+	dotGit := filepath.Join(root, git.GitDirName)
+
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return realPath(dotGit)
+	}
+
+	content, err := ioutil.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+
+	line := strings.TrimSpace(strings.SplitN(string(content), "\n", 2)[0])
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("invalid %s: no gitdir", dotGit)
+	}
+
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(root, gitDir)
+	}
+	return realPath(gitDir)
+}
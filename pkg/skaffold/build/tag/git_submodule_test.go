@@ -0,0 +1,111 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/pkg/errors"
+)
+
+const gitmodules = `[submodule "lib"]
+	path = lib
+	url = https://github.com/org/lib.git
+`
+
+func TestGitCommitHashSubmodules(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		write(".gitmodules", []byte(gitmodules)).
+		add("source.go", ".gitmodules").
+		commit("initial").
+		write("source.go", []byte("updated code"))
+
+	lib := gitInit(t, filepath.Join(tmpDir, "lib")).
+		write("lib.go", []byte("lib")).
+		add("lib.go").
+		commit("lib")
+
+	generate := func(hashSubmodules bool) string {
+		// The scope leaves the files of the submodule out of the hash of the superproject
+		c := &GitCommit{HashSubmodules: hashSubmodules, PathScope: "source.go"}
+		tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+		failNowIfError(t, err)
+		return tag
+	}
+
+	withoutSubmodules := generate(false)
+	withSubmodules := generate(true)
+	if withSubmodules == withoutSubmodules {
+		t.Errorf("Expected the submodules to be folded into the tag, got %s twice", withSubmodules)
+	}
+
+	lib.write("lib.go", []byte("updated lib"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, withoutSubmodules, generate(false))
+	if generate(true) == withSubmodules {
+		t.Errorf("Expected changes in a submodule to change the tag, got %s twice", withSubmodules)
+	}
+}
+
+func TestGitCommitHashSubmodulesCycle(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		write(".gitmodules", []byte(gitmodules)).
+		add("source.go", ".gitmodules").
+		commit("initial").
+		write("source.go", []byte("updated code")).
+		mkdir("lib").
+		// The submodule points back to the git dir of its superproject
+		write("lib/.git", []byte("gitdir: ../.git\n"))
+
+	c := &GitCommit{HashSubmodules: true, PathScope: "source.go"}
+	_, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+	if errors.Cause(err) != ErrSubmoduleCycle {
+		t.Errorf("Expected ErrSubmoduleCycle, got %v", err)
+	}
+}
+
+func TestResolveGitDir(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	gitInit(t, tmpDir).
+		mkdir("sub").
+		write("sub/.git", []byte("gitdir: ../.git/modules/sub\n")).
+		mkdir(".git/modules/sub")
+
+	expected, err := realPath(filepath.Join(tmpDir, ".git"))
+	failNowIfError(t, err)
+	gitDir, err := resolveGitDir(tmpDir)
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, gitDir)
+
+	gitDir, err = resolveGitDir(filepath.Join(tmpDir, "sub"))
+	testutil.CheckErrorAndDeepEqual(t, false, err, filepath.Join(expected, "modules", "sub"), gitDir)
+
+	_, err = resolveGitDir(filepath.Join(tmpDir, "missing"))
+	testutil.CheckErrorAndDeepEqual(t, true, err, true, os.IsNotExist(err))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"strings"
+)
+
+// versionSortSuffixes returns the `versionsort.suffix` values of the git config, in order.
+// Failures to read the config are ignored.
+func versionSortSuffixes(repo gitRepo) []string {
+	cfg, err := repo.Config()
+	if err != nil {
+		return nil
+	}
+	return cfg.Raw.Section("versionsort").Options.GetAll("suffix")
+}
+
+// highestVersion returns the highest of the tag names, like the last tag listed by
+// `git tag --sort=version:refname`. Versions with one of the suffixes, eg. `-rc`,
+// are prereleases that come before the version without a suffix, and are ordered
+// as the suffixes are.
+func highestVersion(names []string, suffixes []string) string {
+	var highest string
+	for i, name := range names {
+		if i == 0 || compareVersions(name, highest, suffixes) > 0 {
+			highest = name
+		}
+	}
+	return highest
+}
+
+// compareVersions compares two tag names, honoring the prerelease suffixes.
+func compareVersions(a, b string, suffixes []string) int {
+	baseA, suffixA, restA := splitVersionSuffix(a, suffixes)
+	baseB, suffixB, restB := splitVersionSuffix(b, suffixes)
+
+	if cmp := compareVersionStrings(baseA, baseB); cmp != 0 || (suffixA == -1 && suffixB == -1) {
+		return cmp
+	}
+
+	switch {
+	case suffixA == suffixB:
+		return compareVersionStrings(restA, restB)
+	case suffixA == -1:
+		return 1
+	case suffixB == -1:
+		return -1
+	case suffixA < suffixB:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// splitVersionSuffix splits a tag name at the first prerelease suffix, eg. `v1.0-rc2`
+// with the `-rc` suffix gives `v1.0`, the index of the suffix and `2`.
+// The index is -1 if there's no suffix.
+func splitVersionSuffix(name string, suffixes []string) (string, int, string) {
+	position, index := -1, -1
+	for i, suffix := range suffixes {
+		if suffix == "" {
+			continue
+		}
+		if p := strings.Index(name, suffix); p > 0 && (position == -1 || p < position) {
+			position, index = p, i
+		}
+	}
+
+	if index == -1 {
+		return name, -1, ""
+	}
+	return name[:position], index, name[position+len(suffixes[index]):]
+}
+
+// compareVersionStrings compares two strings, with the sequences of digits
+// compared as numbers, eg. `v1.10` is greater than `v1.9`.
+func compareVersionStrings(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			numberA, numberB := leadingDigits(a), leadingDigits(b)
+			a, b = a[len(numberA):], b[len(numberB):]
+
+			numberA, numberB = strings.TrimLeft(numberA, "0"), strings.TrimLeft(numberB, "0")
+			if len(numberA) != len(numberB) {
+				return compareInts(len(numberA), len(numberB))
+			}
+			if cmp := strings.Compare(numberA, numberB); cmp != 0 {
+				return cmp
+			}
+			continue
+		}
+
+		if a[0] != b[0] {
+			return compareInts(int(a[0]), int(b[0]))
+		}
+		a, b = a[1:], b[1:]
+	}
+
+	return compareInts(len(a), len(b))
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestHighestVersion(t *testing.T) {
+	tests := []struct {
+		description string
+		names       []string
+		suffixes    []string
+		expected    string
+	}{
+		{
+			description: "single",
+			names:       []string{"v1.0.0"},
+			expected:    "v1.0.0",
+		},
+		{
+			description: "numeric order",
+			names:       []string{"v1.10.0", "v1.9.0", "v1.2.0"},
+			expected:    "v1.10.0",
+		},
+		{
+			description: "prerelease without suffix config",
+			names:       []string{"v1.0.0", "v1.0.0-rc1"},
+			expected:    "v1.0.0-rc1",
+		},
+		{
+			description: "release beats prerelease",
+			names:       []string{"v1.0.0-rc1", "v1.0.0", "v1.0.0-beta"},
+			suffixes:    []string{"-beta", "-rc"},
+			expected:    "v1.0.0",
+		},
+		{
+			description: "suffixes are ordered as configured",
+			names:       []string{"v1.0.0-rc1", "v1.0.0-beta2"},
+			suffixes:    []string{"-beta", "-rc"},
+			expected:    "v1.0.0-rc1",
+		},
+		{
+			description: "suffixes in reverse order",
+			names:       []string{"v1.0.0-rc1", "v1.0.0-beta2"},
+			suffixes:    []string{"-rc", "-beta"},
+			expected:    "v1.0.0-beta2",
+		},
+		{
+			description: "same suffix",
+			names:       []string{"v1.0.0-rc2", "v1.0.0-rc10"},
+			suffixes:    []string{"-rc"},
+			expected:    "v1.0.0-rc10",
+		},
+		{
+			description: "higher prerelease beats lower release",
+			names:       []string{"v1.0.0", "v1.1.0-rc1"},
+			suffixes:    []string{"-rc"},
+			expected:    "v1.1.0-rc1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, highestVersion(test.names, test.suffixes))
+		})
+	}
+}
+
+func TestGitCommitVersionSortSuffix(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial").
+		tag("v1.0.0-beta").
+		tag("v1.0.0-rc1").
+		tag("v1.0.0")
+
+	c := &GitCommit{}
+	tag, err := c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:v1.0.0-rc1", tag)
+
+	repo.config("versionsort", "suffix", "-beta", "-rc")
+	tag, err = c.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+	testutil.CheckErrorAndDeepEqual(t, false, err, "test:v1.0.0", tag)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// precedenceTagger tags an image with the first of its sources that yields a valid tag.
+// precedenceTagger implements Tagger
+type precedenceTagger struct {
+	sources []string
+	taggers []Tagger
+}
+
+// NewPrecedenceTagger creates a tagger from an ordered list of sources.
+// A source is either the name of a strategy, `gitCommit`, `sha256` or `dateTime`,
+// or the name of an environment variable prefixed with `$`, eg. `$SKAFFOLD_TAG`.
+func NewPrecedenceTagger(sources []string) (Tagger, error) {
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no tag source provided")
+	}
+
+	var taggers []Tagger
+	for _, source := range sources {
+		tagger, err := precedenceSource(source)
+		if err != nil {
+			return nil, err
+		}
+		taggers = append(taggers, tagger)
+	}
+
+	return &precedenceTagger{
+		sources: sources,
+		taggers: taggers,
+	}, nil
+}
+
+func precedenceSource(source string) (Tagger, error) {
+	if strings.HasPrefix(source, "$") {
+		if len(source) == 1 {
+			return nil, fmt.Errorf("empty environment variable name in tag source %q", source)
+		}
+		return &envVarTagger{Name: source[1:]}, nil
+	}
+
+	tagger, err := newTagger(source)
+	if err != nil {
+		return nil, fmt.Errorf("unknown tag source %q", source)
+	}
+	return tagger, nil
+}
+
+// GenerateFullyQualifiedImageName tags an image with the first source that yields a valid tag.
+func (c *precedenceTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("tag options not provided")
+	}
+
+	for i, tagger := range c.taggers {
+		fqn, err := tagger.GenerateFullyQualifiedImageName(workingDir, opts)
+		if err != nil {
+			logrus.Debugf("Skipping tag source %s: %s", c.sources[i], err)
+			continue
+		}
+		if err := ValidateRef(fqn); err != nil {
+			logrus.Debugf("Skipping tag source %s: %s", c.sources[i], err)
+			continue
+		}
+
+		return fqn, nil
+	}
+
+	return "", fmt.Errorf("none of the tag sources %v yielded a valid tag", c.sources)
+}
+
+// envVarTagger tags an image with the value of an environment variable.
+type envVarTagger struct {
+	Name string
+}
+
+func (c *envVarTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	value := os.Getenv(c.Name)
+	if value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", c.Name)
+	}
+	if err := checkReserved(value, opts); err != nil {
+		return "", errors.Wrapf(err, "reading tag from %s", c.Name)
+	}
+
+	name, err := imageName(opts)
+	if err != nil {
+		return "", errors.Wrap(err, "reading image name")
+	}
+
+	return fullyQualifiedName(name, value, opts)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestPrecedenceTagger(t *testing.T) {
+	sources := []string{"$SKAFFOLD_TAG", "gitCommit", "dateTime"}
+
+	var tests = []struct {
+		description   string
+		env           map[string]string
+		createGitRepo func(string)
+		expectedName  string
+	}{
+		{
+			description: "env var wins",
+			env:         map[string]string{"SKAFFOLD_TAG": "v1.0"},
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			expectedName: "test:v1.0",
+		},
+		{
+			description: "falls through to git when unset",
+			env:         map[string]string{"SKAFFOLD_TAG": ""},
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			expectedName: "test:eefe1b9",
+		},
+		{
+			description: "falls through to git when invalid",
+			env:         map[string]string{"SKAFFOLD_TAG": "not a tag"},
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial")
+			},
+			expectedName: "test:eefe1b9",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+			defer testutil.SetEnvs(t, test.env)(t)
+
+			test.createGitRepo(tmpDir)
+
+			tagger, err := NewPrecedenceTagger(sources)
+			failNowIfError(t, err)
+
+			name, err := tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedName, name)
+		})
+	}
+}
+
+func TestPrecedenceTaggerFallsThroughToLastSource(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	defer testutil.SetEnvs(t, map[string]string{"SKAFFOLD_TAG": ""})(t)
+
+	tagger, err := NewPrecedenceTagger([]string{"$SKAFFOLD_TAG", "gitCommit", "dateTime"})
+	failNowIfError(t, err)
+
+	name, err := tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+	testutil.CheckError(t, false, err)
+	testutil.CheckError(t, false, ValidateRef(name))
+}
+
+func TestPrecedenceTaggerNoValidSource(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	defer testutil.SetEnvs(t, map[string]string{"SKAFFOLD_TAG": ""})(t)
+
+	tagger, err := NewPrecedenceTagger([]string{"$SKAFFOLD_TAG", "gitCommit"})
+	failNowIfError(t, err)
+
+	_, err = tagger.GenerateFullyQualifiedImageName(tmpDir, &Options{ImageName: "test"})
+
+	testutil.CheckError(t, true, err)
+}
+
+func TestNewPrecedenceTagger(t *testing.T) {
+	var tests = []struct {
+		description string
+		sources     []string
+		shouldErr   bool
+	}{
+		{description: "strategies and env vars", sources: []string{"$TAG", "gitCommit", "sha256", "dateTime"}},
+		{description: "no source", shouldErr: true},
+		{description: "unknown strategy", sources: []string{"unknown"}, shouldErr: true},
+		{description: "empty env var name", sources: []string{"$"}, shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := NewPrecedenceTagger(test.sources)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileTagger tags an image with the tagger of the active profile, eg. a tagger
+// that's fine with dirty worktrees in `dev` and a strict one in `prod`.
+type ProfileTagger struct {
+	// Taggers are keyed by profile name.
+	Taggers map[string]Tagger
+
+	// ActiveProfile selects the tagger to use.
+	ActiveProfile string
+}
+
+// GenerateFullyQualifiedImageName tags an image with the tagger of the active profile.
+func (p *ProfileTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	tagger, found := p.Taggers[p.ActiveProfile]
+	if !found || tagger == nil {
+		var profiles []string
+		for profile := range p.Taggers {
+			profiles = append(profiles, profile)
+		}
+		sort.Strings(profiles)
+
+		return "", fmt.Errorf("no tagger for profile %q, expected one of [%s]", p.ActiveProfile, strings.Join(profiles, ", "))
+	}
+
+	return tagger.GenerateFullyQualifiedImageName(workingDir, opts)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestProfileTagger(t *testing.T) {
+	taggers := map[string]Tagger{
+		"dev":  &CustomTag{Tag: "dev"},
+		"prod": &CustomTag{Tag: "v1.0.0"},
+	}
+
+	var tests = []struct {
+		description string
+		profile     string
+		expected    string
+		shouldErr   bool
+	}{
+		{description: "dev", profile: "dev", expected: "test:dev"},
+		{description: "prod", profile: "prod", expected: "test:v1.0.0"},
+		{description: "unknown profile", profile: "staging", shouldErr: true},
+		{description: "no active profile", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tagger := &ProfileTagger{
+				Taggers:       taggers,
+				ActiveProfile: test.profile,
+			}
+
+			name, err := tagger.GenerateFullyQualifiedImageName(".", &Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, name)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+)
+
+var (
+	// pathComponentRegexp matches one component of the repository path,
+	// following the same rules as `github.com/docker/distribution/reference`.
+	pathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+	validDigestRegexp   = regexp.MustCompile(`^` + reference.DigestRegexp.String() + `$`)
+)
+
+// imageRef holds the components of an image reference.
+type imageRef struct {
+	registry  string
+	path      string
+	tag       string
+	digest    string
+	hasTag    bool
+	hasDigest bool
+}
+
+// splitRef splits an image reference into its registry, repository path,
+// tag and digest. The registry host is never modified.
+func splitRef(ref string) imageRef {
+	var parsed imageRef
+
+	if i := strings.Index(ref, "@"); i != -1 {
+		ref, parsed.digest, parsed.hasDigest = ref[:i], ref[i+1:], true
+	}
+	if i := strings.LastIndex(ref, ":"); i != -1 && !strings.Contains(ref[i+1:], "/") {
+		ref, parsed.tag, parsed.hasTag = ref[:i], ref[i+1:], true
+	}
+	if i := strings.Index(ref, "/"); i != -1 && isRegistry(ref[:i]) {
+		parsed.registry, ref = ref[:i], ref[i+1:]
+	}
+	parsed.path = ref
+
+	return parsed
+}
+
+// isRegistry tells if the first component of a reference is a registry host.
+// Uppercase letters are not allowed in repository paths so a component
+// containing some is also considered to be a host.
+func isRegistry(component string) bool {
+	return isRegistryHost(component) || strings.ToLower(component) != component
+}
+
+// isRegistryHost tells if a component can only be a registry host, that is if
+// it contains a `.` or a `:`, or if it's `localhost`.
+func isRegistryHost(component string) bool {
+	return component == "localhost" || strings.ContainsAny(component, ".:")
+}
+
+// ValidateRef checks that ref is a valid image reference. The registry host
+// is left as is and only the repository path, the tag and the digest are
+// validated, so that private registries with unusual host names are accepted.
+func ValidateRef(ref string) error {
+	parsed := splitRef(ref)
+
+	if parsed.path == "" {
+		return fmt.Errorf("invalid reference %q: empty repository path", ref)
+	}
+	for _, component := range strings.Split(parsed.path, "/") {
+		if !pathComponentRegexp.MatchString(component) {
+			return fmt.Errorf("invalid reference %q: invalid repository path component %q", ref, component)
+		}
+	}
+
+	if parsed.hasTag {
+		if err := validateTag(parsed.tag); err != nil {
+			return fmt.Errorf("invalid reference %q: %s", ref, err)
+		}
+	}
+
+	if parsed.hasDigest && !validDigestRegexp.MatchString(parsed.digest) {
+		return fmt.Errorf("invalid reference %q: invalid digest %q", ref, parsed.digest)
+	}
+
+	return nil
+}
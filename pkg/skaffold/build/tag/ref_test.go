@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestValidateRef(t *testing.T) {
+	var tests = []struct {
+		description string
+		ref         string
+		shouldErr   bool
+	}{
+		{description: "name", ref: "app"},
+		{description: "name and tag", ref: "gcr.io/project/app:v1.0"},
+		{description: "digest", ref: "app@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+		{description: "localhost", ref: "localhost/app:latest"},
+		{description: "uppercase host", ref: "Registry.Example.COM/team/app:v1"},
+		{description: "uppercase host without dot", ref: "MYREGISTRY/app:v1"},
+		{description: "host with port", ref: "registry.local:32000/app:v1"},
+		{description: "uppercase host with port", ref: "REGISTRY:5000/app"},
+		{description: "uppercase path", ref: "gcr.io/Project/app", shouldErr: true},
+		{description: "invalid tag", ref: "Registry.Example.COM/app:-bad", shouldErr: true},
+		{description: "tag with slash in host", ref: "registry:5000/app:v1/2", shouldErr: true},
+		{description: "empty tag", ref: "Registry.Example.COM/app:", shouldErr: true},
+		{description: "too long tag", ref: "app:" + string(make([]byte, 129)), shouldErr: true},
+		{description: "invalid digest", ref: "app@sha256:abc", shouldErr: true},
+		{description: "empty path", ref: "gcr.io/", shouldErr: true},
+		{description: "empty", ref: "", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := ValidateRef(test.ref)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
+
+func TestSplitRef(t *testing.T) {
+	parsed := splitRef("Registry.Example.COM:5000/team/app:v1@sha256:abc")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "Registry.Example.COM:5000", parsed.registry)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "team/app", parsed.path)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "v1", parsed.tag)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "sha256:abc", parsed.digest)
+}
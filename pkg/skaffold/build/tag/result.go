@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SourceGitCommit is the source of tags computed by the GitCommit tagger.
+const SourceGitCommit = "gitCommit"
+
+// TagResult describes how an image was tagged.
+type TagResult struct {
+	// FQN is the fully qualified image name, including the tag.
+	FQN string
+	// Source is the tagging strategy that produced the tag.
+	Source string
+	// Commit is the git commit the tag was derived from, if any.
+	Commit string
+	// Dirty tells if the tag covers local changes.
+	Dirty bool
+	// DirtyFiles are the sorted, repo-relative, paths of the changed files of a dirty tag.
+	DirtyFiles []string
+	// GeneratedAt is when the tag was computed.
+	GeneratedAt time.Time
+}
+
+// String returns a single line summary of the result.
+func (r TagResult) String() string {
+	parts := []string{r.FQN, "source=" + r.Source}
+	if r.Commit != "" {
+		parts = append(parts, "commit="+shortCommitHash(r.Commit))
+	}
+	parts = append(parts, fmt.Sprintf("dirty=%t", r.Dirty))
+
+	return strings.Join(parts, " ")
+}
+
+// Equal tells if two results describe the same tag. GeneratedAt, that differs
+// from one run to the other, is ignored and should be compared explicitly if needed.
+func (r TagResult) Equal(other TagResult) bool {
+	return r.FQN == other.FQN &&
+		r.Source == other.Source &&
+		r.Commit == other.Commit &&
+		r.Dirty == other.Dirty &&
+		equalStrings(r.DirtyFiles, other.DirtyFiles)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DiffTagResults describes, in a human readable way, why two results differ.
+// It returns no reason if they describe the same tag.
+func DiffTagResults(a, b TagResult) []string {
+	var reasons []string
+
+	if a.Source != b.Source {
+		reasons = append(reasons, fmt.Sprintf("source changed %s→%s", a.Source, b.Source))
+	}
+	if a.Commit != b.Commit {
+		reasons = append(reasons, fmt.Sprintf("commit changed %s→%s", shortCommitHash(a.Commit), shortCommitHash(b.Commit)))
+	}
+
+	switch {
+	case !a.Dirty && b.Dirty:
+		reasons = append(reasons, "became dirty")
+	case a.Dirty && !b.Dirty:
+		reasons = append(reasons, "became clean")
+	}
+
+	added, removed := diffStrings(a.DirtyFiles, b.DirtyFiles)
+	if len(added) > 0 {
+		reasons = append(reasons, "dirty files added: "+strings.Join(added, ","))
+	}
+	if len(removed) > 0 {
+		reasons = append(reasons, "dirty files removed: "+strings.Join(removed, ","))
+	}
+
+	if a.FQN != b.FQN && len(reasons) == 0 {
+		if a.Dirty && b.Dirty {
+			reasons = append(reasons, "content of dirty files changed")
+		} else {
+			reasons = append(reasons, fmt.Sprintf("tag changed %s→%s", a.FQN, b.FQN))
+		}
+	}
+
+	return reasons
+}
+
+// shortCommitHash abbreviates a commit hash for display.
+func shortCommitHash(commit string) string {
+	if commit == "" {
+		return "none"
+	}
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// diffStrings returns the sorted strings that are only in b, and those only in a.
+func diffStrings(a, b []string) (added, removed []string) {
+	inA := map[string]bool{}
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := map[string]bool{}
+	for _, s := range b {
+		inB[s] = true
+		if !inA[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range a {
+		if !inB[s] {
+			removed = append(removed, s)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// TagManifest lists the tags of a set of artifacts, eg. for signing tools.
+type TagManifest struct {
+	Tags []TagManifestEntry `json:"tags"`
+}
+
+// TagManifestEntry is the tag of one artifact.
+type TagManifestEntry struct {
+	Artifact string `json:"artifact"`
+	FQN      string `json:"fqn"`
+	Source   string `json:"source"`
+	Commit   string `json:"commit,omitempty"`
+	Dirty    bool   `json:"dirty"`
+}
+
+// BuildManifest creates a manifest out of the tag results of artifacts,
+// keyed by artifact name. Entries are sorted by artifact name.
+func BuildManifest(results map[string]TagResult) TagManifest {
+	var artifacts []string
+	for artifact := range results {
+		artifacts = append(artifacts, artifact)
+	}
+	sort.Strings(artifacts)
+
+	manifest := TagManifest{
+		Tags: []TagManifestEntry{},
+	}
+	for _, artifact := range artifacts {
+		result := results[artifact]
+		manifest.Tags = append(manifest.Tags, TagManifestEntry{
+			Artifact: artifact,
+			FQN:      result.FQN,
+			Source:   result.Source,
+			Commit:   result.Commit,
+			Dirty:    result.Dirty,
+		})
+	}
+
+	return manifest
+}
+
+// JSON serializes the manifest into an indented JSON document.
+func (m TagManifest) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
@@ -0,0 +1,325 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestBuildManifest(t *testing.T) {
+	results := map[string]TagResult{
+		"gcr.io/project/web": {
+			FQN:    "gcr.io/project/web:eefe1b9-dirty-af8de1fde8be4367",
+			Source: SourceGitCommit,
+			Commit: "eefe1b9c44eb0aa87199c9a079f2d48d8eb8baed",
+			Dirty:  true,
+		},
+		"gcr.io/project/api": {
+			FQN:    "gcr.io/project/api:v1",
+			Source: SourceGitCommit,
+			Commit: "eefe1b9c44eb0aa87199c9a079f2d48d8eb8baed",
+		},
+		"gcr.io/project/db": {
+			FQN:    "gcr.io/project/db:2018-06-01",
+			Source: "dateTime",
+		},
+	}
+
+	expected := `{
+  "tags": [
+    {
+      "artifact": "gcr.io/project/api",
+      "fqn": "gcr.io/project/api:v1",
+      "source": "gitCommit",
+      "commit": "eefe1b9c44eb0aa87199c9a079f2d48d8eb8baed",
+      "dirty": false
+    },
+    {
+      "artifact": "gcr.io/project/db",
+      "fqn": "gcr.io/project/db:2018-06-01",
+      "source": "dateTime",
+      "dirty": false
+    },
+    {
+      "artifact": "gcr.io/project/web",
+      "fqn": "gcr.io/project/web:eefe1b9-dirty-af8de1fde8be4367",
+      "source": "gitCommit",
+      "commit": "eefe1b9c44eb0aa87199c9a079f2d48d8eb8baed",
+      "dirty": true
+    }
+  ]
+}`
+
+	// The output doesn't depend on the map iteration order.
+	for i := 0; i < 10; i++ {
+		out, err := BuildManifest(results).JSON()
+
+		testutil.CheckErrorAndDeepEqual(t, false, err, expected, string(out))
+	}
+}
+
+func TestBuildManifestEmpty(t *testing.T) {
+	out, err := BuildManifest(nil).JSON()
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "{\n  \"tags\": []\n}", string(out))
+}
+
+func TestGitCommit_GenerateTagResult(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		write("source.go", []byte("code")).
+		add("source.go").
+		commit("initial")
+
+	c := &GitCommit{}
+	clean, err := c.GenerateTagResult(tmpDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+
+	repo.write("source.go", []byte("updated code"))
+	dirty, err := c.GenerateTagResult(tmpDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+
+	expectedClean := TagResult{
+		FQN:    "test:eefe1b9",
+		Source: SourceGitCommit,
+		Commit: clean.Commit,
+	}
+	expectedDirty := TagResult{
+		FQN:        dirty.FQN,
+		Source:     SourceGitCommit,
+		Commit:     clean.Commit,
+		Dirty:      true,
+		DirtyFiles: []string{"source.go"},
+	}
+	if !clean.Equal(expectedClean) {
+		t.Errorf("Expected %s, got %s", expectedClean, clean)
+	}
+	if !dirty.Equal(expectedDirty) {
+		t.Errorf("Expected %s, got %s", expectedDirty, dirty)
+	}
+	if clean.GeneratedAt.IsZero() || dirty.GeneratedAt.IsZero() {
+		t.Error("Expected the generation time to be set")
+	}
+	if len(clean.Commit) != 40 {
+		t.Errorf("Expected a full commit hash, got %s", clean.Commit)
+	}
+}
+
+func TestTagResultString(t *testing.T) {
+	var tests = []struct {
+		description string
+		result      TagResult
+		expected    string
+	}{
+		{
+			description: "clean",
+			result:      TagResult{FQN: "test:v1", Source: SourceGitCommit, Commit: "eefe1b9c44eb0aa87199c9a079f2d48d8eb8baed"},
+			expected:    "test:v1 source=gitCommit commit=eefe1b9 dirty=false",
+		},
+		{
+			description: "dirty",
+			result:      TagResult{FQN: "test:eefe1b9-dirty-abcd", Source: SourceGitCommit, Commit: "eefe1b9c44eb0aa87199c9a079f2d48d8eb8baed", Dirty: true},
+			expected:    "test:eefe1b9-dirty-abcd source=gitCommit commit=eefe1b9 dirty=true",
+		},
+		{
+			description: "no commit",
+			result:      TagResult{FQN: "test:2018-06-01", Source: "dateTime", GeneratedAt: time.Now()},
+			expected:    "test:2018-06-01 source=dateTime dirty=false",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, test.result.String())
+		})
+	}
+}
+
+func TestTagResultEqual(t *testing.T) {
+	result := TagResult{FQN: "test:v1", Source: SourceGitCommit, Commit: "eefe1b9", GeneratedAt: time.Unix(0, 0)}
+
+	var tests = []struct {
+		description string
+		other       TagResult
+		expected    bool
+	}{
+		{
+			description: "same",
+			other:       result,
+			expected:    true,
+		},
+		{
+			description: "different generation time",
+			other:       TagResult{FQN: "test:v1", Source: SourceGitCommit, Commit: "eefe1b9", GeneratedAt: time.Now()},
+			expected:    true,
+		},
+		{
+			description: "different fqn",
+			other:       TagResult{FQN: "test:v2", Source: SourceGitCommit, Commit: "eefe1b9"},
+		},
+		{
+			description: "different source",
+			other:       TagResult{FQN: "test:v1", Source: "dateTime", Commit: "eefe1b9"},
+		},
+		{
+			description: "different commit",
+			other:       TagResult{FQN: "test:v1", Source: SourceGitCommit, Commit: "a7b32a6"},
+		},
+		{
+			description: "dirty",
+			other:       TagResult{FQN: "test:v1", Source: SourceGitCommit, Commit: "eefe1b9", Dirty: true},
+		},
+		{
+			description: "dirty files",
+			other:       TagResult{FQN: "test:v1", Source: SourceGitCommit, Commit: "eefe1b9", DirtyFiles: []string{"source.go"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, result.Equal(test.other))
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, test.other.Equal(result))
+		})
+	}
+}
+
+func TestGitCommitDirtyFiles(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo := gitInit(t, tmpDir).
+		mkdir("pkg").
+		write("source.go", []byte("code")).
+		write("deleted.go", []byte("deleted")).
+		write("pkg/lib.go", []byte("lib")).
+		add("source.go", "deleted.go", "pkg/lib.go").
+		commit("initial")
+
+	c := &GitCommit{}
+	clean, err := c.GenerateTagResult(tmpDir, &Options{ImageName: "test"})
+	failNowIfError(t, err)
+	if clean.DirtyFiles != nil {
+		t.Errorf("Expected no dirty files for a clean tree, got %v", clean.DirtyFiles)
+	}
+
+	repo.write("source.go", []byte("updated code")).
+		write("pkg/lib.go", []byte("updated lib")).
+		write("added.go", []byte("added")).
+		delete("deleted.go")
+
+	dirty, err := c.GenerateTagResult(tmpDir, &Options{ImageName: "test"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"added.go", "deleted.go", "pkg/lib.go", "source.go"}, dirty.DirtyFiles)
+}
+
+func TestDiffTagResults(t *testing.T) {
+	clean := TagResult{
+		FQN:    "test:aea33bc",
+		Source: SourceGitCommit,
+		Commit: "aea33bcc86b5af8c8570ff45d8a643202d63c808",
+	}
+	dirty := TagResult{
+		FQN:        "test:aea33bc-dirty-0123456789abcdef",
+		Source:     SourceGitCommit,
+		Commit:     "aea33bcc86b5af8c8570ff45d8a643202d63c808",
+		Dirty:      true,
+		DirtyFiles: []string{"a.go", "b.go"},
+	}
+
+	var tests = []struct {
+		description string
+		a, b        TagResult
+		expected    []string
+	}{
+		{
+			description: "same",
+			a:           clean,
+			b:           clean,
+		},
+		{
+			description: "commit change",
+			a:           clean,
+			b: TagResult{
+				FQN:    "test:def5678",
+				Source: SourceGitCommit,
+				Commit: "def5678a86b5af8c8570ff45d8a643202d63c808",
+			},
+			expected: []string{"commit changed aea33bc→def5678"},
+		},
+		{
+			description: "clean to dirty",
+			a:           clean,
+			b:           dirty,
+			expected:    []string{"became dirty", "dirty files added: a.go,b.go"},
+		},
+		{
+			description: "dirty to clean",
+			a:           dirty,
+			b:           clean,
+			expected:    []string{"became clean", "dirty files removed: a.go,b.go"},
+		},
+		{
+			description: "changed dirty files",
+			a:           dirty,
+			b: TagResult{
+				FQN:        "test:aea33bc-dirty-fedcba9876543210",
+				Source:     SourceGitCommit,
+				Commit:     "aea33bcc86b5af8c8570ff45d8a643202d63c808",
+				Dirty:      true,
+				DirtyFiles: []string{"b.go", "d.go", "c.go"},
+			},
+			expected: []string{"dirty files added: c.go,d.go", "dirty files removed: a.go"},
+		},
+		{
+			description: "changed content of dirty files",
+			a:           dirty,
+			b: TagResult{
+				FQN:        "test:aea33bc-dirty-fedcba9876543210",
+				Source:     SourceGitCommit,
+				Commit:     "aea33bcc86b5af8c8570ff45d8a643202d63c808",
+				Dirty:      true,
+				DirtyFiles: []string{"a.go", "b.go"},
+			},
+			expected: []string{"content of dirty files changed"},
+		},
+		{
+			description: "other source",
+			a:           clean,
+			b:           TagResult{FQN: "test:v1", Source: "envTemplate"},
+			expected:    []string{"source changed gitCommit→envTemplate", "commit changed aea33bc→none"},
+		},
+		{
+			description: "tag change only",
+			a:           TagResult{FQN: "test:v1", Source: "envTemplate"},
+			b:           TagResult{FQN: "test:v2", Source: "envTemplate"},
+			expected:    []string{"tag changed test:v1→test:v2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			reasons := DiffTagResults(test.a, test.b)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, reasons)
+		})
+	}
+}
@@ -35,5 +35,9 @@ func (c *ChecksumTagger) GenerateFullyQualifiedImageName(workingDir string, opts
 		return "", fmt.Errorf("Digest wrong format: %s, expected sha256:<checksum>", digestSplit)
 	}
 	checksum := digestSplit[1]
-	return fmt.Sprintf("%s:%s", opts.ImageName, checksum), nil
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+	return fullyQualifiedName(name, checksum, opts)
 }
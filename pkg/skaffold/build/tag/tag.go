@@ -16,6 +16,22 @@ limitations under the License.
 
 package tag
 
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/docker/distribution/reference"
+	"github.com/pkg/errors"
+)
+
+var validTagRegexp = regexp.MustCompile(`^` + reference.TagRegexp.String() + `$`)
+
+// ErrEmptyImageName is returned when tagging an image without a name.
+var ErrEmptyImageName = errors.New("image name is empty")
+
 // Tagger is an interface for tag strategies to be implemented against
 type Tagger interface {
 	GenerateFullyQualifiedImageName(workingDir string, tagOpts *Options) (string, error)
@@ -24,4 +40,235 @@ type Tagger interface {
 type Options struct {
 	ImageName string
 	Digest    string
+
+	// BuildArgs are folded into the tag since they can change
+	// the resulting image even if the sources are unchanged.
+	BuildArgs map[string]string
+
+	// CanonicalizeName normalizes the image name to always include a registry,
+	// eg. `app` becomes `docker.io/library/app`.
+	CanonicalizeName bool
+
+	// Progress, if set, is called periodically while the changed files
+	// of a dirty worktree are hashed.
+	Progress func(bytesHashed, totalBytes int64) `json:"-"`
+
+	// Salt, usually a build ID, is folded into the hash of dirty worktrees
+	// so that repeated dirty builds don't reuse the same tag.
+	Salt string
+
+	// HashEncoding is the encoding of the hashes in tags. Defaults to hex.
+	HashEncoding HashEncoding
+
+	// DirtyHashLength is the number of characters of the hashes in tags. Defaults to 16
+	// and can be up to the length of the whole hash, eg. 64 in hex.
+	DirtyHashLength int
+
+	// UniquenessChecker, if set, is called with the fully qualified name of dirty tags and
+	// reports whether it collides with an existing image, eg. by querying the registry.
+	// On collisions, the hash is deterministically extended and the name is checked again.
+	UniquenessChecker func(tag string) (bool, error) `json:"-"`
+
+	// CaseInsensitiveOrder hashes changed files in an order that ignores the case
+	// of their paths, so that tags are the same on case-insensitive filesystems.
+	CaseInsensitiveOrder bool
+
+	// BaseImageDigest, eg. `sha256:...`, is the digest of the base image resolved by the
+	// builder. It's folded into the tag so that updates of the base image change the tag.
+	BaseImageDigest string
+
+	// EnvHashPrefix, if set, folds the env variables whose name starts with it into the tag,
+	// eg. `BUILD_` for builds that are configured by env variables.
+	EnvHashPrefix string
+
+	// DockerfilePath, relative to the working directory, is a Dockerfile whose content
+	// is always folded into the tag, even if it's not tracked or out of scope.
+	DockerfilePath string
+
+	// ReserveLatest prevents taggers from using the `latest` tag, to avoid overwriting
+	// the floating tag by accident. Git taggers fall back to the short commit hash
+	// and constant taggers fail.
+	ReserveLatest bool
+
+	// NormalizeEOLInHash converts CRLF line endings to LF before hashing the changed
+	// text files, so that tags are the same regardless of the platform's line endings.
+	NormalizeEOLInHash bool
+
+	// SequenceProvider, if set, is called for each dirty tag to get a monotonic sequence
+	// number that's included in the tag, eg. `v1-dirty-3-abcdef`. It helps correlating
+	// logs with rebuilds during dev loops and should be left unset in other flows.
+	SequenceProvider func() int `json:"-"`
+
+	// DirtyFileWarnThreshold, if set, is the number of dirty files above which a warning
+	// listing some of them is logged. So many changes are usually caused by a bad checkout
+	// or line ending changes, and make the tagging slow.
+	DirtyFileWarnThreshold int
+
+	// DirtyFileErrorThreshold, if set, is the number of dirty files above which tagging fails.
+	DirtyFileErrorThreshold int
+
+	// DirtyReducer, if set, is applied to each changed file before it's hashed.
+	DirtyReducer DirtyReducer `json:"-"`
+
+	// MetadataOnlyHash hashes the path, status, size and mode of each changed file
+	// instead of its content, which is much faster on large repos. The tradeoff is that
+	// a change that keeps the size of a file, eg. fixing a typo, doesn't change the tag,
+	// so an image might not be rebuilt nor redeployed. DirtyReducer and NormalizeEOLInHash
+	// are ignored.
+	MetadataOnlyHash bool
+
+	// NormalizeRepoPath lowercases the repository path of the image name, as required
+	// by docker, eg. `MyOrg/App` becomes `myorg/app`. The registry host is left untouched.
+	NormalizeRepoPath bool
+
+	// Label, eg. the developer's username, is appended to every tag as `<tag>-<label>`.
+	// The characters that are not allowed in tags are replaced with `-`.
+	Label string
+
+	// FQNTemplate, if set, is a go template that composes the fully qualified name
+	// from FQNFields, eg. `{{.Name}}:{{.Tag}}{{if .Dirty}}.dev{{end}}`. It's used by
+	// every tagger except envTemplate, whose template already composes the whole name.
+	// The label is only included if the template uses it.
+	FQNTemplate string
+}
+
+// DirtyReducer transforms the content of a changed file before it's hashed, eg. to remove
+// the timestamps of generated files. Returning false leaves the file out of the hash.
+// Deleted files are passed a nil content.
+type DirtyReducer func(path string, content []byte) ([]byte, bool)
+
+// imageName returns the image name to be used when composing the fully qualified name.
+// It fails with ErrEmptyImageName if the image name is empty.
+func imageName(opts *Options) (string, error) {
+	if opts.ImageName == "" {
+		return "", ErrEmptyImageName
+	}
+
+	name := opts.ImageName
+	if opts.NormalizeRepoPath {
+		name = normalizeRepoPath(name)
+	}
+
+	if !opts.CanonicalizeName {
+		return name, nil
+	}
+
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return "", errors.Wrapf(err, "canonicalizing image name %s", name)
+	}
+
+	return named.Name(), nil
+}
+
+// normalizeRepoPath lowercases the repository path of an image name. The first component
+// is left untouched only if isRegistryHost says it's a registry host. Unlike isRegistry,
+// an uppercase component is lowercased as part of the path since fixing the case of such
+// paths is the point of normalizing. The result uses the same rules as ValidateRef.
+func normalizeRepoPath(name string) string {
+	if i := strings.Index(name, "/"); i != -1 {
+		if host := name[:i]; isRegistryHost(host) {
+			return host + "/" + strings.ToLower(name[i+1:])
+		}
+	}
+	return strings.ToLower(name)
+}
+
+// FQNFields are the fields available to Options.FQNTemplate.
+type FQNFields struct {
+	// Name is the image name.
+	Name string
+	// Tag is the tag, without its suffix, eg. the git tag or the short commit hash.
+	Tag string
+	// Dirty tells if the tag was computed from a dirty git worktree.
+	Dirty bool
+	// ShortHash is the short commit hash, for git taggers.
+	ShortHash string
+	// Suffix is appended to Tag by default, eg. `-dirty-<hash>` for dirty git worktrees
+	// or the hash of the build args.
+	Suffix string
+	// Label is the sanitized label of the options.
+	Label string
+}
+
+// fullyQualifiedName composes `name:tag`, with the label of the options, if any.
+func fullyQualifiedName(name, tag string, opts *Options) (string, error) {
+	return composeFQN(FQNFields{Name: name, Tag: tag}, opts)
+}
+
+// composeFQN composes the fully qualified name with the FQNTemplate of the options or,
+// by default, as `name:<tag><suffix>-<label>`.
+func composeFQN(fields FQNFields, opts *Options) (string, error) {
+	fields.Label = sanitizeLabel(opts.Label)
+	if opts.FQNTemplate == "" {
+		return fmt.Sprintf("%s:%s", fields.Name, labeledTag(fields.Tag+fields.Suffix, fields.Label)), nil
+	}
+
+	tmpl, err := template.New("fqn").Option("missingkey=error").Parse(opts.FQNTemplate)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing fqn template %s", opts.FQNTemplate)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, fields); err != nil {
+		return "", errors.Wrapf(err, "executing fqn template %s", opts.FQNTemplate)
+	}
+
+	fqn := buf.String()
+	if err := ValidateRef(fqn); err != nil {
+		return "", errors.Wrapf(err, "executing fqn template %s", opts.FQNTemplate)
+	}
+	return fqn, nil
+}
+
+// sanitizeLabel replaces the characters of a label that are not allowed in tags.
+func sanitizeLabel(label string) string {
+	return strings.Trim(invalidTagCharRegexp.ReplaceAllString(label, "-"), ".-")
+}
+
+// labeledTag appends a sanitized label to a tag.
+func labeledTag(tag, label string) string {
+	label = sanitizeLabel(label)
+	if label == "" {
+		return tag
+	}
+
+	tag = fmt.Sprintf("%s-%s", tag, label)
+	if len(tag) > maxTagLength {
+		tag = tag[:maxTagLength]
+	}
+	return tag
+}
+
+const latestTag = "latest"
+
+// checkReserved fails if a constant tag is reserved.
+func checkReserved(tag string, opts *Options) error {
+	if opts.ReserveLatest && tag == latestTag {
+		return fmt.Errorf("tag %q is reserved", tag)
+	}
+	return nil
+}
+
+// validateTag checks that a string can be used as an image tag.
+func validateTag(tag string) error {
+	if !validTagRegexp.MatchString(tag) {
+		return fmt.Errorf("invalid tag %q", tag)
+	}
+	return nil
+}
+
+// TagValidator checks that a tag is accepted by a registry.
+type TagValidator func(tag string) error
+
+// LowercaseTagValidator rejects tags with uppercase letters,
+// for registries that don't support them.
+func LowercaseTagValidator(tag string) error {
+	if err := validateTag(tag); err != nil {
+		return err
+	}
+	if strings.ToLower(tag) != tag {
+		return fmt.Errorf("tag %q contains uppercase letters", tag)
+	}
+	return nil
 }
@@ -0,0 +1,301 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestCanonicalizeName(t *testing.T) {
+	var tests = []struct {
+		description string
+		imageName   string
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "single segment",
+			imageName:   "app",
+			expected:    "docker.io/library/app:v1",
+		},
+		{
+			description: "two segments",
+			imageName:   "user/app",
+			expected:    "docker.io/user/app:v1",
+		},
+		{
+			description: "with registry",
+			imageName:   "gcr.io/p/app",
+			expected:    "gcr.io/p/app:v1",
+		},
+		{
+			description: "invalid name",
+			imageName:   "UPPER",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			c := &CustomTag{
+				Tag: "v1",
+			}
+			tag, err := c.GenerateFullyQualifiedImageName(".", &Options{
+				ImageName:        test.imageName,
+				CanonicalizeName: true,
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, tag)
+		})
+	}
+}
+
+func TestCanonicalizeNameDisabled(t *testing.T) {
+	c := &CustomTag{
+		Tag: "v1",
+	}
+	tag, err := c.GenerateFullyQualifiedImageName(".", &Options{
+		ImageName: "app",
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "app:v1", tag)
+}
+
+func TestLowercaseTagValidator(t *testing.T) {
+	var tests = []struct {
+		tag       string
+		shouldErr bool
+	}{
+		{tag: "v1.0"},
+		{tag: "release-1.0_rc1"},
+		{tag: "Release-1.0", shouldErr: true},
+		{tag: "feature/login", shouldErr: true},
+		{tag: "", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tag, func(t *testing.T) {
+			err := LowercaseTagValidator(test.tag)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
+
+func TestEmptyImageName(t *testing.T) {
+	var tests = []struct {
+		description string
+		tagger      Tagger
+	}{
+		{description: "custom", tagger: &CustomTag{Tag: "v1"}},
+		{description: "sha256", tagger: &ChecksumTagger{}},
+		{description: "dateTime", tagger: NewDateTimeTagger("", "")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := test.tagger.GenerateFullyQualifiedImageName(".", &Options{Digest: "sha256:12345abcde"})
+
+			if err != ErrEmptyImageName {
+				t.Errorf("Expected %v, got %v", ErrEmptyImageName, err)
+			}
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	var tests = []struct {
+		description string
+		tagger      Tagger
+		label       string
+		expected    string
+	}{
+		{
+			description: "custom",
+			tagger:      &CustomTag{Tag: "v1"},
+			label:       "alice",
+			expected:    "test:v1-alice",
+		},
+		{
+			description: "sha256",
+			tagger:      &ChecksumTagger{},
+			label:       "alice",
+			expected:    "test:12345abcde-alice",
+		},
+		{
+			description: "sanitized",
+			tagger:      &CustomTag{Tag: "v1"},
+			label:       " Jane Doe/dev ",
+			expected:    "test:v1-Jane-Doe-dev",
+		},
+		{
+			description: "only invalid characters",
+			tagger:      &CustomTag{Tag: "v1"},
+			label:       "@@",
+			expected:    "test:v1",
+		},
+		{
+			description: "env template",
+			tagger:      mustEnvTemplateTagger(t, "{{.IMAGE_NAME}}:v1"),
+			label:       "alice",
+			expected:    "test:v1-alice",
+		},
+		{
+			description: "env template without tag",
+			tagger:      mustEnvTemplateTagger(t, "{{.IMAGE_NAME}}"),
+			label:       "alice",
+			expected:    "test",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			name, err := test.tagger.GenerateFullyQualifiedImageName(".", &Options{
+				ImageName: "test",
+				Digest:    "sha256:12345abcde",
+				Label:     test.label,
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, name)
+		})
+	}
+}
+
+func mustEnvTemplateTagger(t *testing.T, template string) Tagger {
+	tagger, err := NewEnvTemplateTagger(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tagger
+}
+
+func TestLabeledTagMaxLength(t *testing.T) {
+	tag := labeledTag(strings.Repeat("a", 120), "developer")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, strings.Repeat("a", 120)+"-develop", tag)
+}
+
+func TestNormalizeRepoPath(t *testing.T) {
+	var tests = []struct {
+		description  string
+		imageName    string
+		canonicalize bool
+		expected     string
+	}{
+		{
+			description: "repository path",
+			imageName:   "MyOrg/App",
+			expected:    "myorg/app:Tag",
+		},
+		{
+			description: "single segment",
+			imageName:   "App",
+			expected:    "app:Tag",
+		},
+		{
+			description: "registry host with port",
+			imageName:   "GCR.io:5000/MyOrg/App",
+			expected:    "GCR.io:5000/myorg/app:Tag",
+		},
+		{
+			description: "localhost",
+			imageName:   "localhost/MyOrg/App",
+			expected:    "localhost/myorg/app:Tag",
+		},
+		{
+			description:  "canonicalized",
+			imageName:    "MyOrg/App",
+			canonicalize: true,
+			expected:     "docker.io/myorg/app:Tag",
+		},
+		{
+			description: "uppercase first component without a dot",
+			imageName:   "MyHost/app",
+			expected:    "myhost/app:Tag",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			c := &CustomTag{Tag: "Tag"}
+
+			name, err := c.GenerateFullyQualifiedImageName(".", &Options{
+				ImageName:         test.imageName,
+				NormalizeRepoPath: true,
+				CanonicalizeName:  test.canonicalize,
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, name)
+			testutil.CheckError(t, false, ValidateRef(name))
+		})
+	}
+}
+
+func TestFQNTemplate(t *testing.T) {
+	var tests = []struct {
+		description string
+		template    string
+		label       string
+		expected    string
+		shouldErr   bool
+	}{
+		{
+			description: "default",
+			label:       "alice",
+			expected:    "test:v1-alice",
+		},
+		{
+			description: "custom",
+			template:    "{{.Name}}:release-{{.Tag}}",
+			label:       "alice",
+			expected:    "test:release-v1",
+		},
+		{
+			description: "label",
+			template:    "{{.Name}}:{{.Label}}.{{.Tag}}",
+			label:       "alice",
+			expected:    "test:alice.v1",
+		},
+		{
+			description: "invalid template",
+			template:    "{{.Name",
+			shouldErr:   true,
+		},
+		{
+			description: "uppercase repository path",
+			template:    "{{.Name}}/App:{{.Tag}}",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			c := &CustomTag{Tag: "v1"}
+
+			name, err := c.GenerateFullyQualifiedImageName(".", &Options{
+				ImageName:   "test",
+				Label:       test.label,
+				FQNTemplate: test.template,
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, name)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tagtesting provides helpers to test taggers.
+package tagtesting
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+)
+
+// ImageName is the image name used to generate tags.
+const ImageName = "test"
+
+// RepoFixture populates a directory with a repository.
+// It should produce the exact same content each time it's called.
+type RepoFixture func(t testing.TB, dir string)
+
+// AssertReproducible checks that a tagger generates identical tags
+// for two separate copies of the same repository.
+func AssertReproducible(t testing.TB, tagger tag.Tagger, fixture RepoFixture) {
+	first, ok := generate(t, tagger, fixture)
+	if !ok {
+		return
+	}
+	second, ok := generate(t, tagger, fixture)
+	if !ok {
+		return
+	}
+
+	if first != second {
+		t.Errorf("Tagger is not reproducible: got %s then %s", first, second)
+	}
+}
+
+func generate(t testing.TB, tagger tag.Tagger, fixture RepoFixture) (string, bool) {
+	dir, err := ioutil.TempDir("", "skaffold")
+	if err != nil {
+		t.Errorf("creating temp dir: %s", err)
+		return "", false
+	}
+	defer os.RemoveAll(dir)
+
+	fixture(t, dir)
+
+	name, err := tagger.GenerateFullyQualifiedImageName(dir, &tag.Options{ImageName: ImageName})
+	if err != nil {
+		t.Errorf("generating tag in %s: %s", dir, err)
+		return "", false
+	}
+
+	return name, true
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tagtesting
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// recorder records the errors reported by AssertReproducible.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// counterTagger generates a different tag each time.
+type counterTagger struct {
+	count int
+}
+
+func (c *counterTagger) GenerateFullyQualifiedImageName(workingDir string, opts *tag.Options) (string, error) {
+	c.count++
+	return fmt.Sprintf("%s:%d", opts.ImageName, c.count), nil
+}
+
+func committedRepo(t testing.TB, dir string) {
+	repo, err := git.PlainInit(dir, false)
+	failNowIfError(t, err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "source.go"), []byte("code"), 0644)
+	failNowIfError(t, err)
+
+	w, err := repo.Worktree()
+	failNowIfError(t, err)
+	_, err = w.Add("source.go")
+	failNowIfError(t, err)
+
+	_, err = w.Commit("initial", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "John Doe",
+			Email: "john@doe.org",
+			When:  time.Date(2013, 2, 3, 19, 54, 0, 0, time.UTC),
+		},
+	})
+	failNowIfError(t, err)
+}
+
+func dirtyRepo(t testing.TB, dir string) {
+	committedRepo(t, dir)
+
+	err := ioutil.WriteFile(filepath.Join(dir, "source.go"), []byte("updated code"), 0644)
+	failNowIfError(t, err)
+}
+
+func TestAssertReproducible(t *testing.T) {
+	var tests = []struct {
+		description string
+		tagger      tag.Tagger
+		fixture     RepoFixture
+		shouldFail  bool
+	}{
+		{
+			description: "git commit",
+			tagger:      &tag.GitCommit{},
+			fixture:     committedRepo,
+		},
+		{
+			description: "dirty git commit",
+			tagger:      &tag.GitCommit{},
+			fixture:     dirtyRepo,
+		},
+		{
+			description: "custom tag",
+			tagger:      &tag.CustomTag{Tag: "v1"},
+			fixture:     func(testing.TB, string) {},
+		},
+		{
+			description: "non deterministic tagger",
+			tagger:      &counterTagger{},
+			fixture:     committedRepo,
+			shouldFail:  true,
+		},
+		{
+			description: "tagger error",
+			tagger:      &tag.GitCommit{},
+			fixture:     func(testing.TB, string) {},
+			shouldFail:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			r := &recorder{TB: t}
+
+			AssertReproducible(r, test.tagger, test.fixture)
+
+			if test.shouldFail && len(r.errors) == 0 {
+				t.Error("Expected AssertReproducible to report an error")
+			}
+			if !test.shouldFail && len(r.errors) > 0 {
+				t.Errorf("Unexpected errors: %v", r.errors)
+			}
+		})
+	}
+}
+
+func failNowIfError(t testing.TB, err error) {
+	if err != nil {
+		t.Fatal(err)
+	}
+}
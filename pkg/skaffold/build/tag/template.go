@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// TemplateTagger tags an image with a go template that composes the fully
+// qualified name, eg. `{{.IMAGE_NAME}}:{{.GIT_BRANCH}}-{{.TIMESTAMP}}`.
+// The template has access to the env variables and to these fields:
+//   - IMAGE_NAME, DIGEST, DIGEST_ALGO and DIGEST_HEX, like envTemplate;
+//   - GIT_COMMIT, the short commit hash, and GIT_COMMIT_FULL;
+//   - GIT_TAG, the tag pointing to HEAD, if any;
+//   - GIT_BRANCH, the current branch, sanitized to be used in tags;
+//   - TIMESTAMP, the current time formatted like the dateTime tagger.
+//
+// The git fields are empty outside of git repositories.
+type TemplateTagger struct {
+	Template *template.Template
+
+	timeFn func() time.Time
+}
+
+// NewTemplateTagger creates a new TemplateTagger
+func NewTemplateTagger(t string) (*TemplateTagger, error) {
+	tmpl, err := util.ParseEnvTemplate(t)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+
+	return &TemplateTagger{
+		Template: tmpl,
+		timeFn:   time.Now,
+	}, nil
+}
+
+// GenerateFullyQualifiedImageName tags an image with the result of the template.
+func (t *TemplateTagger) GenerateFullyQualifiedImageName(workingDir string, opts *Options) (string, error) {
+	if opts == nil {
+		return "", fmt.Errorf("tag options not provided")
+	}
+
+	name, err := imageName(opts)
+	if err != nil {
+		return "", err
+	}
+
+	customMap := templateGitFields(workingDir)
+	customMap["IMAGE_NAME"] = name
+	customMap["DIGEST"] = opts.Digest
+	if names := strings.SplitN(opts.Digest, ":", 2); len(names) == 2 {
+		customMap["DIGEST_ALGO"] = names[0]
+		customMap["DIGEST_HEX"] = names[1]
+	}
+
+	now := time.Now
+	if t.timeFn != nil {
+		now = t.timeFn
+	}
+	customMap["TIMESTAMP"] = now().Format(tagTime)
+
+	fqn, err := util.ExecuteEnvTemplate(t.Template, customMap)
+	if err != nil {
+		return "", err
+	}
+
+	fqn = cleanTemplateOutput(fqn)
+	if err := ValidateRef(fqn); err != nil {
+		return "", errors.Wrap(err, "executing tag template")
+	}
+
+	return fqn, nil
+}
+
+// templateGitFields reads the git fields of the template. Any failure results in empty fields.
+func templateGitFields(workingDir string) map[string]string {
+	fields := map[string]string{
+		"GIT_COMMIT":      "",
+		"GIT_COMMIT_FULL": "",
+		"GIT_TAG":         "",
+		"GIT_BRANCH":      "",
+	}
+
+	if gitDisabled() {
+		return fields
+	}
+
+	repo, err := openGitRepo(workingDir)
+	if err != nil {
+		logrus.Debugf("Unable to open git repo at %s: %s", workingDir, err)
+		return fields
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		logrus.Debugf("Unable to determine current git commit: %s", err)
+		return fields
+	}
+
+	fields["GIT_COMMIT_FULL"] = head.Hash().String()
+	fields["GIT_COMMIT"] = head.Hash().String()[0:defaultCommitLength]
+	if head.Name().IsBranch() {
+		fields["GIT_BRANCH"] = branchTag(head.Name().Short())
+	}
+
+	tagName, err := gitTag(repo, head.Hash(), false)
+	if err != nil {
+		logrus.Debugf("Unable to determine git tag: %s", err)
+	}
+	fields["GIT_TAG"] = tagName
+
+	return fields
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestTemplateTagger(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      string
+		createGitRepo func(string)
+		env           []string
+		opts          *Options
+		want          string
+		shouldErr     bool
+	}{
+		{
+			name:     "branch and timestamp",
+			template: "{{.IMAGE_NAME}}:{{.GIT_BRANCH}}-{{.TIMESTAMP}}",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					branch("feature/login")
+			},
+			opts: &Options{ImageName: "test"},
+			want: "test:feature-login-2018-06-21_15-04-05_UTC",
+		},
+		{
+			name:     "commit and tag",
+			template: "{{.IMAGE_NAME}}:{{.GIT_TAG}}-{{.GIT_COMMIT}}",
+			createGitRepo: func(dir string) {
+				gitInit(t, dir).
+					write("source.go", []byte("code")).
+					add("source.go").
+					commit("initial").
+					tag("v1")
+			},
+			opts: &Options{ImageName: "test"},
+			want: "test:v1-eefe1b9",
+		},
+		{
+			name:          "env and digest",
+			template:      "{{.IMAGE_NAME}}:{{.RELEASE}}-{{.DIGEST_HEX}}",
+			createGitRepo: func(dir string) {},
+			env:           []string{"RELEASE=r3"},
+			opts:          &Options{ImageName: "test", Digest: "sha256:abcd"},
+			want:          "test:r3-abcd",
+		},
+		{
+			name:          "no git repo",
+			template:      "{{.IMAGE_NAME}}:dev{{.GIT_COMMIT}}",
+			createGitRepo: func(dir string) {},
+			opts:          &Options{ImageName: "test"},
+			want:          "test:dev",
+		},
+		{
+			name:          "invalid reference",
+			template:      "{{.IMAGE_NAME}}:{{.GIT_BRANCH}}",
+			createGitRepo: func(dir string) {},
+			opts:          &Options{ImageName: "test"},
+			shouldErr:     true,
+		},
+		{
+			name:          "empty image name",
+			template:      "{{.IMAGE_NAME}}:v1",
+			createGitRepo: func(dir string) {},
+			opts:          &Options{},
+			shouldErr:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			test.createGitRepo(tmpDir)
+			util.OSEnviron = func() []string {
+				return test.env
+			}
+
+			tagger, err := NewTemplateTagger(test.template)
+			failNowIfError(t, err)
+			tagger.timeFn = func() time.Time {
+				return time.Date(2018, 6, 21, 15, 4, 5, 0, time.UTC)
+			}
+
+			got, err := tagger.GenerateFullyQualifiedImageName(tmpDir, test.opts)
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.want, got)
+		})
+	}
+}
+
+func TestNewTemplateTagger(t *testing.T) {
+	_, err := NewTemplateTagger("{{.IMAGE_NAME")
+	testutil.CheckError(t, true, err)
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildpacks
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+)
+
+// Args are the arguments of `pack build` that build an image from the workspace
+// of an artifact into the local docker daemon.
+func Args(a *v1alpha2.BuildpackArtifact, image, workspace string) []string {
+	args := []string{"build", image, "--builder", a.Builder, "--path", workspace}
+	for _, buildpack := range a.Buildpacks {
+		args = append(args, "--buildpack", buildpack)
+	}
+	if a.RunImage != "" {
+		args = append(args, "--run-image", a.RunImage)
+	}
+	return args
+}
+
+// GetDependencies lists the files of the workspace, relative to the workspace, since
+// the buildpacks detect and build the whole application source. Hidden directories,
+// eg. `.git`, are ignored.
+func GetDependencies(a *v1alpha2.Artifact) ([]string, error) {
+	var deps []string
+
+	err := filepath.Walk(a.Workspace, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(a.Workspace, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if rel != "." && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		deps = append(deps, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "walking workspace %s", a.Workspace)
+	}
+
+	sort.Strings(deps)
+	return deps, nil
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package buildpacks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestArgs(t *testing.T) {
+	var tests = []struct {
+		description string
+		artifact    *v1alpha2.BuildpackArtifact
+		expected    []string
+	}{
+		{
+			description: "builder",
+			artifact:    &v1alpha2.BuildpackArtifact{Builder: "heroku/buildpacks:18"},
+			expected:    []string{"build", "img", "--builder", "heroku/buildpacks:18", "--path", "app"},
+		},
+		{
+			description: "buildpacks and run image",
+			artifact: &v1alpha2.BuildpackArtifact{
+				Builder:    "heroku/buildpacks:18",
+				Buildpacks: []string{"heroku/nodejs", "heroku/procfile"},
+				RunImage:   "heroku/pack:18",
+			},
+			expected: []string{"build", "img", "--builder", "heroku/buildpacks:18", "--path", "app",
+				"--buildpack", "heroku/nodejs", "--buildpack", "heroku/procfile", "--run-image", "heroku/pack:18"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			args := Args(test.artifact, "img", "app")
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, args)
+		})
+	}
+}
+
+func TestGetDependencies(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	for _, file := range []string{"package.json", "src/index.js", ".git/HEAD", "Procfile"} {
+		path := filepath.Join(tmpDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deps, err := GetDependencies(&v1alpha2.Artifact{
+		Workspace: tmpDir,
+		ArtifactType: v1alpha2.ArtifactType{
+			BuildpackArtifact: &v1alpha2.BuildpackArtifact{Builder: "heroku/buildpacks:18"},
+		},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"Procfile", "package.json", filepath.Join("src", "index.js")}, deps)
+}
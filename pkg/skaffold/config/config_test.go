@@ -76,7 +76,7 @@ func TestParseConfig(t *testing.T) {
 			config:      minimalConfig,
 			expected: config(
 				withLocalBuild(
-					withTagPolicy(v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}}),
+					withTagPolicy(v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}, Defaulted: true}),
 				),
 			),
 		},
@@ -16,6 +16,15 @@ limitations under the License.
 
 package config
 
+const (
+	// OnErrorExit ends dev loops on the first build or deploy error.
+	OnErrorExit = "exit"
+
+	// OnErrorContinue reports the build and deploy errors of dev loops,
+	// that keep watching and try again on the next change.
+	OnErrorContinue = "continue"
+)
+
 // SkaffoldOptions are options that are set by command line arguments not included
 // in the config file itself
 type SkaffoldOptions struct {
@@ -24,4 +33,41 @@ type SkaffoldOptions struct {
 	Profiles     []string
 	CustomTag    string
 	Namespace    string
+
+	// CacheArtifacts skips the builds of artifacts whose inputs didn't change.
+	CacheArtifacts bool
+
+	// StatusCheck waits for the deployed workloads to be rolled out after each deploy.
+	StatusCheck bool
+
+	// PortForward forwards the ports of the deployed pods during dev loops.
+	PortForward bool
+
+	// EnableDebug rewrites the deployed manifests to enable debuggers in the containers
+	// that run the built images. It's not supported by the helm deployer.
+	EnableDebug bool
+
+	// Command is the skaffold command being run, eg. `dev`.
+	Command string
+
+	// Trigger is how dev loops detect changes: `polling`, `notify` or `manual`.
+	Trigger string
+
+	// WatchPollInterval is the interval between two checks for file changes, in ms.
+	WatchPollInterval int
+
+	// Tail streams the logs of the deployed containers during dev loops.
+	Tail bool
+
+	// LogSelector is a label selector that restricts the pods whose logs are streamed.
+	LogSelector string
+
+	// OnError is what dev loops do when a build or a deploy fails: OnErrorExit
+	// or OnErrorContinue. Defaults to OnErrorContinue.
+	OnError string
+
+	// RPCPort and RPCHTTPPort are the ports of the gRPC and HTTP servers that expose
+	// the state of dev loops. 0 disables the server.
+	RPCPort     int
+	RPCHTTPPort int
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"os"
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
@@ -79,6 +80,7 @@ func TestApplyProfiles(t *testing.T) {
 					},
 					TagPolicy: v1alpha2.TagPolicy{
 						GitTagger: &v1alpha2.GitTagger{},
+						Defaulted: true,
 					},
 				},
 				Deploy: v1alpha2.DeployConfig{},
@@ -92,7 +94,7 @@ func TestApplyProfiles(t *testing.T) {
 					Artifacts: []*v1alpha2.Artifact{
 						{ImageName: "image"},
 					},
-					TagPolicy: v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}},
+					TagPolicy: v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}, Defaulted: true},
 				},
 				Deploy: v1alpha2.DeployConfig{},
 				Profiles: []v1alpha2.Profile{
@@ -203,6 +205,7 @@ func TestApplyProfiles(t *testing.T) {
 				Build: v1alpha2.BuildConfig{
 					TagPolicy: v1alpha2.TagPolicy{
 						GitTagger: &v1alpha2.GitTagger{},
+						Defaulted: true,
 					},
 					BuildType: v1alpha2.BuildType{
 						LocalBuild: &v1alpha2.LocalBuild{},
@@ -225,3 +228,71 @@ func TestApplyProfiles(t *testing.T) {
 		})
 	}
 }
+
+func TestActivatedProfiles(t *testing.T) {
+	os.Setenv("SKAFFOLD_TEST_ENV", "prod")
+	defer os.Unsetenv("SKAFFOLD_TEST_ENV")
+
+	profiles := []v1alpha2.Profile{
+		{Name: "always", Activation: []v1alpha2.Activation{{}}},
+		{Name: "never"},
+		{Name: "env", Activation: []v1alpha2.Activation{{Env: "SKAFFOLD_TEST_ENV=prod|staging"}}},
+		{Name: "other-env", Activation: []v1alpha2.Activation{{Env: "SKAFFOLD_TEST_ENV=pro"}}},
+		{Name: "minikube", Activation: []v1alpha2.Activation{{KubeContext: "minikube"}}},
+		{Name: "gke", Activation: []v1alpha2.Activation{{KubeContext: "gke_.*"}}},
+		{Name: "dev", Activation: []v1alpha2.Activation{{Command: "dev"}}},
+		{Name: "dev-on-gke", Activation: []v1alpha2.Activation{{Command: "dev", KubeContext: "gke_.*"}}},
+		{Name: "run-or-gke", Activation: []v1alpha2.Activation{{Command: "run"}, {KubeContext: "gke_.*"}}},
+	}
+
+	var tests = []struct {
+		description string
+		profiles    []v1alpha2.Profile
+		kubeContext string
+		command     string
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "minikube dev",
+			profiles:    profiles,
+			kubeContext: "minikube",
+			command:     "dev",
+			expected:    []string{"always", "env", "minikube", "dev"},
+		},
+		{
+			description: "gke run",
+			profiles:    profiles,
+			kubeContext: "gke_project_zone_cluster",
+			command:     "run",
+			expected:    []string{"always", "env", "gke", "run-or-gke"},
+		},
+		{
+			description: "gke dev",
+			profiles:    profiles,
+			kubeContext: "gke_project_zone_cluster",
+			command:     "dev",
+			expected:    []string{"always", "env", "gke", "dev", "dev-on-gke", "run-or-gke"},
+		},
+		{
+			description: "invalid regexp",
+			profiles:    []v1alpha2.Profile{{Name: "invalid", Activation: []v1alpha2.Activation{{KubeContext: "("}}}},
+			shouldErr:   true,
+		},
+		{
+			description: "invalid env",
+			profiles:    []v1alpha2.Profile{{Name: "invalid", Activation: []v1alpha2.Activation{{Env: "ENV"}}}},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cfg := &SkaffoldConfig{Profiles: test.profiles}
+
+			activated, err := cfg.ActivatedProfiles(test.kubeContext, test.command)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, activated)
+		})
+	}
+}
@@ -37,10 +37,29 @@ const (
 
 	DefaultMinikubeContext         = "minikube"
 	DefaultDockerForDesktopContext = "docker-for-desktop"
+	DefaultDockerDesktopContext    = "docker-desktop"
 	GCSBucketSuffix                = "_cloudbuild"
 
 	DefaultKustomizationPath = "."
 
 	// DefaultKanikoImage is v0.1.0
 	DefaultKanikoImage = "gcr.io/kaniko-project/executor:v0.1.0@sha256:501056bf52f3a96f151ccbeb028715330d5d5aa6647e7572ce6c6c55f91ab374"
+
+	// DefaultArtifactCacheFile is where the builds of the artifacts are cached.
+	DefaultArtifactCacheFile = "~/.skaffold/cache"
+
+	// DefaultRepoCacheDir is where the git repositories of the required configs are cloned.
+	DefaultRepoCacheDir = "~/.skaffold/repos"
+
+	// DefaultCloudBuildDockerImage is the image of the build step that runs docker on Google Cloud Build.
+	DefaultCloudBuildDockerImage = "gcr.io/cloud-builders/docker"
+
+	// DefaultKanikoNamespace is where kaniko pods are created.
+	DefaultKanikoNamespace = "default"
+
+	// DefaultKanikoTimeout is how long skaffold waits for a kaniko build to complete.
+	DefaultKanikoTimeout = "20m"
+
+	// DefaultStatusCheckDeadline is how long skaffold waits for the deployed workloads to be rolled out.
+	DefaultStatusCheckDeadline = "10m"
 )
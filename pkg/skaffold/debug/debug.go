@@ -0,0 +1,335 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package debug rewrites the manifests so that debuggers can be attached to the
+// containers running the built images.
+package debug
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/google/go-containerregistry/pkg/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// runtime enables a debugger for a language runtime.
+type runtime struct {
+	name     string
+	portName string
+	port     int
+
+	// detect tells if a container runs this runtime, given its env and its command line.
+	detect func(env map[string]string, command []string) bool
+
+	// enable rewrites the container to enable the debugger. It returns false when it can't.
+	enable func(container map[interface{}]interface{}, command []string) bool
+}
+
+var runtimes = []runtime{
+	{
+		name:     "java",
+		portName: "jdwp",
+		port:     5005,
+		detect: func(env map[string]string, command []string) bool {
+			return hasEnv(env, "JAVA_VERSION", "JAVA_HOME") || isCommand(command, "java")
+		},
+		enable: func(container map[interface{}]interface{}, _ []string) bool {
+			appendEnv(container, "JAVA_TOOL_OPTIONS", "-agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=5005")
+			return true
+		},
+	},
+	{
+		name:     "nodejs",
+		portName: "devtools",
+		port:     9229,
+		detect: func(env map[string]string, command []string) bool {
+			return hasEnv(env, "NODE_VERSION") || isCommand(command, "node", "nodejs")
+		},
+		enable: func(container map[interface{}]interface{}, command []string) bool {
+			if isCommand(command, "node", "nodejs") {
+				setCommand(container, insert(command, 1, "--inspect=0.0.0.0:9229"))
+			} else {
+				// eg. `npm start`
+				appendEnv(container, "NODE_OPTIONS", "--inspect=0.0.0.0:9229")
+			}
+			return true
+		},
+	},
+	{
+		name:     "python",
+		portName: "dap",
+		port:     5678,
+		detect: func(env map[string]string, command []string) bool {
+			return hasEnv(env, "PYTHON_VERSION") || isPython(command)
+		},
+		enable: func(container map[interface{}]interface{}, command []string) bool {
+			// The ptvsd module must be installed in the image.
+			if !isPython(command) || len(command) < 2 || command[1] == "-m" {
+				return false
+			}
+			setCommand(container, insert(command, 1, "-m", "ptvsd", "--host", "0.0.0.0", "--port", "5678"))
+			return true
+		},
+	},
+	{
+		name:     "go",
+		portName: "dlv",
+		port:     56268,
+		detect: func(env map[string]string, command []string) bool {
+			// Go binaries usually run in minimal images, so the runtime is detected
+			// through the env variables that configure it.
+			return hasEnv(env, "GOTRACEBACK", "GODEBUG", "GOMAXPROCS", "GOGC")
+		},
+		enable: func(container map[interface{}]interface{}, command []string) bool {
+			if len(command) == 0 {
+				return false
+			}
+			// dlv must be on the PATH of the image.
+			dlv := []string{"dlv", "exec", "--headless", "--continue", "--accept-multiclient", "--listen=:56268", "--api-version=2", command[0]}
+			if len(command) > 1 {
+				dlv = append(append(dlv, "--"), command[1:]...)
+			}
+			setCommand(container, dlv)
+			return true
+		},
+	},
+}
+
+// ApplyDebuggingTransforms rewrites the containers that run the built images to enable
+// the debugger of their runtime: java, nodejs, python or go. The debug ports are
+// added to the containers so that they are forwarded like any other port.
+func ApplyDebuggingTransforms(manifests [][]byte, builds []build.Build) ([][]byte, error) {
+	images := map[string]bool{}
+	for _, b := range builds {
+		images[b.Tag] = true
+	}
+
+	var updated [][]byte
+	for _, manifest := range manifests {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+
+		if len(m) == 0 {
+			continue
+		}
+
+		transformContainers(m, images)
+
+		updatedManifest, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling yaml")
+		}
+
+		updated = append(updated, updatedManifest)
+	}
+
+	return updated, nil
+}
+
+func transformContainers(i interface{}, images map[string]bool) {
+	switch t := i.(type) {
+	case []interface{}:
+		for _, v := range t {
+			transformContainers(v, images)
+		}
+	case map[interface{}]interface{}:
+		for k, v := range t {
+			if k != "containers" {
+				transformContainers(v, images)
+				continue
+			}
+
+			containers, ok := v.([]interface{})
+			if !ok {
+				continue
+			}
+			for _, c := range containers {
+				if container, ok := c.(map[interface{}]interface{}); ok {
+					transformContainer(container, images)
+				}
+			}
+		}
+	}
+}
+
+func transformContainer(container map[interface{}]interface{}, images map[string]bool) {
+	image, _ := container["image"].(string)
+	if !images[image] {
+		return
+	}
+
+	cfg, err := docker.RetrieveImage(image)
+	if err != nil {
+		logrus.Warnf("Unable to retrieve the configuration of image %s, debugging is not enabled: %s", image, err)
+		return
+	}
+
+	env := envMap(container, cfg.Config)
+	command := commandLine(container, cfg.Config)
+
+	for _, r := range runtimes {
+		if !r.detect(env, command) {
+			continue
+		}
+
+		if !r.enable(container, command) {
+			logrus.Warnf("Unable to enable the %s debugger for image %s", r.name, image)
+			return
+		}
+
+		addPort(container, r.portName, r.port)
+		logrus.Infof("Enabled the %s debugger for image %s on port %d", r.name, image, r.port)
+		return
+	}
+
+	logrus.Warnf("Unable to detect the runtime of image %s, debugging is not enabled", image)
+}
+
+// envMap merges the env of the image with the env of the container.
+func envMap(container map[interface{}]interface{}, cfg v1.Config) map[string]string {
+	env := map[string]string{}
+	for _, kv := range cfg.Env {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	vars, _ := container["env"].([]interface{})
+	for _, v := range vars {
+		if m, ok := v.(map[interface{}]interface{}); ok {
+			name, _ := m["name"].(string)
+			value, _ := m["value"].(string)
+			env[name] = value
+		}
+	}
+
+	return env
+}
+
+// commandLine computes the command line of a container, like kubernetes does:
+// command and args override the entrypoint and the cmd of the image.
+func commandLine(container map[interface{}]interface{}, cfg v1.Config) []string {
+	command, hasCommand := stringList(container["command"])
+	args, hasArgs := stringList(container["args"])
+
+	if !hasCommand {
+		command = cfg.Entrypoint
+		if !hasArgs {
+			args = cfg.Cmd
+		}
+	}
+
+	return append(append([]string{}, command...), args...)
+}
+
+func stringList(i interface{}) ([]string, bool) {
+	list, ok := i.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	var values []string
+	for _, v := range list {
+		values = append(values, fmt.Sprint(v))
+	}
+	return values, true
+}
+
+func hasEnv(env map[string]string, names ...string) bool {
+	for _, name := range names {
+		if _, present := env[name]; present {
+			return true
+		}
+	}
+	return false
+}
+
+func isCommand(command []string, names ...string) bool {
+	if len(command) == 0 {
+		return false
+	}
+
+	base := filepath.Base(command[0])
+	for _, name := range names {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isPython(command []string) bool {
+	return len(command) > 0 && strings.HasPrefix(filepath.Base(command[0]), "python")
+}
+
+func insert(command []string, index int, values ...string) []string {
+	var inserted []string
+	inserted = append(inserted, command[:index]...)
+	inserted = append(inserted, values...)
+	return append(inserted, command[index:]...)
+}
+
+// setCommand replaces the command and the args of a container.
+func setCommand(container map[interface{}]interface{}, command []string) {
+	var list []interface{}
+	for _, c := range command {
+		list = append(list, c)
+	}
+
+	container["command"] = list
+	delete(container, "args")
+}
+
+// appendEnv sets an env variable of a container, or appends to its value if it's already set.
+func appendEnv(container map[interface{}]interface{}, name, value string) {
+	vars, _ := container["env"].([]interface{})
+	for _, v := range vars {
+		if m, ok := v.(map[interface{}]interface{}); ok && m["name"] == name {
+			old, _ := m["value"].(string)
+			m["value"] = strings.TrimSpace(old + " " + value)
+			return
+		}
+	}
+
+	container["env"] = append(vars, map[interface{}]interface{}{
+		"name":  name,
+		"value": value,
+	})
+}
+
+// addPort exposes the debug port of a container, unless it's already exposed.
+func addPort(container map[interface{}]interface{}, name string, port int) {
+	ports, _ := container["ports"].([]interface{})
+	for _, p := range ports {
+		if m, ok := p.(map[interface{}]interface{}); ok && m["containerPort"] == port {
+			return
+		}
+	}
+
+	container["ports"] = append(ports, map[interface{}]interface{}{
+		"name":          name,
+		"containerPort": port,
+	})
+}
@@ -0,0 +1,252 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/google/go-containerregistry/pkg/v1"
+)
+
+var imageConfigs = map[string]v1.Config{
+	"java:tag":       {Env: []string{"JAVA_VERSION=8"}, Entrypoint: []string{"java", "-jar", "app.jar"}},
+	"node:tag":       {Cmd: []string{"node", "server.js"}},
+	"npm:tag":        {Env: []string{"NODE_VERSION=10"}, Cmd: []string{"npm", "start"}},
+	"python:tag":     {Env: []string{"PYTHON_VERSION=3.7"}, Cmd: []string{"python", "app.py"}},
+	"gunicorn:tag":   {Env: []string{"PYTHON_VERSION=3.7"}, Cmd: []string{"gunicorn", "app"}},
+	"go:tag":         {Env: []string{"GOTRACEBACK=single"}, Entrypoint: []string{"/app"}, Cmd: []string{"--port", "8080"}},
+	"unknown:tag":    {Entrypoint: []string{"/app"}},
+	"javaenv:tag":    {Env: []string{"JAVA_HOME=/jdk"}, Entrypoint: []string{"/start.sh"}},
+	"overridden:tag": {Entrypoint: []string{"/app"}, Cmd: []string{"arg"}},
+}
+
+func mockRetrieveImage(image string) (*v1.ConfigFile, error) {
+	if cfg, present := imageConfigs[image]; present {
+		return &v1.ConfigFile{Config: cfg}, nil
+	}
+	return nil, fmt.Errorf("no image found for %s", image)
+}
+
+func podWithContainer(container string) string {
+	return `apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+` + container
+}
+
+func TestApplyDebuggingTransforms(t *testing.T) {
+	var tests = []struct {
+		description string
+		image       string
+		container   string
+		expected    string
+	}{
+		{
+			description: "java",
+			image:       "java:tag",
+			container: `  - image: java:tag
+    name: app
+`,
+			expected: `  - env:
+    - name: JAVA_TOOL_OPTIONS
+      value: -agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=5005
+    image: java:tag
+    name: app
+    ports:
+    - containerPort: 5005
+      name: jdwp
+`,
+		},
+		{
+			description: "java with existing options and port",
+			image:       "javaenv:tag",
+			container: `  - env:
+    - name: JAVA_TOOL_OPTIONS
+      value: -Xmx1g
+    image: javaenv:tag
+    name: app
+    ports:
+    - containerPort: 5005
+`,
+			expected: `  - env:
+    - name: JAVA_TOOL_OPTIONS
+      value: -Xmx1g -agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=5005
+    image: javaenv:tag
+    name: app
+    ports:
+    - containerPort: 5005
+`,
+		},
+		{
+			description: "node",
+			image:       "node:tag",
+			container: `  - image: node:tag
+    name: app
+`,
+			expected: `  - command:
+    - node
+    - --inspect=0.0.0.0:9229
+    - server.js
+    image: node:tag
+    name: app
+    ports:
+    - containerPort: 9229
+      name: devtools
+`,
+		},
+		{
+			description: "npm",
+			image:       "npm:tag",
+			container: `  - image: npm:tag
+    name: app
+`,
+			expected: `  - env:
+    - name: NODE_OPTIONS
+      value: --inspect=0.0.0.0:9229
+    image: npm:tag
+    name: app
+    ports:
+    - containerPort: 9229
+      name: devtools
+`,
+		},
+		{
+			description: "python",
+			image:       "python:tag",
+			container: `  - image: python:tag
+    name: app
+`,
+			expected: `  - command:
+    - python
+    - -m
+    - ptvsd
+    - --host
+    - 0.0.0.0
+    - --port
+    - "5678"
+    - app.py
+    image: python:tag
+    name: app
+    ports:
+    - containerPort: 5678
+      name: dap
+`,
+		},
+		{
+			description: "python without python command",
+			image:       "gunicorn:tag",
+			container: `  - image: gunicorn:tag
+    name: app
+`,
+			expected: `  - image: gunicorn:tag
+    name: app
+`,
+		},
+		{
+			description: "go",
+			image:       "go:tag",
+			container: `  - image: go:tag
+    name: app
+`,
+			expected: `  - command:
+    - dlv
+    - exec
+    - --headless
+    - --continue
+    - --accept-multiclient
+    - --listen=:56268
+    - --api-version=2
+    - /app
+    - --
+    - --port
+    - "8080"
+    image: go:tag
+    name: app
+    ports:
+    - containerPort: 56268
+      name: dlv
+`,
+		},
+		{
+			description: "container command overrides the image",
+			image:       "overridden:tag",
+			container: `  - args:
+    - --inspect
+    command:
+    - node
+    image: overridden:tag
+    name: app
+`,
+			expected: `  - command:
+    - node
+    - --inspect=0.0.0.0:9229
+    - --inspect
+    image: overridden:tag
+    name: app
+    ports:
+    - containerPort: 9229
+      name: devtools
+`,
+		},
+		{
+			description: "unknown runtime",
+			image:       "unknown:tag",
+			container: `  - image: unknown:tag
+    name: app
+`,
+			expected: `  - image: unknown:tag
+    name: app
+`,
+		},
+		{
+			description: "image not built",
+			image:       "other:tag",
+			container: `  - image: java:tag
+    name: app
+`,
+			expected: `  - image: java:tag
+    name: app
+`,
+		},
+	}
+
+	defer func(r func(string) (*v1.ConfigFile, error)) { docker.RetrieveImage = r }(docker.RetrieveImage)
+	docker.RetrieveImage = mockRetrieveImage
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			builds := []build.Build{{ImageName: "image", Tag: test.image}}
+
+			manifests, err := ApplyDebuggingTransforms([][]byte{[]byte(podWithContainer(test.container))}, builds)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, podWithContainer(test.expected), string(manifests[0]))
+		})
+	}
+}
+
+func TestApplyDebuggingTransformsBadYaml(t *testing.T) {
+	_, err := ApplyDebuggingTransforms([][]byte{[]byte("bad:\nyaml")}, nil)
+
+	testutil.CheckError(t, true, err)
+}
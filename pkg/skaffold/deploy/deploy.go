@@ -37,6 +37,25 @@ type Deployer interface {
 
 	// Cleanup deletes what was deployed by calling Deploy.
 	Cleanup(context.Context, io.Writer) error
+
+	// Render writes the manifests, with the images of the build results,
+	// to the writer instead of deploying them.
+	Render(context.Context, io.Writer, []build.Build) error
+}
+
+// ManifestTransform transforms the manifests, with the images of the build results,
+// before they are deployed or rendered.
+type ManifestTransform func(manifests [][]byte, builds []build.Build) ([][]byte, error)
+
+func applyManifestTransforms(manifests manifestList, transforms []ManifestTransform, builds []build.Build) (manifestList, error) {
+	for _, transform := range transforms {
+		transformed, err := transform(manifests, builds)
+		if err != nil {
+			return nil, err
+		}
+		manifests = transformed
+	}
+	return manifests, nil
 }
 
 func JoinTagsToBuildResult(builds []build.Build, params map[string]string) (map[string]build.Build, error) {
@@ -0,0 +1,72 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/sirupsen/logrus"
+)
+
+// remoteDigest is replaced in tests.
+var remoteDigest = docker.RemoteDigest
+
+// digestDeployer deploys the images by their digest in the registry.
+type digestDeployer struct {
+	Deployer
+}
+
+// WithDigests makes a deployer reference the images by their immutable digest,
+// eg. `gcr.io/project/app:v1@sha256:...`, in manifests and helm values, so that
+// the cluster runs exactly what was built even if the tag is overwritten.
+// Images that were not pushed, and have no digest in a registry, are deployed by tag.
+func WithDigests(d Deployer) Deployer {
+	return &digestDeployer{Deployer: d}
+}
+
+func (d *digestDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Build) error {
+	return d.Deployer.Deploy(ctx, out, withDigests(builds))
+}
+
+func (d *digestDeployer) Render(ctx context.Context, out io.Writer, builds []build.Build) error {
+	return d.Deployer.Render(ctx, out, withDigests(builds))
+}
+
+func withDigests(builds []build.Build) []build.Build {
+	var digested []build.Build
+	for _, b := range builds {
+		switch {
+		case strings.Contains(b.Tag, "@"):
+			// Already deployed by digest.
+		case b.Digest != "":
+			b.Tag = b.Tag + "@" + b.Digest
+		default:
+			digest, err := remoteDigest(b.Tag)
+			if err != nil {
+				logrus.Warnf("Deploying %s by tag, unable to get its digest: %s", b.Tag, err)
+			} else {
+				b.Tag = b.Tag + "@" + digest
+			}
+		}
+		digested = append(digested, b)
+	}
+	return digested
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type buildsRecorder struct {
+	Deployer
+	deployed []build.Build
+}
+
+func (r *buildsRecorder) Deploy(ctx context.Context, out io.Writer, builds []build.Build) error {
+	r.deployed = builds
+	return nil
+}
+
+func TestWithDigests(t *testing.T) {
+	defer func(f func(string) (string, error)) { remoteDigest = f }(remoteDigest)
+	remoteDigest = func(image string) (string, error) {
+		if image == "local:abcd" {
+			return "", fmt.Errorf("not found")
+		}
+		return "sha256:123", nil
+	}
+
+	recorder := &buildsRecorder{}
+	err := WithDigests(recorder).Deploy(context.Background(), ioutil.Discard, []build.Build{
+		{ImageName: "gcr.io/project/app", Tag: "gcr.io/project/app:v1"},
+		{ImageName: "local", Tag: "local:abcd"},
+		{ImageName: "digested", Tag: "digested@sha256:456"},
+		{ImageName: "known", Tag: "known:v2", Digest: "sha256:789"},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []build.Build{
+		{ImageName: "gcr.io/project/app", Tag: "gcr.io/project/app:v1@sha256:123"},
+		{ImageName: "local", Tag: "local:abcd"},
+		{ImageName: "digested", Tag: "digested@sha256:456"},
+		{ImageName: "known", Tag: "known:v2@sha256:789", Digest: "sha256:789"},
+	}, recorder.deployed)
+}
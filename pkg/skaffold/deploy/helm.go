@@ -20,8 +20,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
@@ -63,13 +66,17 @@ func (h *HelmDeployer) Dependencies() ([]string, error) {
 }
 
 // Cleanup deletes what was deployed by calling Deploy.
+// Every release is deleted, even if deleting one of them fails.
 func (h *HelmDeployer) Cleanup(ctx context.Context, out io.Writer) error {
+	var errs []string
 	for _, r := range h.HelmDeploy.Releases {
 		if err := h.deleteRelease(out, r); err != nil {
-			releaseName, _ := evaluateReleaseName(r.Name)
-			return errors.Wrapf(err, "deploying %s", releaseName)
+			errs = append(errs, err.Error())
 		}
 	}
+	if len(errs) > 0 {
+		return fmt.Errorf("deleting releases: %s", strings.Join(errs, ", "))
+	}
 	return nil
 }
 
@@ -94,9 +101,117 @@ func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, buil
 		fmt.Fprintf(out, "Helm release %s not installed. Installing...\n", releaseName)
 		isInstalled = false
 	}
+	setOpts, err := setFlags(r, builds)
+	if err != nil {
+		return err
+	}
+
+	// First build dependencies of local charts.
+	if shouldBuildDependencies(r) {
+		logrus.Infof("Building helm dependencies...")
+		if err := h.helm(out, "dep", "build", r.ChartPath); err != nil {
+			return errors.Wrap(err, "building helm dependencies")
+		}
+	}
+
+	var args []string
+	if !isInstalled {
+		args = append(args, "install", "--name", releaseName, r.ChartPath)
+	} else {
+		args = append(args, "upgrade", releaseName, r.ChartPath)
+	}
+
+	valuesOpts, err := h.valuesFlags(r)
+	if err != nil {
+		return err
+	}
+	defer removeOverrides(r)
+	args = append(args, valuesOpts...)
+
+	if r.Version != "" {
+		version, err := evaluateEnvTemplate(r.Version)
+		if err != nil {
+			return errors.Wrap(err, "cannot parse the version template")
+		}
+		args = append(args, "--version", version)
+	}
+	if r.Wait {
+		args = append(args, "--wait")
+	}
+	args = append(args, setOpts...)
+
+	if err := h.helm(out, args...); err != nil {
+		return err
+	}
+
+	return h.labelRelease(out, releaseName, r)
+}
+
+// labelRelease labels and annotates the resources of a release, since helm can't.
+func (h *HelmDeployer) labelRelease(out io.Writer, releaseName string, r v1alpha2.HelmRelease) error {
+	labels := resourceLabels(h.DeployConfig)
+	if len(labels) == 0 && len(h.Annotations) == 0 {
+		return nil
+	}
+
+	cmd := exec.Command("helm", "--kube-context", h.kubeContext, "get", "manifest", releaseName)
+	manifest, err := util.RunCmdOut(cmd)
+	if err != nil {
+		return errors.Wrap(err, "getting the manifest of the release")
+	}
+
+	return labelResources(manifest, out, h.kubeContext, h.releaseNamespace(r), labels, h.Annotations)
+}
+
+// Render writes the manifests of the releases, rendered by `helm template`, to out.
+// Remote charts are not supported since `helm template` only renders local charts.
+func (h *HelmDeployer) Render(ctx context.Context, out io.Writer, builds []build.Build) error {
+	for _, r := range h.HelmDeploy.Releases {
+		releaseName, err := evaluateReleaseName(r.Name)
+		if err != nil {
+			return errors.Wrap(err, "cannot parse the release name template")
+		}
+		if r.Remote {
+			return fmt.Errorf("rendering remote chart %s of %s is not supported", r.ChartPath, releaseName)
+		}
+
+		if err := h.renderRelease(out, releaseName, r, builds); err != nil {
+			return errors.Wrapf(err, "rendering %s", releaseName)
+		}
+	}
+	return nil
+}
+
+func (h *HelmDeployer) renderRelease(out io.Writer, releaseName string, r v1alpha2.HelmRelease, builds []build.Build) error {
+	setOpts, err := setFlags(r, builds)
+	if err != nil {
+		return err
+	}
+
+	valuesOpts, err := h.valuesFlags(r)
+	if err != nil {
+		return err
+	}
+	defer removeOverrides(r)
+
+	args := []string{"--kube-context", h.kubeContext, "template", r.ChartPath, "--name", releaseName}
+	args = append(args, valuesOpts...)
+	args = append(args, setOpts...)
+
+	manifests, err := util.RunCmdOut(exec.Command("helm", args...))
+	if err != nil {
+		return errors.Wrap(err, "running helm template")
+	}
+
+	_, err = out.Write(manifests)
+	return err
+}
+
+// setFlags returns the `--set` flags of a release: the image of each value, then the set values.
+func setFlags(r v1alpha2.HelmRelease, builds []build.Build) ([]string, error) {
 	params, err := JoinTagsToBuildResult(builds, r.Values)
 	if err != nil {
-		return errors.Wrap(err, "matching build results to chart values")
+		return nil, errors.Wrap(err, "matching build results to chart values")
 	}
 
 	var setOpts []string
@@ -105,67 +220,65 @@ func (h *HelmDeployer) deployRelease(out io.Writer, r v1alpha2.HelmRelease, buil
 		setOpts = append(setOpts, fmt.Sprintf("%s=%s", k, v.Tag))
 	}
 
-	// First build dependencies.
-	logrus.Infof("Building helm dependencies...")
-	if err := h.helm(out, "dep", "build", r.ChartPath); err != nil {
-		return errors.Wrap(err, "building helm dependencies")
+	var keys []string
+	for k := range r.SetValues {
+		keys = append(keys, k)
 	}
-
-	var args []string
-	if !isInstalled {
-		args = append(args, "install", "--name", releaseName, r.ChartPath)
-	} else {
-		args = append(args, "upgrade", releaseName, r.ChartPath)
+	sort.Strings(keys)
+	for _, k := range keys {
+		setOpts = append(setOpts, "--set")
+		setOpts = append(setOpts, fmt.Sprintf("%s=%s", k, r.SetValues[k]))
 	}
 
-	var ns string
-	if h.namespace != "" {
-		ns = h.namespace
-	} else if r.Namespace != "" {
-		ns = r.Namespace
-	} else {
-		ns = os.Getenv("SKAFFOLD_DEPLOY_NAMESPACE")
+	return setOpts, nil
+}
+
+// releaseNamespace is the namespace a release is deployed into.
+func (h *HelmDeployer) releaseNamespace(r v1alpha2.HelmRelease) string {
+	switch {
+	case h.namespace != "":
+		return h.namespace
+	case r.Namespace != "":
+		return r.Namespace
+	default:
+		return os.Getenv("SKAFFOLD_DEPLOY_NAMESPACE")
 	}
-	if ns != "" {
+}
+
+// valuesFlags returns the namespace and values files flags of a release. Overrides are
+// written to skaffold-overrides.yaml, that should be removed with removeOverrides.
+func (h *HelmDeployer) valuesFlags(r v1alpha2.HelmRelease) ([]string, error) {
+	var args []string
+
+	if ns := h.releaseNamespace(r); ns != "" {
 		args = append(args, "--namespace", ns)
 	}
 	if len(r.Overrides) != 0 {
 		overrides, err := yaml.Marshal(r.Overrides)
 		if err != nil {
-			return errors.Wrap(err, "cannot marshal overrides to create overrides values.yaml")
+			return nil, errors.Wrap(err, "cannot marshal overrides to create overrides values.yaml")
 		}
 		overridesFile, err := os.Create("skaffold-overrides.yaml")
 		if err != nil {
-			return errors.Wrap(err, "cannot create file skaffold-overrides.yaml")
+			return nil, errors.Wrap(err, "cannot create file skaffold-overrides.yaml")
 		}
+		defer overridesFile.Close()
 		if _, err := overridesFile.WriteString(string(overrides)); err != nil {
-			return errors.Wrap(err, "failed to write file skaffold-overrides.yaml")
+			return nil, errors.Wrap(err, "failed to write file skaffold-overrides.yaml")
 		}
 		args = append(args, "-f", "skaffold-overrides.yaml")
 	}
 	if r.ValuesFilePath != "" {
 		args = append(args, "-f", r.ValuesFilePath)
 	}
-	if r.Version != "" {
-		args = append(args, "--version", r.Version)
-	}
 
-	if len(r.SetValues) != 0 {
-		for k, v := range r.SetValues {
-			setOpts = append(setOpts, "--set")
-			setOpts = append(setOpts, fmt.Sprintf("%s=%s", k, v))
-		}
-	}
-	if r.Wait {
-		args = append(args, "--wait")
-	}
-	args = append(args, setOpts...)
+	return args, nil
+}
 
-	helmErr := h.helm(out, args...)
+func removeOverrides(r v1alpha2.HelmRelease) {
 	if len(r.Overrides) != 0 {
 		os.Remove("skaffold-overrides.yaml")
 	}
-	return helmErr
 }
 
 func (h *HelmDeployer) deleteRelease(out io.Writer, r v1alpha2.HelmRelease) error {
@@ -174,16 +287,30 @@ func (h *HelmDeployer) deleteRelease(out io.Writer, r v1alpha2.HelmRelease) erro
 		return errors.Wrap(err, "cannot parse the release name template")
 	}
 
+	if err := h.helm(ioutil.Discard, "get", releaseName); err != nil {
+		logrus.Debugf("release %s is not installed, skipping deletion", releaseName)
+		return nil
+	}
+
 	if err := h.helm(out, "delete", releaseName, "--purge"); err != nil {
-		logrus.Debugf("deleting release %s: %v\n", releaseName, err)
+		return errors.Wrapf(err, "deleting %s", releaseName)
 	}
 
 	return nil
 }
 
+// shouldBuildDependencies tells if `helm dep build` should be run for a release.
+// Packaged charts and charts from a remote repository already contain their dependencies.
+func shouldBuildDependencies(r v1alpha2.HelmRelease) bool {
+	return !r.SkipBuildDependencies && !r.Remote && !strings.HasSuffix(r.ChartPath, ".tgz")
+}
+
 func evaluateReleaseName(nameTemplate string) (string, error) {
+	return evaluateEnvTemplate(nameTemplate)
+}
 
-	tmpl, err := util.ParseEnvTemplate(nameTemplate)
+func evaluateEnvTemplate(s string) (string, error) {
+	tmpl, err := util.ParseEnvTemplate(s)
 	if err != nil {
 		return "", errors.Wrap(err, "parsing template")
 	}
@@ -20,7 +20,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
@@ -74,6 +76,54 @@ var testDeployConfigParameterUnmatched = &v1alpha2.DeployConfig{
 	},
 }
 
+var testDeployConfigPackaged = &v1alpha2.DeployConfig{
+	DeployType: v1alpha2.DeployType{
+		HelmDeploy: &v1alpha2.HelmDeploy{
+			Releases: []v1alpha2.HelmRelease{
+				{
+					Name:      "skaffold-helm",
+					ChartPath: "https://charts.example.com/skaffold-helm-1.2.3.tgz",
+					Version:   "{{.CHART_VERSION}}",
+					SetValues: map[string]string{
+						"b": "2",
+						"a": "1",
+					},
+				},
+			},
+		},
+	},
+}
+
+var testDeployConfigSkipDependencies = &v1alpha2.DeployConfig{
+	DeployType: v1alpha2.DeployType{
+		HelmDeploy: &v1alpha2.HelmDeploy{
+			Releases: []v1alpha2.HelmRelease{
+				{
+					Name:                  "skaffold-helm",
+					ChartPath:             "examples/test",
+					SkipBuildDependencies: true,
+				},
+			},
+		},
+	},
+}
+
+var testDeployConfigRender = &v1alpha2.DeployConfig{
+	DeployType: v1alpha2.DeployType{
+		HelmDeploy: &v1alpha2.HelmDeploy{
+			Releases: []v1alpha2.HelmRelease{
+				{
+					Name:      "skaffold-helm",
+					ChartPath: "examples/test",
+					Values: map[string]string{
+						"image.tag": "skaffold-helm",
+					},
+				},
+			},
+		},
+	},
+}
+
 var testNamespace = "testNamespace"
 
 func TestHelmDeploy(t *testing.T) {
@@ -136,6 +186,24 @@ func TestHelmDeploy(t *testing.T) {
 			deployer:  NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace),
 			builds:    testBuilds,
 		},
+		{
+			description: "skip dep build",
+			cmd: &MockHelm{
+				t:         t,
+				depResult: fmt.Errorf("should not have called dep build"),
+			},
+			deployer: NewHelmDeployer(testDeployConfigSkipDependencies, testKubeContext, testNamespace),
+			builds:   testBuilds,
+		},
+		{
+			description: "no dep build for packaged charts",
+			cmd: &MockHelm{
+				t:         t,
+				depResult: fmt.Errorf("should not have called dep build"),
+			},
+			deployer: NewHelmDeployer(testDeployConfigPackaged, testKubeContext, testNamespace),
+			builds:   testBuilds,
+		},
 	}
 
 	for _, tt := range tests {
@@ -149,6 +217,91 @@ func TestHelmDeploy(t *testing.T) {
 	}
 }
 
+func TestHelmDeployVersionTemplate(t *testing.T) {
+	cmd := &MockHelm{t: t}
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = cmd
+
+	os.Setenv("CHART_VERSION", "1.2.3")
+	defer os.Unsetenv("CHART_VERSION")
+
+	err := NewHelmDeployer(testDeployConfigPackaged, testKubeContext, testNamespace).Deploy(context.Background(), &bytes.Buffer{}, testBuilds)
+
+	expected := "helm --kube-context kubecontext upgrade skaffold-helm https://charts.example.com/skaffold-helm-1.2.3.tgz --namespace testNamespace --version 1.2.3 --set a=1 --set b=2"
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, cmd.deployed)
+}
+
+func TestHelmCleanup(t *testing.T) {
+	var tests = []struct {
+		description string
+		cmd         *MockHelm
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "delete installed release",
+			cmd:         &MockHelm{t: t},
+			expected:    []string{"skaffold-helm"},
+		},
+		{
+			description: "skip release that is not installed",
+			cmd: &MockHelm{
+				t:         t,
+				getResult: fmt.Errorf("not found"),
+			},
+		},
+		{
+			description: "delete error",
+			cmd: &MockHelm{
+				t:            t,
+				deleteResult: fmt.Errorf("unexpected error"),
+			},
+			expected:  []string{"skaffold-helm"},
+			shouldErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = tt.cmd
+
+			err := NewHelmDeployer(testDeployConfig, testKubeContext, testNamespace).Cleanup(context.Background(), &bytes.Buffer{})
+			testutil.CheckErrorAndDeepEqual(t, tt.shouldErr, err, tt.expected, tt.cmd.deleted)
+		})
+	}
+}
+
+func TestHelmRender(t *testing.T) {
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut("helm --kube-context kubecontext template examples/test --name skaffold-helm --namespace testNamespace --set image.tag=skaffold-helm:3605e7bc17cf46e53f4d81c4cbc24e5b4c495184", "manifests", nil)
+
+	var out bytes.Buffer
+	err := NewHelmDeployer(testDeployConfigRender, testKubeContext, testNamespace).Render(context.Background(), &out, testBuilds)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "manifests", out.String())
+}
+
+func TestHelmRenderRemoteChart(t *testing.T) {
+	cfg := &v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			HelmDeploy: &v1alpha2.HelmDeploy{
+				Releases: []v1alpha2.HelmRelease{
+					{
+						Name:      "nginx",
+						ChartPath: "stable/nginx",
+						Remote:    true,
+					},
+				},
+			},
+		},
+	}
+
+	err := NewHelmDeployer(cfg, testKubeContext, testNamespace).Render(context.Background(), &bytes.Buffer{}, nil)
+
+	testutil.CheckError(t, true, err)
+}
+
 type MockHelm struct {
 	t *testing.T
 
@@ -156,6 +309,10 @@ type MockHelm struct {
 	installResult error
 	upgradeResult error
 	depResult     error
+	deleteResult  error
+
+	deployed string
+	deleted  []string
 }
 
 func (m *MockHelm) RunCmdOut(c *exec.Cmd) ([]byte, error) {
@@ -176,11 +333,16 @@ func (m *MockHelm) RunCmd(c *exec.Cmd) error {
 	case "get":
 		return m.getResult
 	case "install":
+		m.deployed = strings.Join(c.Args, " ")
 		return m.installResult
 	case "upgrade":
+		m.deployed = strings.Join(c.Args, " ")
 		return m.upgradeResult
 	case "dep":
 		return m.depResult
+	case "delete":
+		m.deleted = append(m.deleted, c.Args[4])
+		return m.deleteResult
 	default:
 		m.t.Errorf("Unknown helm command: %+v", c)
 		return nil
@@ -19,6 +19,7 @@ package deploy
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os/exec"
@@ -39,15 +40,20 @@ type KubectlDeployer struct {
 
 	workingDir  string
 	kubeContext string
+	namespace   string
+	transforms  []ManifestTransform
 }
 
 // NewKubectlDeployer returns a new KubectlDeployer for a DeployConfig filled
-// with the needed configuration for `kubectl apply`
-func NewKubectlDeployer(workingDir string, cfg *v1alpha2.DeployConfig, kubeContext string) *KubectlDeployer {
+// with the needed configuration for `kubectl apply`. The transforms are applied
+// to the manifests, after their images are replaced.
+func NewKubectlDeployer(workingDir string, cfg *v1alpha2.DeployConfig, kubeContext string, namespace string, transforms ...ManifestTransform) *KubectlDeployer {
 	return &KubectlDeployer{
 		DeployConfig: cfg,
 		workingDir:   workingDir,
 		kubeContext:  kubeContext,
+		namespace:    namespace,
+		transforms:   transforms,
 	}
 }
 
@@ -64,13 +70,43 @@ func (k *KubectlDeployer) Deploy(ctx context.Context, out io.Writer, builds []bu
 		return errors.Wrap(err, "replacing images in manifests")
 	}
 
-	if err := kubectl(manifests.reader(), out, k.kubeContext, "apply", "-f", "-"); err != nil {
+	manifests, err = applyManifestTransforms(manifests, k.transforms, builds)
+	if err != nil {
+		return errors.Wrap(err, "transforming manifests")
+	}
+
+	manifests, err = manifests.setLabels(resourceLabels(k.DeployConfig), k.Annotations)
+	if err != nil {
+		return errors.Wrap(err, "labelling manifests")
+	}
+
+	if err := kubectl(manifests.reader(), out, k.kubeContext, withNamespace(k.namespace, "apply", "-f", "-")...); err != nil {
 		return errors.Wrap(err, "deploying manifests")
 	}
 
 	return nil
 }
 
+// Render writes the manifests, with their images replaced, to out.
+func (k *KubectlDeployer) Render(ctx context.Context, out io.Writer, builds []build.Build) error {
+	manifests, err := k.readManifests()
+	if err != nil {
+		return errors.Wrap(err, "reading manifests")
+	}
+
+	manifests, err = manifests.replaceImages(builds)
+	if err != nil {
+		return errors.Wrap(err, "replacing images in manifests")
+	}
+
+	manifests, err = applyManifestTransforms(manifests, k.transforms, builds)
+	if err != nil {
+		return errors.Wrap(err, "transforming manifests")
+	}
+
+	return writeManifests(out, manifests)
+}
+
 // Cleanup deletes what was deployed by calling Deploy.
 func (k *KubectlDeployer) Cleanup(ctx context.Context, out io.Writer) error {
 	manifests, err := k.readManifests()
@@ -78,7 +114,8 @@ func (k *KubectlDeployer) Cleanup(ctx context.Context, out io.Writer) error {
 		return errors.Wrap(err, "reading manifests")
 	}
 
-	if err := kubectl(manifests.reader(), out, k.kubeContext, "delete", "-f", "-"); err != nil {
+	// Resources that were already deleted, eg. by a previous cleanup, are ignored.
+	if err := kubectl(manifests.reader(), out, k.kubeContext, withNamespace(k.namespace, "delete", "--ignore-not-found=true", "-f", "-")...); err != nil {
 		return errors.Wrap(err, "deleting manifests")
 	}
 
@@ -100,6 +137,14 @@ func kubectl(in io.Reader, out io.Writer, kubeContext string, arg ...string) err
 	return util.RunCmd(cmd)
 }
 
+// withNamespace prepends the namespace flag to kubectl arguments.
+func withNamespace(namespace string, args ...string) []string {
+	if namespace == "" {
+		return args
+	}
+	return append([]string{"--namespace", namespace}, args...)
+}
+
 func (k *KubectlDeployer) manifestFiles(manifests []string) ([]string, error) {
 	list, err := util.ExpandPathsGlob(k.workingDir, manifests)
 	if err != nil {
@@ -160,6 +205,8 @@ func (k *KubectlDeployer) readRemoteManifest(name string) ([]byte, error) {
 	if parts := strings.Split(name, ":"); len(parts) > 1 {
 		args = append(args, "--namespace", parts[0])
 		name = parts[1]
+	} else if k.namespace != "" {
+		args = append(args, "--namespace", k.namespace)
 	}
 	args = append(args, "get", name, "-o", "yaml")
 
@@ -190,6 +237,11 @@ func (l *manifestList) String() string {
 	return str
 }
 
+func writeManifests(out io.Writer, manifests manifestList) error {
+	_, err := fmt.Fprintln(out, manifests.String())
+	return err
+}
+
 func (l *manifestList) reader() io.Reader {
 	return strings.NewReader(l.String())
 }
@@ -60,6 +60,7 @@ func TestKubectlDeploy(t *testing.T) {
 	var tests = []struct {
 		description string
 		cfg         *v1alpha2.DeployConfig
+		namespace   string
 		builds      []build.Build
 		command     util.Command
 		shouldErr   bool
@@ -115,6 +116,24 @@ func TestKubectlDeploy(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "deploy into namespace",
+			cfg: &v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					KubectlDeploy: &v1alpha2.KubectlDeploy{
+						Manifests: []string{"test/deployment.yaml"},
+					},
+				},
+			},
+			namespace: "dev-jane",
+			command:   testutil.NewFakeCmd("kubectl --context kubecontext --namespace dev-jane apply -f -", nil),
+			builds: []build.Build{
+				{
+					ImageName: "leeroy-web",
+					Tag:       "leeroy-web:123",
+				},
+			},
+		},
 		{
 			description: "deploy command error",
 			shouldErr:   true,
@@ -148,7 +167,7 @@ func TestKubectlDeploy(t *testing.T) {
 				util.DefaultExecCommand = test.command
 			}
 
-			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext)
+			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext, test.namespace)
 			err := k.Deploy(context.Background(), &bytes.Buffer{}, test.builds)
 
 			testutil.CheckError(t, test.shouldErr, err)
@@ -156,6 +175,82 @@ func TestKubectlDeploy(t *testing.T) {
 	}
 }
 
+func TestKubectlRender(t *testing.T) {
+	tmp, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	os.MkdirAll(filepath.Join(tmp, "test"), 0750)
+	ioutil.WriteFile(filepath.Join(tmp, "test", "deployment.yaml"), []byte(deploymentYAML), 0644)
+
+	cfg := &v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			KubectlDeploy: &v1alpha2.KubectlDeploy{
+				Manifests: []string{"test/deployment.yaml"},
+			},
+		},
+	}
+	builds := []build.Build{
+		{
+			ImageName: "leeroy-web",
+			Tag:       "leeroy-web:123",
+		},
+	}
+
+	var out bytes.Buffer
+	err := NewKubectlDeployer(tmp, cfg, testKubeContext, "").Render(context.Background(), &out, builds)
+
+	expected := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  labels:
+    app: leeroy-web
+  name: leeroy-web
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: leeroy-web
+  template:
+    metadata:
+      labels:
+        app: leeroy-web
+    spec:
+      containers:
+      - image: leeroy-web:123
+        name: leeroy-web
+        ports:
+        - containerPort: 8080
+`
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, out.String())
+}
+
+func TestKubectlRenderWithTransform(t *testing.T) {
+	var transformed []build.Build
+	transform := func(manifests [][]byte, builds []build.Build) ([][]byte, error) {
+		transformed = builds
+		return [][]byte{[]byte("transformed")}, nil
+	}
+
+	tmp, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	ioutil.WriteFile(filepath.Join(tmp, "deployment.yaml"), []byte(deploymentYAML), 0644)
+
+	cfg := &v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{
+			KubectlDeploy: &v1alpha2.KubectlDeploy{
+				Manifests: []string{"deployment.yaml"},
+			},
+		},
+	}
+	builds := []build.Build{{ImageName: "leeroy-web", Tag: "leeroy-web:123"}}
+
+	var out bytes.Buffer
+	err := NewKubectlDeployer(tmp, cfg, testKubeContext, "", transform).Render(context.Background(), &out, builds)
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "transformed\n", out.String())
+	testutil.CheckErrorAndDeepEqual(t, false, nil, builds, transformed)
+}
+
 func TestKubectlCleanup(t *testing.T) {
 	var tests = []struct {
 		description string
@@ -172,7 +267,7 @@ func TestKubectlCleanup(t *testing.T) {
 					},
 				},
 			},
-			command: testutil.NewFakeCmd("kubectl --context kubecontext delete -f -", nil),
+			command: testutil.NewFakeCmd("kubectl --context kubecontext delete --ignore-not-found=true -f -", nil),
 		},
 		{
 			description: "cleanup error",
@@ -183,7 +278,7 @@ func TestKubectlCleanup(t *testing.T) {
 					},
 				},
 			},
-			command:   testutil.NewFakeCmd("kubectl --context kubecontext delete -f -", errors.New("BUG")),
+			command:   testutil.NewFakeCmd("kubectl --context kubecontext delete --ignore-not-found=true -f -", errors.New("BUG")),
 			shouldErr: true,
 		},
 	}
@@ -201,7 +296,7 @@ func TestKubectlCleanup(t *testing.T) {
 				util.DefaultExecCommand = test.command
 			}
 
-			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext)
+			k := NewKubectlDeployer(tmp, test.cfg, testKubeContext, "")
 			err := k.Cleanup(context.Background(), &bytes.Buffer{})
 
 			testutil.CheckError(t, test.shouldErr, err)
@@ -19,31 +19,42 @@ package deploy
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
 	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
 )
 
 type KustomizeDeployer struct {
 	*v1alpha2.DeployConfig
 	kubeContext string
+	namespace   string
+	transforms  []ManifestTransform
 }
 
-func NewKustomizeDeployer(cfg *v1alpha2.DeployConfig, kubeContext string) *KustomizeDeployer {
+// NewKustomizeDeployer returns a new KustomizeDeployer. The transforms are applied
+// to the built manifests, after their images are replaced.
+func NewKustomizeDeployer(cfg *v1alpha2.DeployConfig, kubeContext string, namespace string, transforms ...ManifestTransform) *KustomizeDeployer {
 	return &KustomizeDeployer{
 		DeployConfig: cfg,
 		kubeContext:  kubeContext,
+		namespace:    namespace,
+		transforms:   transforms,
 	}
 }
 
 func (k *KustomizeDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Build) error {
-	manifests, err := buildManifests(constants.DefaultKustomizationPath)
+	manifests, err := buildManifests(k.kustomizePath())
 	if err != nil {
 		return errors.Wrap(err, "kustomize")
 	}
@@ -55,12 +66,41 @@ func (k *KustomizeDeployer) Deploy(ctx context.Context, out io.Writer, builds []
 	if err != nil {
 		return errors.Wrap(err, "replacing images")
 	}
-	if err := kubectl(manifestList.reader(), out, k.kubeContext, "apply", "-f", "-"); err != nil {
+	manifestList, err = applyManifestTransforms(manifestList, k.transforms, builds)
+	if err != nil {
+		return errors.Wrap(err, "transforming manifests")
+	}
+	manifestList, err = manifestList.setLabels(resourceLabels(k.DeployConfig), k.Annotations)
+	if err != nil {
+		return errors.Wrap(err, "labelling manifests")
+	}
+	if err := kubectl(manifestList.reader(), out, k.kubeContext, withNamespace(k.namespace, "apply", "-f", "-")...); err != nil {
 		return errors.Wrap(err, "running kubectl")
 	}
 	return nil
 }
 
+// Render writes the manifests built by kustomize, with their images replaced, to out.
+func (k *KustomizeDeployer) Render(ctx context.Context, out io.Writer, builds []build.Build) error {
+	manifests, err := buildManifests(k.kustomizePath())
+	if err != nil {
+		return errors.Wrap(err, "kustomize")
+	}
+	manifestList, err := newManifestList(manifests)
+	if err != nil {
+		return errors.Wrap(err, "getting manifest list")
+	}
+	manifestList, err = manifestList.replaceImages(builds)
+	if err != nil {
+		return errors.Wrap(err, "replacing images")
+	}
+	manifestList, err = applyManifestTransforms(manifestList, k.transforms, builds)
+	if err != nil {
+		return errors.Wrap(err, "transforming manifests")
+	}
+	return writeManifests(out, manifestList)
+}
+
 func newManifestList(r io.Reader) (manifestList, error) {
 	var manifests manifestList
 	buf, err := ioutil.ReadAll(r)
@@ -77,19 +117,122 @@ func newManifestList(r io.Reader) (manifestList, error) {
 }
 
 func (k *KustomizeDeployer) Cleanup(ctx context.Context, out io.Writer) error {
-	manifests, err := buildManifests(constants.DefaultKustomizationPath)
+	manifests, err := buildManifests(k.kustomizePath())
 	if err != nil {
 		return errors.Wrap(err, "kustomize")
 	}
-	if err := kubectl(manifests, out, k.kubeContext, "delete", "-f", "-"); err != nil {
+	if err := kubectl(manifests, out, k.kubeContext, withNamespace(k.namespace, "delete", "--ignore-not-found=true", "-f", "-")...); err != nil {
 		return errors.Wrap(err, "kubectl delete")
 	}
 	return nil
 }
 
+// Dependencies lists the kustomization files of the kustomization and of its bases,
+// with the resources, patches and generator files they reference.
 func (k *KustomizeDeployer) Dependencies() ([]string, error) {
-	// TODO(r2d4): parse kustomization yaml and add base and patches as dependencies
-	return []string{constants.DefaultKustomizationPath}, nil
+	return dependenciesForKustomization(k.kustomizePath())
+}
+
+func (k *KustomizeDeployer) kustomizePath() string {
+	if k.KustomizeDeploy == nil || k.KustomizeDeploy.KustomizePath == "" {
+		return constants.DefaultKustomizationPath
+	}
+	return k.KustomizeDeploy.KustomizePath
+}
+
+// kustomization is the part of a kustomization.yaml that references other files.
+type kustomization struct {
+	Bases                 []string             `yaml:"bases"`
+	Resources             []string             `yaml:"resources"`
+	Patches               []string             `yaml:"patches"`
+	PatchesStrategicMerge []string             `yaml:"patchesStrategicMerge"`
+	PatchesJSON6902       []patchJSON6902      `yaml:"patchesJson6902"`
+	CRDs                  []string             `yaml:"crds"`
+	ConfigMapGenerator    []kustomizeGenerator `yaml:"configMapGenerator"`
+	SecretGenerator       []kustomizeGenerator `yaml:"secretGenerator"`
+}
+
+type patchJSON6902 struct {
+	Path string `yaml:"path"`
+}
+
+type kustomizeGenerator struct {
+	Files []string `yaml:"files"`
+}
+
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+func dependenciesForKustomization(dir string) ([]string, error) {
+	path, err := findKustomization(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+
+	var content kustomization
+	if err := yaml.Unmarshal(buf, &content); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", path)
+	}
+
+	deps := []string{path}
+
+	// Resources can also be directories of other kustomizations.
+	for _, base := range append(content.Bases, content.Resources...) {
+		if isRemoteBase(base) {
+			continue
+		}
+
+		basePath := filepath.Join(dir, base)
+		if info, err := os.Stat(basePath); err != nil || !info.IsDir() {
+			deps = append(deps, basePath)
+			continue
+		}
+
+		baseDeps, err := dependenciesForKustomization(basePath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting dependencies of base %s", base)
+		}
+		deps = append(deps, baseDeps...)
+	}
+
+	files := append(content.Patches, content.PatchesStrategicMerge...)
+	files = append(files, content.CRDs...)
+	for _, patch := range content.PatchesJSON6902 {
+		files = append(files, patch.Path)
+	}
+	for _, generator := range append(content.ConfigMapGenerator, content.SecretGenerator...) {
+		for _, file := range generator.Files {
+			// Files can be given a key, with `key=path`.
+			if i := strings.Index(file, "="); i != -1 {
+				file = file[i+1:]
+			}
+			files = append(files, file)
+		}
+	}
+	for _, file := range files {
+		deps = append(deps, filepath.Join(dir, file))
+	}
+
+	return deps, nil
+}
+
+func findKustomization(dir string) (string, error) {
+	for _, name := range kustomizationFiles {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no kustomization found in %s", dir)
+}
+
+// isRemoteBase tells if a base is a url, eg. `github.com/org/repo//path`.
+func isRemoteBase(base string) bool {
+	return strings.Contains(base, "://") || strings.HasPrefix(base, "github.com/")
 }
 
 func buildManifests(kustomization string) (io.Reader, error) {
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestKustomizeDependencies(t *testing.T) {
+	var tests = []struct {
+		description string
+		files       map[string]string
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "resources and patches",
+			files: map[string]string{
+				"kustomization.yaml": `resources: [deployment.yaml]
+patchesStrategicMerge: [patch.yaml]
+patchesJson6902:
+- path: json-patch.yaml
+configMapGenerator:
+- files: [config.properties, key=other.properties]`,
+			},
+			expected: []string{"kustomization.yaml", "deployment.yaml", "patch.yaml", "json-patch.yaml", "config.properties", "other.properties"},
+		},
+		{
+			description: "bases",
+			files: map[string]string{
+				"kustomization.yaml":      `bases: [base, github.com/org/repo//remote]`,
+				"base/kustomization.yaml": `resources: [service.yaml]`,
+			},
+			expected: []string{"kustomization.yaml", "base/kustomization.yaml", "base/service.yaml"},
+		},
+		{
+			description: "kustomization.yml",
+			files: map[string]string{
+				"kustomization.yml": `crds: [crd.yaml]`,
+			},
+			expected: []string{"kustomization.yml", "crd.yaml"},
+		},
+		{
+			description: "missing kustomization",
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tmpDir, cleanup := testutil.TempDir(t)
+			defer cleanup()
+
+			for path, content := range test.files {
+				path = filepath.Join(tmpDir, path)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deployer := NewKustomizeDeployer(&v1alpha2.DeployConfig{
+				DeployType: v1alpha2.DeployType{
+					KustomizeDeploy: &v1alpha2.KustomizeDeploy{
+						KustomizePath: tmpDir,
+					},
+				},
+			}, testKubeContext, "")
+			deps, err := deployer.Dependencies()
+
+			var expected []string
+			for _, path := range test.expected {
+				expected = append(expected, filepath.Join(tmpDir, path))
+			}
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, expected, deps)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/version"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The labels that skaffold adds to every deployed resource.
+const (
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	RunIDLabel     = "skaffold.dev/run-id"
+	UserLabel      = "skaffold.dev/user"
+	GitCommitLabel = "skaffold.dev/git-commit"
+	VersionLabel   = "skaffold.dev/version"
+)
+
+// maxLabelValueLength is the maximum length of a label value.
+const maxLabelValueLength = 63
+
+var invalidLabelValueChars = regexp.MustCompile(`[^-A-Za-z0-9_.]+`)
+
+var (
+	// runID identifies the deployments of a skaffold process.
+	runID = util.RandomID()
+
+	defaultLabelsOnce  sync.Once
+	defaultLabelValues map[string]string
+)
+
+// RunLabels are the labels of the resources deployed by this skaffold process.
+func RunLabels() map[string]string {
+	return map[string]string{
+		ManagedByLabel: "skaffold",
+		RunIDLabel:     runID,
+	}
+}
+
+// defaultLabels are computed once and replaced in tests.
+var defaultLabels = func() map[string]string {
+	defaultLabelsOnce.Do(func() {
+		user := os.Getenv("USER")
+		if user == "" {
+			user = os.Getenv("USERNAME")
+		}
+
+		defaultLabelValues = map[string]string{
+			ManagedByLabel: "skaffold",
+			RunIDLabel:     runID,
+			UserLabel:      user,
+			VersionLabel:   version.Get().Version,
+		}
+	})
+	return defaultLabelValues
+}
+
+// resourceLabels are the skaffold labels, sanitized and without the empty ones,
+// and the custom labels of the config, that take precedence.
+func resourceLabels(cfg *v1alpha2.DeployConfig) map[string]string {
+	labels := map[string]string{}
+	for k, v := range defaultLabels() {
+		if v = sanitizeLabelValue(v); v != "" {
+			labels[k] = v
+		}
+	}
+	if commit := sanitizeLabelValue(cfg.GitCommit); commit != "" {
+		labels[GitCommitLabel] = commit
+	}
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// sanitizeLabelValue replaces the characters that are not allowed in label values.
+func sanitizeLabelValue(value string) string {
+	value = invalidLabelValueChars.ReplaceAllString(value, "-")
+	if len(value) > maxLabelValueLength {
+		value = value[:maxLabelValueLength]
+	}
+	return strings.Trim(value, "-_.")
+}
+
+// setLabels adds labels and annotations to the metadata of every manifest,
+// and of the items of lists.
+func (l *manifestList) setLabels(labels, annotations map[string]string) (manifestList, error) {
+	if len(labels) == 0 && len(annotations) == 0 {
+		return *l, nil
+	}
+
+	var updated manifestList
+	for _, manifest := range *l {
+		m := make(map[interface{}]interface{})
+		if err := yaml.Unmarshal(manifest, &m); err != nil {
+			return nil, errors.Wrap(err, "reading kubernetes YAML")
+		}
+
+		if len(m) == 0 {
+			continue
+		}
+
+		setMetadata(m, labels, annotations)
+
+		updatedManifest, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshalling yaml")
+		}
+		updated = append(updated, updatedManifest)
+	}
+	return updated, nil
+}
+
+func setMetadata(resource map[interface{}]interface{}, labels, annotations map[string]string) {
+	if items, ok := resource["items"].([]interface{}); ok {
+		for _, item := range items {
+			if itemResource, ok := item.(map[interface{}]interface{}); ok {
+				setMetadata(itemResource, labels, annotations)
+			}
+		}
+	}
+
+	metadata, ok := resource["metadata"].(map[interface{}]interface{})
+	if !ok {
+		metadata = map[interface{}]interface{}{}
+		resource["metadata"] = metadata
+	}
+	mergeInto(metadata, "labels", labels)
+	mergeInto(metadata, "annotations", annotations)
+}
+
+func mergeInto(metadata map[interface{}]interface{}, field string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+
+	existing, ok := metadata[field].(map[interface{}]interface{})
+	if !ok {
+		existing = map[interface{}]interface{}{}
+		metadata[field] = existing
+	}
+	for k, v := range values {
+		existing[k] = v
+	}
+}
+
+// labelResources labels and annotates the resources read from in, eg. the manifest
+// of a helm release, with `kubectl label` and `kubectl annotate`.
+func labelResources(manifest []byte, out io.Writer, kubeContext, namespace string, labels, annotations map[string]string) error {
+	if len(strings.TrimSpace(string(manifest))) == 0 {
+		return nil
+	}
+
+	if len(labels) > 0 {
+		args := append([]string{"label", "--overwrite", "-f", "-"}, keyValues(labels)...)
+		if err := kubectl(strings.NewReader(string(manifest)), out, kubeContext, withNamespace(namespace, args...)...); err != nil {
+			return errors.Wrap(err, "labelling resources")
+		}
+	}
+	if len(annotations) > 0 {
+		args := append([]string{"annotate", "--overwrite", "-f", "-"}, keyValues(annotations)...)
+		if err := kubectl(strings.NewReader(string(manifest)), out, kubeContext, withNamespace(namespace, args...)...); err != nil {
+			return errors.Wrap(err, "annotating resources")
+		}
+	}
+	return nil
+}
+
+// keyValues are sorted `key=value` arguments.
+func keyValues(values map[string]string) []string {
+	var args []string
+	for k, v := range values {
+		args = append(args, k+"="+v)
+	}
+	sort.Strings(args)
+	return args
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestMain(m *testing.M) {
+	// So that the deployed manifests don't depend on the environment.
+	defaultLabels = func() map[string]string { return nil }
+
+	os.Exit(m.Run())
+}
+
+func TestSetLabels(t *testing.T) {
+	manifests := manifestList{
+		[]byte(`apiVersion: v1
+kind: Pod
+metadata:
+  labels:
+    app: web
+  name: web
+`),
+		[]byte(`apiVersion: v1
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: web
+kind: List
+`),
+	}
+
+	labelled, err := manifests.setLabels(map[string]string{"team": "payments"}, map[string]string{"owner": "jane"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, `apiVersion: v1
+kind: Pod
+metadata:
+  annotations:
+    owner: jane
+  labels:
+    app: web
+    team: payments
+  name: web
+---
+apiVersion: v1
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    annotations:
+      owner: jane
+    labels:
+      team: payments
+    name: web
+kind: List
+metadata:
+  annotations:
+    owner: jane
+  labels:
+    team: payments`, labelled.String())
+}
+
+func TestResourceLabels(t *testing.T) {
+	defer func(f func() map[string]string) { defaultLabels = f }(defaultLabels)
+	defaultLabels = func() map[string]string {
+		return map[string]string{
+			RunIDLabel:   "abcd",
+			UserLabel:    "Jane Doe@corp",
+			VersionLabel: "",
+		}
+	}
+
+	labels := resourceLabels(&v1alpha2.DeployConfig{
+		Labels:    map[string]string{"team": "payments", RunIDLabel: "custom"},
+		GitCommit: "a2f3c1e",
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, map[string]string{
+		RunIDLabel:     "custom",
+		UserLabel:      "Jane-Doe-corp",
+		GitCommitLabel: "a2f3c1e",
+		"team":         "payments",
+	}, labels)
+}
+
+// recordingCommand returns outputs for the commands run with RunCmdOut,
+// and records all the commands.
+type recordingCommand struct {
+	outputs map[string]string
+	run     []string
+}
+
+func (r *recordingCommand) RunCmdOut(cmd *exec.Cmd) ([]byte, error) {
+	command := strings.Join(cmd.Args, " ")
+	r.run = append(r.run, command)
+	return []byte(r.outputs[command]), nil
+}
+
+func (r *recordingCommand) RunCmd(cmd *exec.Cmd) error {
+	r.run = append(r.run, strings.Join(cmd.Args, " "))
+	return nil
+}
+
+func TestHelmLabelRelease(t *testing.T) {
+	cmd := &recordingCommand{outputs: map[string]string{
+		"helm --kube-context kubecontext get manifest skaffold-helm": "kind: Deployment\n",
+	}}
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = cmd
+
+	cfg := &v1alpha2.DeployConfig{
+		Labels:      map[string]string{"team": "payments"},
+		Annotations: map[string]string{"owner": "jane"},
+	}
+	err := NewHelmDeployer(cfg, testKubeContext, "dev").labelRelease(&bytes.Buffer{}, "skaffold-helm", v1alpha2.HelmRelease{})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{
+		"helm --kube-context kubecontext get manifest skaffold-helm",
+		"kubectl --context kubecontext --namespace dev label --overwrite -f - team=payments",
+		"kubectl --context kubecontext --namespace dev annotate --overwrite -f - owner=jane",
+	}, cmd.run)
+}
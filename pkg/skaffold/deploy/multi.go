@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+)
+
+// multiDeployer deploys with several deployers, in order.
+type multiDeployer []Deployer
+
+// NewMultiDeployer returns a deployer that deploys with each deployer in order,
+// eg. the deployers of required configs before the deployer that requires them.
+// Cleanups are done in the reverse order.
+func NewMultiDeployer(deployers ...Deployer) Deployer {
+	if len(deployers) == 1 {
+		return deployers[0]
+	}
+	return multiDeployer(deployers)
+}
+
+func (m multiDeployer) Deploy(ctx context.Context, out io.Writer, builds []build.Build) error {
+	for _, d := range m {
+		if err := d.Deploy(ctx, out, builds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiDeployer) Dependencies() ([]string, error) {
+	var deps []string
+	for _, d := range m {
+		result, err := d.Dependencies()
+		if err != nil {
+			return nil, err
+		}
+		deps = append(deps, result...)
+	}
+	return deps, nil
+}
+
+func (m multiDeployer) Cleanup(ctx context.Context, out io.Writer) error {
+	for i := len(m) - 1; i >= 0; i-- {
+		if err := m[i].Cleanup(ctx, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiDeployer) Render(ctx context.Context, out io.Writer, builds []build.Build) error {
+	for _, d := range m {
+		if err := d.Render(ctx, out, builds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
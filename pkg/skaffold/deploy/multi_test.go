@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+type recordingDeployer struct {
+	name   string
+	calls  *[]string
+	deploy error
+}
+
+func (r *recordingDeployer) Deploy(context.Context, io.Writer, []build.Build) error {
+	*r.calls = append(*r.calls, "deploy "+r.name)
+	return r.deploy
+}
+
+func (r *recordingDeployer) Dependencies() ([]string, error) {
+	return []string{r.name + ".yaml"}, nil
+}
+
+func (r *recordingDeployer) Cleanup(context.Context, io.Writer) error {
+	*r.calls = append(*r.calls, "cleanup "+r.name)
+	return nil
+}
+
+func (r *recordingDeployer) Render(context.Context, io.Writer, []build.Build) error {
+	*r.calls = append(*r.calls, "render "+r.name)
+	return nil
+}
+
+func TestMultiDeployer(t *testing.T) {
+	var calls []string
+	d := NewMultiDeployer(&recordingDeployer{name: "a", calls: &calls}, &recordingDeployer{name: "b", calls: &calls})
+
+	err := d.Deploy(context.Background(), ioutil.Discard, nil)
+	testutil.CheckError(t, false, err)
+	err = d.Render(context.Background(), ioutil.Discard, nil)
+	testutil.CheckError(t, false, err)
+	err = d.Cleanup(context.Background(), ioutil.Discard)
+	testutil.CheckError(t, false, err)
+	deps, err := d.Dependencies()
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"a.yaml", "b.yaml"}, deps)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"deploy a", "deploy b", "render a", "render b", "cleanup b", "cleanup a"}, calls)
+}
+
+func TestMultiDeployerStopsOnError(t *testing.T) {
+	var calls []string
+	d := NewMultiDeployer(&recordingDeployer{name: "a", calls: &calls, deploy: fmt.Errorf("")}, &recordingDeployer{name: "b", calls: &calls})
+
+	err := d.Deploy(context.Background(), ioutil.Discard, nil)
+
+	testutil.CheckErrorAndDeepEqual(t, true, err, []string{"deploy a"}, calls)
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateNamespace creates a namespace unless it already exists.
+func CreateNamespace(out io.Writer, kubeContext, namespace string) error {
+	if err := kubectl(nil, ioutil.Discard, kubeContext, "get", "namespace", namespace); err == nil {
+		logrus.Debugf("Namespace %s already exists", namespace)
+		return nil
+	}
+
+	if err := kubectl(nil, out, kubeContext, "create", "namespace", namespace); err != nil {
+		return errors.Wrapf(err, "creating namespace %s", namespace)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+)
+
+// statusCheckInterval is how often the rollouts are checked.
+var statusCheckInterval = time.Second
+
+// statusCheckLogLines is the number of log lines shown for crashing containers.
+var statusCheckLogLines int64 = 10
+
+// failedReasons are the reasons of waiting containers that won't recover without a redeploy.
+var failedReasons = map[string]bool{
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CrashLoopBackOff":           true,
+	"CreateContainerConfigError": true,
+}
+
+// rollout is a workload that uses one of the built images.
+type rollout struct {
+	name      string
+	namespace string
+	done      func(client clientgo.Interface) (bool, error)
+}
+
+// StatusCheck waits for the Deployments, StatefulSets and DaemonSets that were deployed
+// by this skaffold process, with its run-id label, and that use the built images to be rolled out. It fails as soon as one of their containers can't start, eg.
+// because its image can't be pulled or because it crashes, or when the deadline is exceeded.
+func StatusCheck(ctx context.Context, out io.Writer, builds []build.Build, deadline time.Duration) error {
+	client, err := kubernetes.Client()
+	if err != nil {
+		return errors.Wrap(err, "getting kubernetes client")
+	}
+
+	images := map[string]bool{}
+	for _, b := range builds {
+		images[b.Tag] = true
+	}
+
+	rollouts, err := listRollouts(client, images)
+	if err != nil {
+		return errors.Wrap(err, "listing workloads")
+	}
+	if len(rollouts) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(out, "Waiting for %d workloads to stabilize...\n", len(rollouts))
+
+	ctx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	ticker := time.NewTicker(statusCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		var pending []rollout
+		for _, r := range rollouts {
+			done, err := r.done(client)
+			if err != nil {
+				return errors.Wrapf(err, "checking %s", r.name)
+			}
+			if done {
+				fmt.Fprintf(out, "%s is ready.\n", r.name)
+			} else {
+				pending = append(pending, r)
+			}
+		}
+		rollouts = pending
+		if len(rollouts) == 0 {
+			return nil
+		}
+
+		if err := checkContainers(client, rollouts, images); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			var names []string
+			for _, r := range rollouts {
+				names = append(names, r.name)
+			}
+			return fmt.Errorf("%s not ready after %s", strings.Join(names, ", "), deadline)
+		case <-ticker.C:
+		}
+	}
+}
+
+func listRollouts(client clientgo.Interface, images map[string]bool) ([]rollout, error) {
+	apps := client.AppsV1()
+	deployed := metav1.ListOptions{LabelSelector: RunIDLabel + "=" + runID}
+
+	var rollouts []rollout
+
+	deployments, err := apps.Deployments("").List(deployed)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing deployments")
+	}
+	for _, d := range deployments.Items {
+		if !usesImages(d.Spec.Template.Spec, images) {
+			continue
+		}
+		name, namespace := d.Name, d.Namespace
+		rollouts = append(rollouts, rollout{
+			name:      "deployment/" + name,
+			namespace: namespace,
+			done: func(client clientgo.Interface) (bool, error) {
+				d, err := client.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				return deploymentDone(d), nil
+			},
+		})
+	}
+
+	statefulSets, err := apps.StatefulSets("").List(deployed)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing statefulsets")
+	}
+	for _, s := range statefulSets.Items {
+		if !usesImages(s.Spec.Template.Spec, images) {
+			continue
+		}
+		name, namespace := s.Name, s.Namespace
+		rollouts = append(rollouts, rollout{
+			name:      "statefulset/" + name,
+			namespace: namespace,
+			done: func(client clientgo.Interface) (bool, error) {
+				s, err := client.AppsV1().StatefulSets(namespace).Get(name, metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				return statefulSetDone(s), nil
+			},
+		})
+	}
+
+	daemonSets, err := apps.DaemonSets("").List(deployed)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing daemonsets")
+	}
+	for _, ds := range daemonSets.Items {
+		if !usesImages(ds.Spec.Template.Spec, images) {
+			continue
+		}
+		name, namespace := ds.Name, ds.Namespace
+		rollouts = append(rollouts, rollout{
+			name:      "daemonset/" + name,
+			namespace: namespace,
+			done: func(client clientgo.Interface) (bool, error) {
+				ds, err := client.AppsV1().DaemonSets(namespace).Get(name, metav1.GetOptions{})
+				if err != nil {
+					return false, err
+				}
+				return daemonSetDone(ds), nil
+			},
+		})
+	}
+
+	sort.Slice(rollouts, func(i, j int) bool { return rollouts[i].name < rollouts[j].name })
+	return rollouts, nil
+}
+
+func usesImages(spec v1.PodSpec, images map[string]bool) bool {
+	for _, c := range append(spec.InitContainers, spec.Containers...) {
+		if images[c.Image] {
+			return true
+		}
+	}
+	return false
+}
+
+func replicas(r *int32) int32 {
+	if r == nil {
+		return 1
+	}
+	return *r
+}
+
+// deploymentDone tells if the new replicas are available and the old ones are gone.
+func deploymentDone(d *appsv1.Deployment) bool {
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas(d.Spec.Replicas) &&
+		d.Status.Replicas == d.Status.UpdatedReplicas &&
+		d.Status.AvailableReplicas == d.Status.UpdatedReplicas
+}
+
+func statefulSetDone(s *appsv1.StatefulSet) bool {
+	return s.Status.ObservedGeneration >= s.Generation &&
+		s.Status.UpdatedReplicas == replicas(s.Spec.Replicas) &&
+		s.Status.Replicas == s.Status.UpdatedReplicas &&
+		s.Status.ReadyReplicas == replicas(s.Spec.Replicas)
+}
+
+func daemonSetDone(ds *appsv1.DaemonSet) bool {
+	return ds.Status.ObservedGeneration >= ds.Generation &&
+		ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+		ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled
+}
+
+// checkContainers fails if a container running one of the images, in the namespaces
+// of the pending rollouts, can't start.
+func checkContainers(client clientgo.Interface, rollouts []rollout, images map[string]bool) error {
+	namespaces := map[string]bool{}
+	for _, r := range rollouts {
+		namespaces[r.namespace] = true
+	}
+
+	var pods []v1.Pod
+	for namespace := range namespaces {
+		list, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+		if err != nil {
+			return errors.Wrap(err, "listing pods")
+		}
+		pods = append(pods, list.Items...)
+	}
+
+	for _, pod := range pods {
+		// The images of the statuses can be normalized, eg. with a `docker.io/library/` prefix.
+		containerImages := map[string]string{}
+		for _, c := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			containerImages[c.Name] = c.Image
+		}
+
+		for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if !images[containerImages[status.Name]] || status.State.Waiting == nil || !failedReasons[status.State.Waiting.Reason] {
+				continue
+			}
+
+			waiting := status.State.Waiting
+			err := fmt.Errorf("container %s of pod %s/%s is in %s: %s", status.Name, pod.Namespace, pod.Name, waiting.Reason, waiting.Message)
+			if waiting.Reason == "CrashLoopBackOff" {
+				if logs := lastLogs(client, pod, status.Name); logs != "" {
+					err = fmt.Errorf("%s\nlast logs:\n%s", err, logs)
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lastLogs returns the last lines logged by the previous instance of a container.
+func lastLogs(client clientgo.Interface, pod v1.Pod, container string) string {
+	logs, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+		Container: container,
+		Previous:  true,
+		TailLines: &statusCheckLogLines,
+	}).Do().Raw()
+	if err != nil {
+		logrus.Debugf("Unable to get logs of %s/%s: %s", pod.Name, container, err)
+		return ""
+	}
+	return strings.TrimSpace(string(logs))
+}
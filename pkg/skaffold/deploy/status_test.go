@@ -0,0 +1,184 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deploy
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podSpec(image string) v1.PodTemplateSpec {
+	return v1.PodTemplateSpec{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "app", Image: image}},
+		},
+	}
+}
+
+func deployedLabels() map[string]string {
+	return map[string]string{RunIDLabel: runID}
+}
+
+func deployment(name string, image string, available int32) *appsv1.Deployment {
+	replicas := int32(1)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: deployedLabels()},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: podSpec(image),
+		},
+		Status: appsv1.DeploymentStatus{
+			Replicas:          1,
+			UpdatedReplicas:   1,
+			AvailableReplicas: available,
+		},
+	}
+}
+
+func notDeployed(d *appsv1.Deployment) *appsv1.Deployment {
+	d.Labels = nil
+	return d
+}
+
+func withReplicas(d *appsv1.Deployment, replicas int32) *appsv1.Deployment {
+	d.Status.Replicas = replicas
+	return d
+}
+
+func waitingPod(image string, reason string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"},
+		Spec:       podSpec(image).Spec,
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  "app",
+					Image: "docker.io/library/" + image,
+					State: v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{Reason: reason},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestStatusCheck(t *testing.T) {
+	defer func(interval time.Duration) { statusCheckInterval = interval }(statusCheckInterval)
+	statusCheckInterval = time.Millisecond
+
+	builds := []build.Build{
+		{ImageName: "app", Tag: "app:123"},
+	}
+
+	var tests = []struct {
+		description string
+		objects     []runtime.Object
+		shouldErr   bool
+	}{
+		{
+			description: "nothing deployed",
+		},
+		{
+			description: "deployment rolled out",
+			objects: []runtime.Object{
+				deployment("app", "app:123", 1),
+			},
+		},
+		{
+			description: "other images are ignored",
+			objects: []runtime.Object{
+				deployment("other", "other:123", 0),
+				deployment("app", "app:123", 1),
+			},
+		},
+		{
+			description: "statefulset and daemonset rolled out",
+			objects: []runtime.Object{
+				&appsv1.StatefulSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Labels: deployedLabels()},
+					Spec:       appsv1.StatefulSetSpec{Template: podSpec("app:123")},
+					Status:     appsv1.StatefulSetStatus{Replicas: 1, UpdatedReplicas: 1, ReadyReplicas: 1},
+				},
+				&appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Labels: deployedLabels()},
+					Spec:       appsv1.DaemonSetSpec{Template: podSpec("app:123")},
+					Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, UpdatedNumberScheduled: 2, NumberAvailable: 2},
+				},
+			},
+		},
+		{
+			description: "workloads of other runs are ignored",
+			objects: []runtime.Object{
+				notDeployed(deployment("app", "app:123", 0)),
+			},
+		},
+		{
+			description: "old replicas are still running",
+			objects: []runtime.Object{
+				withReplicas(deployment("app", "app:123", 1), 2),
+			},
+			shouldErr: true,
+		},
+		{
+			description: "deadline exceeded",
+			objects: []runtime.Object{
+				deployment("app", "app:123", 0),
+			},
+			shouldErr: true,
+		},
+		{
+			description: "image can't be pulled",
+			objects: []runtime.Object{
+				deployment("app", "app:123", 0),
+				waitingPod("app:123", "ImagePullBackOff"),
+			},
+			shouldErr: true,
+		},
+		{
+			description: "container is starting",
+			objects: []runtime.Object{
+				deployment("app", "app:123", 1),
+				waitingPod("app:123", "ContainerCreating"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.objects...)
+			defer func(c func() (clientgo.Interface, error)) { kubernetes.Client = c }(kubernetes.Client)
+			kubernetes.Client = func() (clientgo.Interface, error) { return client, nil }
+
+			err := StatusCheck(context.Background(), ioutil.Discard, builds, 50*time.Millisecond)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
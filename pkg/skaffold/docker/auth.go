@@ -59,12 +59,28 @@ type AuthConfigHelper interface {
 
 type credsHelper struct{}
 
+// GetAuthConfig gets the credentials of a registry from the docker config. If there are none,
+// temporary credentials are resolved for ECR and ACR registries.
 func (credsHelper) GetAuthConfig(registry string) (types.AuthConfig, error) {
 	cf, err := config.Load(configDir)
 	if err != nil {
 		return types.AuthConfig{}, errors.Wrap(err, "docker config")
 	}
-	return cf.GetAuthConfig(registry)
+
+	ac, err := cf.GetAuthConfig(registry)
+	if err != nil || !isEmpty(ac) {
+		return ac, err
+	}
+
+	cloudAC, found, err := cloudAuthConfig(registry)
+	if err != nil {
+		return types.AuthConfig{}, err
+	}
+	if found {
+		return cloudAC, nil
+	}
+
+	return ac, nil
 }
 
 func (credsHelper) GetAllAuthConfigs() (map[string]types.AuthConfig, error) {
@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// RunBuildKit builds an image with the docker CLI and BuildKit enabled. It's used
+// for ssh forwarding and secret mounts that the docker API doesn't support.
+// The CLI talks to the same daemon as the API client, eg. minikube's.
+func RunBuildKit(ctx context.Context, opts *BuildOptions) error {
+	args := append([]string{"build", "--tag", opts.ImageName, "-f", opts.Dockerfile}, BuildFlags(opts)...)
+	args = append(args, ".")
+
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = opts.ContextDir
+	cmd.Env = append(append(util.OSEnviron(), DaemonEnv()...), "DOCKER_BUILDKIT=1")
+	cmd.Stdout = opts.BuildBuf
+	cmd.Stderr = opts.BuildBuf
+
+	if err := util.RunCmd(cmd); err != nil {
+		return errors.Wrap(err, "docker build with BuildKit")
+	}
+	return nil
+}
+
+// BuildFlags are the `docker build` flags that configure a build, apart from the
+// tag, the Dockerfile and the context. Build args without a value are left out,
+// like with the docker API, so that the Dockerfile's default is used.
+func BuildFlags(opts *BuildOptions) []string {
+	var keys []string
+	for k, v := range opts.BuildArgs {
+		if v != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var flags []string
+	for _, k := range keys {
+		flags = append(flags, "--build-arg", fmt.Sprintf("%s=%s", k, *opts.BuildArgs[k]))
+	}
+	if opts.Target != "" {
+		flags = append(flags, "--target", opts.Target)
+	}
+	if opts.NetworkMode != "" {
+		flags = append(flags, "--network", opts.NetworkMode)
+	}
+	for _, image := range opts.CacheFrom {
+		flags = append(flags, "--cache-from", image)
+	}
+	for _, ssh := range opts.SSH {
+		flags = append(flags, "--ssh", ssh)
+	}
+	for _, secret := range opts.Secrets {
+		flags = append(flags, "--secret", secret)
+	}
+	return flags
+}
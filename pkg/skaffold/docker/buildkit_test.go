@@ -0,0 +1,62 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestBuildFlags(t *testing.T) {
+	value := "value"
+	flags := BuildFlags(&BuildOptions{
+		BuildArgs:   map[string]*string{"b": &value, "a": &value, "unset": nil},
+		Target:      "builder",
+		NetworkMode: "host",
+		CacheFrom:   []string{"gcr.io/project/image:v1", "gcr.io/project/image:v2"},
+		SSH:         []string{"default"},
+		Secrets:     []string{"id=npmrc,src=.npmrc"},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		"--build-arg", "a=value",
+		"--build-arg", "b=value",
+		"--target", "builder",
+		"--network", "host",
+		"--cache-from", "gcr.io/project/image:v1",
+		"--cache-from", "gcr.io/project/image:v2",
+		"--ssh", "default",
+		"--secret", "id=npmrc,src=.npmrc",
+	}, flags)
+}
+
+func TestRunBuildWithBuildKit(t *testing.T) {
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmd("docker build --tag image -f Dockerfile --ssh default .", nil)
+
+	err := RunBuild(context.Background(), nil, &BuildOptions{
+		ImageName:  "image",
+		Dockerfile: "Dockerfile",
+		ContextDir: ".",
+		SSH:        []string{"default"},
+	})
+
+	testutil.CheckError(t, false, err)
+}
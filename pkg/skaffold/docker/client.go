@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
@@ -45,8 +46,30 @@ var (
 	dockerAPIClientOnce sync.Once
 	dockerAPIClient     APIClient
 	dockerAPIClientErr  error
+
+	// localClusterOverride, if set, replaces the detection of local clusters.
+	localClusterOverride *bool
+
+	// daemonEnv is the environment of the docker daemon that the API client talks
+	// to, when it's not the one configured by the environment.
+	daemonEnv map[string]string
 )
 
+// ConfigureLocalCluster overrides the detection of local clusters, whose docker daemon
+// is used to build images that then don't need to be pushed. It has to be called
+// before the API client is created.
+func ConfigureLocalCluster(localCluster *bool) {
+	localClusterOverride = localCluster
+}
+
+// IsLocalCluster tells if images built for a kube context can be run without being pushed.
+func IsLocalCluster(kubeContext string) bool {
+	if localClusterOverride != nil {
+		return *localClusterOverride
+	}
+	return kubernetes.DetectLocalCluster(kubeContext) != kubernetes.NotLocal
+}
+
 // NewAPIClient guesses the docker client to use based on current kubernetes context.
 func NewAPIClient() (APIClient, error) {
 	dockerAPIClientOnce.Do(func() {
@@ -63,9 +86,14 @@ func NewAPIClient() (APIClient, error) {
 }
 
 // newAPIClient guesses the docker client to use based on current kubernetes context.
+// minikube names its contexts after its profiles. Setting the local cluster override
+// to false always uses the docker daemon configured by the environment.
 func newAPIClient(kubeContext string) (APIClient, error) {
-	if kubeContext == constants.DefaultMinikubeContext {
-		return newMinikubeAPIClient()
+	if localClusterOverride != nil && !*localClusterOverride {
+		return newEnvAPIClient()
+	}
+	if kubernetes.DetectLocalCluster(kubeContext) == kubernetes.Minikube {
+		return newMinikubeAPIClient(kubeContext)
 	}
 	return newEnvAPIClient()
 }
@@ -84,9 +112,9 @@ func newEnvAPIClient() (APIClient, error) {
 }
 
 // newMinikubeAPIClient returns a docker client using the environment variables
-// provided by minikube.
-func newMinikubeAPIClient() (APIClient, error) {
-	env, err := getMinikubeDockerEnv()
+// provided by minikube for the given profile.
+func newMinikubeAPIClient(profile string) (APIClient, error) {
+	env, err := getMinikubeDockerEnv(profile)
 	if err != nil {
 		logrus.Warnf("Could not get minikube docker env, falling back to local docker daemon")
 		return newEnvAPIClient()
@@ -122,11 +150,33 @@ func newMinikubeAPIClient() (APIClient, error) {
 		version = api.DefaultVersion
 	}
 
-	return client.NewClient(host, version, httpclient, nil)
+	cli, err := client.NewClient(host, version, httpclient, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	daemonEnv = env
+	return cli, nil
 }
 
-func getMinikubeDockerEnv() (map[string]string, error) {
-	cmd := exec.Command("minikube", "docker-env", "--shell", "none")
+// DaemonEnv returns the `KEY=value` env variables that point the docker CLI
+// to the same daemon as the API client.
+func DaemonEnv() []string {
+	var env []string
+	for k, v := range daemonEnv {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(env)
+	return env
+}
+
+func getMinikubeDockerEnv(profile string) (map[string]string, error) {
+	args := []string{"docker-env", "--shell", "none"}
+	if profile != "" && profile != constants.DefaultMinikubeContext {
+		args = append(args, "-p", profile)
+	}
+
+	cmd := exec.Command("minikube", args...)
 	out, err := util.RunCmdOut(cmd)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting minikube env")
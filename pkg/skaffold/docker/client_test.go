@@ -59,6 +59,7 @@ func TestNewEnvClient(t *testing.T) {
 func TestNewMinikubeImageAPIClient(t *testing.T) {
 	var tests = []struct {
 		description string
+		profile     string
 		cmd         util.Command
 
 		expected  APIClient
@@ -99,6 +100,14 @@ DOCKER_CERT_PATH=testdata
 DOCKER_API_VERSION=1.23`, nil),
 			shouldErr: true,
 		},
+		{
+			description: "minikube profile",
+			profile:     "dev",
+			cmd: testutil.NewFakeCmdOut("minikube docker-env --shell none -p dev", `DOCKER_TLS_VERIFY=1
+DOCKER_HOST=http://127.0.0.1:8080
+DOCKER_CERT_PATH=testdata
+DOCKER_API_VERSION=1.23`, nil),
+		},
 		{
 			description: "bad env output, should fallback to host docker",
 			cmd: testutil.NewFakeCmdOut("minikube docker-env --shell none", `DOCKER_TLS_VERIFY=1
@@ -113,8 +122,73 @@ DOCKER_API_VERSION=1.23`, nil),
 			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
 			util.DefaultExecCommand = test.cmd
 
-			_, err := newMinikubeAPIClient()
+			profile := test.profile
+			if profile == "" {
+				profile = "minikube"
+			}
+
+			_, err := newMinikubeAPIClient(profile)
 			testutil.CheckError(t, test.shouldErr, err)
 		})
 	}
 }
+
+func TestDaemonEnv(t *testing.T) {
+	defer func(env map[string]string) { daemonEnv = env }(daemonEnv)
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut("minikube docker-env --shell none", `DOCKER_TLS_VERIFY=1
+DOCKER_HOST=http://127.0.0.1:8080
+DOCKER_CERT_PATH=testdata`, nil)
+
+	_, err := newMinikubeAPIClient("minikube")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{
+		"DOCKER_CERT_PATH=testdata",
+		"DOCKER_HOST=http://127.0.0.1:8080",
+		"DOCKER_TLS_VERIFY=1",
+	}, DaemonEnv())
+}
+
+func TestIsLocalCluster(t *testing.T) {
+	var tests = []struct {
+		description string
+		kubeContext string
+		override    *bool
+		expected    bool
+	}{
+		{
+			description: "minikube",
+			kubeContext: "minikube",
+			expected:    true,
+		},
+		{
+			description: "docker desktop",
+			kubeContext: "docker-desktop",
+			expected:    true,
+		},
+		{
+			description: "remote cluster",
+			kubeContext: "gke_project_zone_cluster",
+		},
+		{
+			description: "override local cluster",
+			kubeContext: "minikube",
+			override:    util.BoolPtr(false),
+		},
+		{
+			description: "override remote cluster",
+			kubeContext: "gke_project_zone_cluster",
+			override:    util.BoolPtr(true),
+			expected:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer ConfigureLocalCluster(nil)
+			ConfigureLocalCluster(test.override)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, IsLocalCluster(test.kubeContext))
+		})
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/docker/docker/api/types"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// acrUsername is the username that goes with the access tokens of Azure Container Registry.
+const acrUsername = "00000000-0000-0000-0000-000000000000"
+
+var (
+	// ecrRegistryRegexp matches Amazon ECR registries, eg. `123456789012.dkr.ecr.us-east-1.amazonaws.com`.
+	ecrRegistryRegexp = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(-fips)?\.([a-z0-9-]+)\.amazonaws\.com(\.cn)?$`)
+
+	acrRegistrySuffixes = []string{".azurecr.io", ".azurecr.cn", ".azurecr.de", ".azurecr.us"}
+
+	// ACR access tokens are valid for 3 hours. They are renewed a bit before.
+	acrTokenValidity = 2 * time.Hour
+
+	cloudAuthConfigs = cloudAuthCache{
+		configs: map[string]cachedAuthConfig{},
+	}
+)
+
+// keychain resolves the credentials of the remote image operations with the docker config,
+// then with the cloud credentials for ECR and ACR registries.
+var keychain authn.Keychain = cloudKeychain{}
+
+type cloudKeychain struct{}
+
+func (cloudKeychain) Resolve(reg name.Registry) (authn.Authenticator, error) {
+	auth, err := authn.DefaultKeychain.Resolve(reg)
+	if err != nil || auth != authn.Anonymous {
+		return auth, err
+	}
+
+	ac, found, err := cloudAuthConfig(reg.RegistryStr())
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return authn.Anonymous, nil
+	}
+
+	return &authn.Basic{
+		Username: ac.Username,
+		Password: ac.Password,
+	}, nil
+}
+
+type cachedAuthConfig struct {
+	config    types.AuthConfig
+	expiresAt time.Time
+}
+
+type cloudAuthCache struct {
+	sync.Mutex
+	configs map[string]cachedAuthConfig
+}
+
+// cloudAuthConfig gets temporary credentials for ECR registries with the aws cli,
+// and for ACR registries with the az cli. It reports false for other registries.
+func cloudAuthConfig(registry string) (types.AuthConfig, bool, error) {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+
+	var get func(string) (types.AuthConfig, time.Time, error)
+	switch {
+	case ecrRegistryRegexp.MatchString(registry):
+		get = ecrAuthConfig
+	case isACRRegistry(registry):
+		get = acrAuthConfig
+	default:
+		return types.AuthConfig{}, false, nil
+	}
+
+	cloudAuthConfigs.Lock()
+	defer cloudAuthConfigs.Unlock()
+
+	if cached, present := cloudAuthConfigs.configs[registry]; present && time.Now().Before(cached.expiresAt) {
+		return cached.config, true, nil
+	}
+
+	ac, expiresAt, err := get(registry)
+	if err != nil {
+		return types.AuthConfig{}, false, errors.Wrapf(err, "getting credentials for %s", registry)
+	}
+	logrus.Debugf("Got credentials for %s, valid until %s", registry, expiresAt)
+
+	cloudAuthConfigs.configs[registry] = cachedAuthConfig{
+		config:    ac,
+		expiresAt: expiresAt,
+	}
+	return ac, true, nil
+}
+
+func isACRRegistry(registry string) bool {
+	for _, suffix := range acrRegistrySuffixes {
+		if strings.HasSuffix(registry, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+type ecrAuthorizationData struct {
+	AuthorizationData []struct {
+		AuthorizationToken string  `json:"authorizationToken"`
+		ExpiresAt          float64 `json:"expiresAt"`
+	} `json:"authorizationData"`
+}
+
+// ecrAuthConfig calls ECR's GetAuthorizationToken through the aws cli, so that
+// the usual aws configuration, profiles and env variables are used.
+func ecrAuthConfig(registry string) (types.AuthConfig, time.Time, error) {
+	parts := ecrRegistryRegexp.FindStringSubmatch(registry)
+	accountID, region := parts[1], parts[3]
+
+	cmd := exec.Command("aws", "ecr", "get-authorization-token", "--region", region, "--registry-ids", accountID, "--output", "json")
+	out, err := util.RunCmdOut(cmd)
+	if err != nil {
+		return types.AuthConfig{}, time.Time{}, errors.Wrap(err, "running aws ecr get-authorization-token")
+	}
+
+	var data ecrAuthorizationData
+	if err := json.Unmarshal(out, &data); err != nil {
+		return types.AuthConfig{}, time.Time{}, errors.Wrap(err, "parsing authorization token")
+	}
+	if len(data.AuthorizationData) == 0 {
+		return types.AuthConfig{}, time.Time{}, fmt.Errorf("no authorization token for %s", registry)
+	}
+
+	token, err := base64.StdEncoding.DecodeString(data.AuthorizationData[0].AuthorizationToken)
+	if err != nil {
+		return types.AuthConfig{}, time.Time{}, errors.Wrap(err, "decoding authorization token")
+	}
+
+	credentials := strings.SplitN(string(token), ":", 2)
+	if len(credentials) != 2 {
+		return types.AuthConfig{}, time.Time{}, errors.New("invalid authorization token")
+	}
+
+	expiresAt := time.Unix(int64(data.AuthorizationData[0].ExpiresAt), 0)
+	return types.AuthConfig{
+		Username:      credentials[0],
+		Password:      credentials[1],
+		ServerAddress: registry,
+	}, expiresAt, nil
+}
+
+type acrAccessToken struct {
+	AccessToken string `json:"accessToken"`
+}
+
+// acrAuthConfig gets an access token for the registry through the az cli.
+func acrAuthConfig(registry string) (types.AuthConfig, time.Time, error) {
+	registryName := strings.SplitN(registry, ".", 2)[0]
+
+	cmd := exec.Command("az", "acr", "login", "--name", registryName, "--expose-token", "--output", "json")
+	out, err := util.RunCmdOut(cmd)
+	if err != nil {
+		return types.AuthConfig{}, time.Time{}, errors.Wrap(err, "running az acr login")
+	}
+
+	var token acrAccessToken
+	if err := json.Unmarshal(out, &token); err != nil {
+		return types.AuthConfig{}, time.Time{}, errors.Wrap(err, "parsing access token")
+	}
+	if token.AccessToken == "" {
+		return types.AuthConfig{}, time.Time{}, fmt.Errorf("no access token for %s", registry)
+	}
+
+	return types.AuthConfig{
+		Username:      acrUsername,
+		Password:      token.AccessToken,
+		ServerAddress: registry,
+	}, time.Now().Add(acrTokenValidity), nil
+}
+
+// isEmpty tells if an auth config has no credentials.
+func isEmpty(ac types.AuthConfig) bool {
+	return ac.Username == "" && ac.Password == "" && ac.Auth == "" && ac.IdentityToken == "" && ac.RegistryToken == ""
+}
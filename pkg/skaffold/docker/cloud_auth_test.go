@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/docker/docker/api/types"
+)
+
+func TestCloudAuthConfig(t *testing.T) {
+	ecrToken := base64.StdEncoding.EncodeToString([]byte("AWS:password"))
+
+	var tests = []struct {
+		description string
+		registry    string
+		command     util.Command
+		expected    types.AuthConfig
+		found       bool
+		shouldErr   bool
+	}{
+		{
+			description: "ecr",
+			registry:    "123456789012.dkr.ecr.us-east-1.amazonaws.com",
+			command: testutil.NewFakeCmdOut("aws ecr get-authorization-token --region us-east-1 --registry-ids 123456789012 --output json",
+				fmt.Sprintf(`{"authorizationData": [{"authorizationToken": "%s", "expiresAt": 4102444800.0}]}`, ecrToken), nil),
+			expected: types.AuthConfig{
+				Username:      "AWS",
+				Password:      "password",
+				ServerAddress: "123456789012.dkr.ecr.us-east-1.amazonaws.com",
+			},
+			found: true,
+		},
+		{
+			description: "ecr with scheme",
+			registry:    "https://123456789012.dkr.ecr.eu-west-3.amazonaws.com",
+			command: testutil.NewFakeCmdOut("aws ecr get-authorization-token --region eu-west-3 --registry-ids 123456789012 --output json",
+				fmt.Sprintf(`{"authorizationData": [{"authorizationToken": "%s", "expiresAt": 4102444800.0}]}`, ecrToken), nil),
+			expected: types.AuthConfig{
+				Username:      "AWS",
+				Password:      "password",
+				ServerAddress: "123456789012.dkr.ecr.eu-west-3.amazonaws.com",
+			},
+			found: true,
+		},
+		{
+			description: "ecr invalid token",
+			registry:    "123456789012.dkr.ecr.us-west-2.amazonaws.com",
+			command:     testutil.NewFakeCmdOut("aws ecr get-authorization-token --region us-west-2 --registry-ids 123456789012 --output json", `{"authorizationData": []}`, nil),
+			shouldErr:   true,
+		},
+		{
+			description: "acr",
+			registry:    "myregistry.azurecr.io",
+			command:     testutil.NewFakeCmdOut("az acr login --name myregistry --expose-token --output json", `{"accessToken": "token", "loginServer": "myregistry.azurecr.io"}`, nil),
+			expected: types.AuthConfig{
+				Username:      acrUsername,
+				Password:      "token",
+				ServerAddress: "myregistry.azurecr.io",
+			},
+			found: true,
+		},
+		{
+			description: "acr error",
+			registry:    "other.azurecr.io",
+			command:     testutil.NewFakeCmdOut("az acr login --name other --expose-token --output json", "", fmt.Errorf("not logged in")),
+			shouldErr:   true,
+		},
+		{
+			description: "other registry",
+			registry:    "gcr.io",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = test.command
+
+			cloudAuthConfigs.configs = map[string]cachedAuthConfig{}
+
+			ac, found, err := cloudAuthConfig(test.registry)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, ac)
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.found, found)
+		})
+	}
+}
+
+func TestCloudAuthConfigCache(t *testing.T) {
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut("az acr login --name cached --expose-token --output json", `{"accessToken": "token"}`, nil)
+
+	cloudAuthConfigs.configs = map[string]cachedAuthConfig{}
+	cloudAuthConfig("cached.azurecr.io")
+
+	// The cli must not be called again.
+	util.DefaultExecCommand = testutil.NewFakeCmdOut("unexpected", "", fmt.Errorf("unexpected call"))
+	ac, found, err := cloudAuthConfig("cached.azurecr.io")
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, "token", ac.Password)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, found)
+}
@@ -46,7 +46,7 @@ func TestDockerContext(t *testing.T) {
 
 	reader, writer := io.Pipe()
 	go func() {
-		err := CreateDockerTarContext(writer, "Dockerfile", tmpDir)
+		err := CreateDockerTarContext(writer, "Dockerfile", tmpDir, nil)
 		if err != nil {
 			writer.CloseWithError(err)
 		} else {
@@ -43,12 +43,23 @@ type BuildOptions struct {
 	ProgressBuf io.Writer
 	BuildBuf    io.Writer
 	BuildArgs   map[string]*string
+	Target      string
+	NetworkMode string
+	CacheFrom   []string
+
+	// SSH and Secrets require BuildKit, see RunBuildKit.
+	SSH     []string
+	Secrets []string
 }
 
 // RunBuild performs a docker build and returns nothing
 func RunBuild(ctx context.Context, cli APIClient, opts *BuildOptions) error {
 	logrus.Debugf("Running docker build: context: %s, dockerfile: %s", opts.ContextDir, opts.Dockerfile)
 
+	if len(opts.SSH) > 0 || len(opts.Secrets) > 0 {
+		return RunBuildKit(ctx, opts)
+	}
+
 	// Like `docker build`, we ignore the errors
 	// See https://github.com/docker/cli/blob/75c1bb1f33d7cedbaf48404597d5bf9818199480/cli/command/image/build.go#L364
 	authConfigs, _ := DefaultAuthHelper.GetAllAuthConfigs()
@@ -57,12 +68,15 @@ func RunBuild(ctx context.Context, cli APIClient, opts *BuildOptions) error {
 		Tags:        []string{opts.ImageName},
 		Dockerfile:  opts.Dockerfile,
 		BuildArgs:   opts.BuildArgs,
+		Target:      opts.Target,
+		NetworkMode: opts.NetworkMode,
+		CacheFrom:   opts.CacheFrom,
 		AuthConfigs: authConfigs,
 	}
 
 	buildCtx, buildCtxWriter := io.Pipe()
 	go func() {
-		err := CreateDockerTarContext(buildCtxWriter, opts.Dockerfile, opts.ContextDir)
+		err := CreateDockerTarContext(buildCtxWriter, opts.Dockerfile, opts.ContextDir, opts.BuildArgs)
 		if err != nil {
 			buildCtxWriter.CloseWithError(errors.Wrap(err, "creating docker context"))
 			return
@@ -89,6 +103,8 @@ func StreamDockerMessages(dst io.Writer, src io.Reader) error {
 }
 
 func RunPush(ctx context.Context, cli APIClient, ref string, out io.Writer) error {
+	checkDaemonInsecureRegistry(ctx, cli, ref)
+
 	registryAuth, err := encodedRegistryAuth(ctx, cli, DefaultAuthHelper, ref)
 	if err != nil {
 		return errors.Wrapf(err, "getting auth config for %s", ref)
@@ -109,7 +125,7 @@ func AddTag(src, target string) error {
 		return errors.Wrap(err, "getting source reference")
 	}
 
-	auth, err := authn.DefaultKeychain.Resolve(srcRef.Context().Registry)
+	auth, err := keychain.Resolve(srcRef.Context().Registry)
 	if err != nil {
 		return err
 	}
@@ -119,7 +135,7 @@ func AddTag(src, target string) error {
 		return errors.Wrap(err, "getting target reference")
 	}
 
-	return addTag(srcRef, targetRef, auth, http.DefaultTransport)
+	return addTag(srcRef, targetRef, auth, remoteTransport)
 }
 
 func addTag(ref name.Reference, targetRef name.Reference, auth authn.Authenticator, t http.RoundTripper) error {
@@ -164,12 +180,12 @@ func remoteImage(identifier string) (v1.Image, error) {
 		return nil, errors.Wrap(err, "parsing initial ref")
 	}
 
-	auth, err := authn.DefaultKeychain.Resolve(ref.Context().Registry)
+	auth, err := keychain.Resolve(ref.Context().Registry)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting default keychain auth")
 	}
 
-	return remote.Image(ref, auth, http.DefaultTransport)
+	return remote.Image(ref, auth, remoteTransport)
 }
 
 func RemoteDigest(identifier string) (string, error) {
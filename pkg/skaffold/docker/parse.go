@@ -37,6 +37,7 @@ import (
 
 const (
 	add  = "add"
+	arg  = "arg"
 	copy = "copy"
 	env  = "env"
 	from = "from"
@@ -45,7 +46,7 @@ const (
 // RetrieveImage is overriden for unit testing
 var RetrieveImage = retrieveImage
 
-func readDockerfile(workspace, dockerfilePath string) ([]string, error) {
+func readDockerfile(workspace, dockerfilePath string, buildArgs map[string]*string) ([]string, error) {
 	path := filepath.Join(workspace, dockerfilePath)
 	f, err := os.Open(path)
 	if err != nil {
@@ -58,24 +59,53 @@ func readDockerfile(workspace, dockerfilePath string) ([]string, error) {
 		return nil, errors.Wrap(err, "parsing dockerfile")
 	}
 
-	envs := map[string]string{}
+	// ARGs declared before the first FROM can be used in FROM instructions
+	// and are the defaults of the ARGs redeclared, without a value, in the stages.
+	globalArgs := map[string]string{}
+	stages := map[string]bool{}
+	slex := shell.NewLex('\\')
+
 	depMap := map[string]struct{}{}
 	// First process onbuilds, if present.
 	onbuildsImages := [][]string{}
+	seenFrom := false
 	for _, value := range res.AST.Children {
 		switch value.Value {
+		case arg:
+			if !seenFrom {
+				processArg(value, globalArgs, globalArgs, buildArgs)
+			}
 		case from:
-			onbuilds, err := processBaseImage(value)
+			seenFrom = true
+			base, err := processShellWord(slex, value.Next.Value, globalArgs)
 			if err != nil {
-				logrus.Warnf("Error processing base image for onbuild triggers: %s. Dependencies may be incomplete.", err)
+				return nil, errors.Wrap(err, "processing base image")
+			}
+
+			// Stages built from a previous stage don't have onbuild triggers of their own.
+			if !stages[strings.ToLower(base)] {
+				onbuilds, err := processBaseImage(base)
+				if err != nil {
+					logrus.Warnf("Error processing base image for onbuild triggers: %s. Dependencies may be incomplete.", err)
+				}
+				onbuildsImages = append(onbuildsImages, onbuilds)
+			}
+
+			if name := stageName(value); name != "" {
+				stages[strings.ToLower(name)] = true
 			}
-			onbuildsImages = append(onbuildsImages, onbuilds)
 		}
 	}
 
 	var dispatchInstructions = func(r *parser.Result) {
+		// ENVs and ARGs are scoped to the stage they are declared in.
+		envs := map[string]string{}
 		for _, value := range r.AST.Children {
 			switch value.Value {
+			case from:
+				envs = map[string]string{}
+			case arg:
+				processArg(value, envs, globalArgs, buildArgs)
 			case add, copy:
 				processCopy(value, depMap, envs)
 			case env:
@@ -104,8 +134,10 @@ func readDockerfile(workspace, dockerfilePath string) ([]string, error) {
 	return deps, nil
 }
 
-func GetDependencies(dockerfilePath, workspace string) ([]string, error) {
-	deps, err := readDockerfile(workspace, dockerfilePath)
+// GetDependencies finds the files of the workspace that a Dockerfile depends on.
+// The build args are used to evaluate the ARGs of the Dockerfile.
+func GetDependencies(dockerfilePath, workspace string, buildArgs map[string]*string) ([]string, error) {
+	deps, err := readDockerfile(workspace, dockerfilePath, buildArgs)
 	if err != nil {
 		return nil, err
 	}
@@ -171,8 +203,7 @@ func GetDependencies(dockerfilePath, workspace string) ([]string, error) {
 	return dependencies, nil
 }
 
-func processBaseImage(value *parser.Node) ([]string, error) {
-	base := value.Next.Value
+func processBaseImage(base string) ([]string, error) {
 	logrus.Debugf("Checking base image %s for ONBUILD triggers.", base)
 	if strings.ToLower(base) == "scratch" {
 		logrus.Debugf("SCRATCH base image found, skipping check: %s", base)
@@ -262,6 +293,33 @@ func processCopy(value *parser.Node, paths map[string]struct{}, envs map[string]
 	return nil
 }
 
+// processArg evaluates an ARG instruction. The value of a build arg takes precedence
+// over the default value of the instruction, which takes precedence over the
+// value of the global ARG with the same name.
+func processArg(value *parser.Node, envs, globalArgs map[string]string, buildArgs map[string]*string) {
+	for node := value.Next; node != nil; node = node.Next {
+		kv := strings.SplitN(node.Value, "=", 2)
+		name := kv[0]
+
+		if v, present := buildArgs[name]; present && v != nil {
+			envs[name] = *v
+		} else if len(kv) == 2 {
+			envs[name] = kv[1]
+		} else if v, present := globalArgs[name]; present {
+			envs[name] = v
+		}
+	}
+}
+
+// stageName returns the name of a stage declared with `FROM image AS name`.
+func stageName(value *parser.Node) string {
+	as := value.Next.Next
+	if as == nil || !strings.EqualFold(as.Value, "as") || as.Next == nil {
+		return ""
+	}
+	return as.Next.Value
+}
+
 func processShellWord(lex *shell.Lex, word string, envs map[string]string) (string, error) {
 	envSlice := []string{}
 	for envKey, envVal := range envs {
@@ -88,6 +88,33 @@ COPY . /files
 CMD nginx
 `
 
+const fromStage = `
+FROM ubuntu:14.04 as base
+COPY server.go .
+
+FROM base
+COPY worker.go .
+`
+
+const argInFrom = `
+ARG BASE=busybox
+FROM ${BASE}
+COPY server.go .
+`
+
+const argInCopy = `
+FROM nginx
+ARG FILE=server.go
+COPY $FILE .
+`
+
+const globalArgDefault = `
+ARG FILE=worker.go
+FROM nginx
+ARG FILE
+COPY $FILE .
+`
+
 // This has an ONBUILD instruction of "COPY . /go/src/app"
 const onbuild = `
 FROM golang:onbuild
@@ -132,11 +159,15 @@ func mockRetrieveImage(image string) (*v1.ConfigFile, error) {
 }
 
 func TestGetDependencies(t *testing.T) {
+	noImage := "noimage:latest"
+	workerGo := "worker.go"
+
 	var tests = []struct {
 		description string
 		dockerfile  string
 		workspace   string
 		ignore      string
+		buildArgs   map[string]*string
 
 		expected  []string
 		badReader bool
@@ -229,6 +260,51 @@ func TestGetDependencies(t *testing.T) {
 			workspace:   ".",
 			expected:    []string{"Dockerfile", "file"},
 		},
+		{
+			description: "multistage from a previous stage",
+			dockerfile:  fromStage,
+			workspace:   ".",
+			expected:    []string{"Dockerfile", "server.go", "worker.go"},
+		},
+		{
+			description: "arg in from",
+			dockerfile:  argInFrom,
+			workspace:   ".",
+			expected:    []string{"Dockerfile", "server.go"},
+		},
+		{
+			description: "unknown base image from arg",
+			dockerfile:  argInFrom,
+			workspace:   ".",
+			buildArgs:   map[string]*string{"BASE": &noImage},
+			expected:    []string{"Dockerfile", "server.go"},
+		},
+		{
+			description: "arg in copy",
+			dockerfile:  argInCopy,
+			workspace:   ".",
+			expected:    []string{"Dockerfile", "server.go"},
+		},
+		{
+			description: "build arg overrides arg",
+			dockerfile:  argInCopy,
+			workspace:   ".",
+			buildArgs:   map[string]*string{"FILE": &workerGo},
+			expected:    []string{"Dockerfile", "worker.go"},
+		},
+		{
+			description: "nil build arg",
+			dockerfile:  argInCopy,
+			workspace:   ".",
+			buildArgs:   map[string]*string{"FILE": nil},
+			expected:    []string{"Dockerfile", "server.go"},
+		},
+		{
+			description: "global arg default",
+			dockerfile:  globalArgDefault,
+			workspace:   ".",
+			expected:    []string{"Dockerfile", "worker.go"},
+		},
 	}
 
 	RetrieveImage = mockRetrieveImage
@@ -255,7 +331,7 @@ func TestGetDependencies(t *testing.T) {
 				ioutil.WriteFile(filepath.Join(workspace, ".dockerignore"), []byte(test.ignore), 0644)
 			}
 
-			deps, err := GetDependencies("Dockerfile", workspace)
+			deps, err := GetDependencies("Dockerfile", workspace, test.buildArgs)
 			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, deps)
 		})
 	}
@@ -0,0 +1,223 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	insecureRegistries = map[string]bool{}
+
+	// remoteTransport is used by the remote operations on registries, eg. checking if an
+	// image exists or tagging a pushed image.
+	remoteTransport http.RoundTripper = http.DefaultTransport
+)
+
+// ConfigureRegistries configures the insecure registries, that are accessed without verifying
+// their certificate or over plain HTTP, and the CA certificates, mapped by registry, of
+// registries with self-signed certificates.
+func ConfigureRegistries(insecure []string, certificates map[string]string) error {
+	rt, err := newRegistryTransport(insecure, certificates)
+	if err != nil {
+		return err
+	}
+
+	insecureRegistries = map[string]bool{}
+	for _, registry := range insecure {
+		insecureRegistries[registry] = true
+	}
+	remoteTransport = rt
+	return nil
+}
+
+// IsInsecureRegistry tells if the registry of an image was configured as insecure.
+func IsInsecureRegistry(image string) bool {
+	return insecureRegistries[registryOf(image)]
+}
+
+func registryOf(image string) string {
+	ref, err := name.ParseReference(image, name.WeakValidation)
+	if err != nil {
+		return ""
+	}
+	return ref.Context().RegistryStr()
+}
+
+// registryTransport uses a specific transport for each configured registry and
+// falls back to plain HTTP for insecure registries that don't serve HTTPS.
+type registryTransport struct {
+	transports map[string]http.RoundTripper
+	insecure   map[string]bool
+	fallback   http.RoundTripper
+
+	mu        sync.Mutex
+	plainHTTP map[string]bool
+}
+
+func newRegistryTransport(insecure []string, certificates map[string]string) (http.RoundTripper, error) {
+	if len(insecure) == 0 && len(certificates) == 0 {
+		return http.DefaultTransport, nil
+	}
+
+	t := &registryTransport{
+		transports: map[string]http.RoundTripper{},
+		insecure:   map[string]bool{},
+		fallback:   http.DefaultTransport,
+		plainHTTP:  map[string]bool{},
+	}
+
+	for registry, certificate := range certificates {
+		pool, err := certPool(certificate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading CA certificate of registry %s", registry)
+		}
+		t.transports[registry] = newTransport(&tls.Config{RootCAs: pool})
+	}
+
+	for _, registry := range insecure {
+		t.insecure[registry] = true
+		t.transports[registry] = newTransport(&tls.Config{InsecureSkipVerify: true})
+	}
+
+	return t, nil
+}
+
+func certPool(certificate string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no PEM certificate found in %s", certificate)
+	}
+	return pool, nil
+}
+
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:     tlsConfig,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+	}
+}
+
+func (t *registryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	rt, found := t.transports[host]
+	if !found {
+		return t.fallback.RoundTrip(req)
+	}
+
+	if !t.insecure[host] || req.URL.Scheme != "https" {
+		return rt.RoundTrip(req)
+	}
+
+	if !t.usePlainHTTP(host) {
+		resp, err := rt.RoundTrip(req)
+		if err == nil || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		logrus.Debugf("Falling back to plain HTTP for insecure registry %s: %s", host, err)
+	}
+
+	plain, err := plainHTTPRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rt.RoundTrip(plain)
+	if err == nil {
+		t.mu.Lock()
+		t.plainHTTP[host] = true
+		t.mu.Unlock()
+	}
+	return resp, err
+}
+
+func (t *registryTransport) usePlainHTTP(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.plainHTTP[host]
+}
+
+// plainHTTPRequest copies a request, with a fresh body, to be sent over plain HTTP.
+func plainHTTPRequest(req *http.Request) (*http.Request, error) {
+	plain := req.WithContext(req.Context())
+	u := *req.URL
+	u.Scheme = "http"
+	plain.URL = &u
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		plain.Body = body
+	}
+	return plain, nil
+}
+
+// checkDaemonInsecureRegistry warns if the docker daemon, that pushes the images,
+// is not configured to consider an insecure registry as insecure.
+func checkDaemonInsecureRegistry(ctx context.Context, cli APIClient, image string) {
+	registry := registryOf(image)
+	if !insecureRegistries[registry] {
+		return
+	}
+
+	info, err := cli.Info(ctx)
+	if err != nil || info.RegistryConfig == nil {
+		return
+	}
+	if index, found := info.RegistryConfig.IndexConfigs[registry]; found && !index.Secure {
+		return
+	}
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, cidr := range info.RegistryConfig.InsecureRegistryCIDRs {
+			if (*net.IPNet)(cidr).Contains(ip) {
+				return
+			}
+		}
+	}
+
+	logrus.Warnf("The docker daemon doesn't consider %s as an insecure registry, add it to the insecure-registries of its daemon.json", registry)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestRegistryTransport(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+	plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plainServer.Close()
+
+	tlsHost := host(t, tlsServer.URL)
+	plainHost := host(t, plainServer.URL)
+
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+	certificate := filepath.Join(tmpDir, "ca.pem")
+	ioutil.WriteFile(certificate, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw}), 0644)
+
+	var tests = []struct {
+		description  string
+		insecure     []string
+		certificates map[string]string
+		host         string
+		shouldErr    bool
+	}{
+		{
+			description: "self-signed certificate",
+			host:        tlsHost,
+			shouldErr:   true,
+		},
+		{
+			description:  "registry certificate",
+			certificates: map[string]string{tlsHost: certificate},
+			host:         tlsHost,
+		},
+		{
+			description: "insecure registry",
+			insecure:    []string{tlsHost},
+			host:        tlsHost,
+		},
+		{
+			description: "plain http",
+			host:        plainHost,
+			shouldErr:   true,
+		},
+		{
+			description: "insecure registry over plain http",
+			insecure:    []string{plainHost},
+			host:        plainHost,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			rt, err := newRegistryTransport(test.insecure, test.certificates)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req, _ := http.NewRequest("GET", "https://"+test.host+"/v2/", nil)
+			resp, err := rt.RoundTrip(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
+
+func TestRegistryTransportInvalidCertificate(t *testing.T) {
+	_, err := newRegistryTransport(nil, map[string]string{"registry": "missing.pem"})
+
+	testutil.CheckError(t, true, err)
+}
+
+func TestIsInsecureRegistry(t *testing.T) {
+	if err := ConfigureRegistries([]string{"localhost:5000", "registry.local"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigureRegistries(nil, nil)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, IsInsecureRegistry("localhost:5000/app:v1"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, IsInsecureRegistry("registry.local/team/app"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, IsInsecureRegistry("gcr.io/project/app"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, IsInsecureRegistry("app"))
+}
+
+func host(t *testing.T, rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"sync"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/server/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/sirupsen/logrus"
+)
+
+// Statuses of the builds and of the deployment.
+const (
+	// NotStarted is the status of a build or a deployment that hasn't started yet.
+	NotStarted = "Not Started"
+	// InProgress is the status of a build or a deployment that is running.
+	InProgress = "In Progress"
+	// Complete is the status of a build or a deployment that succeeded.
+	Complete = "Complete"
+	// Failed is the status of a build or a deployment that failed.
+	Failed = "Failed"
+)
+
+// maxLogEntries is the number of past events that are kept to be sent to new listeners.
+const maxLogEntries = 1000
+
+// listenerBuffer is the number of events that a listener can lag behind before
+// the next events are dropped for it.
+const listenerBuffer = 100
+
+var handler = newHandler()
+
+// eventHandler records the state of the dev loop and a log of the events
+// that led to it, to be sent to the listeners.
+type eventHandler struct {
+	sync.Mutex
+
+	state     proto.State
+	log       eventLog
+	listeners []chan *proto.LogEntry
+}
+
+// eventLog is a ring buffer of the latest log entries.
+type eventLog struct {
+	entries []*proto.LogEntry
+	next    int
+}
+
+func (l *eventLog) add(entry *proto.LogEntry) {
+	if len(l.entries) < maxLogEntries {
+		l.entries = append(l.entries, entry)
+		return
+	}
+
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % maxLogEntries
+}
+
+// all returns a copy of the entries, from the oldest to the latest.
+func (l *eventLog) all() []*proto.LogEntry {
+	all := make([]*proto.LogEntry, 0, len(l.entries))
+	all = append(all, l.entries[l.next:]...)
+	return append(all, l.entries[:l.next]...)
+}
+
+func newHandler() *eventHandler {
+	return &eventHandler{
+		state: emptyState(nil),
+	}
+}
+
+func emptyState(artifacts []*v1alpha2.Artifact) proto.State {
+	builds := map[string]string{}
+	for _, a := range artifacts {
+		builds[a.ImageName] = NotStarted
+	}
+
+	return proto.State{
+		BuildState: &proto.BuildState{
+			Artifacts: builds,
+		},
+		DeployState: &proto.DeployState{
+			Status: NotStarted,
+		},
+	}
+}
+
+// InitializeState resets the state and the log of events for the given artifacts.
+func InitializeState(artifacts []*v1alpha2.Artifact) {
+	handler.Lock()
+	defer handler.Unlock()
+
+	handler.state = emptyState(artifacts)
+	handler.log = eventLog{}
+}
+
+// GetState returns a copy of the current state.
+func GetState() *proto.State {
+	handler.Lock()
+	defer handler.Unlock()
+
+	builds := map[string]string{}
+	for name, status := range handler.state.BuildState.Artifacts {
+		builds[name] = status
+	}
+
+	return &proto.State{
+		BuildState: &proto.BuildState{
+			Artifacts: builds,
+		},
+		DeployState: &proto.DeployState{
+			Status: handler.state.DeployState.Status,
+		},
+	}
+}
+
+// BuildInProgress notifies that an artifact is being built.
+func BuildInProgress(imageName string) {
+	handleBuildEvent(imageName, InProgress, nil)
+}
+
+// BuildComplete notifies that an artifact was built.
+func BuildComplete(imageName string) {
+	handleBuildEvent(imageName, Complete, nil)
+}
+
+// BuildFailed notifies that the build of an artifact failed.
+func BuildFailed(imageName string, err error) {
+	handleBuildEvent(imageName, Failed, err)
+}
+
+// DeployInProgress notifies that a deployment started.
+func DeployInProgress() {
+	handleDeployEvent(InProgress, nil)
+}
+
+// DeployComplete notifies that a deployment succeeded.
+func DeployComplete() {
+	handleDeployEvent(Complete, nil)
+}
+
+// DeployFailed notifies that a deployment failed.
+func DeployFailed(err error) {
+	handleDeployEvent(Failed, err)
+}
+
+// FileChanged notifies that the dev loop detected changes.
+func FileChanged(paths []string) {
+	handler.handle(&proto.Event{
+		FileChangeEvent: &proto.FileChangeEvent{
+			Paths: paths,
+		},
+	}, "File changes detected")
+}
+
+func handleBuildEvent(imageName, status string, err error) {
+	handler.handle(&proto.Event{
+		BuildEvent: &proto.BuildEvent{
+			Artifact: imageName,
+			Status:   status,
+			Err:      errorMessage(err),
+		},
+	}, "Build "+status+" for "+imageName)
+}
+
+func handleDeployEvent(status string, err error) {
+	handler.handle(&proto.Event{
+		DeployEvent: &proto.DeployEvent{
+			Status: status,
+			Err:    errorMessage(err),
+		},
+	}, "Deploy "+status)
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (ev *eventHandler) handle(event *proto.Event, entry string) {
+	logEntry := &proto.LogEntry{
+		Timestamp: ptypes.TimestampNow(),
+		Event:     event,
+		Entry:     entry,
+	}
+
+	ev.Lock()
+	defer ev.Unlock()
+
+	switch {
+	case event.BuildEvent != nil:
+		ev.state.BuildState.Artifacts[event.BuildEvent.Artifact] = event.BuildEvent.Status
+	case event.DeployEvent != nil:
+		ev.state.DeployState.Status = event.DeployEvent.Status
+	}
+
+	ev.log.add(logEntry)
+	for _, listener := range ev.listeners {
+		select {
+		case listener <- logEntry:
+		default:
+			logrus.Warnln("Dropping event for a slow listener:", entry)
+		}
+	}
+}
+
+// ForEachEvent calls the callback with the latest events that already happened, then
+// with the new ones, until the callback returns an error or done is closed. The events
+// are dropped, with a warning, for callbacks that lag behind so that the dev loop is
+// never blocked.
+func ForEachEvent(done <-chan struct{}, callback func(*proto.LogEntry) error) error {
+	return handler.forEachEvent(done, callback)
+}
+
+func (ev *eventHandler) forEachEvent(done <-chan struct{}, callback func(*proto.LogEntry) error) error {
+	// The listener is buffered so that callbacks can be briefly slower than the dev loop.
+	// Past that, events are dropped for them.
+	listener := make(chan *proto.LogEntry, listenerBuffer)
+
+	ev.Lock()
+	oldEvents := ev.log.all()
+	ev.listeners = append(ev.listeners, listener)
+	ev.Unlock()
+
+	defer ev.removeListener(listener)
+
+	for _, entry := range oldEvents {
+		if err := callback(entry); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-done:
+			return nil
+		case entry := <-listener:
+			if err := callback(entry); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (ev *eventHandler) removeListener(listener chan *proto.LogEntry) {
+	ev.Lock()
+	defer ev.Unlock()
+
+	for i, l := range ev.listeners {
+		if l == listener {
+			ev.listeners = append(ev.listeners[:i], ev.listeners[i+1:]...)
+			return
+		}
+	}
+}
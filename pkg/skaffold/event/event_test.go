@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/server/proto"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestState(t *testing.T) {
+	defer func(h *eventHandler) { handler = h }(handler)
+	handler = newHandler()
+
+	InitializeState([]*v1alpha2.Artifact{{ImageName: "image1"}, {ImageName: "image2"}})
+	testutil.CheckErrorAndDeepEqual(t, false, nil, &proto.State{
+		BuildState:  &proto.BuildState{Artifacts: map[string]string{"image1": NotStarted, "image2": NotStarted}},
+		DeployState: &proto.DeployState{Status: NotStarted},
+	}, GetState())
+
+	BuildInProgress("image1")
+	BuildComplete("image1")
+	BuildFailed("image2", errors.New("BUG"))
+	DeployInProgress()
+	testutil.CheckErrorAndDeepEqual(t, false, nil, &proto.State{
+		BuildState:  &proto.BuildState{Artifacts: map[string]string{"image1": Complete, "image2": Failed}},
+		DeployState: &proto.DeployState{Status: InProgress},
+	}, GetState())
+
+	DeployFailed(errors.New("BUG"))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, Failed, GetState().DeployState.Status)
+}
+
+func TestForEachEvent(t *testing.T) {
+	defer func(h *eventHandler) { handler = h }(handler)
+	handler = newHandler()
+
+	InitializeState([]*v1alpha2.Artifact{{ImageName: "image"}})
+	BuildInProgress("image")
+
+	var entries []string
+	stop := errors.New("stop")
+	err := ForEachEvent(make(chan struct{}), func(entry *proto.LogEntry) error {
+		entries = append(entries, entry.Entry)
+		if entry.Event.FileChangeEvent != nil {
+			return stop
+		}
+
+		// Events that happen while listening are received too.
+		if entry.Event.BuildEvent != nil && entry.Event.BuildEvent.Status == InProgress {
+			BuildComplete("image")
+			FileChanged([]string{"file"})
+		}
+		return nil
+	})
+
+	if err != stop {
+		t.Errorf("Expected the callback's error, got %v", err)
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"Build In Progress for image", "Build Complete for image", "File changes detected"}, entries)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(handler.listeners))
+}
+
+func TestEventLogIsCapped(t *testing.T) {
+	defer func(h *eventHandler) { handler = h }(handler)
+	handler = newHandler()
+
+	InitializeState([]*v1alpha2.Artifact{{ImageName: "image"}})
+	for i := 0; i < maxLogEntries; i++ {
+		BuildInProgress("image")
+	}
+	BuildComplete("image")
+	FileChanged([]string{"file"})
+
+	all := handler.log.all()
+	testutil.CheckErrorAndDeepEqual(t, false, nil, maxLogEntries, len(all))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "Build In Progress for image", all[0].Entry)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "Build Complete for image", all[maxLogEntries-2].Entry)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "File changes detected", all[maxLogEntries-1].Entry)
+}
+
+func TestForEachEventDone(t *testing.T) {
+	defer func(h *eventHandler) { handler = h }(handler)
+	handler = newHandler()
+
+	done := make(chan struct{})
+	close(done)
+
+	err := ForEachEvent(done, func(*proto.LogEntry) error { return nil })
+
+	testutil.CheckError(t, false, err)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// MavenCommand returns the maven executable for a workspace: the maven wrapper if
+// there's one, or else `mvn`.
+func MavenCommand(workspace string) string {
+	return wrapperOr(workspace, "mvnw", "mvn")
+}
+
+// GradleCommand returns the gradle executable for a workspace: the gradle wrapper if
+// there's one, or else `gradle`.
+func GradleCommand(workspace string) string {
+	return wrapperOr(workspace, "gradlew", "gradle")
+}
+
+func wrapperOr(workspace, wrapper, defaultCommand string) string {
+	if _, err := os.Stat(filepath.Join(workspace, wrapper)); err == nil {
+		return "./" + wrapper
+	}
+	return defaultCommand
+}
+
+// MavenArgs are the arguments that select the module and the profile of a jibMaven artifact.
+func MavenArgs(a *v1alpha2.JibMavenArtifact) []string {
+	var args []string
+	if a.Module != "" {
+		args = append(args, "--projects", a.Module, "--also-make")
+	}
+	if a.Profile != "" {
+		args = append(args, "--activate-profiles", a.Profile)
+	}
+	return args
+}
+
+// GradleTask prefixes a task with the project of a jibGradle artifact.
+func GradleTask(a *v1alpha2.JibGradleArtifact, task string) string {
+	if a.Project == "" {
+		return task
+	}
+	return ":" + a.Project + ":" + task
+}
+
+// GetDependenciesMaven asks the jib maven plugin for the source dependencies of an artifact.
+func GetDependenciesMaven(a *v1alpha2.Artifact) ([]string, error) {
+	args := append(MavenArgs(a.JibMavenArtifact), "--quiet", "--non-recursive", "jib:_skaffold-files")
+	cmd := exec.Command(MavenCommand(a.Workspace), args...)
+	cmd.Dir = a.Workspace
+
+	return getDependencies(cmd, a.Workspace)
+}
+
+// GetDependenciesGradle asks the jib gradle plugin for the source dependencies of an artifact.
+func GetDependenciesGradle(a *v1alpha2.Artifact) ([]string, error) {
+	cmd := exec.Command(GradleCommand(a.Workspace), "--quiet", GradleTask(a.JibGradleArtifact, "_jibSkaffoldFiles"))
+	cmd.Dir = a.Workspace
+
+	return getDependencies(cmd, a.Workspace)
+}
+
+// getDependencies runs a command that prints the files and directories an artifact depends on,
+// one per line, and returns the files found there relative to the workspace.
+func getDependencies(cmd *exec.Cmd, workspace string) ([]string, error) {
+	stdout, err := util.RunCmdOut(cmd)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting jib dependencies")
+	}
+
+	absWorkspace, err := filepath.Abs(workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []string
+	for _, line := range strings.Split(string(stdout), "\n") {
+		path := strings.TrimSpace(line)
+		if path == "" {
+			continue
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(absWorkspace, path)
+		}
+
+		// Source directories that don't exist yet are listed too.
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(absWorkspace, file)
+			if err != nil {
+				return err
+			}
+			deps = append(deps, rel)
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "walking %s", path)
+		}
+	}
+
+	sort.Strings(deps)
+	return deps, nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestGetDependenciesMaven(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	for _, file := range []string{"pom.xml", "src/main/java/App.java", "src/main/resources/app.properties"} {
+		path := filepath.Join(tmpDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		description string
+		stdout      string
+		err         error
+		expected    []string
+		shouldErr   bool
+	}{
+		{
+			description: "files and directories",
+			stdout:      fmt.Sprintf("%s\n%s\n%s\n", filepath.Join(tmpDir, "pom.xml"), filepath.Join(tmpDir, "src", "main"), filepath.Join(tmpDir, "src", "test")),
+			expected:    []string{"pom.xml", filepath.Join("src", "main", "java", "App.java"), filepath.Join("src", "main", "resources", "app.properties")},
+		},
+		{
+			description: "relative paths",
+			stdout:      "pom.xml\n",
+			expected:    []string{"pom.xml"},
+		},
+		{
+			description: "failure",
+			stdout:      "",
+			err:         fmt.Errorf("BUILD FAILURE"),
+			shouldErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = testutil.NewFakeCmdOut("mvn --projects app --also-make --quiet --non-recursive jib:_skaffold-files", test.stdout, test.err)
+
+			deps, err := GetDependenciesMaven(&v1alpha2.Artifact{
+				Workspace: tmpDir,
+				ArtifactType: v1alpha2.ArtifactType{
+					JibMavenArtifact: &v1alpha2.JibMavenArtifact{Module: "app"},
+				},
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, deps)
+		})
+	}
+}
+
+func TestGetDependenciesGradle(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "build.gradle"), []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmdOut("gradle --quiet :app:_jibSkaffoldFiles", filepath.Join(tmpDir, "build.gradle"), nil)
+
+	deps, err := GetDependenciesGradle(&v1alpha2.Artifact{
+		Workspace: tmpDir,
+		ArtifactType: v1alpha2.ArtifactType{
+			JibGradleArtifact: &v1alpha2.JibGradleArtifact{Project: "app"},
+		},
+	})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []string{"build.gradle"}, deps)
+}
+
+func TestWrapper(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "mvn", MavenCommand(tmpDir))
+
+	if err := ioutil.WriteFile(filepath.Join(tmpDir, "mvnw"), []byte(""), 0755); err != nil {
+		t.Fatal(err)
+	}
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "./mvnw", MavenCommand(tmpDir))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "gradle", GradleCommand(tmpDir))
+}
+
+func TestMavenArgs(t *testing.T) {
+	args := MavenArgs(&v1alpha2.JibMavenArtifact{Module: "app", Profile: "dev"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{"--projects", "app", "--also-make", "--activate-profiles", "dev"}, args)
+}
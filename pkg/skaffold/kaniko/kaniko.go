@@ -20,6 +20,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
@@ -32,12 +36,48 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact, cfg *v1alpha2.KanikoBuild) (string, error) {
+const (
+	// RegistryCertificatesSecret is the secret that holds the CA certificates of the registries.
+	RegistryCertificatesSecret = "kaniko-registry-certificates"
+
+	// registryCertificatesPath is where the CA certificates are mounted in kaniko pods.
+	registryCertificatesPath = "/kaniko/registry-certificates"
+)
+
+var invalidSecretKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// certificateKey is the key of the CA certificate of a registry in the secret.
+func certificateKey(registry string) string {
+	return invalidSecretKeyChars.ReplaceAllString(registry, "_") + ".pem"
+}
+
+// RegistryCertificatesData reads the CA certificates, mapped by registry, into the data
+// of the RegistryCertificatesSecret.
+func RegistryCertificatesData(certificates map[string]string) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	for registry, certificate := range certificates {
+		pem, err := ioutil.ReadFile(certificate)
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading CA certificate of registry %s", registry)
+		}
+		data[certificateKey(registry)] = pem
+	}
+	return data, nil
+}
+
+// RunKanikoBuild builds and pushes an artifact with a kaniko pod. If there are registry
+// certificates, the RegistryCertificatesSecret must exist in the namespace of the pod.
+func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artifact, cfg *v1alpha2.KanikoBuild, registryCertificates map[string]string) (string, error) {
 	dockerfilePath := artifact.DockerArtifact.DockerfilePath
 
+	timeout, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing kaniko timeout %s", cfg.Timeout)
+	}
+
 	initialTag := util.RandomID()
 	tarName := "context.tar.gz" // TODO(r2d4): until this is configurable upstream
-	if err := docker.UploadContextToGCS(ctx, dockerfilePath, artifact.Workspace, cfg.GCSBucket, tarName); err != nil {
+	if err := docker.UploadContextToGCS(ctx, dockerfilePath, artifact.Workspace, cfg.GCSBucket, tarName, artifact.DockerArtifact.BuildArgs); err != nil {
 		return "", errors.Wrap(err, "uploading tar to gcs")
 	}
 
@@ -49,15 +89,81 @@ func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artif
 	imageList := kubernetes.NewImageList()
 	imageList.Add(constants.DefaultKanikoImage)
 
-	logger := kubernetes.NewLogAggregator(out, imageList, kubernetes.NewColorPicker([]*v1alpha2.Artifact{artifact}))
+	logger := kubernetes.NewLogAggregator(out, imageList, kubernetes.NewColorPicker([]*v1alpha2.Artifact{artifact}), "")
 	if err := logger.Start(ctx); err != nil {
 		return "", errors.Wrap(err, "starting log streamer")
 	}
 	imageDst := fmt.Sprintf("%s:%s", artifact.ImageName, initialTag)
-	p, err := client.CoreV1().Pods("default").Create(&v1.Pod{
+	pods := client.CoreV1().Pods(cfg.Namespace)
+	p, err := pods.Create(kanikoPod(artifact.DockerArtifact, imageDst, cfg, registryCertificates))
+	if err != nil {
+		return "", errors.Wrap(err, "creating kaniko pod")
+	}
+
+	defer func() {
+		imageList.Remove(constants.DefaultKanikoImage)
+		if err := pods.Delete(p.Name, &metav1.DeleteOptions{
+			GracePeriodSeconds: new(int64),
+		}); err != nil {
+			logrus.Warnf("deleting pod %s: %s", p.Name, err)
+		}
+	}()
+
+	if err := kubernetes.WaitForPodComplete(pods, p.Name, timeout); err != nil {
+		return "", errors.Wrap(err, "waiting for pod to complete")
+	}
+
+	return imageDst, nil
+}
+
+// kanikoArgs are the arguments of the kaniko executor. Images are pushed to insecure
+// registries over plain HTTP or without verifying their certificate, and to registries
+// with self-signed certificates with their mounted CA certificate.
+// Kaniko supports build args and targets, the other docker build flags are ignored.
+func kanikoArgs(a *v1alpha2.DockerArtifact, imageDst string, cfg *v1alpha2.KanikoBuild, registryCertificates map[string]string) []string {
+	args := []string{
+		fmt.Sprintf("--dockerfile=%s", a.DockerfilePath),
+		fmt.Sprintf("--bucket=%s", cfg.GCSBucket),
+		fmt.Sprintf("--destination=%s", imageDst),
+		fmt.Sprintf("-v=%s", logrus.GetLevel().String()),
+	}
+
+	var buildArgs []string
+	for k, v := range a.BuildArgs {
+		if v != nil {
+			buildArgs = append(buildArgs, fmt.Sprintf("--build-arg=%s=%s", k, *v))
+		}
+	}
+	sort.Strings(buildArgs)
+	args = append(args, buildArgs...)
+
+	if a.Target != "" {
+		args = append(args, fmt.Sprintf("--target=%s", a.Target))
+	}
+	if a.NetworkMode != "" || len(a.CacheFrom) > 0 || len(a.SSH) > 0 || len(a.Secrets) > 0 {
+		logrus.Warnf("network, cacheFrom, ssh and secrets are not supported by kaniko and are ignored")
+	}
+
+	if docker.IsInsecureRegistry(imageDst) {
+		args = append(args, "--insecure", "--skip-tls-verify")
+	}
+
+	var certificates []string
+	for registry := range registryCertificates {
+		certificates = append(certificates, fmt.Sprintf("--registry-certificate=%s=%s/%s", registry, registryCertificatesPath, certificateKey(registry)))
+	}
+	sort.Strings(certificates)
+	return append(args, certificates...)
+}
+
+// kanikoPod is the pod that builds and pushes an image with kaniko. Its name is generated
+// so that concurrent builds don't collide.
+func kanikoPod(a *v1alpha2.DockerArtifact, imageDst string, cfg *v1alpha2.KanikoBuild, registryCertificates map[string]string) *v1.Pod {
+	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   "kaniko",
-			Labels: map[string]string{"kaniko": "kaniko"},
+			GenerateName: "kaniko-",
+			Namespace:    cfg.Namespace,
+			Labels:       map[string]string{"kaniko": "kaniko"},
 		},
 		Spec: v1.PodSpec{
 			Containers: []v1.Container{
@@ -65,12 +171,7 @@ func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artif
 					Name:            "kaniko",
 					Image:           constants.DefaultKanikoImage,
 					ImagePullPolicy: v1.PullIfNotPresent,
-					Args: []string{
-						fmt.Sprintf("--dockerfile=%s", dockerfilePath),
-						fmt.Sprintf("--bucket=%s", cfg.GCSBucket),
-						fmt.Sprintf("--destination=%s", imageDst),
-						fmt.Sprintf("-v=%s", logrus.GetLevel().String()),
-					},
+					Args:            kanikoArgs(a, imageDst, cfg, registryCertificates),
 					VolumeMounts: []v1.VolumeMount{
 						{
 							Name:      "kaniko-secret",
@@ -97,24 +198,22 @@ func RunKanikoBuild(ctx context.Context, out io.Writer, artifact *v1alpha2.Artif
 			},
 			RestartPolicy: v1.RestartPolicyNever,
 		},
-	})
-
-	if err != nil {
-		return "", errors.Wrap(err, "creating kaniko pod")
 	}
 
-	defer func() {
-		imageList.Remove(constants.DefaultKanikoImage)
-		if err := client.CoreV1().Pods("default").Delete(p.Name, &metav1.DeleteOptions{
-			GracePeriodSeconds: new(int64),
-		}); err != nil {
-			logrus.Fatalf("deleting pod: %s", err)
-		}
-	}()
-
-	if err := kubernetes.WaitForPodComplete(client.CoreV1().Pods("default"), p.Name); err != nil {
-		return "", errors.Wrap(err, "waiting for pod to complete")
+	if len(registryCertificates) > 0 {
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, v1.VolumeMount{
+			Name:      RegistryCertificatesSecret,
+			MountPath: registryCertificatesPath,
+			ReadOnly:  true,
+		})
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: RegistryCertificatesSecret,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: RegistryCertificatesSecret,
+				},
+			},
+		})
 	}
-
-	return imageDst, nil
+	return pod
 }
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kaniko
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestKanikoPod(t *testing.T) {
+	pod := kanikoPod(&v1alpha2.DockerArtifact{DockerfilePath: "Dockerfile"}, "gcr.io/project/image:abcd", &v1alpha2.KanikoBuild{
+		GCSBucket: "bucket",
+		Namespace: "builds",
+	}, nil)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "kaniko-", pod.GenerateName)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "builds", pod.Namespace)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		"--dockerfile=Dockerfile",
+		"--bucket=bucket",
+		"--destination=gcr.io/project/image:abcd",
+		"-v=info",
+	}, pod.Spec.Containers[0].Args)
+}
+
+func TestKanikoArgsInsecureRegistry(t *testing.T) {
+	if err := docker.ConfigureRegistries([]string{"localhost:5000"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer docker.ConfigureRegistries(nil, nil)
+
+	args := kanikoArgs(&v1alpha2.DockerArtifact{DockerfilePath: "Dockerfile"}, "localhost:5000/image:abcd", &v1alpha2.KanikoBuild{GCSBucket: "bucket"}, nil)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		"--dockerfile=Dockerfile",
+		"--bucket=bucket",
+		"--destination=localhost:5000/image:abcd",
+		"-v=info",
+		"--insecure",
+		"--skip-tls-verify",
+	}, args)
+}
+
+func TestKanikoArgsBuildFlags(t *testing.T) {
+	value := "value"
+	args := kanikoArgs(&v1alpha2.DockerArtifact{
+		DockerfilePath: "Dockerfile",
+		BuildArgs:      map[string]*string{"b": &value, "a": &value, "unset": nil},
+		Target:         "builder",
+	}, "gcr.io/project/image:abcd", &v1alpha2.KanikoBuild{GCSBucket: "bucket"}, nil)
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		"--dockerfile=Dockerfile",
+		"--bucket=bucket",
+		"--destination=gcr.io/project/image:abcd",
+		"-v=info",
+		"--build-arg=a=value",
+		"--build-arg=b=value",
+		"--target=builder",
+	}, args)
+}
+
+func TestKanikoPodRegistryCertificates(t *testing.T) {
+	pod := kanikoPod(&v1alpha2.DockerArtifact{DockerfilePath: "Dockerfile"}, "registry.local:5000/image:abcd", &v1alpha2.KanikoBuild{
+		GCSBucket: "bucket",
+	}, map[string]string{"registry.local:5000": "/etc/ssl/registry-ca.pem"})
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []string{
+		"--dockerfile=Dockerfile",
+		"--bucket=bucket",
+		"--destination=registry.local:5000/image:abcd",
+		"-v=info",
+		"--registry-certificate=registry.local:5000=/kaniko/registry-certificates/registry.local_5000.pem",
+	}, pod.Spec.Containers[0].Args)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "/kaniko/registry-certificates", pod.Spec.Containers[0].VolumeMounts[1].MountPath)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, RegistryCertificatesSecret, pod.Spec.Volumes[1].Secret.SecretName)
+}
+
+func TestRegistryCertificatesData(t *testing.T) {
+	tmp, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	certificate := filepath.Join(tmp, "ca.pem")
+	if err := ioutil.WriteFile(certificate, []byte("PEM"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := RegistryCertificatesData(map[string]string{"registry.local:5000": certificate})
+	testutil.CheckErrorAndDeepEqual(t, false, err, map[string][]byte{"registry.local_5000.pem": []byte("PEM")}, data)
+
+	_, err = RegistryCertificatesData(map[string]string{"registry.local:5000": filepath.Join(tmp, "missing.pem")})
+	testutil.CheckError(t, true, err)
+}
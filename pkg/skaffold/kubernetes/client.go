@@ -26,9 +26,17 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
+// ClientForContext is for tests
+var ClientForContext = GetClientsetForContext
+
 func GetClientset() (kubernetes.Interface, error) {
+	return GetClientsetForContext("")
+}
+
+// GetClientsetForContext creates a client for a kube context, or for the current context if it's empty.
+func GetClientsetForContext(kubeContext string) (kubernetes.Interface, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext})
 	clientConfig, err := kubeConfig.ClientConfig()
 	if err != nil {
 		return nil, fmt.Errorf("Error creating kubeConfig: %s", err)
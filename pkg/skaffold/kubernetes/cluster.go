@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// LocalCluster is the kind of local cluster a kube context points to.
+type LocalCluster string
+
+const (
+	// NotLocal is for remote clusters, to which images have to be pushed.
+	NotLocal LocalCluster = ""
+	// Minikube clusters run their own docker daemon, given by `minikube docker-env`.
+	Minikube LocalCluster = "minikube"
+	// DockerDesktop clusters share the host's docker daemon.
+	DockerDesktop LocalCluster = "docker-desktop"
+)
+
+// DetectLocalCluster tells if a kube context points to a local cluster, whose nodes
+// can run the images built by their docker daemon without pushing them.
+func DetectLocalCluster(kubeContext string) LocalCluster {
+	cfg, err := rawConfig()
+	if err != nil {
+		logrus.Debugf("Unable to load kubeconfig, detecting local clusters by context name: %s", err)
+	}
+	return detectLocalCluster(kubeContext, cfg)
+}
+
+// detectLocalCluster first looks at the name of the context, and then at its cluster.
+// minikube profiles have their certificate authority in `~/.minikube` and Docker Desktop
+// clusters are served on `kubernetes.docker.internal`.
+func detectLocalCluster(kubeContext string, cfg api.Config) LocalCluster {
+	switch kubeContext {
+	case constants.DefaultMinikubeContext:
+		return Minikube
+	case constants.DefaultDockerForDesktopContext, constants.DefaultDockerDesktopContext:
+		return DockerDesktop
+	}
+
+	context, present := cfg.Contexts[kubeContext]
+	if !present {
+		return NotLocal
+	}
+	cluster, present := cfg.Clusters[context.Cluster]
+	if !present {
+		return NotLocal
+	}
+
+	if strings.Contains(filepath.ToSlash(cluster.CertificateAuthority), "/.minikube/") {
+		return Minikube
+	}
+	if server, err := url.Parse(cluster.Server); err == nil && server.Hostname() == "kubernetes.docker.internal" {
+		return DockerDesktop
+	}
+	return NotLocal
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestDetectLocalCluster(t *testing.T) {
+	cfg := api.Config{
+		Contexts: map[string]*api.Context{
+			"dev":     {Cluster: "dev"},
+			"desktop": {Cluster: "desktop"},
+			"gke":     {Cluster: "gke"},
+		},
+		Clusters: map[string]*api.Cluster{
+			"dev":     {Server: "https://192.168.99.100:8443", CertificateAuthority: "/home/user/.minikube/ca.crt"},
+			"desktop": {Server: "https://kubernetes.docker.internal:6443"},
+			"gke":     {Server: "https://35.0.0.1"},
+		},
+	}
+
+	var tests = []struct {
+		description string
+		kubeContext string
+		expected    LocalCluster
+	}{
+		{
+			description: "default minikube context",
+			kubeContext: "minikube",
+			expected:    Minikube,
+		},
+		{
+			description: "docker for desktop context",
+			kubeContext: "docker-for-desktop",
+			expected:    DockerDesktop,
+		},
+		{
+			description: "docker desktop context",
+			kubeContext: "docker-desktop",
+			expected:    DockerDesktop,
+		},
+		{
+			description: "minikube profile",
+			kubeContext: "dev",
+			expected:    Minikube,
+		},
+		{
+			description: "renamed docker desktop context",
+			kubeContext: "desktop",
+			expected:    DockerDesktop,
+		},
+		{
+			description: "remote cluster",
+			kubeContext: "gke",
+			expected:    NotLocal,
+		},
+		{
+			description: "unknown context",
+			kubeContext: "unknown",
+			expected:    NotLocal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, detectLocalCluster(test.kubeContext, cfg))
+		})
+	}
+}
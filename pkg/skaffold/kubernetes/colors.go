@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/docker/distribution/reference"
 	"k8s.io/api/core/v1"
 )
 
@@ -61,7 +62,7 @@ type colorPicker struct {
 func NewColorPicker(artifacts []*v1alpha2.Artifact) ColorPicker {
 	colors := map[string]color{}
 	for i, artifact := range artifacts {
-		colors[artifact.ImageName] = colorCodes[i%len(colorCodes)]
+		colors[stripTag(artifact.ImageName)] = colorCodes[i%len(colorCodes)]
 	}
 
 	return &colorPicker{
@@ -79,10 +80,13 @@ func (p *colorPicker) Pick(pod *v1.Pod) color {
 	return colorCodeWhite
 }
 
+// stripTag removes the tag and the digest of an image. Registries with a port,
+// eg. `localhost:5000/image:tag`, are supported.
 func stripTag(image string) string {
-	if !strings.Contains(image, ":") {
-		return image
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return strings.SplitN(image, ":", 2)[0]
 	}
 
-	return strings.SplitN(image, ":", 2)[0]
+	return reference.FamiliarName(named)
 }
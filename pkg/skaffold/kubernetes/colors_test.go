@@ -76,11 +76,34 @@ func TestColorPicker(t *testing.T) {
 			},
 			expectedColor: colorCodes[1],
 		},
+		{
+			description: "registry with port",
+			pod: &v1.Pod{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{Image: "localhost:5000/third:tag"},
+					},
+				},
+			},
+			expectedColor: colorCodes[2],
+		},
+		{
+			description: "digest",
+			pod: &v1.Pod{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{Image: "second@sha256:8d7e1a4f2c9ef1e2ba6b8b6f1e5a4d6f3a1d0c988a6f0c90b1b3e7c1b8c5a0f2"},
+					},
+				},
+			},
+			expectedColor: colorCodes[1],
+		},
 	}
 
 	picker := NewColorPicker([]*v1alpha2.Artifact{
 		{ImageName: "image"},
 		{ImageName: "second"},
+		{ImageName: "localhost:5000/third"},
 	})
 
 	for _, test := range tests {
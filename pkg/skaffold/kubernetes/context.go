@@ -21,6 +21,7 @@ import (
 
 	"github.com/pkg/errors"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 var (
@@ -31,10 +32,7 @@ var (
 
 func CurrentContext() (string, error) {
 	currentContextOnce.Do(func() {
-		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-		kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
-
-		cfg, err := kubeConfig.RawConfig()
+		cfg, err := rawConfig()
 		if err != nil {
 			currentContextErr = errors.Wrap(err, "loading kubeconfig")
 			return
@@ -45,3 +43,23 @@ func CurrentContext() (string, error) {
 
 	return currentContext, currentContextErr
 }
+
+// ContextNamespace returns the namespace of a kube context, `default` if it has none.
+func ContextNamespace(kubeContext string) (string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext})
+
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return "", errors.Wrapf(err, "getting namespace of context %s", kubeContext)
+	}
+	return namespace, nil
+}
+
+// rawConfig loads the kubeconfig, following the default loading rules.
+func rawConfig() (api.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+
+	return kubeConfig.RawConfig()
+}
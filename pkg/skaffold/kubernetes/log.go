@@ -25,6 +25,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/docker/distribution/reference"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
@@ -38,9 +39,10 @@ var Client = GetClientset
 
 // LogAggregator aggregates the logs for all the deployed pods.
 type LogAggregator struct {
-	output      io.Writer
-	podSelector PodSelector
-	colorPicker ColorPicker
+	output        io.Writer
+	podSelector   PodSelector
+	colorPicker   ColorPicker
+	labelSelector string
 
 	muted             int32
 	startTime         time.Time
@@ -48,11 +50,13 @@ type LogAggregator struct {
 }
 
 // NewLogAggregator creates a new LogAggregator for a given output.
-func NewLogAggregator(out io.Writer, podSelector PodSelector, colorPicker ColorPicker) *LogAggregator {
+// If set, the label selector restricts the pods that are logged.
+func NewLogAggregator(out io.Writer, podSelector PodSelector, colorPicker ColorPicker, labelSelector string) *LogAggregator {
 	return &LogAggregator{
-		output:      out,
-		podSelector: podSelector,
-		colorPicker: colorPicker,
+		output:        out,
+		podSelector:   podSelector,
+		colorPicker:   colorPicker,
+		labelSelector: labelSelector,
 		trackedContainers: trackedContainers{
 			ids: map[string]bool{},
 		},
@@ -68,19 +72,32 @@ func (a *LogAggregator) Start(ctx context.Context) error {
 
 	a.startTime = time.Now()
 
-	watcher, err := client.Pods("").Watch(meta_v1.ListOptions{
-		IncludeUninitialized: true,
-	})
+	watcher, err := a.watchPods(client)
 	if err != nil {
 		return err
 	}
 
 	go func() {
+		defer func() { watcher.Stop() }()
+
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case evt := <-watcher.ResultChan():
+			case evt, ok := <-watcher.ResultChan():
+				if !ok {
+					// The api server closes watches after a while.
+					logrus.Debugln("Pod watch closed, restarting it")
+					watcher.Stop()
+					w, err := a.watchPods(client)
+					if err != nil {
+						logrus.Errorln("watching pods:", err)
+						return
+					}
+					watcher = w
+					continue
+				}
+
 				if evt.Type != watch.Added && evt.Type != watch.Modified {
 					continue
 				}
@@ -100,15 +117,32 @@ func (a *LogAggregator) Start(ctx context.Context) error {
 	return nil
 }
 
+func (a *LogAggregator) watchPods(client corev1.PodsGetter) (watch.Interface, error) {
+	return client.Pods("").Watch(meta_v1.ListOptions{
+		IncludeUninitialized: true,
+		LabelSelector:        a.labelSelector,
+	})
+}
+
 func (a *LogAggregator) streamLogs(ctx context.Context, client corev1.PodsGetter, pod *v1.Pod) error {
 	pods := client.Pods(pod.Namespace)
 
+	images := map[string]string{}
+	for _, container := range pod.Spec.Containers {
+		images[container.Name] = container.Image
+	}
+
 	for _, container := range pod.Status.ContainerStatuses {
 		containerID := container.ContainerID
 		if containerID == "" {
 			continue
 		}
 
+		// Skip the sidecars of multi-container pods.
+		if s, ok := a.podSelector.(ContainerSelector); ok && !s.SelectContainer(images[container.Name]) {
+			continue
+		}
+
 		alreadyTracked := a.trackedContainers.add(containerID)
 		if alreadyTracked {
 			continue
@@ -232,7 +266,15 @@ type PodSelector interface {
 	Select(pod *v1.Pod) bool
 }
 
-// ImageList implements PodSelector based on a list of images names.
+// ContainerSelector can be implemented by PodSelectors to choose which
+// containers of the selected pods to log.
+type ContainerSelector interface {
+	SelectContainer(image string) bool
+}
+
+// ImageList implements PodSelector and ContainerSelector based on a list of images names.
+// Images are matched by their normalized name and tag, or by their digest, so that
+// `app:tag` matches `docker.io/library/app:tag`.
 type ImageList struct {
 	sync.RWMutex
 	names map[string]bool
@@ -248,27 +290,57 @@ func NewImageList() *ImageList {
 // Add adds an image to the list.
 func (l *ImageList) Add(image string) {
 	l.Lock()
-	l.names[image] = true
+	for _, key := range imageKeys(image) {
+		l.names[key] = true
+	}
 	l.Unlock()
 }
 
 // Remove removes an image from the list.
 func (l *ImageList) Remove(image string) {
 	l.Lock()
-	delete(l.names, image)
+	for _, key := range imageKeys(image) {
+		delete(l.names, key)
+	}
 	l.Unlock()
 }
 
 // Select returns true if one of the pod's images is in the list.
 func (l *ImageList) Select(pod *v1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		if l.SelectContainer(container.Image) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectContainer returns true if the image is in the list.
+func (l *ImageList) SelectContainer(image string) bool {
 	l.RLock()
 	defer l.RUnlock()
 
-	for _, container := range pod.Spec.Containers {
-		if l.names[container.Image] {
+	for _, key := range imageKeys(image) {
+		if l.names[key] {
 			return true
 		}
 	}
 
 	return false
 }
+
+// imageKeys returns the keys an image is matched with: its normalized reference
+// and, if it has a digest, its normalized name with the digest.
+func imageKeys(image string) []string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return []string{image}
+	}
+
+	keys := []string{named.String()}
+	if digested, ok := named.(reference.Digested); ok {
+		keys = append(keys, named.Name()+"@"+digested.Digest().String())
+	}
+	return keys
+}
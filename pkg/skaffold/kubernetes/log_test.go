@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	v1 "k8s.io/api/core/v1"
+)
+
+const testDigest = "sha256:8d7e1a4f2c9ef1e2ba6b8b6f1e5a4d6f3a1d0c988a6f0c90b1b3e7c1b8c5a0f2"
+
+func TestImageListSelect(t *testing.T) {
+	var tests = []struct {
+		description string
+		added       string
+		image       string
+		expected    bool
+	}{
+		{
+			description: "same image",
+			added:       "app:tag",
+			image:       "app:tag",
+			expected:    true,
+		},
+		{
+			description: "other tag",
+			added:       "app:tag",
+			image:       "app:other",
+		},
+		{
+			description: "normalized name",
+			added:       "app:tag",
+			image:       "docker.io/library/app:tag",
+			expected:    true,
+		},
+		{
+			description: "same digest",
+			added:       "gcr.io/project/app:tag@" + testDigest,
+			image:       "gcr.io/project/app@" + testDigest,
+			expected:    true,
+		},
+		{
+			description: "other image",
+			added:       "app:tag",
+			image:       "sidecar:tag",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			l := NewImageList()
+			l.Add(test.added)
+
+			selected := l.Select(&v1.Pod{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Image: test.image}},
+				},
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, selected)
+		})
+	}
+}
+
+func TestImageListRemove(t *testing.T) {
+	l := NewImageList()
+	l.Add("app:tag")
+	l.Remove("docker.io/library/app:tag")
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, l.SelectContainer("app:tag"))
+}
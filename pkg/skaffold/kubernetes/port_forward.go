@@ -0,0 +1,299 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// PortForwarder forwards the container ports of the deployed pods, and the ports
+// of the configured resources, to local ports with `kubectl port-forward`.
+type PortForwarder struct {
+	output      io.Writer
+	podSelector PodSelector
+	kubeContext string
+	resources   []v1alpha2.PortForwardResource
+
+	sync.Mutex
+	forwards   map[string]*portForward
+	localPorts map[string]int
+	usedPorts  map[int]bool
+	readyPods  map[string]*v1.Pod
+
+	// forward is for tests.
+	forward func(ctx context.Context, f *portForward) error
+}
+
+// portForward is a port of a resource forwarded to a local port.
+type portForward struct {
+	resource  string
+	namespace string
+	port      int
+	localPort int
+
+	cancel context.CancelFunc
+}
+
+func (f *portForward) String() string {
+	return fmt.Sprintf("%s/%s %d -> %d", f.namespace, f.resource, f.port, f.localPort)
+}
+
+// portForwardRetryDelay is how long to wait before restarting a failed port forward.
+var portForwardRetryDelay = time.Second
+
+// NewPortForwarder creates a new PortForwarder for the pods chosen by the selector.
+func NewPortForwarder(out io.Writer, podSelector PodSelector, kubeContext string, resources []v1alpha2.PortForwardResource) *PortForwarder {
+	p := &PortForwarder{
+		output:      out,
+		podSelector: podSelector,
+		kubeContext: kubeContext,
+		resources:   resources,
+		forwards:    map[string]*portForward{},
+		localPorts:  map[string]int{},
+		usedPorts:   map[int]bool{},
+		readyPods:   map[string]*v1.Pod{},
+	}
+	p.forward = p.kubectlPortForward
+	return p
+}
+
+// Start forwards the configured resources and watches the pods to forward their container
+// ports. Each container port is forwarded to the same local port, if it's available, and
+// keeps the same local port when its pod is replaced by another pod of the same workload.
+func (p *PortForwarder) Start(ctx context.Context) error {
+	for _, r := range p.resources {
+		localPort := r.LocalPort
+		if localPort == 0 {
+			localPort = r.Port
+		}
+		namespace := r.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+
+		key := fmt.Sprintf("%s/%s/%s/%d", namespace, r.ResourceType, r.ResourceName, r.Port)
+		p.start(ctx, key, &portForward{
+			resource:  fmt.Sprintf("%s/%s", r.ResourceType, r.ResourceName),
+			namespace: namespace,
+			port:      r.Port,
+			localPort: localPort,
+		})
+	}
+
+	kubeclient, err := Client()
+	if err != nil {
+		return errors.Wrap(err, "getting k8s client")
+	}
+
+	watcher, err := kubeclient.CoreV1().Pods("").Watch(meta_v1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "watching pods")
+	}
+
+	go func() {
+		defer watcher.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-watcher.ResultChan():
+				if !ok {
+					return
+				}
+
+				pod, ok := evt.Object.(*v1.Pod)
+				if !ok || !p.podSelector.Select(pod) {
+					continue
+				}
+
+				if evt.Type == watch.Deleted || !isPodReady(pod) {
+					p.stopPod(ctx, pod)
+				} else {
+					p.forwardPod(ctx, pod)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// forwardPod forwards the container ports of a ready pod. A port that's already forwarded
+// for another pod of the same workload stays on that pod until it's deleted or not ready.
+func (p *PortForwarder) forwardPod(ctx context.Context, pod *v1.Pod) {
+	p.Lock()
+	p.readyPods[pod.Namespace+"/"+pod.Name] = pod
+	p.Unlock()
+
+	workload := podWorkload(pod)
+	for _, c := range pod.Spec.Containers {
+		for _, port := range c.Ports {
+			key := fmt.Sprintf("%s/%s/%s/%d", pod.Namespace, workload, c.Name, port.ContainerPort)
+			p.start(ctx, key, &portForward{
+				resource:  "pod/" + pod.Name,
+				namespace: pod.Namespace,
+				port:      int(port.ContainerPort),
+				localPort: int(port.ContainerPort),
+			})
+		}
+	}
+}
+
+// stopPod stops forwarding the ports of a deleted, or not ready, pod. Its forwards are
+// moved to the other ready pods of the same workload, if any.
+func (p *PortForwarder) stopPod(ctx context.Context, pod *v1.Pod) {
+	p.Lock()
+	delete(p.readyPods, pod.Namespace+"/"+pod.Name)
+
+	stopped := false
+	for key, f := range p.forwards {
+		if f.resource == "pod/"+pod.Name && f.namespace == pod.Namespace {
+			f.cancel()
+			delete(p.forwards, key)
+			stopped = true
+		}
+	}
+
+	var others []*v1.Pod
+	if stopped {
+		for _, other := range p.readyPods {
+			if other.Namespace == pod.Namespace && podWorkload(other) == podWorkload(pod) {
+				others = append(others, other)
+			}
+		}
+	}
+	p.Unlock()
+
+	for _, other := range others {
+		p.forwardPod(ctx, other)
+	}
+}
+
+// podWorkload names the workload that owns a pod, eg. `deployment/app`. The pods that
+// a deployment creates during a rollout belong to new replica sets, whose names are
+// the name of the deployment and the `pod-template-hash` label of their pods.
+// Pods without a controller are their own workload.
+func podWorkload(pod *v1.Pod) string {
+	owner := meta_v1.GetControllerOf(pod)
+	if owner == nil {
+		return "pod/" + pod.Name
+	}
+
+	if owner.Kind == "ReplicaSet" {
+		suffix := "-" + pod.Labels["pod-template-hash"]
+		if suffix != "-" && strings.HasSuffix(owner.Name, suffix) {
+			return "deployment/" + strings.TrimSuffix(owner.Name, suffix)
+		}
+	}
+
+	return strings.ToLower(owner.Kind) + "/" + owner.Name
+}
+
+// isPodReady tells if a pod is running and its containers are ready.
+func isPodReady(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == v1.PodReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (p *PortForwarder) start(ctx context.Context, key string, f *portForward) {
+	p.Lock()
+	defer p.Unlock()
+
+	if _, present := p.forwards[key]; present {
+		return
+	}
+
+	if localPort, present := p.localPorts[key]; present {
+		f.localPort = localPort
+	} else {
+		f.localPort = p.availablePort(f.localPort)
+		p.localPorts[key] = f.localPort
+		p.usedPorts[f.localPort] = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	f.cancel = cancel
+	p.forwards[key] = f
+
+	fmt.Fprintf(p.output, "Port forwarding %s in namespace %s, remote port %d -> local port %d\n", f.resource, f.namespace, f.port, f.localPort)
+
+	go func() {
+		for {
+			if err := p.forward(ctx, f); err != nil {
+				logrus.Debugf("Port forwarding %s: %s", f, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(portForwardRetryDelay):
+			}
+		}
+	}()
+}
+
+// availablePort returns the given port if it's free, or else a random free port.
+func (p *PortForwarder) availablePort(port int) int {
+	if !p.usedPorts[port] && isPortFree(port) {
+		return port
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return port
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func isPortFree(port int) bool {
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// kubectlPortForward runs `kubectl port-forward` until it fails or the context is cancelled.
+func (p *PortForwarder) kubectlPortForward(ctx context.Context, f *portForward) error {
+	cmd := exec.CommandContext(ctx, "kubectl", "--context", p.kubeContext, "port-forward", f.resource, fmt.Sprintf("%d:%d", f.localPort, f.port), "--namespace", f.namespace)
+	return cmd.Run()
+}
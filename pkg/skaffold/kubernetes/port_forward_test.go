@@ -0,0 +1,187 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podWithPort(name string, port int32) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:  "app",
+					Image: "app:123",
+					Ports: []v1.ContainerPort{{ContainerPort: port}},
+				},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase:      v1.PodRunning,
+			Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+		},
+	}
+}
+
+// deploymentPodWithPort is a pod created by the replica set of a deployment, for a given pod template hash.
+func deploymentPodWithPort(name, deployment, hash string, port int32) *v1.Pod {
+	controller := true
+	pod := podWithPort(name, port)
+	pod.Labels = map[string]string{"pod-template-hash": hash}
+	pod.OwnerReferences = []meta_v1.OwnerReference{{Kind: "ReplicaSet", Name: deployment + "-" + hash, Controller: &controller}}
+	return pod
+}
+
+func newTestPortForwarder(resources []v1alpha2.PortForwardResource) (*PortForwarder, chan *portForward) {
+	forwarded := make(chan *portForward, 10)
+
+	p := NewPortForwarder(ioutil.Discard, NewImageList(), "kubecontext", resources)
+	p.forward = func(ctx context.Context, f *portForward) error {
+		forwarded <- f
+		<-ctx.Done()
+		return nil
+	}
+
+	return p, forwarded
+}
+
+func TestPortForwardPod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p, forwarded := newTestPortForwarder(nil)
+
+	pod1 := deploymentPodWithPort("pod1", "app", "5d8f", 49321)
+	p.forwardPod(ctx, pod1)
+	first := <-forwarded
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "pod/pod1", first.resource)
+
+	// The same pod is only forwarded once.
+	p.forwardPod(ctx, pod1)
+
+	// Another ready replica doesn't move the forward.
+	pod2 := deploymentPodWithPort("pod2", "app", "5d8f", 49321)
+	p.forwardPod(ctx, pod2)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(forwarded))
+
+	// A pod of the next rollout takes over when the forwarded pods are gone.
+	pod3 := deploymentPodWithPort("pod3", "app", "7c9a", 49321)
+	p.forwardPod(ctx, pod3)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(forwarded))
+
+	p.stopPod(ctx, pod1)
+	second := <-forwarded
+	testutil.CheckErrorAndDeepEqual(t, false, nil, first.localPort, second.localPort)
+
+	p.stopPod(ctx, pod2)
+	if second.resource == "pod/pod2" {
+		second = <-forwarded
+	}
+	p.stopPod(ctx, pod3)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, first.localPort, second.localPort)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(p.forwards))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 0, len(forwarded))
+}
+
+func TestPortForwardPodsOfDifferentWorkloads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p, forwarded := newTestPortForwarder(nil)
+
+	// Containers with the same name and port are forwarded for each workload.
+	p.forwardPod(ctx, deploymentPodWithPort("web1", "web", "5d8f", 49324))
+	p.forwardPod(ctx, deploymentPodWithPort("api1", "api", "7c9a", 49324))
+	first := <-forwarded
+	second := <-forwarded
+
+	if first.localPort == second.localPort {
+		t.Errorf("Expected different local ports. Got %d twice", first.localPort)
+	}
+}
+
+func TestPodWorkload(t *testing.T) {
+	controller := true
+	job := podWithPort("job-abcde", 80)
+	job.OwnerReferences = []meta_v1.OwnerReference{{Kind: "Job", Name: "job", Controller: &controller}}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "pod/pod", podWorkload(podWithPort("pod", 80)))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "deployment/app", podWorkload(deploymentPodWithPort("pod", "app", "5d8f", 80)))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "job/job", podWorkload(job))
+}
+
+func TestIsPodReady(t *testing.T) {
+	notReady := podWithPort("pod", 80)
+	notReady.Status.Conditions[0].Status = v1.ConditionFalse
+	pending := podWithPort("pod", 80)
+	pending.Status.Phase = v1.PodPending
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, true, isPodReady(podWithPort("pod", 80)))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, isPodReady(notReady))
+	testutil.CheckErrorAndDeepEqual(t, false, nil, false, isPodReady(pending))
+}
+
+func TestPortForwardDifferentPorts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p, forwarded := newTestPortForwarder(nil)
+
+	p.forwardPod(ctx, podWithPort("pod1", 49321))
+	p.forwardPod(ctx, podWithPort("pod2", 49322))
+	first := <-forwarded
+	second := <-forwarded
+
+	if first.localPort == second.localPort {
+		t.Errorf("Expected different local ports. Got %d twice", first.localPort)
+	}
+}
+
+func TestPortForwardResources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer func(c func() (clientgo.Interface, error)) { Client = c }(Client)
+	Client = func() (clientgo.Interface, error) { return fake.NewSimpleClientset(), nil }
+
+	p, forwarded := newTestPortForwarder([]v1alpha2.PortForwardResource{
+		{
+			ResourceType: "service",
+			ResourceName: "leeroy-web",
+			Port:         8080,
+			LocalPort:    49323,
+		},
+	})
+
+	if err := p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	f := <-forwarded
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, "default/service/leeroy-web 8080 -> 49323", f.String())
+}
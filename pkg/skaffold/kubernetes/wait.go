@@ -73,9 +73,9 @@ func WaitForPodReady(pods corev1.PodInterface, podName string) error {
 	})
 }
 
-func WaitForPodComplete(pods corev1.PodInterface, podName string) error {
-	logrus.Infof("Waiting for %s to be ready", podName)
-	return wait.PollImmediate(time.Millisecond*500, time.Minute*10, func() (bool, error) {
+func WaitForPodComplete(pods corev1.PodInterface, podName string, timeout time.Duration) error {
+	logrus.Infof("Waiting for %s to be complete", podName)
+	return wait.PollImmediate(time.Millisecond*500, timeout, func() (bool, error) {
 		pod, err := pods.Get(podName, meta_v1.GetOptions{
 			IncludeUninitialized: true,
 		})
@@ -266,7 +266,7 @@ func WaitForService(c kubernetes.Interface, namespace, name string, exist bool,
 	return nil
 }
 
-//WaitForServiceEndpointsNum waits until the amount of endpoints that implement service to expectNum.
+// WaitForServiceEndpointsNum waits until the amount of endpoints that implement service to expectNum.
 func WaitForServiceEndpointsNum(c kubernetes.Interface, namespace, serviceName string, expectNum int, interval, timeout time.Duration) error {
 	return wait.Poll(interval, timeout, func() (bool, error) {
 		glog.Infof("Waiting for amount of service:%s endpoints to be %d", serviceName, expectNum)
@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"io"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+)
+
+// WithEvents creates a builder and a deployer that record the state of each phase.
+func WithEvents(b build.Builder, d deploy.Deployer) (build.Builder, deploy.Deployer) {
+	w := withEvents{
+		Builder:  b,
+		Deployer: d,
+	}
+
+	return w, w
+}
+
+type withEvents struct {
+	build.Builder
+	deploy.Deployer
+}
+
+func (w withEvents) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]build.Build, error) {
+	for _, a := range artifacts {
+		event.BuildInProgress(a.ImageName)
+	}
+
+	bRes, err := w.Builder.Build(ctx, out, tagger, artifacts)
+
+	// Builders don't tell which artifact failed. Those that were not built are considered failed.
+	built := map[string]bool{}
+	for _, b := range bRes {
+		built[b.ImageName] = true
+	}
+	for _, a := range artifacts {
+		switch {
+		case err == nil || built[a.ImageName]:
+			event.BuildComplete(a.ImageName)
+		default:
+			event.BuildFailed(a.ImageName, err)
+		}
+	}
+
+	return bRes, err
+}
+
+func (w withEvents) Deploy(ctx context.Context, out io.Writer, builds []build.Build) error {
+	event.DeployInProgress()
+
+	if err := w.Deployer.Deploy(ctx, out, builds); err != nil {
+		event.DeployFailed(err)
+		return err
+	}
+
+	event.DeployComplete()
+	return nil
+}
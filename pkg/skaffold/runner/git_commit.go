@@ -0,0 +1,52 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/sirupsen/logrus"
+)
+
+// headCommit is replaced in tests.
+var headCommit = tag.HeadCommit
+
+// artifactsCommit returns the git commit checked out in the workspaces of the
+// artifacts, or an empty string if they're not in git or not at the same commit.
+func artifactsCommit(artifacts []*v1alpha2.Artifact) string {
+	var commit string
+	for _, a := range artifacts {
+		c := headCommit(a.Workspace)
+		if c == "" {
+			return ""
+		}
+		if commit != "" && c != commit {
+			logrus.Debugf("Artifacts are at different git commits: %s and %s", commit, c)
+			return ""
+		}
+		commit = c
+	}
+	return commit
+}
+
+// setGitCommit sets the commit that every deployer adds as a label.
+func setGitCommit(cfg *v1alpha2.DeployConfig, commit string) {
+	cfg.GitCommit = commit
+	for i := range cfg.Required {
+		cfg.Required[i].GitCommit = commit
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestArtifactsCommit(t *testing.T) {
+	defer func(f func(string) string) { headCommit = f }(headCommit)
+	headCommit = func(workspace string) string {
+		return map[string]string{
+			"web":    "a2f3c1e",
+			"api":    "a2f3c1e",
+			"other":  "9b8d7f6",
+			"no-git": "",
+		}[workspace]
+	}
+
+	tests := []struct {
+		description string
+		workspaces  []string
+		expected    string
+	}{
+		{
+			description: "same commit",
+			workspaces:  []string{"web", "api"},
+			expected:    "a2f3c1e",
+		},
+		{
+			description: "different commits",
+			workspaces:  []string{"web", "other"},
+		},
+		{
+			description: "not in git",
+			workspaces:  []string{"web", "no-git"},
+		},
+		{
+			description: "no artifacts",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			var artifacts []*v1alpha2.Artifact
+			for _, workspace := range test.workspaces {
+				artifacts = append(artifacts, &v1alpha2.Artifact{Workspace: workspace})
+			}
+
+			commit := artifactsCommit(artifacts)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, commit)
+		})
+	}
+}
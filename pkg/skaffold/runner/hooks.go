@@ -0,0 +1,232 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WithHooks creates a builder and a deployer that run the hooks of the artifacts
+// before and after their builds, and the deploy hooks before and after each deployment.
+// The hooks get IMAGE and TAG, for build hooks, or IMAGES, TAGS and NAMESPACE, for
+// deploy hooks, as env variables. TAG is only set after the build. The hooks that run
+// commands in containers after a deployment wait for the deployed workloads to be rolled out.
+func WithHooks(b build.Builder, d deploy.Deployer, cfg *v1alpha2.DeployConfig, kubeContext, namespace string) (build.Builder, deploy.Deployer) {
+	w := withHooks{
+		Builder:     b,
+		Deployer:    d,
+		deployCfg:   cfg,
+		deployHooks: cfg.Hooks,
+		kubeContext: kubeContext,
+		namespace:   namespace,
+	}
+
+	return w, w
+}
+
+type withHooks struct {
+	build.Builder
+	deploy.Deployer
+
+	deployCfg   *v1alpha2.DeployConfig
+	deployHooks *v1alpha2.Hooks
+	kubeContext string
+	namespace   string
+}
+
+func (w withHooks) Build(ctx context.Context, out io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]build.Build, error) {
+	for _, a := range artifacts {
+		if a.Hooks == nil {
+			continue
+		}
+		env := []string{"IMAGE=" + a.ImageName}
+		if err := w.runHooks(ctx, out, a.Hooks.Before, a.Workspace, env, false); err != nil {
+			return nil, errors.Wrapf(err, "running hooks before the build of %s", a.ImageName)
+		}
+	}
+
+	bRes, err := w.Builder.Build(ctx, out, tagger, artifacts)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{}
+	for _, b := range bRes {
+		tags[b.ImageName] = b.Tag
+	}
+
+	for _, a := range artifacts {
+		if a.Hooks == nil {
+			continue
+		}
+		env := []string{"IMAGE=" + a.ImageName, "TAG=" + tags[a.ImageName]}
+		if err := w.runHooks(ctx, out, a.Hooks.After, a.Workspace, env, false); err != nil {
+			return nil, errors.Wrapf(err, "running hooks after the build of %s", a.ImageName)
+		}
+	}
+
+	return bRes, nil
+}
+
+func (w withHooks) Deploy(ctx context.Context, out io.Writer, builds []build.Build) error {
+	if w.deployHooks == nil {
+		return w.Deployer.Deploy(ctx, out, builds)
+	}
+
+	var images, tags []string
+	for _, b := range builds {
+		images = append(images, b.ImageName)
+		tags = append(tags, b.Tag)
+	}
+	env := []string{
+		"IMAGES=" + strings.Join(images, " "),
+		"TAGS=" + strings.Join(tags, " "),
+		"NAMESPACE=" + w.namespace,
+	}
+
+	if err := w.runHooks(ctx, out, w.deployHooks.Before, "", env, true); err != nil {
+		return errors.Wrap(err, "running hooks before deploy")
+	}
+
+	if err := w.Deployer.Deploy(ctx, out, builds); err != nil {
+		return err
+	}
+
+	if hasContainerHooks(w.deployHooks.After) {
+		deadline, err := statusCheckDeadline(w.deployCfg)
+		if err != nil {
+			return errors.Wrap(err, "parsing status check deadline")
+		}
+		if err := waitForRollout(ctx, out, builds, deadline); err != nil {
+			return errors.Wrap(err, "waiting for the rollout before running hooks")
+		}
+	}
+
+	if err := w.runHooks(ctx, out, w.deployHooks.After, "", env, true); err != nil {
+		return errors.Wrap(err, "running hooks after deploy")
+	}
+	return nil
+}
+
+func hasContainerHooks(hooks []v1alpha2.Hook) bool {
+	for _, hook := range hooks {
+		if hook.Container != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (w withHooks) runHooks(ctx context.Context, out io.Writer, hooks []v1alpha2.Hook, dir string, env []string, allowContainers bool) error {
+	for _, hook := range hooks {
+		switch {
+		case len(hook.Command) > 0 && hook.Container != nil:
+			return fmt.Errorf("hook can't have both a command and a container command")
+
+		case len(hook.Command) > 0:
+			if err := runHostHook(ctx, out, hook.Command, dir, env); err != nil {
+				return err
+			}
+
+		case hook.Container != nil:
+			if !allowContainers {
+				return fmt.Errorf("only deploy hooks can run commands in containers")
+			}
+			if err := w.runContainerHook(ctx, out, hook.Container); err != nil {
+				return err
+			}
+
+		default:
+			return fmt.Errorf("hook has no command")
+		}
+	}
+	return nil
+}
+
+func runHostHook(ctx context.Context, out io.Writer, command []string, dir string, env []string) error {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := util.RunCmd(cmd); err != nil {
+		return errors.Wrapf(err, "running %s", strings.Join(command, " "))
+	}
+	return nil
+}
+
+// runContainerHook runs a command in the running pods that match the selector.
+func (w withHooks) runContainerHook(ctx context.Context, out io.Writer, hook *v1alpha2.ContainerHook) error {
+	if len(hook.Command) == 0 {
+		return fmt.Errorf("container hook has no command")
+	}
+
+	client, err := kubernetes.Client()
+	if err != nil {
+		return errors.Wrap(err, "getting kubernetes client")
+	}
+
+	pods, err := client.CoreV1().Pods(w.namespace).List(metav1.ListOptions{
+		LabelSelector: hook.Selector,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "listing pods matching %s", hook.Selector)
+	}
+
+	ran := false
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+
+		args := []string{"--context", w.kubeContext, "exec", pod.Name, "--namespace", pod.Namespace}
+		if hook.Container != "" {
+			args = append(args, "-c", hook.Container)
+		}
+		args = append(args, "--")
+		args = append(args, hook.Command...)
+
+		cmd := exec.CommandContext(ctx, "kubectl", args...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := util.RunCmd(cmd); err != nil {
+			return errors.Wrapf(err, "running %s in pod %s", strings.Join(hook.Command, " "), pod.Name)
+		}
+		ran = true
+	}
+
+	if !ran {
+		return fmt.Errorf("no running pod matches %s", hook.Selector)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBuildHooks(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	builder, _ := WithHooks(&TestBuilder{}, &TestDeployer{}, &v1alpha2.DeployConfig{}, "kubecontext", "")
+
+	_, err := builder.Build(context.Background(), &bytes.Buffer{}, nil, []*v1alpha2.Artifact{{
+		ImageName: "app",
+		Workspace: tmpDir,
+		Hooks: &v1alpha2.Hooks{
+			Before: []v1alpha2.Hook{{Command: []string{"sh", "-c", "echo before $IMAGE >> hooks.log"}}},
+			After:  []v1alpha2.Hook{{Command: []string{"sh", "-c", "echo after $TAG >> hooks.log"}}},
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := ioutil.ReadFile(filepath.Join(tmpDir, "hooks.log"))
+	testutil.CheckErrorAndDeepEqual(t, false, err, "before app\nafter app:latest\n", string(log))
+}
+
+func TestBuildHooksErrors(t *testing.T) {
+	var tests = []struct {
+		description string
+		hooks       *v1alpha2.Hooks
+	}{
+		{
+			description: "failing command",
+			hooks:       &v1alpha2.Hooks{Before: []v1alpha2.Hook{{Command: []string{"false"}}}},
+		},
+		{
+			description: "no command",
+			hooks:       &v1alpha2.Hooks{After: []v1alpha2.Hook{{}}},
+		},
+		{
+			description: "container hook",
+			hooks:       &v1alpha2.Hooks{Before: []v1alpha2.Hook{{Container: &v1alpha2.ContainerHook{Selector: "app=web", Command: []string{"ls"}}}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			builder, _ := WithHooks(&TestBuilder{}, &TestDeployer{}, &v1alpha2.DeployConfig{}, "kubecontext", "")
+
+			_, err := builder.Build(context.Background(), &bytes.Buffer{}, nil, []*v1alpha2.Artifact{{
+				ImageName: "app",
+				Hooks:     test.hooks,
+			}})
+
+			testutil.CheckError(t, true, err)
+		})
+	}
+}
+
+func TestDeployContainerHook(t *testing.T) {
+	defer resetClient()
+	kubernetes.Client = func() (clientgo.Interface, error) {
+		return fake.NewSimpleClientset(
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "dev", Labels: map[string]string{"app": "web"}},
+				Status:     v1.PodStatus{Phase: v1.PodRunning},
+			},
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "dev", Labels: map[string]string{"app": "web"}},
+				Status:     v1.PodStatus{Phase: v1.PodSucceeded},
+			},
+		), nil
+	}
+
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmd("kubectl --context kubecontext exec web-1 --namespace dev -c db -- ./migrate up", nil)
+
+	var waited []build.Build
+	defer func(w func(context.Context, io.Writer, []build.Build, time.Duration) error) { waitForRollout = w }(waitForRollout)
+	waitForRollout = func(ctx context.Context, out io.Writer, builds []build.Build, deadline time.Duration) error {
+		if deadline != 10*time.Minute {
+			t.Errorf("Expected the default deadline. Got %s", deadline)
+		}
+		waited = builds
+		return nil
+	}
+
+	deployer := &TestDeployer{}
+	_, hooked := WithHooks(&TestBuilder{}, deployer, &v1alpha2.DeployConfig{
+		Hooks: &v1alpha2.Hooks{
+			After: []v1alpha2.Hook{{Container: &v1alpha2.ContainerHook{
+				Selector:  "app=web",
+				Container: "db",
+				Command:   []string{"./migrate", "up"},
+			}}},
+		},
+	}, "kubecontext", "dev")
+
+	err := hooked.Deploy(context.Background(), &bytes.Buffer{}, []build.Build{{ImageName: "web", Tag: "web:v1"}})
+
+	testutil.CheckErrorAndDeepEqual(t, false, err, []build.Build{{ImageName: "web", Tag: "web:v1"}}, deployer.deployed)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, []build.Build{{ImageName: "web", Tag: "web:v1"}}, waited)
+}
+
+func TestDeployContainerHookRolloutFailure(t *testing.T) {
+	defer func(w func(context.Context, io.Writer, []build.Build, time.Duration) error) { waitForRollout = w }(waitForRollout)
+	waitForRollout = func(context.Context, io.Writer, []build.Build, time.Duration) error {
+		return fmt.Errorf("deployment/web not ready")
+	}
+
+	defer resetClient()
+	kubernetes.Client = func() (clientgo.Interface, error) {
+		return fake.NewSimpleClientset(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "dev", Labels: map[string]string{"app": "web"}},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}), nil
+	}
+
+	defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+	util.DefaultExecCommand = testutil.NewFakeCmd("kubectl --context kubecontext exec web-1 --namespace dev -- ls", nil)
+
+	_, hooked := WithHooks(&TestBuilder{}, &TestDeployer{}, &v1alpha2.DeployConfig{
+		Hooks: &v1alpha2.Hooks{
+			After: []v1alpha2.Hook{{Container: &v1alpha2.ContainerHook{Selector: "app=web", Command: []string{"ls"}}}},
+		},
+	}, "kubecontext", "dev")
+
+	err := hooked.Deploy(context.Background(), &bytes.Buffer{}, nil)
+
+	testutil.CheckError(t, true, err)
+}
+
+func TestDeployHooksNoRunningPod(t *testing.T) {
+	defer resetClient()
+	kubernetes.Client = fakeGetClient
+
+	_, hooked := WithHooks(&TestBuilder{}, &TestDeployer{}, &v1alpha2.DeployConfig{
+		Hooks: &v1alpha2.Hooks{
+			After: []v1alpha2.Hook{{Container: &v1alpha2.ContainerHook{Selector: "app=web", Command: []string{"ls"}}}},
+		},
+	}, "kubecontext", "dev")
+
+	err := hooked.Deploy(context.Background(), &bytes.Buffer{}, nil)
+
+	testutil.CheckError(t, true, err)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+)
+
+// maxNamespaceLength is the maximum length of a DNS label.
+const maxNamespaceLength = 63
+
+var invalidNamespaceChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// deployNamespace returns the namespace to deploy into: the one given on the
+// command line, the one of the configuration or, optionally, a generated one.
+func deployNamespace(flag string, cfg *v1alpha2.DeployConfig) string {
+	switch {
+	case flag != "":
+		return flag
+	case cfg.Namespace != "":
+		return cfg.Namespace
+	case cfg.GenerateNamespace:
+		return generateNamespace(currentUser(), v1alpha2.GitBranch())
+	default:
+		return ""
+	}
+}
+
+// generateNamespace generates a valid namespace name, eg. `dev-jane-feature-x`,
+// so that developers sharing a cluster don't deploy into each other's namespace.
+func generateNamespace(user, branch string) string {
+	parts := []string{"dev"}
+	for _, part := range []string{user, branch} {
+		part = invalidNamespaceChars.ReplaceAllString(strings.ToLower(part), "-")
+		if part = strings.Trim(part, "-"); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	namespace := strings.Join(parts, "-")
+	if len(namespace) > maxNamespaceLength {
+		namespace = strings.TrimRight(namespace[:maxNamespaceLength], "-")
+	}
+	return namespace
+}
+
+func currentUser() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return os.Getenv("USERNAME")
+}
+
+// withNamespaceCreation creates a deployer that creates the namespace before deploying.
+func withNamespaceCreation(d deploy.Deployer, kubeContext, namespace string) deploy.Deployer {
+	return namespaceCreation{
+		Deployer:    d,
+		kubeContext: kubeContext,
+		namespace:   namespace,
+	}
+}
+
+type namespaceCreation struct {
+	deploy.Deployer
+	kubeContext string
+	namespace   string
+}
+
+func (n namespaceCreation) Deploy(ctx context.Context, out io.Writer, builds []build.Build) error {
+	if err := deploy.CreateNamespace(out, n.kubeContext, n.namespace); err != nil {
+		return errors.Wrap(err, "preparing namespace")
+	}
+	return n.Deployer.Deploy(ctx, out, builds)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestGenerateNamespace(t *testing.T) {
+	var tests = []struct {
+		description string
+		user        string
+		branch      string
+		expected    string
+	}{
+		{
+			description: "user and branch",
+			user:        "jane",
+			branch:      "master",
+			expected:    "dev-jane-master",
+		},
+		{
+			description: "invalid characters",
+			user:        "Jane.Doe",
+			branch:      "feature/Add_Namespaces",
+			expected:    "dev-jane-doe-feature-add-namespaces",
+		},
+		{
+			description: "no branch",
+			user:        "jane",
+			branch:      "",
+			expected:    "dev-jane",
+		},
+		{
+			description: "too long",
+			user:        "jane",
+			branch:      strings.Repeat("a", 54) + "-" + strings.Repeat("b", 10),
+			expected:    "dev-jane-" + strings.Repeat("a", 54),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			namespace := generateNamespace(test.user, test.branch)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, namespace)
+		})
+	}
+}
+
+func TestDeployNamespace(t *testing.T) {
+	defer func(branch func() string) { v1alpha2.GitBranch = branch }(v1alpha2.GitBranch)
+	v1alpha2.GitBranch = func() string { return "master" }
+
+	reset := testutil.SetEnvs(t, map[string]string{"USER": "jane"})
+	defer reset(t)
+
+	var tests = []struct {
+		description string
+		flag        string
+		cfg         *v1alpha2.DeployConfig
+		expected    string
+	}{
+		{
+			description: "none",
+			cfg:         &v1alpha2.DeployConfig{},
+			expected:    "",
+		},
+		{
+			description: "flag wins",
+			flag:        "flag",
+			cfg:         &v1alpha2.DeployConfig{Namespace: "config", GenerateNamespace: true},
+			expected:    "flag",
+		},
+		{
+			description: "config",
+			cfg:         &v1alpha2.DeployConfig{Namespace: "config", GenerateNamespace: true},
+			expected:    "config",
+		},
+		{
+			description: "generated",
+			cfg:         &v1alpha2.DeployConfig{GenerateNamespace: true},
+			expected:    "dev-jane-master",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			namespace := deployNamespace(test.flag, test.cfg)
+
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, namespace)
+		})
+	}
+}
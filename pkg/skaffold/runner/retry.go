@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// retryDelays are the delays between the attempts of an operation that fails
+// with a transient error. They are replaced in tests.
+var retryDelays = []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}
+
+// transientMessages are found in the errors of a docker daemon or an apiserver
+// that are restarting or overloaded, eg. when a laptop wakes up.
+var transientMessages = []string{
+	"Cannot connect to the Docker daemon",
+	"connection refused",
+	"connection reset by peer",
+	"i/o timeout",
+	"TLS handshake timeout",
+	"the server is currently unable to handle the request",
+	"etcdserver: request timed out",
+}
+
+// withRetry runs an operation until it succeeds, fails with an error that's not
+// transient, or all the attempts failed, with an exponential backoff.
+func withRetry(ctx context.Context, description string, operation func() error) error {
+	err := operation()
+	for _, delay := range retryDelays {
+		if err == nil || !isTransient(err) {
+			return err
+		}
+
+		logrus.Warnf("%s failed with a transient error, retrying in %s: %s", description, delay, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		err = operation()
+	}
+	return err
+}
+
+// isTransient tells if an error is likely to go away by retrying.
+func isTransient(err error) bool {
+	cause := errors.Cause(err)
+	if apierrors.IsServerTimeout(cause) || apierrors.IsTimeout(cause) || apierrors.IsTooManyRequests(cause) || apierrors.IsServiceUnavailable(cause) {
+		return true
+	}
+	if netErr, ok := cause.(net.Error); ok && (netErr.Timeout() || netErr.Temporary()) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, transient := range transientMessages {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
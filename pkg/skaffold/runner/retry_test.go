@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestWithRetry(t *testing.T) {
+	defer func(delays []time.Duration) { retryDelays = delays }(retryDelays)
+	retryDelays = []time.Duration{0, 0}
+
+	transient := errors.Wrap(fmt.Errorf("dial tcp 127.0.0.1:8443: connect: connection refused"), "deploying")
+
+	var tests = []struct {
+		description      string
+		errors           []error
+		shouldErr        bool
+		expectedAttempts int
+	}{
+		{
+			description:      "success",
+			expectedAttempts: 1,
+		},
+		{
+			description:      "retry transient errors",
+			errors:           []error{transient, transient},
+			expectedAttempts: 3,
+		},
+		{
+			description:      "give up after the last attempt",
+			errors:           []error{transient, transient, transient},
+			shouldErr:        true,
+			expectedAttempts: 3,
+		},
+		{
+			description:      "don't retry other errors",
+			errors:           []error{fmt.Errorf("syntax error")},
+			shouldErr:        true,
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			attempts := 0
+			err := withRetry(context.Background(), "test", func() error {
+				attempts++
+				if attempts <= len(test.errors) {
+					return test.errors[attempts-1]
+				}
+				return nil
+			})
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expectedAttempts, attempts)
+		})
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	var tests = []struct {
+		description string
+		err         error
+		expected    bool
+	}{
+		{"docker daemon", fmt.Errorf("Cannot connect to the Docker daemon at unix:///var/run/docker.sock"), true},
+		{"apiserver timeout", errors.Wrap(apierrors.NewServerTimeout(schema.GroupResource{Resource: "pods"}, "list", 1), "listing pods"), true},
+		{"not found", apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "web"), false},
+		{"build error", fmt.Errorf("The command '/bin/sh -c make' returned a non-zero code: 2"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, isTransient(test.err))
+		})
+	}
+}
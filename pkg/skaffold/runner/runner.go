@@ -22,14 +22,24 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/debug"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/docker"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/sync"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/test"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
+	homedir "github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
@@ -38,12 +48,17 @@ import (
 // SkaffoldRunner is responsible for running the skaffold build and deploy pipeline.
 type SkaffoldRunner struct {
 	build.Builder
+	test.Tester
 	deploy.Deployer
 	tag.Tagger
 	watch.WatcherFactory
 	build.DependencyMapFactory
+	sync.Syncer
 
-	builds []build.Build
+	opts        *config.SkaffoldOptions
+	kubeContext string
+	portForward []v1alpha2.PortForwardResource
+	builds      []build.Build
 }
 
 // NewForConfig returns a new SkaffoldRunner for a SkaffoldConfig
@@ -54,35 +69,85 @@ func NewForConfig(opts *config.SkaffoldOptions, cfg *config.SkaffoldConfig) (*Sk
 	}
 	logrus.Infof("Using kubectl context: %s", kubeContext)
 
+	if err := docker.ConfigureRegistries(cfg.Build.InsecureRegistries, cfg.Build.RegistryCertificates); err != nil {
+		return nil, errors.Wrap(err, "configuring registries")
+	}
+
+	if cfg.Build.LocalBuild != nil {
+		docker.ConfigureLocalCluster(cfg.Build.LocalBuild.LocalCluster)
+	}
+
 	builder, err := getBuilder(&cfg.Build, kubeContext)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold build config")
 	}
 
-	deployer, err := getDeployer(&cfg.Deploy, kubeContext, opts.Namespace)
+	if opts.CacheArtifacts {
+		builder, err = withArtifactCache(builder, &cfg.Build)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading artifact cache")
+		}
+	}
+
+	namespace := deployNamespace(opts.Namespace, &cfg.Deploy)
+	if namespace != "" {
+		logrus.Infof("Deploying into namespace: %s", namespace)
+	}
+
+	setGitCommit(&cfg.Deploy, artifactsCommit(cfg.Build.Artifacts))
+
+	var transforms []deploy.ManifestTransform
+	if opts.EnableDebug {
+		transforms = append(transforms, debug.ApplyDebuggingTransforms)
+	}
+	deployer, err := getDeployer(&cfg.Deploy, kubeContext, namespace, transforms)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold deploy config")
 	}
 
-	builder, deployer = WithTimings(builder, deployer)
-	if opts.Notification {
-		deployer = WithNotification(deployer)
+	builder, deployer, err = decorate(builder, deployer, opts, &cfg.Deploy, kubeContext, namespace)
+	if err != nil {
+		return nil, err
 	}
 
-	tagger, err := getTagger(cfg.Build.TagPolicy, opts.CustomTag)
+	tagger, err := getArtifactTagger(&cfg.Build, opts.CustomTag)
 	if err != nil {
 		return nil, errors.Wrap(err, "parsing skaffold tag config")
 	}
 
+	watcherFactory, err := getWatcherFactory(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating watch trigger")
+	}
+
 	return &SkaffoldRunner{
 		Builder:              builder,
+		Tester:               test.NewTester(cfg.Test),
 		Deployer:             deployer,
 		Tagger:               tagger,
-		WatcherFactory:       watch.NewWatcher,
+		WatcherFactory:       watcherFactory,
 		DependencyMapFactory: build.NewDependencyMap,
+		Syncer:               sync.NewKubectlSyncer(kubeContext, namespace, deploy.RunLabels()),
+		opts:                 opts,
+		kubeContext:          kubeContext,
+		portForward:          cfg.Deploy.PortForward,
 	}, nil
 }
 
+func getWatcherFactory(opts *config.SkaffoldOptions) (watch.WatcherFactory, error) {
+	interval := watch.DefaultPollInterval
+	if opts.WatchPollInterval > 0 {
+		interval = time.Duration(opts.WatchPollInterval) * time.Millisecond
+	}
+
+	trigger, err := watch.NewTrigger(opts.Trigger, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return watch.NewWatcherFactory(trigger), nil
+}
+
 func getBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (build.Builder, error) {
 	switch {
 	case cfg.LocalBuild != nil:
@@ -102,7 +167,84 @@ func getBuilder(cfg *v1alpha2.BuildConfig, kubeContext string) (build.Builder, e
 	}
 }
 
-func getDeployer(cfg *v1alpha2.DeployConfig, kubeContext string, namespace string) (deploy.Deployer, error) {
+func withArtifactCache(builder build.Builder, cfg *v1alpha2.BuildConfig) (build.Builder, error) {
+	file, err := homedir.Expand(constants.DefaultArtifactCacheFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding home directory")
+	}
+
+	imageExists, err := build.NewImageChecker(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cache, err := build.NewArtifactCache(file, imageExists)
+	if err != nil {
+		return nil, err
+	}
+	return build.WithCache(builder, cache), nil
+}
+
+// decorate wraps the builder and the deployer with the optional steps of the config.
+// The images are resolved to their digest by the outermost deployer, so that the
+// status check and the hooks see the references that are actually deployed.
+func decorate(builder build.Builder, deployer deploy.Deployer, opts *config.SkaffoldOptions, cfg *v1alpha2.DeployConfig, kubeContext string, namespace string) (build.Builder, deploy.Deployer, error) {
+	if cfg.CreateNamespace && namespace != "" {
+		deployer = withNamespaceCreation(deployer, kubeContext, namespace)
+	}
+
+	if opts.StatusCheck {
+		var err error
+		deployer, err = withStatusCheck(deployer, cfg)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "parsing status check deadline")
+		}
+	}
+
+	builder, deployer = WithHooks(builder, deployer, cfg, kubeContext, namespace)
+	builder, deployer = WithEvents(builder, deployer)
+	builder, deployer = WithTimings(builder, deployer)
+	if opts.Notification {
+		deployer = WithNotification(deployer)
+	}
+
+	if cfg.DeployByDigest {
+		deployer = deploy.WithDigests(deployer)
+	}
+
+	return builder, deployer, nil
+}
+
+func getDeployer(cfg *v1alpha2.DeployConfig, kubeContext string, namespace string, transforms []deploy.ManifestTransform) (deploy.Deployer, error) {
+	if len(cfg.Required) == 0 {
+		return getSingleDeployer(cfg, kubeContext, namespace, transforms)
+	}
+
+	// The required configs are deployed first, into the same namespace.
+	var deployers []deploy.Deployer
+	for i := range cfg.Required {
+		d, err := getSingleDeployer(&cfg.Required[i], kubeContext, namespace, transforms)
+		if err != nil {
+			return nil, err
+		}
+		deployers = append(deployers, d)
+	}
+
+	// A config can just aggregate the configs it requires.
+	if cfg.DeployType != (v1alpha2.DeployType{}) {
+		d, err := getSingleDeployer(cfg, kubeContext, namespace, transforms)
+		if err != nil {
+			return nil, err
+		}
+		deployers = append(deployers, d)
+	}
+
+	return deploy.NewMultiDeployer(deployers...), nil
+}
+
+// getSingleDeployer creates the deployer of a config. The manifest transforms are
+// rejected by helm, whose releases are installed by tiller.
+func getSingleDeployer(cfg *v1alpha2.DeployConfig, kubeContext string, namespace string, transforms []deploy.ManifestTransform) (deploy.Deployer, error) {
 	switch {
 	case cfg.KubectlDeploy != nil:
 		// TODO(dgageot): this should be the folder containing skaffold.yaml. Should also be moved elsewhere.
@@ -110,13 +252,16 @@ func getDeployer(cfg *v1alpha2.DeployConfig, kubeContext string, namespace strin
 		if err != nil {
 			return nil, errors.Wrap(err, "finding current directory")
 		}
-		return deploy.NewKubectlDeployer(cwd, cfg, kubeContext), nil
+		return deploy.NewKubectlDeployer(cwd, cfg, kubeContext, namespace, transforms...), nil
 
 	case cfg.HelmDeploy != nil:
+		if len(transforms) > 0 {
+			return nil, errors.New("debugging helm releases is not supported")
+		}
 		return deploy.NewHelmDeployer(cfg, kubeContext, namespace), nil
 
 	case cfg.KustomizeDeploy != nil:
-		return deploy.NewKustomizeDeployer(cfg, kubeContext), nil
+		return deploy.NewKustomizeDeployer(cfg, kubeContext, namespace, transforms...), nil
 
 	default:
 		return nil, fmt.Errorf("Unknown deployer for config %+v", cfg)
@@ -130,6 +275,25 @@ func getTagger(t v1alpha2.TagPolicy, customTag string) (tag.Tagger, error) {
 			Tag: customTag,
 		}, nil
 
+	case t.Defaulted:
+		// Without a configured policy, the tagger selected by SKAFFOLD_DEFAULT_TAGGER,
+		// or a `skaffold.tagTemplate` found in git config, takes precedence over gitCommit.
+		if os.Getenv(tag.DefaultTaggerEnvVar) != "" {
+			return tag.NewTagger("")
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, errors.Wrap(err, "finding current directory")
+		}
+		template, err := tag.GitConfigTagTemplate(cwd)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading tag template from git config")
+		}
+		if template != "" {
+			return tag.NewEnvTemplateTagger(template)
+		}
+		return &tag.GitCommit{}, nil
+
 	case t.EnvTemplateTagger != nil:
 		return tag.NewEnvTemplateTagger(t.EnvTemplateTagger.Template)
 
@@ -137,23 +301,55 @@ func getTagger(t v1alpha2.TagPolicy, customTag string) (tag.Tagger, error) {
 		return &tag.ChecksumTagger{}, nil
 
 	case t.GitTagger != nil:
-		return &tag.GitCommit{}, nil
+		return gitTagger(t.GitTagger)
 
 	case t.DateTimeTagger != nil:
 		return tag.NewDateTimeTagger(t.DateTimeTagger.Format, t.DateTimeTagger.TimeZone), nil
 
+	case t.PrecedenceTagger != nil:
+		return tag.NewPrecedenceTagger(t.PrecedenceTagger.Sources)
+
+	case t.TemplateTagger != nil:
+		return tag.NewTemplateTagger(t.TemplateTagger.Template)
+
+	case t.FileTagger != nil:
+		return &tag.FileTag{Path: t.FileTagger.Path}, nil
+
 	default:
 		return nil, fmt.Errorf("Unknown tagger for strategy %+v", t)
 	}
 }
 
-// Run builds artifacts ad then deploys them.
+// gitTagger creates a git commit tagger from its configuration.
+func gitTagger(cfg *v1alpha2.GitTagger) (*tag.GitCommit, error) {
+	tagger, err := tag.NewGitCommit(cfg.Variant)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.AbbrevLen != 0 {
+		if tagger.CommitLength != 0 {
+			return nil, fmt.Errorf("abbrevLen can't be used with the %s variant", cfg.Variant)
+		}
+		tagger.CommitLength = cfg.AbbrevLen
+	}
+	tagger.Prefix = cfg.Prefix
+	tagger.BranchPrefix = cfg.BranchPrefix
+
+	return tagger, nil
+}
+
+// Run builds artifacts, tests them and then deploys them.
 func (r *SkaffoldRunner) Run(ctx context.Context, out io.Writer, artifacts []*v1alpha2.Artifact) error {
 	bRes, err := r.Build(ctx, out, r.Tagger, artifacts)
 	if err != nil {
 		return errors.Wrap(err, "build step")
 	}
 
+	if err := r.Test(ctx, out, bRes); err != nil {
+		return errors.Wrap(err, "test step")
+	}
+
 	if err := r.Deploy(ctx, out, bRes); err != nil {
 		return errors.Wrap(err, "deploy step")
 	}
@@ -161,9 +357,40 @@ func (r *SkaffoldRunner) Run(ctx context.Context, out io.Writer, artifacts []*v1
 	return nil
 }
 
+// Render builds artifacts and writes the manifests that would be deployed to manifestsOut.
+func (r *SkaffoldRunner) Render(ctx context.Context, out io.Writer, manifestsOut io.Writer, artifacts []*v1alpha2.Artifact) error {
+	bRes, err := r.Build(ctx, out, r.Tagger, artifacts)
+	if err != nil {
+		return errors.Wrap(err, "build step")
+	}
+
+	if err := r.Deployer.Render(ctx, manifestsOut, bRes); err != nil {
+		return errors.Wrap(err, "render step")
+	}
+
+	return nil
+}
+
 // Dev watches for changes and runs the skaffold build and deploy
 // pipeline until interrrupted by the user.
 func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1alpha2.Artifact) ([]build.Build, error) {
+	onError := config.OnErrorContinue
+	if r.opts != nil && r.opts.OnError != "" {
+		onError = r.opts.OnError
+	}
+	if onError != config.OnErrorExit && onError != config.OnErrorContinue {
+		return nil, fmt.Errorf("invalid --on-error %q, must be %s or %s", onError, config.OnErrorExit, config.OnErrorContinue)
+	}
+
+	// handleError either ends the dev loop or reports the error.
+	handleError := func(err error, description string) error {
+		if onError == config.OnErrorExit {
+			return errors.Wrap(err, description)
+		}
+		logrus.Errorf("%s, waiting for the next change: %s", description, err)
+		return nil
+	}
+
 	depMap, err := r.DependencyMapFactory(artifacts)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting path to dependency map")
@@ -185,25 +412,70 @@ func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1
 		return nil, errors.Wrap(err, "creating deploy watcher")
 	}
 
+	testDeps, err := r.TestDependencies()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting test dependencies")
+	}
+
+	var testPaths []string
+	for _, paths := range testDeps {
+		testPaths = append(testPaths, paths...)
+	}
+	testWatcher, err := r.WatcherFactory(testPaths)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating test watcher")
+	}
+
 	imageList := kubernetes.NewImageList()
 	colorPicker := kubernetes.NewColorPicker(artifacts)
-	logger := kubernetes.NewLogAggregator(out, imageList, colorPicker)
+	var labelSelector string
+	if r.opts != nil {
+		labelSelector = r.opts.LogSelector
+	}
+	logger := kubernetes.NewLogAggregator(out, imageList, colorPicker, labelSelector)
+
+	// The artifacts whose build failed are built again on the next change.
+	failed := map[*v1alpha2.Artifact]bool{}
+	first := true
+	built := false
+	// The images whose tests failed block the deploys until their tests pass.
+	failedTests := map[string]bool{}
 
 	onChange := func(changedPaths []string) error {
 		logger.Mute()
 		defer logger.Unmute()
 
-		changedArtifacts := depMap.ArtifactsForPaths(changedPaths)
+		if !first {
+			event.FileChanged(changedPaths)
+		}
 
-		bRes, err := r.Builder.Build(ctx, out, r.Tagger, changedArtifacts)
-		if err != nil {
-			if r.builds == nil {
-				return errors.Wrap(err, "exiting dev mode because the first build failed")
+		changedArtifacts := r.syncChanges(ctx, depMap.ChangedPathsByArtifact(changedPaths))
+		for _, a := range artifacts {
+			if failed[a] && !containsArtifact(changedArtifacts, a) {
+				changedArtifacts = append(changedArtifacts, a)
 			}
-
-			logrus.Warnln("Skipping Deploy due to build error:", err)
+		}
+		if !first && len(changedArtifacts) == 0 {
 			return nil
 		}
+		first = false
+
+		var bRes []build.Build
+		err := withRetry(ctx, "build", func() error {
+			var err error
+			bRes, err = r.Builder.Build(ctx, out, r.Tagger, changedArtifacts)
+			return err
+		})
+		if err != nil {
+			for _, a := range changedArtifacts {
+				failed[a] = true
+			}
+			return handleError(err, "build failed, skipping deploy")
+		}
+		for _, a := range changedArtifacts {
+			delete(failed, a)
+		}
+		built = true
 
 		// Update which images are logged.
 		for _, build := range bRes {
@@ -213,14 +485,44 @@ func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1
 		// Make sure all artifacts are redeployed. Not only those that were just rebuilt.
 		r.builds = mergeWithPreviousBuilds(bRes, r.builds)
 
-		return r.Deploy(ctx, out, r.builds)
+		if err := r.runTests(ctx, out, bRes, failedTests); err != nil {
+			return handleError(err, "tests failed, skipping deploy")
+		}
+		return r.redeploy(ctx, out, handleError)
+	}
+
+	onTestChange := func(changedPaths []string) error {
+		logger.Mute()
+		defer logger.Unmute()
+
+		event.FileChanged(changedPaths)
+
+		toTest := buildsToTest(r.builds, testDeps, changedPaths)
+		if len(toTest) == 0 {
+			return nil
+		}
+
+		if err := r.runTests(ctx, out, toTest, failedTests); err != nil {
+			return handleError(err, "tests failed, skipping deploy")
+		}
+		return r.redeploy(ctx, out, handleError)
 	}
 
 	onDeployChange := func(changedPaths []string) error {
 		logger.Mute()
 		defer logger.Unmute()
 
-		return r.Deploy(ctx, out, r.builds)
+		event.FileChanged(changedPaths)
+
+		// Nothing can be deployed until the first build succeeds.
+		if !built {
+			return nil
+		}
+		if len(failedTests) > 0 {
+			logrus.Warnf("Not redeploying until the tests of %s pass", strings.Join(sortedImages(failedTests), ", "))
+			return nil
+		}
+		return r.redeploy(ctx, out, handleError)
 	}
 
 	if err := onChange(depMap.Paths()); err != nil {
@@ -228,22 +530,144 @@ func (r *SkaffoldRunner) Dev(ctx context.Context, out io.Writer, artifacts []*v1
 	}
 
 	// Start logs
-	if err = logger.Start(ctx); err != nil {
-		return r.builds, errors.Wrap(err, "starting logger")
+	if r.opts == nil || r.opts.Tail {
+		if err = logger.Start(ctx); err != nil {
+			return r.builds, errors.Wrap(err, "starting logger")
+		}
+	}
+
+	if r.opts != nil && r.opts.PortForward {
+		forwarder := kubernetes.NewPortForwarder(out, imageList, r.kubeContext, r.portForward)
+		if err := forwarder.Start(ctx); err != nil {
+			return r.builds, errors.Wrap(err, "starting port forwarder")
+		}
 	}
 
-	// Watch files and rebuild
+	// Watch files and rebuild. The callbacks share the builds and
+	// the failures, so they never run concurrently.
+	var serial serializer
 	g, watchCtx := errgroup.WithContext(ctx)
 	g.Go(func() error {
-		return watcher.Start(watchCtx, out, onChange)
+		return watcher.Start(watchCtx, out, serial.wrap(onChange))
+	})
+	g.Go(func() error {
+		return deployWatcher.Start(watchCtx, ioutil.Discard, serial.wrap(onDeployChange))
 	})
 	g.Go(func() error {
-		return deployWatcher.Start(watchCtx, ioutil.Discard, onDeployChange)
+		return testWatcher.Start(watchCtx, ioutil.Discard, serial.wrap(onTestChange))
 	})
 
 	return r.builds, g.Wait()
 }
 
+// redeploy deploys the latest builds, retrying on transient errors.
+func (r *SkaffoldRunner) redeploy(ctx context.Context, out io.Writer, handleError func(error, string) error) error {
+	err := withRetry(ctx, "deploy", func() error {
+		return r.Deploy(ctx, out, r.builds)
+	})
+	if err != nil {
+		return handleError(err, "deploy failed")
+	}
+	return nil
+}
+
+// runTests tests each build and keeps track of the images whose tests failed.
+// It fails if the tests of any image, tested now or before, are failing.
+func (r *SkaffoldRunner) runTests(ctx context.Context, out io.Writer, builds []build.Build, failedTests map[string]bool) error {
+	for _, b := range builds {
+		if err := r.Test(ctx, out, []build.Build{b}); err != nil {
+			logrus.Errorf("%s", err)
+			failedTests[b.ImageName] = true
+		} else {
+			delete(failedTests, b.ImageName)
+		}
+	}
+
+	if len(failedTests) > 0 {
+		return fmt.Errorf("tests of %s are failing", strings.Join(sortedImages(failedTests), ", "))
+	}
+	return nil
+}
+
+// buildsToTest returns the builds of the images whose test files changed.
+func buildsToTest(builds []build.Build, testDeps map[string][]string, changedPaths []string) []build.Build {
+	changed := map[string]bool{}
+	for _, path := range changedPaths {
+		changed[path] = true
+	}
+
+	var toTest []build.Build
+	for _, b := range builds {
+		for _, dep := range testDeps[b.ImageName] {
+			if changed[dep] {
+				toTest = append(toTest, b)
+				break
+			}
+		}
+	}
+	return toTest
+}
+
+func sortedImages(images map[string]bool) []string {
+	var sorted []string
+	for image := range images {
+		sorted = append(sorted, image)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func containsArtifact(artifacts []*v1alpha2.Artifact, a *v1alpha2.Artifact) bool {
+	for _, artifact := range artifacts {
+		if artifact == a {
+			return true
+		}
+	}
+	return false
+}
+
+// syncChanges syncs the changed files of the artifacts that were
+// already deployed, when possible, and returns the artifacts that should be rebuilt.
+func (r *SkaffoldRunner) syncChanges(ctx context.Context, changes map[*v1alpha2.Artifact][]string) []*v1alpha2.Artifact {
+	var toBuild []*v1alpha2.Artifact
+	for a, changedPaths := range changes {
+		if r.builds == nil || r.Syncer == nil || !r.syncArtifact(ctx, a, changedPaths) {
+			toBuild = append(toBuild, a)
+		}
+	}
+	return toBuild
+}
+
+// syncArtifact tells whether the changed files of an artifact were synced.
+func (r *SkaffoldRunner) syncArtifact(ctx context.Context, a *v1alpha2.Artifact, changedPaths []string) bool {
+	var image string
+	for _, b := range r.builds {
+		if b.ImageName == a.ImageName {
+			image = b.Tag
+		}
+	}
+	if image == "" {
+		return false
+	}
+
+	item, err := sync.NewItem(a, changedPaths, image)
+	if err != nil {
+		logrus.Warnf("Unable to sync %s, rebuilding: %s", a.ImageName, err)
+		return false
+	}
+	if item == nil {
+		return false
+	}
+
+	if err := r.Sync(ctx, item); err != nil {
+		logrus.Warnf("Unable to sync %s, rebuilding: %s", a.ImageName, err)
+		return false
+	}
+
+	logrus.Infof("Synced %d files of %s", len(item.Copy)+len(item.Delete), a.ImageName)
+	return true
+}
+
 func mergeWithPreviousBuilds(builds, previous []build.Build) []build.Build {
 	updatedBuilds := map[string]bool{}
 	for _, build := range builds {
@@ -17,11 +17,16 @@ limitations under the License.
 package runner
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
@@ -29,8 +34,10 @@ import (
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/sync"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/watch"
 	"github.com/GoogleContainerTools/skaffold/testutil"
+	git "gopkg.in/src-d/go-git.v4"
 	clientgo "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -52,6 +59,7 @@ func (t *TestBuilder) Build(ctx context.Context, w io.Writer, tagger tag.Tagger,
 	for _, artifact := range artifacts {
 		builds = append(builds, build.Build{
 			ImageName: artifact.ImageName,
+			Tag:       artifact.ImageName + ":latest",
 		})
 	}
 
@@ -81,6 +89,54 @@ func (t *TestDeployer) Cleanup(ctx context.Context, out io.Writer) error {
 	return nil
 }
 
+func (t *TestDeployer) Render(ctx context.Context, out io.Writer, builds []build.Build) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	for _, b := range builds {
+		fmt.Fprintln(out, b.Tag)
+	}
+	return nil
+}
+
+// TestTester fails the tests of an image as many times as given by failures.
+type TestTester struct {
+	tested   []build.Build
+	deps     map[string][]string
+	failures map[string]int
+}
+
+func (t *TestTester) Test(ctx context.Context, out io.Writer, builds []build.Build) error {
+	var err error
+	for _, b := range builds {
+		t.tested = append(t.tested, b)
+		if t.failures[b.ImageName] > 0 {
+			t.failures[b.ImageName]--
+			err = fmt.Errorf("tests of %s failed", b.ImageName)
+		}
+	}
+	return err
+}
+
+func (t *TestTester) TestDependencies() (map[string][]string, error) {
+	return t.deps, nil
+}
+
+type TestSyncer struct {
+	synced []*sync.Item
+	err    error
+}
+
+func (t *TestSyncer) Sync(ctx context.Context, item *sync.Item) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	t.synced = append(t.synced, item)
+	return nil
+}
+
 func resetClient()                               { kubernetes.Client = kubernetes.GetClientset }
 func fakeGetClient() (clientgo.Interface, error) { return fake.NewSimpleClientset(), nil }
 
@@ -98,7 +154,9 @@ func NewWatcherFactory(err error, changes ...[]string) watch.WatcherFactory {
 
 func (t *TestWatcher) Start(context context.Context, out io.Writer, onChange func([]string) error) error {
 	for _, change := range t.changes {
-		onChange(change)
+		if err := onChange(change); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -196,11 +254,284 @@ func TestNewForConfig(t *testing.T) {
 	}
 }
 
+func TestGetDeployerWithTransforms(t *testing.T) {
+	transforms := []deploy.ManifestTransform{
+		func(manifests [][]byte, builds []build.Build) ([][]byte, error) { return manifests, nil },
+	}
+
+	kubectl, err := getDeployer(&v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{KubectlDeploy: &v1alpha2.KubectlDeploy{}},
+	}, "kubecontext", "", transforms)
+	testutil.CheckErrorAndTypeEquality(t, false, err, &deploy.KubectlDeployer{}, kubectl)
+
+	_, err = getDeployer(&v1alpha2.DeployConfig{
+		DeployType: v1alpha2.DeployType{HelmDeploy: &v1alpha2.HelmDeploy{}},
+	}, "kubecontext", "", transforms)
+	testutil.CheckError(t, true, err)
+}
+
+func TestDeployByDigestWithStatusCheck(t *testing.T) {
+	var waited []build.Build
+	defer func(w func(context.Context, io.Writer, []build.Build, time.Duration) error) { waitForRollout = w }(waitForRollout)
+	waitForRollout = func(ctx context.Context, out io.Writer, builds []build.Build, deadline time.Duration) error {
+		waited = builds
+		return nil
+	}
+
+	deployer := &TestDeployer{}
+	_, decorated, err := decorate(&TestBuilder{}, deployer, &config.SkaffoldOptions{StatusCheck: true}, &v1alpha2.DeployConfig{
+		DeployByDigest: true,
+	}, "kubecontext", "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = decorated.Deploy(context.Background(), &bytes.Buffer{}, []build.Build{{ImageName: "web", Tag: "web:v1", Digest: "sha256:abcd"}})
+
+	expected := []build.Build{{ImageName: "web", Tag: "web:v1@sha256:abcd", Digest: "sha256:abcd"}}
+	testutil.CheckErrorAndDeepEqual(t, false, err, expected, deployer.deployed)
+	testutil.CheckErrorAndDeepEqual(t, false, nil, expected, waited)
+}
+
+func TestGetTaggerFromGitConfig(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	repo, err := git.PlainInit(tmpDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Raw.Section("skaffold").SetOption("tagTemplate", "{{.IMAGE_NAME}}:{{.FOO}}")
+	if err := repo.Storer.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	defer testutil.SetEnvs(t, map[string]string{"FOO": "bar"})(t)
+
+	var tests = []struct {
+		description  string
+		tagPolicy    v1alpha2.TagPolicy
+		customTag    string
+		expectedName string
+	}{
+		{
+			description:  "git config overrides default policy",
+			tagPolicy:    v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}, Defaulted: true},
+			expectedName: "test:bar",
+		},
+		{
+			description: "explicit policy",
+			tagPolicy: v1alpha2.TagPolicy{EnvTemplateTagger: &v1alpha2.EnvTemplateTagger{
+				Template: "{{.IMAGE_NAME}}:explicit",
+			}},
+			expectedName: "test:explicit",
+		},
+		{
+			description:  "custom tag",
+			tagPolicy:    v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}, Defaulted: true},
+			customTag:    "v1",
+			expectedName: "test:v1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tagger, err := getTagger(test.tagPolicy, test.customTag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			name, err := tagger.GenerateFullyQualifiedImageName(tmpDir, &tag.Options{ImageName: "test"})
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedName, name)
+		})
+	}
+}
+
+func TestGetTaggerFromEnv(t *testing.T) {
+	var tests = []struct {
+		description string
+		env         string
+		tagPolicy   v1alpha2.TagPolicy
+		expected    tag.Tagger
+		shouldErr   bool
+	}{
+		{
+			description: "env overrides default policy",
+			env:         "sha256",
+			tagPolicy:   v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}, Defaulted: true},
+			expected:    &tag.ChecksumTagger{},
+		},
+		{
+			description: "explicit gitCommit policy",
+			env:         "sha256",
+			tagPolicy:   v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}},
+			expected:    &tag.GitCommit{},
+		},
+		{
+			description: "explicit policy",
+			env:         "gitCommit",
+			tagPolicy:   v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
+			expected:    &tag.ChecksumTagger{},
+		},
+		{
+			description: "invalid env",
+			env:         "unknown",
+			tagPolicy:   v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}, Defaulted: true},
+			shouldErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			defer testutil.SetEnvs(t, map[string]string{"SKAFFOLD_DEFAULT_TAGGER": test.env})(t)
+
+			tagger, err := getTagger(test.tagPolicy, "")
+
+			testutil.CheckErrorAndTypeEquality(t, test.shouldErr, err, test.expected, tagger)
+		})
+	}
+}
+
+func TestGetArtifactTagger(t *testing.T) {
+	envTemplate := func(template string) *v1alpha2.TagPolicy {
+		return &v1alpha2.TagPolicy{EnvTemplateTagger: &v1alpha2.EnvTemplateTagger{Template: template}}
+	}
+
+	var tests = []struct {
+		description string
+		artifacts   []*v1alpha2.Artifact
+		customTag   string
+		expected    map[string]string
+		shouldErr   bool
+	}{
+		{
+			description: "global policy",
+			artifacts:   []*v1alpha2.Artifact{{ImageName: "a"}, {ImageName: "b"}},
+			expected:    map[string]string{"a": "a:global", "b": "b:global"},
+		},
+		{
+			description: "override",
+			artifacts:   []*v1alpha2.Artifact{{ImageName: "a"}, {ImageName: "b", TagPolicy: envTemplate("{{.IMAGE_NAME}}:override")}},
+			expected:    map[string]string{"a": "a:global", "b": "b:override"},
+		},
+		{
+			description: "custom tag takes precedence",
+			artifacts:   []*v1alpha2.Artifact{{ImageName: "a", TagPolicy: envTemplate("{{.IMAGE_NAME}}:override")}},
+			customTag:   "v1",
+			expected:    map[string]string{"a": "a:v1"},
+		},
+		{
+			description: "invalid override",
+			artifacts:   []*v1alpha2.Artifact{{ImageName: "a", TagPolicy: envTemplate("{{.IMAGE_NAME")}},
+			shouldErr:   true,
+		},
+		{
+			description: "duplicate override",
+			artifacts: []*v1alpha2.Artifact{
+				{ImageName: "a", TagPolicy: envTemplate("{{.IMAGE_NAME}}:one")},
+				{ImageName: "a", TagPolicy: envTemplate("{{.IMAGE_NAME}}:two")},
+			},
+			shouldErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cfg := &v1alpha2.BuildConfig{
+				TagPolicy: *envTemplate("{{.IMAGE_NAME}}:global"),
+				Artifacts: test.artifacts,
+			}
+
+			tagger, err := getArtifactTagger(cfg, test.customTag)
+			if test.shouldErr {
+				testutil.CheckError(t, true, err)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			names := map[string]string{}
+			for _, artifact := range test.artifacts {
+				name, err := tagger.GenerateFullyQualifiedImageName(".", &tag.Options{ImageName: artifact.ImageName})
+				if err != nil {
+					t.Fatal(err)
+				}
+				names[artifact.ImageName] = name
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, nil, test.expected, names)
+		})
+	}
+}
+
+func TestGetGitTagger(t *testing.T) {
+	var tests = []struct {
+		description string
+		cfg         *v1alpha2.GitTagger
+		expected    *tag.GitCommit
+		shouldErr   bool
+	}{
+		{
+			description: "abbreviated commit sha",
+			cfg:         &v1alpha2.GitTagger{Variant: "AbbrevCommitSha", AbbrevLen: 10, Prefix: "ci-"},
+			expected:    &tag.GitCommit{CommitLength: 10, Prefix: "ci-"},
+		},
+		{
+			description: "full commit sha",
+			cfg:         &v1alpha2.GitTagger{Variant: "CommitSha"},
+			expected:    &tag.GitCommit{CommitLength: 40},
+		},
+		{
+			description: "tree sha with branch prefix",
+			cfg:         &v1alpha2.GitTagger{Variant: "TreeSha", BranchPrefix: true},
+			expected:    &tag.GitCommit{WorkspaceTreeHash: true, ScopeToWorkingDir: true, BranchPrefix: true},
+		},
+		{
+			description: "abbrevLen with full commit sha",
+			cfg:         &v1alpha2.GitTagger{Variant: "CommitSha", AbbrevLen: 10},
+			shouldErr:   true,
+		},
+		{
+			description: "unknown variant",
+			cfg:         &v1alpha2.GitTagger{Variant: "Other"},
+			shouldErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tagger, err := gitTagger(test.cfg)
+
+			// GitCommit has unexported fields that can't be compared.
+			var got []interface{}
+			if tagger != nil {
+				got = []interface{}{tagger.CommitLength, tagger.Prefix, tagger.BranchPrefix, tagger.WorkspaceTreeHash, tagger.ScopeToWorkingDir}
+			}
+			var expected []interface{}
+			if test.expected != nil {
+				expected = []interface{}{test.expected.CommitLength, test.expected.Prefix, test.expected.BranchPrefix, test.expected.WorkspaceTreeHash, test.expected.ScopeToWorkingDir}
+			}
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, expected, got)
+		})
+	}
+}
+
 func TestRun(t *testing.T) {
 	var tests = []struct {
 		description string
 		config      *config.SkaffoldConfig
 		builder     build.Builder
+		tester      *TestTester
 		deployer    deploy.Deployer
 		shouldErr   bool
 	}{
@@ -208,6 +539,7 @@ func TestRun(t *testing.T) {
 			description: "run no error",
 			config:      &v1alpha2.SkaffoldConfig{},
 			builder:     &TestBuilder{},
+			tester:      &TestTester{},
 			deployer:    &TestDeployer{},
 		},
 		{
@@ -218,6 +550,24 @@ func TestRun(t *testing.T) {
 			},
 			shouldErr: true,
 		},
+		{
+			description: "run test error",
+			config: &v1alpha2.SkaffoldConfig{
+				Build: v1alpha2.BuildConfig{
+					Artifacts: []*v1alpha2.Artifact{
+						{
+							ImageName: "test",
+						},
+					},
+				},
+			},
+			builder: &TestBuilder{},
+			tester: &TestTester{
+				failures: map[string]int{"test": 1},
+			},
+			deployer:  &TestDeployer{},
+			shouldErr: true,
+		},
 		{
 			description: "run deploy error",
 			config: &v1alpha2.SkaffoldConfig{
@@ -230,6 +580,7 @@ func TestRun(t *testing.T) {
 				},
 			},
 			builder: &TestBuilder{},
+			tester:  &TestTester{},
 			deployer: &TestDeployer{
 				err: fmt.Errorf(""),
 			},
@@ -241,6 +592,7 @@ func TestRun(t *testing.T) {
 		t.Run(test.description, func(t *testing.T) {
 			runner := &SkaffoldRunner{
 				Builder:  test.builder,
+				Tester:   test.tester,
 				Deployer: test.deployer,
 				Tagger:   &tag.ChecksumTagger{},
 			}
@@ -251,6 +603,59 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRender(t *testing.T) {
+	var tests = []struct {
+		description string
+		builder     build.Builder
+		deployer    deploy.Deployer
+		shouldErr   bool
+		expected    string
+	}{
+		{
+			description: "render",
+			builder:     &TestBuilder{},
+			deployer:    &TestDeployer{},
+			expected:    "image1:latest\nimage2:latest\n",
+		},
+		{
+			description: "build error",
+			builder: &TestBuilder{
+				errors: []error{fmt.Errorf("")},
+			},
+			deployer:  &TestDeployer{},
+			shouldErr: true,
+		},
+		{
+			description: "render error",
+			builder:     &TestBuilder{},
+			deployer: &TestDeployer{
+				err: fmt.Errorf(""),
+			},
+			shouldErr: true,
+		},
+	}
+
+	artifacts := []*v1alpha2.Artifact{
+		{ImageName: "image1"},
+		{ImageName: "image2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			runner := &SkaffoldRunner{
+				Builder:  test.builder,
+				Deployer: test.deployer,
+				Tagger:   &tag.ChecksumTagger{},
+			}
+
+			var manifests bytes.Buffer
+			err := runner.Render(context.Background(), ioutil.Discard, &manifests, artifacts)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, manifests.String())
+		})
+	}
+}
+
 func TestDev(t *testing.T) {
 	kubernetes.Client = fakeGetClient
 	defer resetClient()
@@ -258,14 +663,37 @@ func TestDev(t *testing.T) {
 	var tests = []struct {
 		description    string
 		builder        build.Builder
+		deployer       *TestDeployer
+		onError        string
 		watcherFactory watch.WatcherFactory
 		shouldErr      bool
 	}{
 		{
-			description: "fails to build the first time",
+			description: "exit if the first build fails",
 			builder: &TestBuilder{
 				errors: []error{fmt.Errorf("")},
 			},
+			onError:        config.OnErrorExit,
+			watcherFactory: NewWatcherFactory(nil),
+			shouldErr:      true,
+		},
+		{
+			description: "keep watching if the first build fails",
+			builder: &TestBuilder{
+				errors: []error{fmt.Errorf("")},
+			},
+			watcherFactory: NewWatcherFactory(nil),
+		},
+		{
+			description:    "keep watching if a deploy fails",
+			builder:        &TestBuilder{},
+			deployer:       &TestDeployer{err: fmt.Errorf("")},
+			watcherFactory: NewWatcherFactory(nil, nil),
+		},
+		{
+			description:    "invalid on-error",
+			builder:        &TestBuilder{},
+			onError:        "ignore",
 			watcherFactory: NewWatcherFactory(nil),
 			shouldErr:      true,
 		},
@@ -286,12 +714,18 @@ func TestDev(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
+			deployer := test.deployer
+			if deployer == nil {
+				deployer = &TestDeployer{}
+			}
 			runner := &SkaffoldRunner{
 				Builder:              test.builder,
-				Deployer:             &TestDeployer{},
+				Tester:               &TestTester{},
+				Deployer:             deployer,
 				Tagger:               &tag.ChecksumTagger{},
 				DependencyMapFactory: build.NewDependencyMap,
 				WatcherFactory:       test.watcherFactory,
+				opts:                 &config.SkaffoldOptions{OnError: test.onError},
 			}
 			_, err := runner.Dev(context.Background(), ioutil.Discard, nil)
 
@@ -317,6 +751,7 @@ func TestBuildAndDeployAllArtifacts(t *testing.T) {
 
 	runner := &SkaffoldRunner{
 		Builder:  builder,
+		Tester:   &TestTester{},
 		Deployer: deployer,
 		DependencyMapFactory: func(artifacts []*v1alpha2.Artifact) (*build.DependencyMap, error) {
 			return build.NewExplicitDependencyMap(artifacts, pathToArtifacts), nil
@@ -353,3 +788,232 @@ func TestBuildAndDeployAllArtifacts(t *testing.T) {
 		t.Errorf("Expected 2 artifacts to be deployed. Got %d", len(deployer.deployed))
 	}
 }
+
+func TestDevRebuildsFailedArtifacts(t *testing.T) {
+	kubernetes.Client = fakeGetClient
+	defer resetClient()
+
+	artifacts := []*v1alpha2.Artifact{
+		{ImageName: "image1"},
+		{ImageName: "image2"},
+	}
+	pathToArtifacts := map[string][]*v1alpha2.Artifact{
+		"path1": artifacts[0:1],
+		"path2": artifacts[1:],
+	}
+
+	var tests = []struct {
+		description   string
+		onError       string
+		errors        []error
+		shouldErr     bool
+		expectedBuilt int
+	}{
+		{
+			description:   "rebuild the artifacts of the failed build",
+			onError:       config.OnErrorContinue,
+			errors:        []error{fmt.Errorf("")},
+			expectedBuilt: 2,
+		},
+		{
+			description:   "rebuild only the changed artifacts",
+			onError:       config.OnErrorContinue,
+			expectedBuilt: 1,
+		},
+		{
+			description: "exit on a subsequent build error",
+			onError:     config.OnErrorExit,
+			errors:      []error{nil, fmt.Errorf("")},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			builder := &TestBuilder{errors: test.errors}
+			runner := &SkaffoldRunner{
+				Builder:  builder,
+				Tester:   &TestTester{},
+				Deployer: &TestDeployer{},
+				DependencyMapFactory: func(artifacts []*v1alpha2.Artifact) (*build.DependencyMap, error) {
+					return build.NewExplicitDependencyMap(artifacts, pathToArtifacts), nil
+				},
+				WatcherFactory: NewWatcherFactory(nil, []string{"path2"}),
+				opts:           &config.SkaffoldOptions{OnError: test.onError},
+			}
+
+			_, err := runner.Dev(context.Background(), ioutil.Discard, artifacts)
+
+			testutil.CheckError(t, test.shouldErr, err)
+			if !test.shouldErr {
+				testutil.CheckErrorAndDeepEqual(t, false, nil, test.expectedBuilt, len(builder.built))
+			}
+		})
+	}
+}
+
+func TestDevTests(t *testing.T) {
+	kubernetes.Client = fakeGetClient
+	defer resetClient()
+
+	artifacts := []*v1alpha2.Artifact{
+		{ImageName: "image1"},
+		{ImageName: "image2"},
+	}
+	pathToArtifacts := map[string][]*v1alpha2.Artifact{
+		"path1": artifacts[0:1],
+		"path2": artifacts[1:],
+	}
+	testDeps := map[string][]string{
+		"image1": {"test1.yaml"},
+	}
+
+	var tests = []struct {
+		description      string
+		changes          []string
+		failures         map[string]int
+		expectedTested   []string
+		expectedDeployed int
+	}{
+		{
+			description:      "test the rebuilt images before deploying",
+			changes:          []string{"path2"},
+			expectedTested:   []string{"image1", "image2", "image2"},
+			expectedDeployed: 2,
+		},
+		{
+			description:    "don't deploy if tests fail",
+			failures:       map[string]int{"image1": 1},
+			expectedTested: []string{"image1", "image2"},
+		},
+		{
+			description:      "retest the images whose test files changed, and deploy when they pass",
+			changes:          []string{"test1.yaml"},
+			failures:         map[string]int{"image1": 1},
+			expectedTested:   []string{"image1", "image1", "image2"},
+			expectedDeployed: 2,
+		},
+		{
+			description:    "don't deploy while other tests fail",
+			changes:        []string{"path1"},
+			failures:       map[string]int{"image2": 1},
+			expectedTested: []string{"image1", "image1", "image2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			tester := &TestTester{deps: testDeps, failures: test.failures}
+			deployer := &TestDeployer{}
+			runner := &SkaffoldRunner{
+				Builder:  &TestBuilder{},
+				Tester:   tester,
+				Deployer: deployer,
+				DependencyMapFactory: func(artifacts []*v1alpha2.Artifact) (*build.DependencyMap, error) {
+					return build.NewExplicitDependencyMap(artifacts, pathToArtifacts), nil
+				},
+				WatcherFactory: NewWatcherFactory(nil, test.changes),
+			}
+
+			_, err := runner.Dev(context.Background(), ioutil.Discard, artifacts)
+
+			// The watchers run concurrently, so only the set of test runs is checked.
+			var tested []string
+			for _, b := range tester.tested {
+				tested = append(tested, b.ImageName)
+			}
+			sort.Strings(tested)
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedTested, tested)
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedDeployed, len(deployer.deployed))
+		})
+	}
+}
+
+func TestSyncChangedFiles(t *testing.T) {
+	kubernetes.Client = fakeGetClient
+	defer resetClient()
+
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	html := filepath.Join(tmpDir, "index.html")
+	main := filepath.Join(tmpDir, "main.go")
+	for _, file := range []string{html, main} {
+		if err := ioutil.WriteFile(file, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifacts := []*v1alpha2.Artifact{
+		{
+			ImageName: "image",
+			Workspace: tmpDir,
+			Sync:      map[string]string{"*.html": "/app"},
+		},
+	}
+	pathToArtifacts := map[string][]*v1alpha2.Artifact{
+		html: artifacts,
+		main: artifacts,
+	}
+
+	var tests = []struct {
+		description    string
+		changes        []string
+		syncer         *TestSyncer
+		expectedSynced []*sync.Item
+		expectedBuilt  int
+	}{
+		{
+			description: "sync matching files",
+			changes:     []string{html},
+			syncer:      &TestSyncer{},
+			expectedSynced: []*sync.Item{
+				{Image: "image:latest", Copy: map[string]string{html: "/app/index.html"}},
+			},
+			expectedBuilt: 1,
+		},
+		{
+			description:   "rebuild if a file doesn't match",
+			changes:       []string{html, main},
+			syncer:        &TestSyncer{},
+			expectedBuilt: 2,
+		},
+		{
+			description:   "rebuild if sync fails",
+			changes:       []string{html},
+			syncer:        &TestSyncer{err: fmt.Errorf("")},
+			expectedBuilt: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			builder := &countingBuilder{}
+			runner := &SkaffoldRunner{
+				Builder:  builder,
+				Tester:   &TestTester{},
+				Deployer: &TestDeployer{},
+				Syncer:   test.syncer,
+				DependencyMapFactory: func(artifacts []*v1alpha2.Artifact) (*build.DependencyMap, error) {
+					return build.NewExplicitDependencyMap(artifacts, pathToArtifacts), nil
+				},
+				WatcherFactory: NewWatcherFactory(nil, test.changes),
+			}
+
+			_, err := runner.Dev(context.Background(), ioutil.Discard, artifacts)
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedSynced, test.syncer.synced)
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expectedBuilt, builder.count)
+		})
+	}
+}
+
+type countingBuilder struct {
+	TestBuilder
+	count int
+}
+
+func (c *countingBuilder) Build(ctx context.Context, w io.Writer, tagger tag.Tagger, artifacts []*v1alpha2.Artifact) ([]build.Build, error) {
+	c.count++
+	return c.TestBuilder.Build(ctx, w, tagger, artifacts)
+}
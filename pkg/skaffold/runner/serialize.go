@@ -0,0 +1,34 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import "sync"
+
+// serializer runs the callbacks of several watchers one at a time, since
+// they all read and update the state of the dev loop.
+type serializer struct {
+	mu sync.Mutex
+}
+
+func (s *serializer) wrap(onChange func([]string) error) func([]string) error {
+	return func(changedPaths []string) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return onChange(changedPaths)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/deploy"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+)
+
+// waitForRollout is for tests.
+var waitForRollout = deploy.StatusCheck
+
+// withStatusCheck creates a deployer that waits for the deployed workloads to be rolled out.
+func withStatusCheck(d deploy.Deployer, cfg *v1alpha2.DeployConfig) (deploy.Deployer, error) {
+	deadline, err := statusCheckDeadline(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return statusCheck{
+		Deployer: d,
+		deadline: deadline,
+	}, nil
+}
+
+type statusCheck struct {
+	deploy.Deployer
+	deadline time.Duration
+}
+
+func (s statusCheck) Deploy(ctx context.Context, out io.Writer, builds []build.Build) error {
+	if err := s.Deployer.Deploy(ctx, out, builds); err != nil {
+		return err
+	}
+
+	return waitForRollout(ctx, out, builds, s.deadline)
+}
+
+// statusCheckDeadline is how long to wait for the deployed workloads to be rolled out.
+func statusCheckDeadline(cfg *v1alpha2.DeployConfig) (time.Duration, error) {
+	value := cfg.StatusCheckDeadline
+	if value == "" {
+		value = constants.DefaultStatusCheckDeadline
+	}
+
+	deadline, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parsing %s", value)
+	}
+	return deadline, nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"fmt"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build/tag"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/pkg/errors"
+)
+
+// getArtifactTagger creates the tagger of the build config. Artifacts that override
+// the tag policy are tagged with their own tagger. A custom tag applies to every artifact.
+func getArtifactTagger(cfg *v1alpha2.BuildConfig, customTag string) (tag.Tagger, error) {
+	defaultTagger, err := getTagger(cfg.TagPolicy, customTag)
+	if err != nil {
+		return nil, err
+	}
+	if customTag != "" {
+		return defaultTagger, nil
+	}
+
+	byImage := map[string]tag.Tagger{}
+	for _, artifact := range cfg.Artifacts {
+		if artifact.TagPolicy == nil {
+			continue
+		}
+		if _, present := byImage[artifact.ImageName]; present {
+			return nil, fmt.Errorf("tag policy of %s is overridden more than once", artifact.ImageName)
+		}
+
+		tagger, err := getTagger(*artifact.TagPolicy, "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "tag policy of %s", artifact.ImageName)
+		}
+		byImage[artifact.ImageName] = tagger
+	}
+
+	if len(byImage) == 0 {
+		return defaultTagger, nil
+	}
+
+	return &artifactTagger{
+		defaultTagger: defaultTagger,
+		byImage:       byImage,
+	}, nil
+}
+
+// artifactTagger selects the tagger by the name of the image being tagged.
+type artifactTagger struct {
+	defaultTagger tag.Tagger
+	byImage       map[string]tag.Tagger
+}
+
+func (t *artifactTagger) GenerateFullyQualifiedImageName(workingDir string, opts *tag.Options) (string, error) {
+	if opts != nil {
+		if tagger, present := t.byImage[opts.ImageName]; present {
+			return tagger.GenerateFullyQualifiedImageName(workingDir, opts)
+		}
+	}
+
+	return t.defaultTagger.GenerateFullyQualifiedImageName(workingDir, opts)
+}
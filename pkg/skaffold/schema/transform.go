@@ -20,39 +20,38 @@ import (
 	"fmt"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/config/transform"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/util"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha1"
 	"github.com/pkg/errors"
 )
 
-type Transform func(vc util.VersionedConfig) (util.VersionedConfig, error)
+// RunTransform upgrades a configuration to the latest version,
+// one version at a time.
+func RunTransform(vc util.VersionedConfig) (util.VersionedConfig, error) {
+	if !isKnownVersion(vc.GetVersion()) {
+		return nil, fmt.Errorf("Unsupported version: %s", vc.GetVersion())
+	}
 
-// Map of schema version to transform functions
-// If there are n schema versions, this should always contain (n-1) entries,
-// since the last schema version should not have a transform
-var transformers = map[string]Transform{
-	v1alpha1.Version: transform.ToV1Alpha2,
-}
+	for vc.GetVersion() != config.LatestVersion {
+		from := vc.GetVersion()
 
-func RunTransform(vc util.VersionedConfig) (util.VersionedConfig, error) {
-	for i, version := range config.Versions {
-		if version == vc.GetVersion() {
-			return transformToLatest(vc, i)
+		upgraded, err := vc.Upgrade()
+		if err != nil {
+			return nil, errors.Wrapf(err, "upgrading skaffold config from %s", from)
 		}
+		if upgraded.GetVersion() == from {
+			return nil, fmt.Errorf("upgrading skaffold config from %s didn't change its version", from)
+		}
+		vc = upgraded
 	}
-	return nil, fmt.Errorf("Unsupported version: %s", vc.GetVersion())
+
+	return vc, nil
 }
 
-func transformToLatest(vc util.VersionedConfig, pos int) (util.VersionedConfig, error) {
-	if pos == len(config.Versions)-1 {
-		// if there are n versions, there are (n-1) transforms
-		return vc, nil
-	}
-	transformer := transformers[config.Versions[pos]]
-	newConfig, err := transformer(vc)
-	if err != nil {
-		return nil, errors.Wrapf(err, "transforming skaffold config")
+func isKnownVersion(version string) bool {
+	for _, v := range config.Versions {
+		if v == version {
+			return true
+		}
 	}
-	return transformToLatest(newConfig, pos+1)
+	return false
 }
@@ -19,6 +19,9 @@ package util
 type VersionedConfig interface {
 	GetVersion() string
 	Parse([]byte, bool) error
+
+	// Upgrade converts the configuration to the next schema version.
+	Upgrade() (VersionedConfig, error)
 }
 
 type Config interface {
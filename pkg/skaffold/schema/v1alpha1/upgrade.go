@@ -14,23 +14,18 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
-package transform
+package v1alpha1
 
 import (
-	"fmt"
-
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/util"
-	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha1"
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
 	"github.com/sirupsen/logrus"
 )
 
-func ToV1Alpha2(vc util.VersionedConfig) (util.VersionedConfig, error) {
-	if vc.GetVersion() != v1alpha1.Version {
-		return nil, fmt.Errorf("Incompatible version: %s", vc.GetVersion())
-	}
-	oldConfig := vc.(*v1alpha1.SkaffoldConfig)
+// Upgrade upgrades a configuration to the next version, v1alpha2.
+func (config *SkaffoldConfig) Upgrade() (util.VersionedConfig, error) {
+	oldConfig := config
 
 	var tagPolicy v1alpha2.TagPolicy
 	if oldConfig.Build.TagPolicy == constants.TagStrategySha256 {
@@ -63,8 +58,10 @@ func ToV1Alpha2(vc util.VersionedConfig) (util.VersionedConfig, error) {
 	var newKubectlDeploy *v1alpha2.KubectlDeploy
 	if oldConfig.Deploy.DeployType.KubectlDeploy != nil {
 		newManifests := make([]string, 0)
-		logrus.Warn("Ignoring manifest parameters when transforming v1alpha1 config; check kubernetes yaml before running skaffold")
 		for _, manifest := range oldConfig.Deploy.DeployType.KubectlDeploy.Manifests {
+			if len(manifest.Parameters) > 0 {
+				logrus.Warn("Ignoring manifest parameters when transforming v1alpha1 config; check kubernetes yaml before running skaffold")
+			}
 			newManifests = append(newManifests, manifest.Paths...)
 		}
 		newKubectlDeploy = &v1alpha2.KubectlDeploy{
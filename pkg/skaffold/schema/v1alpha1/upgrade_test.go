@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestUpgrade(t *testing.T) {
+	var tests = []struct {
+		description string
+		config      string
+		expected    *v1alpha2.SkaffoldConfig
+	}{
+		{
+			description: "kubectl",
+			config: `apiVersion: skaffold/v1alpha1
+kind: Config
+build:
+  tagPolicy: sha256
+  artifacts:
+  - imageName: gcr.io/k8s-skaffold/skaffold-example
+    workspace: .
+    dockerfilePath: Dockerfile.dev
+  local:
+    skipPush: false
+deploy:
+  kubectl:
+    manifests:
+    - paths:
+      - k8s-*
+`,
+			expected: &v1alpha2.SkaffoldConfig{
+				APIVersion: v1alpha2.Version,
+				Kind:       "Config",
+				Build: v1alpha2.BuildConfig{
+					TagPolicy: v1alpha2.TagPolicy{ShaTagger: &v1alpha2.ShaTagger{}},
+					Artifacts: []*v1alpha2.Artifact{{
+						ImageName: "gcr.io/k8s-skaffold/skaffold-example",
+						Workspace: ".",
+						ArtifactType: v1alpha2.ArtifactType{
+							DockerArtifact: &v1alpha2.DockerArtifact{DockerfilePath: "Dockerfile.dev"},
+						},
+					}},
+					BuildType: v1alpha2.BuildType{
+						LocalBuild: &v1alpha2.LocalBuild{SkipPush: new(bool)},
+					},
+				},
+				Deploy: v1alpha2.DeployConfig{
+					DeployType: v1alpha2.DeployType{
+						KubectlDeploy: &v1alpha2.KubectlDeploy{Manifests: []string{"k8s-*"}},
+					},
+				},
+			},
+		},
+		{
+			description: "helm on gcb",
+			config: `apiVersion: skaffold/v1alpha1
+kind: Config
+build:
+  tagPolicy: gitCommit
+  artifacts: []
+  googleCloudBuild:
+    projectId: my-project
+deploy:
+  helm:
+    releases:
+    - name: skaffold
+      chartPath: dummy
+      valuesFilePath: values.yaml
+      namespace: test
+`,
+			expected: &v1alpha2.SkaffoldConfig{
+				APIVersion: v1alpha2.Version,
+				Kind:       "Config",
+				Build: v1alpha2.BuildConfig{
+					TagPolicy: v1alpha2.TagPolicy{GitTagger: &v1alpha2.GitTagger{}},
+					Artifacts: []*v1alpha2.Artifact{},
+					BuildType: v1alpha2.BuildType{
+						GoogleCloudBuild: &v1alpha2.GoogleCloudBuild{ProjectID: "my-project"},
+					},
+				},
+				Deploy: v1alpha2.DeployConfig{
+					DeployType: v1alpha2.DeployType{
+						HelmDeploy: &v1alpha2.HelmDeploy{
+							Releases: []v1alpha2.HelmRelease{{
+								Name:           "skaffold",
+								ChartPath:      "dummy",
+								ValuesFilePath: "values.yaml",
+								Namespace:      "test",
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cfg := &SkaffoldConfig{}
+			if err := yaml.UnmarshalStrict([]byte(test.config), cfg); err != nil {
+				t.Fatal(err)
+			}
+
+			upgraded, err := cfg.Upgrade()
+
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, upgraded)
+		})
+	}
+}
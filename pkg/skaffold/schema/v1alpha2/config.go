@@ -18,6 +18,9 @@ package v1alpha2
 
 import (
 	"fmt"
+	"os"
+	"regexp"
+	"strings"
 
 	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/constants"
 	homedir "github.com/mitchellh/go-homedir"
@@ -34,8 +37,35 @@ type SkaffoldConfig struct {
 	Kind       string `yaml:"kind"`
 
 	Build    BuildConfig  `yaml:"build,omitempty"`
+	Test     []TestCase   `yaml:"test,omitempty"`
 	Deploy   DeployConfig `yaml:"deploy,omitempty"`
 	Profiles []Profile    `yaml:"profiles,omitempty"`
+
+	// Requires are other skaffold configs whose artifacts are built with this config's
+	// builder, and that are deployed before this config, in dependency order.
+	Requires []ConfigDependency `yaml:"requires,omitempty"`
+}
+
+// ConfigDependency is a skaffold config required by another one.
+// Only one of Path and Git should be set.
+type ConfigDependency struct {
+	// Path is a skaffold config file, or a directory containing a skaffold.yaml,
+	// relative to the requiring config.
+	Path string `yaml:"path,omitempty"`
+
+	// Git is a skaffold config in a git repository.
+	Git *GitDependency `yaml:"git,omitempty"`
+}
+
+// GitDependency is a skaffold config in a git repository that's cloned
+// into ~/.skaffold/repos and updated on each run.
+type GitDependency struct {
+	Repo string `yaml:"repo"`
+	// Ref is a branch or a tag. Defaults to the default branch.
+	Ref string `yaml:"ref,omitempty"`
+	// Path is the skaffold config file, or its directory, relative to the root
+	// of the repository. Defaults to `skaffold.yaml`.
+	Path string `yaml:"path,omitempty"`
 }
 
 func (c *SkaffoldConfig) GetVersion() string {
@@ -47,6 +77,14 @@ type BuildConfig struct {
 	Artifacts []*Artifact `yaml:"artifacts,omitempty"`
 	TagPolicy TagPolicy   `yaml:"tagPolicy,omitempty"`
 	BuildType `yaml:",inline"`
+
+	// InsecureRegistries are accessed without verifying their certificate,
+	// or over plain HTTP, eg. `localhost:5000`.
+	InsecureRegistries []string `yaml:"insecureRegistries,omitempty"`
+
+	// RegistryCertificates maps registries to the path of the CA certificate,
+	// in PEM format, that signed their self-signed certificate.
+	RegistryCertificates map[string]string `yaml:"registryCertificates,omitempty"`
 }
 
 // TagPolicy contains all the configuration for the tagging step
@@ -55,13 +93,25 @@ type TagPolicy struct {
 	ShaTagger         *ShaTagger         `yaml:"sha256"`
 	EnvTemplateTagger *EnvTemplateTagger `yaml:"envTemplate"`
 	DateTimeTagger    *DateTimeTagger    `yaml:"dateTime"`
+	PrecedenceTagger  *PrecedenceTagger  `yaml:"precedence"`
+	TemplateTagger    *TemplateTagger    `yaml:"template"`
+	FileTagger        *FileTagger        `yaml:"file"`
+
+	// Defaulted tells that no tag policy was configured and that gitCommit was selected
+	// by default. SKAFFOLD_DEFAULT_TAGGER and git config can then select another tagger.
+	Defaulted bool `yaml:"-"`
 }
 
 // ShaTagger contains the configuration for the SHA tagger.
 type ShaTagger struct{}
 
 // GitTagger contains the configuration for the git tagger.
-type GitTagger struct{}
+type GitTagger struct {
+	Variant      string `yaml:"variant,omitempty"`
+	Prefix       string `yaml:"prefix,omitempty"`
+	BranchPrefix bool   `yaml:"branchPrefix,omitempty"`
+	AbbrevLen    int    `yaml:"abbrevLen,omitempty"`
+}
 
 // EnvTemplateTagger contains the configuration for the envTemplate tagger.
 type EnvTemplateTagger struct {
@@ -74,6 +124,22 @@ type DateTimeTagger struct {
 	TimeZone string `yaml:"timezone,omitempty"`
 }
 
+// PrecedenceTagger contains the configuration for the precedence tagger.
+type PrecedenceTagger struct {
+	Sources []string `yaml:"sources"`
+}
+
+// TemplateTagger contains the configuration for the template tagger.
+type TemplateTagger struct {
+	Template string `yaml:"template"`
+}
+
+// FileTagger contains the configuration for the file tagger.
+type FileTagger struct {
+	// Path is relative to the directory of the config file.
+	Path string `yaml:"path"`
+}
+
 // BuildType contains the specific implementation and parameters needed
 // for the build step. Only one field should be populated.
 type BuildType struct {
@@ -85,13 +151,22 @@ type BuildType struct {
 // LocalBuild contains the fields needed to do a build on the local docker daemon
 // and optionally push to a repository.
 type LocalBuild struct {
-	SkipPush *bool `yaml:"skipPush"`
+	SkipPush    *bool `yaml:"skipPush"`
+	Concurrency int   `yaml:"concurrency,omitempty"`
+
+	// LocalCluster overrides the detection of minikube and Docker Desktop clusters,
+	// whose docker daemon is used to build the images that are then not pushed.
+	LocalCluster *bool `yaml:"localCluster,omitempty"`
 }
 
 // GoogleCloudBuild contains the fields needed to do a remote build on
 // Google Container Builder.
 type GoogleCloudBuild struct {
-	ProjectID string `yaml:"projectId"`
+	ProjectID   string `yaml:"projectId"`
+	DiskSizeGb  int64  `yaml:"diskSizeGb,omitempty"`
+	MachineType string `yaml:"machineType,omitempty"`
+	Timeout     string `yaml:"timeout,omitempty"`
+	DockerImage string `yaml:"dockerImage,omitempty"`
 }
 
 // KanikoBuild contains the fields needed to do a on-cluster build using
@@ -99,11 +174,64 @@ type GoogleCloudBuild struct {
 type KanikoBuild struct {
 	GCSBucket  string `yaml:"gcsBucket,omitempty"`
 	PullSecret string `yaml:"pullSecret,omitempty"`
+	Namespace  string `yaml:"namespace,omitempty"`
+	Timeout    string `yaml:"timeout,omitempty"`
 }
 
 // DeployConfig contains all the configuration needed by the deploy steps
 type DeployConfig struct {
 	DeployType `yaml:",inline"`
+
+	// Namespace is the namespace the manifests and the helm releases are deployed into,
+	// unless it's overridden by `--namespace`.
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// GenerateNamespace, if no namespace is set, deploys into a namespace named after
+	// the current user and git branch, eg. `dev-jane-feature-x`.
+	GenerateNamespace bool `yaml:"generateNamespace,omitempty"`
+
+	// CreateNamespace creates the namespace before deploying if it doesn't exist.
+	CreateNamespace bool `yaml:"createNamespace,omitempty"`
+
+	// Labels and Annotations are added to every deployed resource, along with the
+	// labels that skaffold adds, eg. `skaffold.dev/run-id`.
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	// GitCommit is the commit of the artifacts' workspace, set by the runner,
+	// that's added as the `skaffold.dev/git-commit` label.
+	GitCommit string `yaml:"-"`
+
+	// DeployByDigest references the images by their digest in the registry, rather than
+	// by their tag, in the manifests and the helm values.
+	DeployByDigest bool `yaml:"deployByDigest,omitempty"`
+
+	// Hooks are run before and after each deployment.
+	Hooks *Hooks `yaml:"hooks,omitempty"`
+
+	// StatusCheckDeadline is how long `skaffold run` waits for the deployed workloads
+	// to be rolled out, eg. `2m`. Defaults to 10 minutes.
+	StatusCheckDeadline string `yaml:"statusCheckDeadline,omitempty"`
+
+	// PortForward lists resources, eg. services, that `skaffold dev` forwards
+	// in addition to the container ports of the deployed pods.
+	PortForward []PortForwardResource `yaml:"portForward,omitempty"`
+
+	// Required are the deploys of the required configs, in dependency order.
+	// They are resolved when the config is loaded, not read from the yaml.
+	Required []DeployConfig `yaml:"-"`
+}
+
+// PortForwardResource is a resource whose port is forwarded to a local port
+// with `kubectl port-forward`.
+type PortForwardResource struct {
+	// ResourceType is the type of the resource, eg. `service`, `deployment` or `pod`.
+	ResourceType string `yaml:"resourceType"`
+	ResourceName string `yaml:"resourceName"`
+	Namespace    string `yaml:"namespace,omitempty"`
+	Port         int    `yaml:"port"`
+	// LocalPort defaults to Port.
+	LocalPort int `yaml:"localPort,omitempty"`
 }
 
 // DeployType contains the specific implementation and parameters needed
@@ -125,7 +253,11 @@ type HelmDeploy struct {
 	Releases []HelmRelease `yaml:"releases,omitempty"`
 }
 
-type KustomizeDeploy struct{}
+// KustomizeDeploy contains the configuration needed for deploying with kustomize
+type KustomizeDeploy struct {
+	// KustomizePath is the directory of the kustomization. Defaults to ".".
+	KustomizePath string `yaml:"path,omitempty"`
+}
 
 type HelmRelease struct {
 	Name           string                 `yaml:"name"`
@@ -135,8 +267,15 @@ type HelmRelease struct {
 	Namespace      string                 `yaml:"namespace"`
 	Version        string                 `yaml:"version"`
 	SetValues      map[string]string      `yaml:"setValues"`
-	Wait           bool                   `yaml:"wait"`
+	Wait           bool                   `yaml:"wait,omitempty"`
 	Overrides      map[string]interface{} `yaml:"overrides"`
+
+	// SkipBuildDependencies skips `helm dep build` before deploying a local chart.
+	SkipBuildDependencies bool `yaml:"skipBuildDependencies,omitempty"`
+
+	// Remote tells that ChartPath is a chart reference, eg. `stable/nginx`,
+	// rather than a local directory.
+	Remote bool `yaml:"remote,omitempty"`
 }
 
 // Artifact represents items that need to be built, along with the context in which
@@ -145,6 +284,70 @@ type Artifact struct {
 	ImageName    string `yaml:"imageName"`
 	Workspace    string `yaml:"workspace,omitempty"`
 	ArtifactType `yaml:",inline"`
+
+	// TagPolicy, if set, overrides the tag policy of the build for this artifact.
+	TagPolicy *TagPolicy `yaml:"tagPolicy,omitempty"`
+
+	// Sync maps globs of files, relative to the workspace, to directories of the containers.
+	// During dev loops, changes of files that all match a glob are copied into the running
+	// containers instead of triggering a rebuild.
+	Sync map[string]string `yaml:"sync,omitempty"`
+
+	// Hooks are run before and after each build of the artifact.
+	Hooks *Hooks `yaml:"hooks,omitempty"`
+}
+
+// Hooks are run, in order, before and after a phase. A failing hook fails the phase.
+type Hooks struct {
+	Before []Hook `yaml:"before,omitempty"`
+	After  []Hook `yaml:"after,omitempty"`
+}
+
+// TestCase lists the tests run on an image after it's built, and before it's deployed.
+type TestCase struct {
+	ImageName string `yaml:"image"`
+
+	// StructureTests are container-structure-test config files, or globs.
+	StructureTests []string `yaml:"structureTests,omitempty"`
+
+	// Custom are commands run on the host, with the built image in the IMAGE env variable.
+	Custom []CustomTest `yaml:"custom,omitempty"`
+
+	// Dir is the directory of the config that defines the test, that the paths
+	// are relative to and that the custom commands run in.
+	Dir string `yaml:"-"`
+}
+
+// CustomTest is a test command, eg. `["sh", "-c", "./smoke-test.sh"]`.
+type CustomTest struct {
+	Command []string `yaml:"command"`
+
+	// Dependencies are the files, or globs, that the command reads. The test is run
+	// again when they change during `skaffold dev`.
+	Dependencies []string `yaml:"dependencies,omitempty"`
+}
+
+// Hook is either a command run on the host or a command run in the deployed containers.
+type Hook struct {
+	// Command is run on the host, eg. `["sh", "-c", "make generate"]`, in the
+	// workspace of the artifact for build hooks.
+	Command []string `yaml:"command,omitempty"`
+
+	// Container runs a command in the running containers of the deployed pods.
+	// Only deploy hooks can use it.
+	Container *ContainerHook `yaml:"container,omitempty"`
+}
+
+// ContainerHook is a command executed with `kubectl exec`.
+type ContainerHook struct {
+	// Selector is a label selector of the pods, eg. `app=web`.
+	Selector string `yaml:"selector"`
+
+	// Container is the name of the container. Defaults to the first container of the pods.
+	Container string `yaml:"container,omitempty"`
+
+	// Command is run in the container, eg. `["./migrate", "up"]`.
+	Command []string `yaml:"command"`
 }
 
 // Profile is additional configuration that overrides default
@@ -152,23 +355,72 @@ type Artifact struct {
 type Profile struct {
 	Name   string       `yaml:"name"`
 	Build  BuildConfig  `yaml:"build,omitempty"`
+	Test   []TestCase   `yaml:"test,omitempty"`
 	Deploy DeployConfig `yaml:"deploy,omitempty"`
+
+	// Activation automatically activates the profile if one of the activations matches.
+	Activation []Activation `yaml:"activation,omitempty"`
+}
+
+// Activation matches when all its criteria match.
+type Activation struct {
+	// Env is `NAME=<regexp>`, eg. `ENV=prod`, that matches the value of an env variable.
+	Env string `yaml:"env,omitempty"`
+
+	// KubeContext is a regexp that matches the current kube context, eg. `minikube`.
+	KubeContext string `yaml:"kubeContext,omitempty"`
+
+	// Command is the skaffold command, eg. `dev`.
+	Command string `yaml:"command,omitempty"`
 }
 
 type ArtifactType struct {
-	DockerArtifact *DockerArtifact `yaml:"docker"`
-	BazelArtifact  *BazelArtifact  `yaml:"bazel"`
+	DockerArtifact    *DockerArtifact    `yaml:"docker"`
+	BazelArtifact     *BazelArtifact     `yaml:"bazel"`
+	JibMavenArtifact  *JibMavenArtifact  `yaml:"jibMaven"`
+	JibGradleArtifact *JibGradleArtifact `yaml:"jibGradle"`
+	BuildpackArtifact *BuildpackArtifact `yaml:"buildpacks"`
 }
 
 type DockerArtifact struct {
 	DockerfilePath string             `yaml:"dockerfilePath,omitempty"`
 	BuildArgs      map[string]*string `yaml:"buildArgs,omitempty"`
+	Target         string             `yaml:"target,omitempty"`
+	NetworkMode    string             `yaml:"network,omitempty"`
+	CacheFrom      []string           `yaml:"cacheFrom,omitempty"`
+	SSH            []string           `yaml:"ssh,omitempty"`
+	Secrets        []string           `yaml:"secrets,omitempty"`
 }
 
 type BazelArtifact struct {
 	BuildTarget string `yaml:"target"`
 }
 
+// JibMavenArtifact builds an image with the jib maven plugin, without a Dockerfile.
+type JibMavenArtifact struct {
+	// Module selects the module to build in a multi-module project.
+	Module string `yaml:"module,omitempty"`
+	// Profile is a maven profile to activate.
+	Profile string `yaml:"profile,omitempty"`
+}
+
+// BuildpackArtifact builds an image with Cloud Native Buildpacks and the `pack` CLI,
+// without a Dockerfile.
+type BuildpackArtifact struct {
+	// Builder is the builder image, eg. `heroku/buildpacks:18`.
+	Builder string `yaml:"builder"`
+	// Buildpacks overrides the buildpacks detected by the builder.
+	Buildpacks []string `yaml:"buildpacks,omitempty"`
+	// RunImage overrides the run image of the builder.
+	RunImage string `yaml:"runImage,omitempty"`
+}
+
+// JibGradleArtifact builds an image with the jib gradle plugin, without a Dockerfile.
+type JibGradleArtifact struct {
+	// Project selects the project to build in a multi-project build.
+	Project string `yaml:"project,omitempty"`
+}
+
 // Parse reads a SkaffoldConfig from yaml.
 func (c *SkaffoldConfig) Parse(contents []byte, useDefaults bool) error {
 	if err := yaml.UnmarshalStrict(contents, c); err != nil {
@@ -190,6 +442,9 @@ func (c *SkaffoldConfig) setDefaultValues() error {
 	c.setDefaultTagger()
 	c.setDefaultDockerfiles()
 	c.setDefaultWorkspaces()
+	c.setDefaultKanikoNamespace()
+	c.setDefaultKanikoTimeout()
+	c.setDefaultKustomizePath()
 	return c.expandKanikoSecretPath()
 }
 
@@ -219,7 +474,7 @@ func (c *SkaffoldConfig) setDefaultTagger() {
 		return
 	}
 
-	c.Build.TagPolicy = TagPolicy{GitTagger: &GitTagger{}}
+	c.Build.TagPolicy = TagPolicy{GitTagger: &GitTagger{}, Defaulted: true}
 }
 
 func (c *SkaffoldConfig) setDefaultDockerfiles() {
@@ -238,6 +493,24 @@ func (c *SkaffoldConfig) setDefaultWorkspaces() {
 	}
 }
 
+func (c *SkaffoldConfig) setDefaultKanikoNamespace() {
+	if c.Build.KanikoBuild != nil && c.Build.KanikoBuild.Namespace == "" {
+		c.Build.KanikoBuild.Namespace = constants.DefaultKanikoNamespace
+	}
+}
+
+func (c *SkaffoldConfig) setDefaultKanikoTimeout() {
+	if c.Build.KanikoBuild != nil && c.Build.KanikoBuild.Timeout == "" {
+		c.Build.KanikoBuild.Timeout = constants.DefaultKanikoTimeout
+	}
+}
+
+func (c *SkaffoldConfig) setDefaultKustomizePath() {
+	if c.Deploy.KustomizeDeploy != nil && c.Deploy.KustomizeDeploy.KustomizePath == "" {
+		c.Deploy.KustomizeDeploy.KustomizePath = constants.DefaultKustomizationPath
+	}
+}
+
 func (c *SkaffoldConfig) expandKanikoSecretPath() error {
 	if c.Build.KanikoBuild == nil || c.Build.KanikoBuild.PullSecret == "" {
 		return nil
@@ -278,9 +551,72 @@ func (c *SkaffoldConfig) ApplyProfiles(profiles []string) error {
 	return nil
 }
 
+// ActivatedProfiles returns the names of the profiles that are automatically activated
+// for the current kube context and skaffold command.
+func (c *SkaffoldConfig) ActivatedProfiles(kubeContext, command string) ([]string, error) {
+	var activated []string
+
+	for _, profile := range c.Profiles {
+		for _, activation := range profile.Activation {
+			active, err := activation.matches(kubeContext, command)
+			if err != nil {
+				return nil, errors.Wrapf(err, "activating profile %s", profile.Name)
+			}
+
+			if active {
+				logrus.Infof("Profile %s is activated", profile.Name)
+				activated = append(activated, profile.Name)
+				break
+			}
+		}
+	}
+
+	return activated, nil
+}
+
+func (a Activation) matches(kubeContext, command string) (bool, error) {
+	if a.Env != "" {
+		parts := strings.SplitN(a.Env, "=", 2)
+		if len(parts) != 2 {
+			return false, fmt.Errorf("invalid env activation %s, should be NAME=value", a.Env)
+		}
+
+		matches, err := matchesFully(parts[1], os.Getenv(parts[0]))
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+
+	if a.KubeContext != "" {
+		matches, err := matchesFully(a.KubeContext, kubeContext)
+		if err != nil || !matches {
+			return false, err
+		}
+	}
+
+	if a.Command != "" && a.Command != command {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// matchesFully tells if a regexp matches a whole value.
+func matchesFully(expr, value string) (bool, error) {
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid regexp %s", expr)
+	}
+	return re.MatchString(value), nil
+}
+
 func applyProfile(config *SkaffoldConfig, profile Profile) error {
 	logrus.Infof("Applying profile: %s", profile.Name)
 
+	if profile.Build.TagPolicy != (TagPolicy{}) {
+		config.Build.TagPolicy.Defaulted = false
+	}
+
 	buf, err := yaml.Marshal(profile)
 	if err != nil {
 		return err
@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Rebase makes the paths of the config, that are relative to the directory of the
+// config file, relative to the given directory instead. It's used to merge a
+// required config, whose paths are relative to its own directory.
+func (c *SkaffoldConfig) Rebase(dir string) {
+	for _, a := range c.Build.Artifacts {
+		a.Workspace = rebasePath(dir, a.Workspace)
+	}
+	for i := range c.Test {
+		c.Test[i].Dir = rebasePath(dir, c.Test[i].Dir)
+	}
+	c.Deploy.rebase(dir)
+}
+
+func (d *DeployConfig) rebase(dir string) {
+	if d.KubectlDeploy != nil {
+		for i, manifest := range d.KubectlDeploy.Manifests {
+			d.KubectlDeploy.Manifests[i] = rebasePath(dir, manifest)
+		}
+	}
+	if d.HelmDeploy != nil {
+		for i := range d.HelmDeploy.Releases {
+			r := &d.HelmDeploy.Releases[i]
+			if !r.Remote {
+				r.ChartPath = rebasePath(dir, r.ChartPath)
+			}
+			if r.ValuesFilePath != "" {
+				r.ValuesFilePath = rebasePath(dir, r.ValuesFilePath)
+			}
+		}
+	}
+	if d.KustomizeDeploy != nil {
+		d.KustomizeDeploy.KustomizePath = rebasePath(dir, d.KustomizeDeploy.KustomizePath)
+	}
+}
+
+// ResolveTagFiles makes the paths of the file taggers, that are relative to dir,
+// the directory of the config file, independent of the current directory.
+func (c *SkaffoldConfig) ResolveTagFiles(dir string) {
+	c.Build.TagPolicy.resolveTagFile(dir)
+	for _, a := range c.Build.Artifacts {
+		if a.TagPolicy != nil {
+			a.TagPolicy.resolveTagFile(dir)
+		}
+	}
+}
+
+func (t *TagPolicy) resolveTagFile(dir string) {
+	if t.FileTagger != nil {
+		t.FileTagger.Path = rebasePath(dir, t.FileTagger.Path)
+	}
+}
+
+// rebasePath leaves absolute paths and urls untouched.
+func rebasePath(dir, path string) string {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// TimestampFormat is the format of the TIMESTAMP built-in of the templated fields.
+const TimestampFormat = "2006-01-02_15-04-05"
+
+// Now and GitBranch are used by the built-ins of the templated fields and replaced in tests.
+var (
+	Now       = time.Now
+	GitBranch = gitBranch
+)
+
+// ExpandTemplates executes the go templates of the image names, docker build args, helm values,
+// manifest paths and namespaces against the environment variables, eg. `{{.USER}}`,
+// and the built-ins TIMESTAMP and GIT_BRANCH.
+func (c *SkaffoldConfig) ExpandTemplates() error {
+	e := &templateExpander{}
+
+	for _, a := range c.Build.Artifacts {
+		e.expand(&a.ImageName, "imageName")
+		if a.DockerArtifact != nil {
+			for k, v := range a.DockerArtifact.BuildArgs {
+				if v != nil {
+					expanded := *v
+					e.expand(&expanded, "buildArgs")
+					a.DockerArtifact.BuildArgs[k] = &expanded
+				}
+			}
+			for i := range a.DockerArtifact.CacheFrom {
+				e.expand(&a.DockerArtifact.CacheFrom[i], "cacheFrom")
+			}
+		}
+	}
+	if c.Build.KanikoBuild != nil {
+		e.expand(&c.Build.KanikoBuild.Namespace, "kaniko namespace")
+	}
+
+	if c.Deploy.KubectlDeploy != nil {
+		for i := range c.Deploy.KubectlDeploy.Manifests {
+			e.expand(&c.Deploy.KubectlDeploy.Manifests[i], "manifests")
+		}
+	}
+	if c.Deploy.HelmDeploy != nil {
+		for i := range c.Deploy.HelmDeploy.Releases {
+			r := &c.Deploy.HelmDeploy.Releases[i]
+			e.expand(&r.Namespace, "helm namespace")
+			e.expand(&r.ValuesFilePath, "helm valuesFilePath")
+			e.expandValues(r.Values, "helm values")
+			e.expandValues(r.SetValues, "helm setValues")
+		}
+	}
+
+	return e.err
+}
+
+// templateExpander expands fields until an error happens.
+type templateExpander struct {
+	builtins map[string]string
+	err      error
+}
+
+func (e *templateExpander) expandValues(values map[string]string, field string) {
+	for k, v := range values {
+		e.expand(&v, field)
+		values[k] = v
+	}
+}
+
+func (e *templateExpander) expand(s *string, field string) {
+	if e.err != nil || !strings.Contains(*s, "{{") {
+		return
+	}
+
+	tmpl, err := template.New(field).Option("missingkey=error").Parse(*s)
+	if err != nil {
+		e.err = errors.Wrapf(err, "parsing template of %s %q", field, *s)
+		return
+	}
+
+	expanded, err := util.ExecuteEnvTemplate(tmpl, e.builtinValues())
+	if err != nil {
+		e.err = errors.Wrapf(err, "expanding template of %s %q", field, *s)
+		return
+	}
+	*s = expanded
+}
+
+// builtinValues are computed once, so that all the fields get the same values.
+func (e *templateExpander) builtinValues() map[string]string {
+	if e.builtins == nil {
+		e.builtins = map[string]string{
+			"TIMESTAMP":  Now().Format(TimestampFormat),
+			"GIT_BRANCH": GitBranch(),
+		}
+	}
+	return e.builtins
+}
+
+func gitBranch() string {
+	out, err := util.RunCmdOut(exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
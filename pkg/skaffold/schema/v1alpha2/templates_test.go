@@ -0,0 +1,140 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestExpandTemplates(t *testing.T) {
+	defer func(environ func() []string) { util.OSEnviron = environ }(util.OSEnviron)
+	util.OSEnviron = func() []string { return []string{"USER=jane", "PROJECT=my-project"} }
+
+	defer func(now func() time.Time, branch func() string) { Now, GitBranch = now, branch }(Now, GitBranch)
+	Now = func() time.Time { return time.Date(2018, 9, 10, 11, 12, 13, 0, time.UTC) }
+	GitBranch = func() string { return "master" }
+
+	var tests = []struct {
+		description string
+		config      *SkaffoldConfig
+		expected    *SkaffoldConfig
+		shouldErr   bool
+	}{
+		{
+			description: "expand fields",
+			config: &SkaffoldConfig{
+				Build: BuildConfig{
+					Artifacts: []*Artifact{{ImageName: "gcr.io/{{.PROJECT}}/app"}},
+					BuildType: BuildType{KanikoBuild: &KanikoBuild{Namespace: "{{.USER}}"}},
+				},
+				Deploy: DeployConfig{
+					DeployType: DeployType{
+						KubectlDeploy: &KubectlDeploy{Manifests: []string{"k8s/{{.GIT_BRANCH}}/*.yaml", "k8s/{{.USER}}.yaml"}},
+						HelmDeploy: &HelmDeploy{Releases: []HelmRelease{{
+							Namespace:      "{{.USER}}",
+							ValuesFilePath: "values-{{.USER}}.yaml",
+							Values:         map[string]string{"image": "gcr.io/{{.PROJECT}}/app"},
+							SetValues:      map[string]string{"deployedAt": "{{.TIMESTAMP}}", "replicas": "1"},
+						}}},
+					},
+				},
+			},
+			expected: &SkaffoldConfig{
+				Build: BuildConfig{
+					Artifacts: []*Artifact{{ImageName: "gcr.io/my-project/app"}},
+					BuildType: BuildType{KanikoBuild: &KanikoBuild{Namespace: "jane"}},
+				},
+				Deploy: DeployConfig{
+					DeployType: DeployType{
+						KubectlDeploy: &KubectlDeploy{Manifests: []string{"k8s/master/*.yaml", "k8s/jane.yaml"}},
+						HelmDeploy: &HelmDeploy{Releases: []HelmRelease{{
+							Namespace:      "jane",
+							ValuesFilePath: "values-jane.yaml",
+							Values:         map[string]string{"image": "gcr.io/my-project/app"},
+							SetValues:      map[string]string{"deployedAt": "2018-09-10_11-12-13", "replicas": "1"},
+						}}},
+					},
+				},
+			},
+		},
+		{
+			description: "expand docker build args",
+			config: &SkaffoldConfig{
+				Build: BuildConfig{
+					Artifacts: []*Artifact{{
+						ImageName: "app",
+						ArtifactType: ArtifactType{DockerArtifact: &DockerArtifact{
+							BuildArgs: map[string]*string{"user": stringPointer("{{.USER}}"), "unset": nil},
+							CacheFrom: []string{"gcr.io/{{.PROJECT}}/app:{{.GIT_BRANCH}}"},
+						}},
+					}},
+				},
+			},
+			expected: &SkaffoldConfig{
+				Build: BuildConfig{
+					Artifacts: []*Artifact{{
+						ImageName: "app",
+						ArtifactType: ArtifactType{DockerArtifact: &DockerArtifact{
+							BuildArgs: map[string]*string{"user": stringPointer("jane"), "unset": nil},
+							CacheFrom: []string{"gcr.io/my-project/app:master"},
+						}},
+					}},
+				},
+			},
+		},
+		{
+			description: "unknown variable",
+			config: &SkaffoldConfig{
+				Build: BuildConfig{
+					Artifacts: []*Artifact{{ImageName: "gcr.io/{{.UNKNOWN}}/app"}},
+				},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "invalid template",
+			config: &SkaffoldConfig{
+				Deploy: DeployConfig{
+					DeployType: DeployType{
+						KubectlDeploy: &KubectlDeploy{Manifests: []string{"k8s/{{.USER"}},
+					},
+				},
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			err := test.config.ExpandTemplates()
+
+			if test.shouldErr {
+				testutil.CheckError(t, true, err)
+				return
+			}
+			testutil.CheckErrorAndDeepEqual(t, false, err, test.expected, test.config)
+		})
+	}
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Validate checks the parts of the config that kubernetes would only reject
+// at deploy time, eg. the custom labels and annotations.
+func (c *SkaffoldConfig) Validate() error {
+	if err := validateLabels(c.Deploy.Labels); err != nil {
+		return errors.Wrap(err, "invalid deploy labels")
+	}
+	if err := validateAnnotations(c.Deploy.Annotations); err != nil {
+		return errors.Wrap(err, "invalid deploy annotations")
+	}
+	return nil
+}
+
+func validateLabels(labels map[string]string) error {
+	for _, k := range sortedKeys(labels) {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return errors.Errorf("key %q: %s", k, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(labels[k]); len(errs) > 0 {
+			return errors.Errorf("value %q of %q: %s", labels[k], k, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+func validateAnnotations(annotations map[string]string) error {
+	for _, k := range sortedKeys(annotations) {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return errors.Errorf("key %q: %s", k, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	var keys []string
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		description string
+		deploy      DeployConfig
+		shouldErr   bool
+	}{
+		{
+			description: "valid labels and annotations",
+			deploy: DeployConfig{
+				Labels:      map[string]string{"team": "payments", "example.com/tier": "web", "empty": ""},
+				Annotations: map[string]string{"owner": "jane@example.com"},
+			},
+		},
+		{
+			description: "invalid label key",
+			deploy: DeployConfig{
+				Labels: map[string]string{"my team": "payments"},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "invalid label value",
+			deploy: DeployConfig{
+				Labels: map[string]string{"owner": "jane@example.com"},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "invalid annotation key",
+			deploy: DeployConfig{
+				Annotations: map[string]string{"-owner": "jane"},
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cfg := &SkaffoldConfig{Deploy: test.deploy}
+
+			err := cfg.Validate()
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
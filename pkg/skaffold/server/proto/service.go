@@ -0,0 +1,246 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package proto contains the messages and the service described in skaffold.proto.
+// They are written by hand, not generated by protoc, and must be kept in sync with
+// skaffold.proto: the field numbers and names are in the struct tags, through which
+// the protobuf library marshals the messages.
+package proto
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"google.golang.org/grpc"
+)
+
+// Empty is the message used by the calls that take no argument.
+type Empty struct{}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+
+// State is the current state of the builds and of the deployment.
+type State struct {
+	BuildState  *BuildState  `protobuf:"bytes,1,opt,name=build_state,json=buildState,proto3" json:"buildState,omitempty"`
+	DeployState *DeployState `protobuf:"bytes,2,opt,name=deploy_state,json=deployState,proto3" json:"deployState,omitempty"`
+}
+
+func (m *State) Reset()         { *m = State{} }
+func (m *State) String() string { return proto.CompactTextString(m) }
+func (*State) ProtoMessage()    {}
+
+// BuildState maps image names to their build status.
+type BuildState struct {
+	Artifacts map[string]string `protobuf:"bytes,1,rep,name=artifacts,proto3" json:"artifacts,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *BuildState) Reset()         { *m = BuildState{} }
+func (m *BuildState) String() string { return proto.CompactTextString(m) }
+func (*BuildState) ProtoMessage()    {}
+
+// DeployState holds the status of the deployment.
+type DeployState struct {
+	Status string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (m *DeployState) Reset()         { *m = DeployState{} }
+func (m *DeployState) String() string { return proto.CompactTextString(m) }
+func (*DeployState) ProtoMessage()    {}
+
+// LogEntry is an event with the time it happened and a human readable description.
+type LogEntry struct {
+	Timestamp *timestamp.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Event     *Event               `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	Entry     string               `protobuf:"bytes,3,opt,name=entry,proto3" json:"entry,omitempty"`
+}
+
+func (m *LogEntry) Reset()         { *m = LogEntry{} }
+func (m *LogEntry) String() string { return proto.CompactTextString(m) }
+func (*LogEntry) ProtoMessage()    {}
+
+// Event has exactly one of its fields set.
+type Event struct {
+	BuildEvent      *BuildEvent      `protobuf:"bytes,1,opt,name=build_event,json=buildEvent,proto3" json:"buildEvent,omitempty"`
+	DeployEvent     *DeployEvent     `protobuf:"bytes,2,opt,name=deploy_event,json=deployEvent,proto3" json:"deployEvent,omitempty"`
+	FileChangeEvent *FileChangeEvent `protobuf:"bytes,3,opt,name=file_change_event,json=fileChangeEvent,proto3" json:"fileChangeEvent,omitempty"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+
+// BuildEvent notifies a change of the build status of an artifact.
+type BuildEvent struct {
+	Artifact string `protobuf:"bytes,1,opt,name=artifact,proto3" json:"artifact,omitempty"`
+	Status   string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Err      string `protobuf:"bytes,3,opt,name=err,proto3" json:"err,omitempty"`
+}
+
+func (m *BuildEvent) Reset()         { *m = BuildEvent{} }
+func (m *BuildEvent) String() string { return proto.CompactTextString(m) }
+func (*BuildEvent) ProtoMessage()    {}
+
+// DeployEvent notifies a change of the deployment status.
+type DeployEvent struct {
+	Status string `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Err    string `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+}
+
+func (m *DeployEvent) Reset()         { *m = DeployEvent{} }
+func (m *DeployEvent) String() string { return proto.CompactTextString(m) }
+func (*DeployEvent) ProtoMessage()    {}
+
+// FileChangeEvent lists the files changed, as detected by the dev loop.
+type FileChangeEvent struct {
+	Paths []string `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+}
+
+func (m *FileChangeEvent) Reset()         { *m = FileChangeEvent{} }
+func (m *FileChangeEvent) String() string { return proto.CompactTextString(m) }
+func (*FileChangeEvent) ProtoMessage()    {}
+
+// SkaffoldServiceServer is the server API for SkaffoldService.
+type SkaffoldServiceServer interface {
+	// GetState returns the current state of the builds and of the deployment.
+	GetState(context.Context, *Empty) (*State, error)
+	// EventLog streams the events that have already happened, then the new ones.
+	EventLog(*Empty, EventLogServer) error
+}
+
+// EventLogServer is the stream of log entries sent by EventLog.
+type EventLogServer interface {
+	Send(*LogEntry) error
+	grpc.ServerStream
+}
+
+// RegisterSkaffoldServiceServer registers the implementation of SkaffoldService on a grpc server.
+func RegisterSkaffoldServiceServer(s *grpc.Server, srv SkaffoldServiceServer) {
+	s.RegisterService(&skaffoldServiceDesc, srv)
+}
+
+var skaffoldServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.SkaffoldService",
+	HandlerType: (*SkaffoldServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler:    getStateHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EventLog",
+			Handler:       eventLogHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "skaffold.proto",
+}
+
+func getStateHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SkaffoldServiceServer).GetState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.SkaffoldService/GetState",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SkaffoldServiceServer).GetState(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func eventLogHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SkaffoldServiceServer).EventLog(m, &eventLogServer{stream})
+}
+
+type eventLogServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventLogServer) Send(m *LogEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// SkaffoldServiceClient is the client API for SkaffoldService.
+type SkaffoldServiceClient interface {
+	// GetState returns the current state of the builds and of the deployment.
+	GetState(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*State, error)
+	// EventLog streams the events that have already happened, then the new ones.
+	EventLog(ctx context.Context, in *Empty, opts ...grpc.CallOption) (EventLogClient, error)
+}
+
+// EventLogClient is the stream of log entries received from EventLog.
+type EventLogClient interface {
+	Recv() (*LogEntry, error)
+	grpc.ClientStream
+}
+
+type skaffoldServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewSkaffoldServiceClient creates a client for SkaffoldService.
+func NewSkaffoldServiceClient(cc *grpc.ClientConn) SkaffoldServiceClient {
+	return &skaffoldServiceClient{cc}
+}
+
+func (c *skaffoldServiceClient) GetState(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*State, error) {
+	out := new(State)
+	if err := c.cc.Invoke(ctx, "/proto.SkaffoldService/GetState", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *skaffoldServiceClient) EventLog(ctx context.Context, in *Empty, opts ...grpc.CallOption) (EventLogClient, error) {
+	stream, err := c.cc.NewStream(ctx, &skaffoldServiceDesc.Streams[0], "/proto.SkaffoldService/EventLog", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventLogClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type eventLogClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventLogClient) Recv() (*LogEntry, error) {
+	m := new(LogEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
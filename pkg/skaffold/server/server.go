@@ -0,0 +1,127 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/server/proto"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// Initialize starts the gRPC server on rpcPort and the HTTP server on httpPort.
+// A port set to 0 disables the corresponding server. Both only listen on localhost.
+// The returned function stops the servers.
+func Initialize(rpcPort, httpPort int) (func(), error) {
+	var shutdowns []func()
+	shutdown := func() {
+		for _, s := range shutdowns {
+			s()
+		}
+	}
+
+	if rpcPort != 0 {
+		l, err := listen(rpcPort)
+		if err != nil {
+			return nil, errors.Wrap(err, "starting gRPC server")
+		}
+
+		s := grpc.NewServer()
+		proto.RegisterSkaffoldServiceServer(s, &server{})
+		go func() {
+			if err := s.Serve(l); err != nil {
+				logrus.Errorln("gRPC server:", err)
+			}
+		}()
+		logrus.Infof("gRPC server listening on %s", l.Addr())
+
+		shutdowns = append(shutdowns, s.Stop)
+	}
+
+	if httpPort != 0 {
+		l, err := listen(httpPort)
+		if err != nil {
+			shutdown()
+			return nil, errors.Wrap(err, "starting HTTP server")
+		}
+
+		s := &http.Server{Handler: newHTTPHandler()}
+		go func() {
+			if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
+				logrus.Errorln("HTTP server:", err)
+			}
+		}()
+		logrus.Infof("HTTP server listening on %s", l.Addr())
+
+		shutdowns = append(shutdowns, func() { s.Close() })
+	}
+
+	return shutdown, nil
+}
+
+func listen(port int) (net.Listener, error) {
+	return net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+}
+
+// server implements the SkaffoldService.
+type server struct{}
+
+func (s *server) GetState(context.Context, *proto.Empty) (*proto.State, error) {
+	return event.GetState(), nil
+}
+
+func (s *server) EventLog(_ *proto.Empty, stream proto.EventLogServer) error {
+	return event.ForEachEvent(stream.Context().Done(), stream.Send)
+}
+
+// newHTTPHandler exposes the state as json on `/v1/state` and streams
+// the log entries as newline delimited json on `/v1/events`.
+func newHTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/v1/state", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(event.GetState()); err != nil {
+			logrus.Debugln("Sending state:", err)
+		}
+	})
+
+	mux.HandleFunc("/v1/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		flusher, _ := w.(http.Flusher)
+		encoder := json.NewEncoder(w)
+
+		event.ForEachEvent(r.Context().Done(), func(entry *proto.LogEntry) error {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return nil
+		})
+	})
+
+	return mux
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/event"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/server/proto"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	"google.golang.org/grpc"
+)
+
+func TestGRPCServer(t *testing.T) {
+	event.InitializeState([]*v1alpha2.Artifact{{ImageName: "image"}})
+	event.BuildComplete("image")
+
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := grpc.NewServer()
+	proto.RegisterSkaffoldServiceServer(s, &server{})
+	go s.Serve(l)
+	defer s.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, l.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := proto.NewSkaffoldServiceClient(conn)
+
+	state, err := client.GetState(ctx, &proto.Empty{})
+	testutil.CheckErrorAndDeepEqual(t, false, err, map[string]string{"image": event.Complete}, state.BuildState.Artifacts)
+
+	stream, err := client.EventLog(ctx, &proto.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, err := stream.Recv()
+	testutil.CheckErrorAndDeepEqual(t, false, err, "image", entry.Event.BuildEvent.Artifact)
+}
+
+func TestHTTPServer(t *testing.T) {
+	event.InitializeState([]*v1alpha2.Artifact{{ImageName: "image"}})
+	event.BuildFailed("image", nil)
+
+	s := httptest.NewServer(newHTTPHandler())
+	defer s.Close()
+
+	resp, err := http.Get(s.URL + "/v1/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var state proto.State
+	err = json.NewDecoder(resp.Body).Decode(&state)
+	testutil.CheckErrorAndDeepEqual(t, false, err, event.Failed, state.BuildState.Artifacts["image"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequest("GET", s.URL+"/v1/events", nil)
+	events, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer events.Body.Close()
+
+	line, err := bufio.NewReader(events.Body).ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry proto.LogEntry
+	err = json.Unmarshal(line, &entry)
+	testutil.CheckErrorAndDeepEqual(t, false, err, event.Failed, entry.Event.BuildEvent.Status)
+}
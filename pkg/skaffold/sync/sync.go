@@ -0,0 +1,285 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgo "k8s.io/client-go/kubernetes"
+)
+
+// maxControllerDepth is how many controllers are followed up from a pod to find
+// the workload that skaffold deployed, eg. pod -> replica set -> deployment.
+const maxControllerDepth = 3
+
+// Item is a set of files to copy into, or delete from, the containers running an image.
+type Item struct {
+	// Image is the tag of the running image.
+	Image string
+	// Copy maps local paths to paths in the containers.
+	Copy map[string]string
+	// Delete are paths in the containers.
+	Delete []string
+}
+
+// Syncer syncs files into the running containers.
+type Syncer interface {
+	Sync(ctx context.Context, item *Item) error
+}
+
+// NewItem returns what to sync for changes of an artifact's files, or nil if one of them
+// doesn't match any sync rule, in which case the artifact should be rebuilt. A file that
+// matches `<glob>: <dir>` is synced to `<dir>/<path relative to the workspace>`.
+// In globs, `**` matches any number of directories.
+func NewItem(a *v1alpha2.Artifact, changedPaths []string, image string) (*Item, error) {
+	if len(a.Sync) == 0 || len(changedPaths) == 0 {
+		return nil, nil
+	}
+
+	item := &Item{
+		Image: image,
+		Copy:  map[string]string{},
+	}
+
+	for _, changed := range changedPaths {
+		rel, err := filepath.Rel(a.Workspace, changed)
+		if err != nil {
+			return nil, errors.Wrapf(err, "finding %s in workspace", changed)
+		}
+
+		dst, err := destination(a.Sync, filepath.ToSlash(rel))
+		if err != nil {
+			return nil, err
+		}
+		if dst == "" {
+			logrus.Debugf("%s doesn't match any sync rule of %s", changed, a.ImageName)
+			return nil, nil
+		}
+
+		if _, err := os.Stat(changed); os.IsNotExist(err) {
+			item.Delete = append(item.Delete, dst)
+		} else {
+			item.Copy[changed] = dst
+		}
+	}
+
+	sort.Strings(item.Delete)
+	return item, nil
+}
+
+// destination finds where a file should be synced. Rules are tried in
+// alphabetical order so that the destination is deterministic.
+func destination(rules map[string]string, rel string) (string, error) {
+	var globs []string
+	for glob := range rules {
+		globs = append(globs, glob)
+	}
+	sort.Strings(globs)
+
+	for _, glob := range globs {
+		matches, err := matchGlob(glob, rel)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid sync rule %s", glob)
+		}
+		if matches {
+			return path.Join(rules[glob], rel), nil
+		}
+	}
+	return "", nil
+}
+
+// matchGlob matches a slash separated path against a glob, where `**` matches
+// zero or more path segments and the other segments follow path.Match.
+func matchGlob(glob, rel string) (bool, error) {
+	patterns := strings.Split(glob, "/")
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return false, err
+		}
+	}
+
+	return matchSegments(patterns, strings.Split(rel, "/")), nil
+}
+
+func matchSegments(patterns, segments []string) bool {
+	if len(patterns) == 0 {
+		return len(segments) == 0
+	}
+
+	if patterns[0] == "**" {
+		return matchSegments(patterns[1:], segments) || (len(segments) > 0 && matchSegments(patterns, segments[1:]))
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	if matches, _ := path.Match(patterns[0], segments[0]); !matches {
+		return false
+	}
+	return matchSegments(patterns[1:], segments[1:])
+}
+
+// KubectlSyncer syncs files with `kubectl cp` and `kubectl exec`.
+type KubectlSyncer struct {
+	kubeContext string
+	namespace   string
+	labels      map[string]string
+}
+
+// NewKubectlSyncer returns a new KubectlSyncer for the pods that skaffold deployed
+// with the given labels in a namespace of a kube context. The namespace of the kube
+// context is used if the namespace is empty.
+func NewKubectlSyncer(kubeContext, namespace string, labels map[string]string) *KubectlSyncer {
+	return &KubectlSyncer{
+		kubeContext: kubeContext,
+		namespace:   namespace,
+		labels:      labels,
+	}
+}
+
+// Sync copies and deletes the files in every running container of the image.
+func (k *KubectlSyncer) Sync(ctx context.Context, item *Item) error {
+	client, err := kubernetes.ClientForContext(k.kubeContext)
+	if err != nil {
+		return errors.Wrap(err, "getting kubernetes client")
+	}
+
+	namespace := k.namespace
+	if namespace == "" {
+		if namespace, err = kubernetes.ContextNamespace(k.kubeContext); err != nil {
+			return err
+		}
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "listing pods")
+	}
+
+	synced := 0
+	for i := range pods.Items {
+		pod := pods.Items[i]
+		if pod.Status.Phase != v1.PodRunning || !k.isDeployed(client, &pod) {
+			continue
+		}
+
+		for _, container := range pod.Spec.Containers {
+			if withoutDigest(container.Image) != withoutDigest(item.Image) {
+				continue
+			}
+
+			if err := k.syncContainer(ctx, pod, container.Name, item); err != nil {
+				return errors.Wrapf(err, "syncing files in %s/%s", pod.Name, container.Name)
+			}
+			synced++
+		}
+	}
+
+	if synced == 0 {
+		return fmt.Errorf("no running container for %s", item.Image)
+	}
+	return nil
+}
+
+// withoutDigest removes the `@digest` suffix of the images deployed by digest,
+// eg. `image:tag@sha256:...`, so that they match the tag that was built.
+func withoutDigest(image string) string {
+	return strings.SplitN(image, "@", 2)[0]
+}
+
+// isDeployed tells if a pod, or one of the workloads that control it, has the labels
+// of the syncer. Skaffold labels the resources that it deploys, not their pod templates.
+func (k *KubectlSyncer) isDeployed(client clientgo.Interface, pod *v1.Pod) bool {
+	var obj metav1.Object = pod
+	for i := 0; i <= maxControllerDepth; i++ {
+		if hasLabels(obj.GetLabels(), k.labels) {
+			return true
+		}
+
+		owner := metav1.GetControllerOf(obj)
+		if owner == nil {
+			return false
+		}
+
+		controller, err := getController(client, obj.GetNamespace(), owner)
+		if err != nil {
+			logrus.Debugf("Getting controller of %s: %s", obj.GetName(), err)
+			return false
+		}
+		obj = controller
+	}
+	return false
+}
+
+func getController(client clientgo.Interface, namespace string, owner *metav1.OwnerReference) (metav1.Object, error) {
+	switch owner.Kind {
+	case "ReplicaSet":
+		return client.AppsV1().ReplicaSets(namespace).Get(owner.Name, metav1.GetOptions{})
+	case "Deployment":
+		return client.AppsV1().Deployments(namespace).Get(owner.Name, metav1.GetOptions{})
+	case "StatefulSet":
+		return client.AppsV1().StatefulSets(namespace).Get(owner.Name, metav1.GetOptions{})
+	case "DaemonSet":
+		return client.AppsV1().DaemonSets(namespace).Get(owner.Name, metav1.GetOptions{})
+	case "Job":
+		return client.BatchV1().Jobs(namespace).Get(owner.Name, metav1.GetOptions{})
+	default:
+		return nil, fmt.Errorf("unsupported controller kind %s", owner.Kind)
+	}
+}
+
+func hasLabels(actual, expected map[string]string) bool {
+	for k, v := range expected {
+		if actual[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (k *KubectlSyncer) syncContainer(ctx context.Context, pod v1.Pod, container string, item *Item) error {
+	for src, dst := range item.Copy {
+		target := fmt.Sprintf("%s/%s:%s", pod.Namespace, pod.Name, dst)
+		cmd := exec.CommandContext(ctx, "kubectl", "--context", k.kubeContext, "cp", src, target, "-c", container)
+		if err := util.RunCmd(cmd); err != nil {
+			return errors.Wrapf(err, "copying %s", src)
+		}
+	}
+
+	if len(item.Delete) > 0 {
+		args := append([]string{"--context", k.kubeContext, "exec", pod.Name, "--namespace", pod.Namespace, "-c", container, "--", "rm", "-rf", "--"}, item.Delete...)
+		if err := util.RunCmd(exec.CommandContext(ctx, "kubectl", args...)); err != nil {
+			return errors.Wrap(err, "deleting files")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/kubernetes"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgo "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewItem(t *testing.T) {
+	tmpDir, cleanup := testutil.TempDir(t)
+	defer cleanup()
+
+	html := filepath.Join(tmpDir, "static", "index.html")
+	css := filepath.Join(tmpDir, "static", "style.css")
+	main := filepath.Join(tmpDir, "main.go")
+	deleted := filepath.Join(tmpDir, "static", "old.html")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "static"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{html, css, main} {
+		if err := ioutil.WriteFile(file, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules := map[string]string{
+		"static/*.html": "/var/www",
+		"static/*.css":  "/var/www",
+	}
+
+	var tests = []struct {
+		description  string
+		sync         map[string]string
+		changedPaths []string
+		shouldErr    bool
+		expected     *Item
+	}{
+		{
+			description:  "copy matching files",
+			sync:         rules,
+			changedPaths: []string{html, css},
+			expected: &Item{
+				Image: "image:tag",
+				Copy: map[string]string{
+					html: "/var/www/static/index.html",
+					css:  "/var/www/static/style.css",
+				},
+			},
+		},
+		{
+			description:  "delete missing files",
+			sync:         rules,
+			changedPaths: []string{deleted},
+			expected: &Item{
+				Image:  "image:tag",
+				Copy:   map[string]string{},
+				Delete: []string{"/var/www/static/old.html"},
+			},
+		},
+		{
+			description:  "rebuild if a file doesn't match",
+			sync:         rules,
+			changedPaths: []string{html, main},
+		},
+		{
+			description:  "double star",
+			sync:         map[string]string{"**/*.css": "/var/www"},
+			changedPaths: []string{css},
+			expected: &Item{
+				Image: "image:tag",
+				Copy:  map[string]string{css: "/var/www/static/style.css"},
+			},
+		},
+		{
+			description:  "no sync rules",
+			changedPaths: []string{html},
+		},
+		{
+			description:  "invalid glob",
+			sync:         map[string]string{"[": "/"},
+			changedPaths: []string{html},
+			shouldErr:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			artifact := &v1alpha2.Artifact{
+				ImageName: "image",
+				Workspace: tmpDir,
+				Sync:      test.sync,
+			}
+
+			item, err := NewItem(artifact, test.changedPaths, "image:tag")
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, item)
+		})
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	var tests = []struct {
+		glob      string
+		rel       string
+		shouldErr bool
+		expected  bool
+	}{
+		{glob: "*.html", rel: "index.html", expected: true},
+		{glob: "*.html", rel: "static/index.html"},
+		{glob: "static/**/*.js", rel: "static/app.js", expected: true},
+		{glob: "static/**/*.js", rel: "static/js/lib/app.js", expected: true},
+		{glob: "static/**/*.js", rel: "other/js/app.js"},
+		{glob: "**", rel: "any/file", expected: true},
+		{glob: "**/[", rel: "file", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.glob+" "+test.rel, func(t *testing.T) {
+			matches, err := matchGlob(test.glob, test.rel)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, matches)
+		})
+	}
+}
+
+func TestKubectlSync(t *testing.T) {
+	controller := true
+	labels := map[string]string{"skaffold.dev/run-id": "123"}
+
+	running := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns", Labels: labels},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "other", Image: "other:tag"},
+				{Name: "app", Image: "image:tag"},
+			},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+	pending := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "ns", Labels: labels},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "app", Image: "image:tag"}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodPending},
+	}
+	byDigest := running.DeepCopy()
+	byDigest.Spec.Containers[1].Image = "image:tag@sha256:abcd"
+	otherNamespace := running.DeepCopy()
+	otherNamespace.Namespace = "other"
+	unlabelled := running.DeepCopy()
+	unlabelled.Labels = nil
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "app-5d8f",
+			Namespace:       "ns",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "app", Controller: &controller}},
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns", Labels: labels},
+	}
+	deployed := unlabelled.DeepCopy()
+	deployed.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "app-5d8f", Controller: &controller}}
+
+	var tests = []struct {
+		description string
+		objects     []runtime.Object
+		item        *Item
+		command     util.Command
+		shouldErr   bool
+	}{
+		{
+			description: "copy",
+			objects:     []runtime.Object{running, pending},
+			item: &Item{
+				Image: "image:tag",
+				Copy:  map[string]string{"index.html": "/var/www/index.html"},
+			},
+			command: testutil.NewFakeCmd("kubectl --context kubecontext cp index.html ns/pod:/var/www/index.html -c app", nil),
+		},
+		{
+			description: "container deployed by digest",
+			objects:     []runtime.Object{byDigest},
+			item: &Item{
+				Image: "image:tag",
+				Copy:  map[string]string{"index.html": "/var/www/index.html"},
+			},
+			command: testutil.NewFakeCmd("kubectl --context kubecontext cp index.html ns/pod:/var/www/index.html -c app", nil),
+		},
+		{
+			description: "delete",
+			objects:     []runtime.Object{running},
+			item: &Item{
+				Image:  "image:tag",
+				Delete: []string{"/var/www/old.html"},
+			},
+			command: testutil.NewFakeCmd("kubectl --context kubecontext exec pod --namespace ns -c app -- rm -rf -- /var/www/old.html", nil),
+		},
+		{
+			description: "copy error",
+			objects:     []runtime.Object{running},
+			item: &Item{
+				Image: "image:tag",
+				Copy:  map[string]string{"index.html": "/var/www/index.html"},
+			},
+			command:   testutil.NewFakeCmd("kubectl --context kubecontext cp index.html ns/pod:/var/www/index.html -c app", fmt.Errorf("")),
+			shouldErr: true,
+		},
+		{
+			description: "pod of a deployed workload",
+			objects:     []runtime.Object{deployed, replicaSet, deployment},
+			item: &Item{
+				Image: "image:tag",
+				Copy:  map[string]string{"index.html": "/var/www/index.html"},
+			},
+			command: testutil.NewFakeCmd("kubectl --context kubecontext cp index.html ns/pod:/var/www/index.html -c app", nil),
+		},
+		{
+			description: "pod of another namespace",
+			objects:     []runtime.Object{otherNamespace},
+			item: &Item{
+				Image: "image:tag",
+				Copy:  map[string]string{"index.html": "/var/www/index.html"},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "pod not deployed by skaffold",
+			objects:     []runtime.Object{unlabelled},
+			item: &Item{
+				Image: "image:tag",
+				Copy:  map[string]string{"index.html": "/var/www/index.html"},
+			},
+			shouldErr: true,
+		},
+		{
+			description: "no running container",
+			objects:     []runtime.Object{pending},
+			item: &Item{
+				Image: "image:tag",
+				Copy:  map[string]string{"index.html": "/var/www/index.html"},
+			},
+			shouldErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.objects...)
+			defer func(c func(string) (clientgo.Interface, error)) { kubernetes.ClientForContext = c }(kubernetes.ClientForContext)
+			kubernetes.ClientForContext = func(kubeContext string) (clientgo.Interface, error) {
+				if kubeContext != "kubecontext" {
+					t.Errorf("Expected client for kubecontext. Got %s", kubeContext)
+				}
+				return client, nil
+			}
+
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			if test.command != nil {
+				util.DefaultExecCommand = test.command
+			}
+
+			err := NewKubectlSyncer("kubecontext", "ns", labels).Sync(context.Background(), test.item)
+
+			testutil.CheckError(t, test.shouldErr, err)
+		})
+	}
+}
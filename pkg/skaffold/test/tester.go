@@ -0,0 +1,139 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"io"
+	"os/exec"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/pkg/errors"
+)
+
+// Tester runs the tests of the built images.
+type Tester interface {
+	Test(ctx context.Context, out io.Writer, builds []build.Build) error
+
+	// TestDependencies returns the files of the tests, by image name.
+	TestDependencies() (map[string][]string, error)
+}
+
+// NewTester returns a Tester that runs the container-structure-tests
+// and the custom commands of the test cases.
+func NewTester(testCases []v1alpha2.TestCase) Tester {
+	return &FullTester{
+		testCases: testCases,
+	}
+}
+
+// FullTester runs every kind of test.
+type FullTester struct {
+	testCases []v1alpha2.TestCase
+}
+
+// Test runs the tests of the given builds, in order, and stops at the first failure.
+// Images without tests are skipped.
+func (t *FullTester) Test(ctx context.Context, out io.Writer, builds []build.Build) error {
+	for _, b := range builds {
+		for _, tc := range t.testCases {
+			if tc.ImageName != b.ImageName {
+				continue
+			}
+			if err := runTests(ctx, out, tc, b.Tag); err != nil {
+				return errors.Wrapf(err, "testing %s", b.ImageName)
+			}
+		}
+	}
+	return nil
+}
+
+// TestDependencies returns the structure test config files and
+// the dependencies of the custom commands.
+func (t *FullTester) TestDependencies() (map[string][]string, error) {
+	deps := map[string][]string{}
+	for _, tc := range t.testCases {
+		paths := append([]string{}, tc.StructureTests...)
+		for _, custom := range tc.Custom {
+			paths = append(paths, custom.Dependencies...)
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		files, err := util.ExpandPathsGlob(tc.Dir, paths)
+		if err != nil {
+			return nil, errors.Wrapf(err, "expanding test files of %s", tc.ImageName)
+		}
+		deps[tc.ImageName] = append(deps[tc.ImageName], files...)
+	}
+	return deps, nil
+}
+
+func runTests(ctx context.Context, out io.Writer, tc v1alpha2.TestCase, image string) error {
+	if len(tc.StructureTests) > 0 {
+		files, err := util.ExpandPathsGlob(tc.Dir, tc.StructureTests)
+		if err != nil {
+			return errors.Wrap(err, "expanding structure tests")
+		}
+		if err := runStructureTests(ctx, out, image, files); err != nil {
+			return err
+		}
+	}
+
+	for _, custom := range tc.Custom {
+		if err := runCustomTest(ctx, out, tc.Dir, image, custom.Command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runStructureTests(ctx context.Context, out io.Writer, image string, files []string) error {
+	args := []string{"test", "-v", "warn", "--image", image}
+	for _, f := range files {
+		args = append(args, "--config", f)
+	}
+
+	cmd := exec.CommandContext(ctx, "container-structure-test", args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := util.RunCmd(cmd); err != nil {
+		return errors.Wrap(err, "running container-structure-test")
+	}
+	return nil
+}
+
+func runCustomTest(ctx context.Context, out io.Writer, dir, image string, command []string) error {
+	if len(command) == 0 {
+		return errors.New("custom test has no command")
+	}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(util.OSEnviron(), "IMAGE="+image)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := util.RunCmd(cmd); err != nil {
+		return errors.Wrapf(err, "running custom test %v", command)
+	}
+	return nil
+}
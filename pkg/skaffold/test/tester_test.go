@@ -0,0 +1,189 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/build"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/schema/v1alpha2"
+	"github.com/GoogleContainerTools/skaffold/pkg/skaffold/util"
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+// recordingCommand records the commands, with the image given to custom
+// tests, and fails the commands that start with failing.
+type recordingCommand struct {
+	failing string
+	run     []string
+}
+
+func (r *recordingCommand) RunCmdOut(cmd *exec.Cmd) ([]byte, error) {
+	return nil, r.RunCmd(cmd)
+}
+
+func (r *recordingCommand) RunCmd(cmd *exec.Cmd) error {
+	command := strings.Join(cmd.Args, " ")
+	for _, env := range cmd.Env {
+		if strings.HasPrefix(env, "IMAGE=") {
+			command = env + " " + command
+		}
+	}
+	r.run = append(r.run, command)
+
+	if r.failing != "" && strings.HasPrefix(strings.Join(cmd.Args, " "), r.failing) {
+		return fmt.Errorf("test failed")
+	}
+	return nil
+}
+
+func TestTest(t *testing.T) {
+	tmpDir, tearDown := testutil.TempDir(t)
+	defer tearDown()
+	for _, file := range []string{"test1.yaml", "test2.yaml", "smoke.sh"} {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []v1alpha2.TestCase{
+		{
+			ImageName:      "image1",
+			StructureTests: []string{"*.yaml"},
+			Custom:         []v1alpha2.CustomTest{{Command: []string{"./smoke.sh"}}},
+			Dir:            tmpDir,
+		},
+		{
+			ImageName: "image2",
+			Custom:    []v1alpha2.CustomTest{{Command: []string{"go", "test"}}},
+			Dir:       tmpDir,
+		},
+	}
+	builds := []build.Build{
+		{ImageName: "image1", Tag: "image1:tag1"},
+		{ImageName: "image2", Tag: "image2:tag2"},
+		{ImageName: "untested", Tag: "untested:tag"},
+	}
+
+	var tests = []struct {
+		description string
+		testCases   []v1alpha2.TestCase
+		failing     string
+		shouldErr   bool
+		expected    []string
+	}{
+		{
+			description: "structure and custom tests",
+			testCases:   testCases,
+			expected: []string{
+				"container-structure-test test -v warn --image image1:tag1 --config " + filepath.Join(tmpDir, "test1.yaml") + " --config " + filepath.Join(tmpDir, "test2.yaml"),
+				"IMAGE=image1:tag1 ./smoke.sh",
+				"IMAGE=image2:tag2 go test",
+			},
+		},
+		{
+			description: "stop at the first failure",
+			testCases:   testCases,
+			failing:     "./smoke.sh",
+			shouldErr:   true,
+			expected: []string{
+				"container-structure-test test -v warn --image image1:tag1 --config " + filepath.Join(tmpDir, "test1.yaml") + " --config " + filepath.Join(tmpDir, "test2.yaml"),
+				"IMAGE=image1:tag1 ./smoke.sh",
+			},
+		},
+		{
+			description: "missing structure tests",
+			testCases:   []v1alpha2.TestCase{{ImageName: "image1", StructureTests: []string{"missing.yaml"}, Dir: tmpDir}},
+			shouldErr:   true,
+		},
+		{
+			description: "custom test without command",
+			testCases:   []v1alpha2.TestCase{{ImageName: "image2", Custom: []v1alpha2.CustomTest{{}}}},
+			shouldErr:   true,
+		},
+		{
+			description: "no tests",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			cmd := &recordingCommand{failing: test.failing}
+			defer func(c util.Command) { util.DefaultExecCommand = c }(util.DefaultExecCommand)
+			util.DefaultExecCommand = cmd
+
+			err := NewTester(test.testCases).Test(context.Background(), ioutil.Discard, builds)
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, cmd.run)
+		})
+	}
+}
+
+func TestTestDependencies(t *testing.T) {
+	tmpDir, tearDown := testutil.TempDir(t)
+	defer tearDown()
+	for _, file := range []string{"test.yaml", "smoke.sh"} {
+		if err := ioutil.WriteFile(filepath.Join(tmpDir, file), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		description string
+		testCases   []v1alpha2.TestCase
+		shouldErr   bool
+		expected    map[string][]string
+	}{
+		{
+			description: "structure tests and custom dependencies",
+			testCases: []v1alpha2.TestCase{
+				{
+					ImageName:      "image1",
+					StructureTests: []string{"*.yaml"},
+					Custom:         []v1alpha2.CustomTest{{Command: []string{"./smoke.sh"}, Dependencies: []string{"smoke.sh"}}},
+					Dir:            tmpDir,
+				},
+				{
+					ImageName: "image2",
+					Custom:    []v1alpha2.CustomTest{{Command: []string{"go", "test"}}},
+				},
+			},
+			expected: map[string][]string{
+				"image1": {filepath.Join(tmpDir, "smoke.sh"), filepath.Join(tmpDir, "test.yaml")},
+			},
+		},
+		{
+			description: "missing file",
+			testCases:   []v1alpha2.TestCase{{ImageName: "image1", StructureTests: []string{"missing.yaml"}, Dir: tmpDir}},
+			shouldErr:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			deps, err := NewTester(test.testCases).TestDependencies()
+
+			testutil.CheckErrorAndDeepEqual(t, test.shouldErr, err, test.expected, deps)
+		})
+	}
+}
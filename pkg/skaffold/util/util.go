@@ -68,7 +68,10 @@ func StrSliceContains(sl []string, s string) bool {
 func ExpandPathsGlob(workingDir string, paths []string) ([]string, error) {
 	expandedPaths := make(map[string]bool)
 	for _, p := range paths {
-		path := filepath.Join(workingDir, p)
+		path := p
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, p)
+		}
 
 		if _, err := os.Stat(path); err == nil {
 			// This is a file reference, so just add it
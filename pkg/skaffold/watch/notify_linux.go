@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const notifyMask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// notifyChanges uses inotify to watch the directories of the paths, rather than the files,
+// so that files that are replaced, eg. by editors that save to a temporary file, are still
+// watched. The channel receives a value each time something changes in the directories.
+func notifyChanges(ctx context.Context, paths []string) (<-chan bool, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing inotify")
+	}
+
+	dirs := map[string]bool{}
+	for _, path := range paths {
+		dirs[filepath.Dir(path)] = true
+	}
+
+	var watches []uint32
+	for dir := range dirs {
+		wd, err := syscall.InotifyAddWatch(fd, dir, notifyMask)
+		if err != nil {
+			syscall.Close(fd)
+			return nil, errors.Wrapf(err, "watching %s", dir)
+		}
+		watches = append(watches, uint32(wd))
+	}
+
+	events := make(chan bool, 1)
+
+	go func() {
+		<-ctx.Done()
+		// Removing the watches wakes up the blocked read.
+		for _, wd := range watches {
+			syscall.InotifyRmWatch(fd, wd)
+		}
+	}()
+
+	go func() {
+		defer close(events)
+		defer syscall.Close(fd)
+
+		var buf [syscall.SizeofInotifyEvent * 4096]byte
+		for {
+			// The events are not decoded since the watcher checks the files anyway.
+			_, err := syscall.Read(fd, buf[:])
+			if ctx.Err() != nil {
+				return
+			}
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil {
+				logrus.Warnf("Reading inotify events: %s", err)
+				return
+			}
+
+			select {
+			case events <- true:
+			default:
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Trigger tells the watchers when to check the files for changes.
+type Trigger interface {
+	// Start returns a channel that receives a value each time the files should be checked.
+	Start(ctx context.Context, paths []string) (<-chan bool, error)
+
+	// WatchForChanges tells the user how changes are detected.
+	WatchForChanges(out io.Writer)
+}
+
+// NewTrigger creates a trigger by name: `polling`, `notify` or `manual`. The interval
+// is how often files are polled and how long notifications are debounced.
+func NewTrigger(name string, interval time.Duration) (Trigger, error) {
+	switch name {
+	case "", "polling":
+		return &pollTrigger{interval: interval}, nil
+	case "notify":
+		return &notifyTrigger{debounce: interval}, nil
+	case "manual":
+		return newManualTrigger(os.Stdin), nil
+	default:
+		return nil, fmt.Errorf("unsupported trigger: %s", name)
+	}
+}
+
+// pollTrigger checks the files at regular intervals.
+type pollTrigger struct {
+	interval time.Duration
+}
+
+func (p *pollTrigger) Start(ctx context.Context, paths []string) (<-chan bool, error) {
+	trigger := make(chan bool)
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case trigger <- true:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return trigger, nil
+}
+
+func (p *pollTrigger) WatchForChanges(out io.Writer) {
+	fmt.Fprintln(out, "Watching for changes...")
+}
+
+// manualTrigger checks the files each time the user presses enter.
+// Every watcher started with the same trigger is notified until its context is cancelled.
+type manualTrigger struct {
+	in io.Reader
+
+	sync.Mutex
+	once      sync.Once
+	listeners []chan bool
+}
+
+func newManualTrigger(in io.Reader) *manualTrigger {
+	return &manualTrigger{
+		in: in,
+	}
+}
+
+func (m *manualTrigger) Start(ctx context.Context, paths []string) (<-chan bool, error) {
+	trigger := make(chan bool, 1)
+
+	unregister := m.addListener(trigger)
+	go func() {
+		<-ctx.Done()
+		unregister()
+	}()
+
+	m.once.Do(func() {
+		go m.readInput()
+	})
+
+	return trigger, nil
+}
+
+// addListener registers a listener and returns the function that unregisters it.
+func (m *manualTrigger) addListener(listener chan bool) func() {
+	m.Lock()
+	defer m.Unlock()
+
+	m.listeners = append(m.listeners, listener)
+
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+
+		for i, l := range m.listeners {
+			if l == listener {
+				m.listeners = append(m.listeners[:i], m.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (m *manualTrigger) readInput() {
+	scanner := bufio.NewScanner(m.in)
+	for scanner.Scan() {
+		m.Lock()
+		for _, listener := range m.listeners {
+			select {
+			case listener <- true:
+			default:
+			}
+		}
+		m.Unlock()
+	}
+}
+
+func (m *manualTrigger) WatchForChanges(out io.Writer) {
+	fmt.Fprintln(out, "Press enter to rebuild and redeploy the changes...")
+}
+
+// notifyTrigger checks the files when the filesystem notifies changes of their directories.
+// Notifications are debounced so that a batch of changes triggers a single check.
+type notifyTrigger struct {
+	debounce time.Duration
+}
+
+func (n *notifyTrigger) Start(ctx context.Context, paths []string) (<-chan bool, error) {
+	events, err := notifyChanges(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	trigger := make(chan bool)
+
+	go func() {
+		var timer <-chan time.Time
+		for {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					return
+				}
+				timer = time.After(n.debounce)
+			case <-timer:
+				timer = nil
+				select {
+				case trigger <- true:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return trigger, nil
+}
+
+func (n *notifyTrigger) WatchForChanges(out io.Writer) {
+	fmt.Fprintln(out, "Watching for changes...")
+}
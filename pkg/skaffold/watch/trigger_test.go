@@ -0,0 +1,126 @@
+/*
+Copyright 2018 The Skaffold Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/GoogleContainerTools/skaffold/testutil"
+)
+
+func TestNewTrigger(t *testing.T) {
+	var tests = []struct {
+		name      string
+		expected  Trigger
+		shouldErr bool
+	}{
+		{name: "", expected: &pollTrigger{interval: time.Second}},
+		{name: "polling", expected: &pollTrigger{interval: time.Second}},
+		{name: "notify", expected: &notifyTrigger{debounce: time.Second}},
+		{name: "manual", expected: &manualTrigger{}},
+		{name: "unknown", shouldErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trigger, err := NewTrigger(test.name, time.Second)
+
+			testutil.CheckErrorAndTypeEquality(t, test.shouldErr, err, test.expected, trigger)
+		})
+	}
+}
+
+func expectTrigger(t *testing.T, c <-chan bool) {
+	select {
+	case <-c:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the trigger to fire")
+	}
+}
+
+func TestPollTrigger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := (&pollTrigger{interval: time.Millisecond}).Start(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectTrigger(t, c)
+	expectTrigger(t, c)
+}
+
+func TestManualTrigger(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in, out := io.Pipe()
+	trigger := newManualTrigger(in)
+
+	c1, _ := trigger.Start(ctx, nil)
+	c2, _ := trigger.Start(ctx, nil)
+
+	go out.Write([]byte("\n"))
+
+	expectTrigger(t, c1)
+	expectTrigger(t, c2)
+}
+
+func TestManualTriggerUnregistersListeners(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	trigger := newManualTrigger(&io.LimitedReader{})
+	trigger.Start(ctx, nil)
+	trigger.Start(context.Background(), nil)
+	cancel()
+
+	listeners := func() int {
+		trigger.Lock()
+		defer trigger.Unlock()
+		return len(trigger.listeners)
+	}
+	for i := 0; i < 100 && listeners() != 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	testutil.CheckErrorAndDeepEqual(t, false, nil, 1, listeners())
+}
+
+func TestNotifyTrigger(t *testing.T) {
+	tmp, teardown := testutil.TempDir(t)
+	defer teardown()
+
+	path := filepath.Join(tmp, "file")
+	write(t, path, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, err := (&notifyTrigger{debounce: time.Millisecond}).Start(ctx, []string{path})
+	if err != nil {
+		t.Skipf("notify trigger is not supported: %s", err)
+	}
+
+	write(t, path, "CONTENT")
+
+	expectTrigger(t, c)
+}
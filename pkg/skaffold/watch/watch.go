@@ -18,7 +18,6 @@ package watch
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"os"
 	"sort"
@@ -38,21 +37,33 @@ type Watcher interface {
 	Start(ctx context.Context, out io.Writer, onChange func([]string) error) error
 }
 
-// mtimeWatcher uses polling on file mTimes.
+// mtimeWatcher compares the mTimes of the files each time the trigger fires.
 type mtimeWatcher struct {
-	files map[string]time.Time
+	files   map[string]time.Time
+	trigger Trigger
 }
 
 func (m *mtimeWatcher) Start(ctx context.Context, out io.Writer, onChange func([]string) error) error {
-	c := time.NewTicker(2 * time.Second)
-	defer c.Stop()
+	var paths []string
+	for f := range m.files {
+		paths = append(paths, f)
+	}
+	sort.Strings(paths)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c, err := m.trigger.Start(ctx, paths)
+	if err != nil {
+		return errors.Wrap(err, "starting trigger")
+	}
 
 	changedPaths := map[string]bool{}
 
-	fmt.Fprintln(out, "Watching for changes...")
+	m.trigger.WatchForChanges(out)
 	for {
 		select {
-		case <-c.C:
+		case <-c:
 			// add things to changedpaths
 			for f := range m.files {
 				fi, err := os.Stat(f)
@@ -82,8 +93,23 @@ func (m *mtimeWatcher) Start(ctx context.Context, out io.Writer, onChange func([
 	}
 }
 
-// NewWatcher creates a new Watcher on a list of files.
+// DefaultPollInterval is how often the files are polled by default.
+const DefaultPollInterval = 2 * time.Second
+
+// NewWatcher creates a new Watcher on a list of files, that polls them.
 func NewWatcher(paths []string) (Watcher, error) {
+	return newWatcher(paths, &pollTrigger{interval: DefaultPollInterval})
+}
+
+// NewWatcherFactory creates a WatcherFactory for Watchers that check the files
+// each time the trigger fires.
+func NewWatcherFactory(trigger Trigger) WatcherFactory {
+	return func(paths []string) (Watcher, error) {
+		return newWatcher(paths, trigger)
+	}
+}
+
+func newWatcher(paths []string, trigger Trigger) (Watcher, error) {
 	logrus.Info("Starting mtime file watcher.")
 
 	sort.Strings(paths)
@@ -98,7 +124,8 @@ func NewWatcher(paths []string) (Watcher, error) {
 	}
 
 	return &mtimeWatcher{
-		files: files,
+		files:   files,
+		trigger: trigger,
 	}, nil
 }
 
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/registry"
@@ -30,6 +31,7 @@ import (
 type FakeImageAPIClient struct {
 	*client.Client
 	tagToImageID map[string]string
+	mu           sync.Mutex
 
 	opts *FakeImageAPIOptions
 }
@@ -60,6 +62,9 @@ func NewFakeImageAPIClient(initContents map[string]string, opts *FakeImageAPIOpt
 }
 
 func (f *FakeImageAPIClient) ImageBuild(ctx context.Context, context io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	if f.opts.ErrImageBuild {
 		return types.ImageBuildResponse{}, fmt.Errorf("")
 	}
@@ -79,6 +84,9 @@ func (f *FakeImageAPIClient) ImageBuild(ctx context.Context, context io.Reader,
 }
 
 func (f *FakeImageAPIClient) ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	if f.opts.ErrImageList {
 		return nil, fmt.Errorf("")
 	}
@@ -103,6 +111,9 @@ func (f *FakeImageAPIClient) ImageList(ctx context.Context, options types.ImageL
 }
 
 func (f *FakeImageAPIClient) ImageTag(ctx context.Context, image, ref string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	if f.opts.ErrImageTag {
 		return fmt.Errorf("")
 	}